@@ -2,6 +2,7 @@ package api
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 
 	"github.com/ipfs/go-cid"
@@ -11,6 +12,7 @@ import (
 	"github.com/libp2p/go-libp2p-core/peer"
 
 	"github.com/filecoin-project/go-lotus/build"
+	"github.com/filecoin-project/go-lotus/chain/actors"
 	"github.com/filecoin-project/go-lotus/chain/address"
 	"github.com/filecoin-project/go-lotus/chain/store"
 	"github.com/filecoin-project/go-lotus/chain/types"
@@ -24,7 +26,11 @@ func init() {
 type Common interface {
 	// Auth
 	AuthVerify(ctx context.Context, token string) ([]string, error)
-	AuthNew(ctx context.Context, perms []string) ([]byte, error)
+	// AuthNew mints a token with the given permissions, and optionally a
+	// policy limiting what can be spent with it
+	AuthNew(ctx context.Context, perms []string, policy *SpendPolicy) ([]byte, error)
+	// AuthPolicy returns the spend policy of the token, or nil if it has none
+	AuthPolicy(ctx context.Context, token string) (*SpendPolicy, error)
 
 	// network
 
@@ -72,6 +78,17 @@ type FullNode interface {
 	MpoolPush(context.Context, *types.SignedMessage) error                          // TODO: remove
 	MpoolPushMessage(context.Context, *types.Message) (*types.SignedMessage, error) // get nonce, sign, push
 	MpoolGetNonce(context.Context, address.Address) (uint64, error)
+	MpoolSub(context.Context) (<-chan MpoolUpdate, error)
+	MpoolStat(context.Context) (*MpoolStat, error)
+
+	// gas
+
+	// GasEstimateGasLimit executes the message on top of the given tipset
+	// (heaviest if nil) and returns the gas it used with a safety margin added
+	GasEstimateGasLimit(context.Context, *types.Message, *types.TipSet) (types.BigInt, error)
+	// GasEstimateGasPrice returns a gas price based on messages included in
+	// the last n tipsets
+	GasEstimateGasPrice(context.Context, uint64) (types.BigInt, error)
 
 	// FullNodeStruct
 
@@ -93,8 +110,31 @@ type FullNode interface {
 	WalletSign(context.Context, address.Address, []byte) (*types.Signature, error)
 	WalletSignMessage(context.Context, address.Address, *types.Message) (*types.SignedMessage, error)
 	WalletDefaultAddress(context.Context) (address.Address, error)
+	WalletSetDefault(context.Context, address.Address) error
+	// WalletDelete moves the key to the trash namespace of the keystore
+	WalletDelete(context.Context, address.Address) error
+	// WalletVerify checks that sig is a signature of data by the key of addr
+	WalletVerify(ctx context.Context, addr address.Address, data []byte, sig *types.Signature) (bool, error)
+	// WalletSetPolicy sets the spend policy of messages signed by addr
+	// through the API, a nil policy removes it
+	WalletSetPolicy(ctx context.Context, addr address.Address, policy *SpendPolicy) error
+	WalletGetPolicy(ctx context.Context, addr address.Address) (*SpendPolicy, error)
 	WalletExport(context.Context, address.Address) (*types.KeyInfo, error)
 	WalletImport(context.Context, *types.KeyInfo) (address.Address, error)
+	// WalletLock clears the wallet keys from memory and locks the encrypted
+	// keystore, signing fails until WalletUnlock is called
+	WalletLock(context.Context) error
+	WalletUnlock(ctx context.Context, passphrase string) error
+	// WalletNewHD derives the next key of the given type from the HD seed. A
+	// new seed is generated when the wallet doesn't have one yet, its
+	// mnemonic is only returned then
+	WalletNewHD(ctx context.Context, typ string) (*HDKey, error)
+	// WalletRestoreHD sets up the HD seed from a BIP-39 mnemonic
+	WalletRestoreHD(ctx context.Context, mnemonic string) error
+	// WalletDeriveHD derives the key of the given type at index from the HD
+	// seed, making all keys up to it usable. The index can be at most 20 past
+	// the next index of the key type
+	WalletDeriveHD(ctx context.Context, typ string, index uint64) (address.Address, error)
 
 	// Other
 
@@ -118,6 +158,21 @@ type FullNode interface {
 	// if tipset is nil, we'll use heaviest
 	StateCall(context.Context, *types.Message, *types.TipSet) (*types.MessageReceipt, error)
 	StateReplay(context.Context, *types.TipSet, cid.Cid) (*ReplayResults, error)
+	// StateCompute applies the given messages on top of the state of the
+	// tipset as if they were included at the given height (0 meaning the
+	// height after the tipset). It returns the resulting state root along
+	// with the receipt and trace of each message. Nothing is persisted
+	StateCompute(ctx context.Context, height uint64, msgs []*types.Message, ts *types.TipSet) (*ComputeStateOutput, error)
+	// StateDecodeParams decodes the parameters of a call to the given method
+	// of the actor at toAddr, returning the method name and the params in
+	// their native (JSON encodable) form
+	StateDecodeParams(ctx context.Context, toAddr address.Address, method uint64, params []byte, ts *types.TipSet) (*DecodedCall, error)
+	// StateDecodeReturn decodes the value returned by the given method of the
+	// actor at toAddr
+	StateDecodeReturn(ctx context.Context, toAddr address.Address, method uint64, ret []byte, ts *types.TipSet) (interface{}, error)
+	// StateEncodeParams serializes JSON encoded parameters of a method on
+	// actors with the given code
+	StateEncodeParams(ctx context.Context, toActCode cid.Cid, method uint64, params json.RawMessage) ([]byte, error)
 	StateGetActor(ctx context.Context, actor address.Address, ts *types.TipSet) (*types.Actor, error)
 	StateReadState(ctx context.Context, act *types.Actor, ts *types.TipSet) (*ActorState, error)
 
@@ -127,20 +182,65 @@ type FullNode interface {
 	StateMinerWorker(context.Context, address.Address, *types.TipSet) (address.Address, error)
 	StateMinerPeerID(ctx context.Context, m address.Address, ts *types.TipSet) (peer.ID, error)
 	StateMinerProvingPeriodEnd(ctx context.Context, actor address.Address, ts *types.TipSet) (uint64, error)
+	// StateMinerRewards returns the block rewards of the miner owner which are
+	// still held by the reward actor
+	StateMinerRewards(ctx context.Context, maddr address.Address, ts *types.TipSet) (*MinerRewards, error)
 	StatePledgeCollateral(context.Context, *types.TipSet) (types.BigInt, error)
+	// StateMarketBalance returns the funds escrowed in the storage market
+	// actor by the given client or provider
+	StateMarketBalance(context.Context, address.Address, *types.TipSet) (MarketBalance, error)
+	// StateMarketDeals returns all deals published to the storage market
+	// actor, keyed by deal ID
+	StateMarketDeals(context.Context, *types.TipSet) (map[string]MarketDeal, error)
 	StateWaitMsg(context.Context, cid.Cid) (*MsgWait, error)
 	StateListMiners(context.Context, *types.TipSet) ([]address.Address, error)
 	StateListActors(context.Context, *types.TipSet) ([]address.Address, error)
+	// StateListActorAddresses lists all actors like StateListActors, also
+	// returning the key address of account actors
+	StateListActorAddresses(context.Context, *types.TipSet) ([]ActorAddresses, error)
+	// StateLookupID returns the ID address of the actor at the given address
+	StateLookupID(context.Context, address.Address, *types.TipSet) (address.Address, error)
+	// StateAccountKey returns the public key address of the given account
+	// actor, which may be referred to by its ID address
+	StateAccountKey(context.Context, address.Address, *types.TipSet) (address.Address, error)
+	// StateChangedActors lists the actors that were created, deleted or
+	// changed between the two state roots
+	StateChangedActors(ctx context.Context, oldRoot cid.Cid, newRoot cid.Cid) ([]*ActorChange, error)
+
+	// MsigGetAvailableBalance returns the part of the multisig wallet balance
+	// which isn't locked at the given tipset
+	MsigGetAvailableBalance(ctx context.Context, addr address.Address, ts *types.TipSet) (types.BigInt, error)
+	// MsigGetPending returns the transactions of the multisig wallet which
+	// weren't executed or canceled yet
+	MsigGetPending(ctx context.Context, addr address.Address, ts *types.TipSet) ([]*MsigTransaction, error)
+	// MsigCreate creates a multisig wallet holding val. If unlockDuration isn't
+	// 0, locked out of val unlocks linearly over that many blocks starting at
+	// unlockStart. It returns the cid of the message creating the wallet, the
+	// wallet address is returned in its receipt
+	MsigCreate(ctx context.Context, required uint64, signers []address.Address, unlockStart uint64, unlockDuration uint64, locked types.BigInt, val types.BigInt, src address.Address) (cid.Cid, error)
+	MsigPropose(ctx context.Context, msig address.Address, to address.Address, amt types.BigInt, src address.Address, method uint64, params []byte) (cid.Cid, error)
+	MsigApprove(ctx context.Context, msig address.Address, txID uint64, src address.Address) (cid.Cid, error)
+	MsigCancel(ctx context.Context, msig address.Address, txID uint64, src address.Address) (cid.Cid, error)
+	// MsigAddSigner proposes adding a signer to the wallet
+	MsigAddSigner(ctx context.Context, msig address.Address, src address.Address, newSigner address.Address, increase bool) (cid.Cid, error)
+	// MsigSwapSigner proposes replacing one of the signers of the wallet
+	MsigSwapSigner(ctx context.Context, msig address.Address, src address.Address, oldSigner address.Address, newSigner address.Address) (cid.Cid, error)
 
 	PaychGet(ctx context.Context, from, to address.Address, ensureFunds types.BigInt) (*ChannelInfo, error)
 	PaychList(context.Context) ([]address.Address, error)
 	PaychStatus(context.Context, address.Address) (*PaychStatus, error)
 	PaychClose(context.Context, address.Address) (cid.Cid, error)
+	// PaychSettle starts settling the channel from our side of it, after
+	// which vouchers can still be submitted until ClosingAt
+	PaychSettle(context.Context, address.Address) (cid.Cid, error)
+	// PaychCollect waits until the channel is settled and sends the channel
+	// funds to both parties
+	PaychCollect(context.Context, address.Address) (cid.Cid, error)
 	PaychAllocateLane(ctx context.Context, ch address.Address) (uint64, error)
 	PaychNewPayment(ctx context.Context, from, to address.Address, vouchers []VoucherSpec) (*PaymentInfo, error)
 	PaychVoucherCheckValid(context.Context, address.Address, *types.SignedVoucher) error
 	PaychVoucherCheckSpendable(context.Context, address.Address, *types.SignedVoucher, []byte, []byte) (bool, error)
-	PaychVoucherCreate(context.Context, address.Address, types.BigInt, uint64) (*types.SignedVoucher, error)
+	PaychVoucherCreate(context.Context, address.Address, types.BigInt, uint64, *VoucherCreateOpts) (*types.SignedVoucher, error)
 	PaychVoucherAdd(context.Context, address.Address, *types.SignedVoucher, []byte, types.BigInt) (types.BigInt, error)
 	PaychVoucherList(context.Context, address.Address) ([]*types.SignedVoucher, error)
 	PaychVoucherSubmit(context.Context, address.Address, *types.SignedVoucher) (cid.Cid, error)
@@ -167,6 +267,17 @@ type StorageMiner interface {
 	SectorsRefs(context.Context) (map[string][]SealedRef, error)
 }
 
+// Wallet is a backend holding keys and signing with them. The full node uses
+// a remote Wallet, usually served by lotus-wallet, when one is configured
+type Wallet interface {
+	WalletNew(context.Context, string) (address.Address, error)
+	WalletHas(context.Context, address.Address) (bool, error)
+	WalletList(context.Context) ([]address.Address, error)
+	WalletSign(context.Context, address.Address, []byte) (*types.Signature, error)
+	WalletExport(context.Context, address.Address) (*types.KeyInfo, error)
+	WalletImport(context.Context, *types.KeyInfo) (address.Address, error)
+}
+
 // Version provides various build-time information
 type Version struct {
 	Version string
@@ -233,6 +344,14 @@ type ActorState struct {
 	State   interface{}
 }
 
+// MsigActorState is the state of a multisig actor as returned by
+// StateReadState, along with the part of its initial balance which is still
+// locked at the requested tipset
+type MsigActorState struct {
+	actors.MultiSigActorState
+	Locked types.BigInt
+}
+
 type PCHDir int
 
 const (
@@ -244,6 +363,26 @@ const (
 type PaychStatus struct {
 	ControlAddr address.Address
 	Direction   PCHDir
+
+	ToSend types.BigInt
+	// ClosingAt is the height after which the channel can be collected, 0 if
+	// the channel isn't settling
+	ClosingAt uint64
+	Lanes     []LaneStatus
+}
+
+type LaneStatus struct {
+	Lane     uint64
+	Closed   bool
+	Redeemed types.BigInt
+	Nonce    uint64
+}
+
+// VoucherCreateOpts are optional parameters for PaychVoucherCreate
+type VoucherCreateOpts struct {
+	// MergeLanes are merged into the lane of the voucher. The voucher amount
+	// must then include the amounts redeemed on these lanes
+	MergeLanes []uint64
 }
 
 type ChannelInfo struct {
@@ -270,6 +409,51 @@ type MinerPower struct {
 	TotalPower types.BigInt
 }
 
+type MinerRewards struct {
+	Owner address.Address
+
+	// Vested is the amount which can be withdrawn now
+	Vested types.BigInt
+
+	// Locked is the amount which hasn't vested yet
+	Locked types.BigInt
+}
+
+type MarketBalance struct {
+	// Locked is the amount backing published deals
+	Locked    types.BigInt
+	Available types.BigInt
+}
+
+type MarketDeal struct {
+	PieceRef  []byte
+	PieceSize uint64
+
+	Client   address.Address
+	Provider address.Address
+
+	Duration             uint64
+	StoragePricePerEpoch types.BigInt
+	StorageCollateral    types.BigInt
+
+	// ActivationEpoch is 0 until the provider activates the deal
+	ActivationEpoch  uint64
+	LastPaymentEpoch uint64
+	SlashedAt        uint64
+}
+
+type MsigTransaction struct {
+	ID     uint64
+	To     address.Address
+	Value  types.BigInt
+	Method uint64
+	Params []byte
+
+	// Approved lists the signers which approved the transaction, starting
+	// with the proposer
+	Approved []address.Address
+}
+
 type SealedRef struct {
 	Piece  string
 	Offset uint64
@@ -315,6 +499,71 @@ type ReplayResults struct {
 	Msg     *types.Message
 	Receipt *types.MessageReceipt
 	Error   string
+
+	ExecutionTrace types.ExecutionTrace
+}
+
+type ComputeStateOutput struct {
+	Root  cid.Cid
+	Trace []*ReplayResults
+}
+
+type ActorChange struct {
+	Address address.Address
+
+	// Old is nil if the actor was created
+	Old *types.Actor
+	// New is nil if the actor was deleted
+	New *types.Actor
+}
+
+type HDKey struct {
+	Address address.Address
+	Index   uint64
+
+	// Mnemonic is only set when a new seed was generated for the key
+	Mnemonic string `json:",omitempty"`
+}
+
+type ActorAddresses struct {
+	ID address.Address
+
+	// Key is the public key address of account actors, and address.Undef
+	// for all other actors
+	Key address.Address
+}
+
+type DecodedCall struct {
+	Method string
+	Params interface{}
+}
+
+type MpoolChange int
+
+const (
+	MpoolAdd MpoolChange = iota
+	MpoolRemove
+)
+
+type MpoolUpdate struct {
+	Type    MpoolChange
+	Message *types.SignedMessage
+}
+
+// MpoolStat describes the current contents of the message pool, along with
+// counts of messages which left it since the node started
+type MpoolStat struct {
+	Pending int
+	Senders int
+
+	// Included counts messages removed because they were included in a block
+	Included uint64
+	// PrunedNonce counts messages dropped because their nonce was already
+	// used on chain by another message
+	PrunedNonce uint64
+	// PrunedFunds counts messages dropped because their sender could no
+	// longer afford them, or no longer has an actor in the head state
+	PrunedFunds uint64
 }
 
 type SyncState struct {
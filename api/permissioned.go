@@ -27,6 +27,13 @@ func WithPerm(ctx context.Context, perms []string) context.Context {
 	return context.WithValue(ctx, permCtxKey, perms)
 }
 
+// FromAPI returns whether the request was made through the API with a token,
+// as opposed to being made by the node itself
+func FromAPI(ctx context.Context) bool {
+	_, ok := ctx.Value(permCtxKey).([]string)
+	return ok
+}
+
 func PermissionedStorMinerAPI(a StorageMiner) StorageMiner {
 	var out StorageMinerStruct
 	permissionedAny(a, &out.Internal)
@@ -41,6 +48,12 @@ func PermissionedFullAPI(a FullNode) FullNode {
 	return &out
 }
 
+func PermissionedWalletAPI(a Wallet) Wallet {
+	var out WalletStruct
+	permissionedAny(a, &out.Internal)
+	return &out
+}
+
 func permissionedAny(in interface{}, out interface{}) {
 	rint := reflect.ValueOf(out).Elem()
 	ra := reflect.ValueOf(in)
@@ -0,0 +1,47 @@
+package api
+
+import (
+	"context"
+
+	"github.com/filecoin-project/go-lotus/chain/address"
+	"github.com/filecoin-project/go-lotus/chain/types"
+)
+
+// SpendPolicy limits the messages which can be signed through the API, either
+// with a token the policy is attached to, or from an address it is set on.
+// Limits which aren't set don't apply. Multisig and payment channel actors can
+// move funds without a message value, so messages to them are rejected when
+// MaxValue or DailyLimit is set
+type SpendPolicy struct {
+	// ID identifies the policy when tracking daily spending. It is set by
+	// AuthNew and WalletSetPolicy
+	ID string
+
+	// MaxValue caps the cost of each message, its value plus the gas it can
+	// pay for
+	MaxValue types.BigInt
+	// DailyLimit caps the total cost of messages signed per UTC day
+	DailyLimit types.BigInt
+
+	// To lists the addresses messages may be sent to
+	To []address.Address
+	// Methods lists the actor methods which may be called, method 0 being a
+	// plain value transfer
+	Methods []uint64
+}
+
+type policyKey int
+
+var policyCtxKey policyKey
+
+// WithSpendPolicy attaches the spend policy of the request token to the
+// context
+func WithSpendPolicy(ctx context.Context, p *SpendPolicy) context.Context {
+	return context.WithValue(ctx, policyCtxKey, p)
+}
+
+// SpendPolicyFromContext returns the spend policy of the request token, or nil
+func SpendPolicyFromContext(ctx context.Context) *SpendPolicy {
+	p, _ := ctx.Value(policyCtxKey).(*SpendPolicy)
+	return p
+}
@@ -2,6 +2,7 @@ package api
 
 import (
 	"context"
+	"encoding/json"
 
 	sectorbuilder "github.com/filecoin-project/go-sectorbuilder"
 	"github.com/ipfs/go-cid"
@@ -18,8 +19,9 @@ var _ = AllPermissions
 
 type CommonStruct struct {
 	Internal struct {
-		AuthVerify func(ctx context.Context, token string) ([]string, error) `perm:"read"`
-		AuthNew    func(ctx context.Context, perms []string) ([]byte, error) `perm:"admin"`
+		AuthVerify func(ctx context.Context, token string) ([]string, error)                      `perm:"read"`
+		AuthNew    func(ctx context.Context, perms []string, policy *SpendPolicy) ([]byte, error) `perm:"admin"`
+		AuthPolicy func(ctx context.Context, token string) (*SpendPolicy, error)                  `perm:"read"`
 
 		NetConnectedness func(context.Context, peer.ID) (network.Connectedness, error) `perm:"read"`
 		NetPeers         func(context.Context) ([]peer.AddrInfo, error)                `perm:"read"`
@@ -70,10 +72,25 @@ type FullNodeStruct struct {
 		WalletSign           func(context.Context, address.Address, []byte) (*types.Signature, error)             `perm:"sign"`
 		WalletSignMessage    func(context.Context, address.Address, *types.Message) (*types.SignedMessage, error) `perm:"sign"`
 		WalletDefaultAddress func(context.Context) (address.Address, error)                                       `perm:"write"`
+		WalletSetDefault     func(context.Context, address.Address) error                                         `perm:"write"`
+		WalletDelete         func(context.Context, address.Address) error                                         `perm:"admin"`
+		WalletVerify         func(context.Context, address.Address, []byte, *types.Signature) (bool, error)       `perm:"read"`
+		WalletSetPolicy      func(context.Context, address.Address, *SpendPolicy) error                           `perm:"admin"`
+		WalletGetPolicy      func(context.Context, address.Address) (*SpendPolicy, error)                         `perm:"read"`
 		WalletExport         func(context.Context, address.Address) (*types.KeyInfo, error)                       `perm:"admin"`
 		WalletImport         func(context.Context, *types.KeyInfo) (address.Address, error)                       `perm:"admin"`
+		WalletLock           func(context.Context) error                                                          `perm:"admin"`
+		WalletUnlock         func(context.Context, string) error                                                  `perm:"admin"`
+		WalletNewHD          func(context.Context, string) (*HDKey, error)                                        `perm:"admin"`
+		WalletRestoreHD      func(context.Context, string) error                                                  `perm:"admin"`
+		WalletDeriveHD       func(context.Context, string, uint64) (address.Address, error)                       `perm:"admin"`
 
 		MpoolGetNonce func(context.Context, address.Address) (uint64, error) `perm:"read"`
+		MpoolSub      func(context.Context) (<-chan MpoolUpdate, error)      `perm:"read"`
+		MpoolStat     func(context.Context) (*MpoolStat, error)              `perm:"read"`
+
+		GasEstimateGasLimit func(context.Context, *types.Message, *types.TipSet) (types.BigInt, error) `perm:"read"`
+		GasEstimateGasPrice func(context.Context, uint64) (types.BigInt, error)                        `perm:"read"`
 
 		ClientImport      func(ctx context.Context, path string) (cid.Cid, error)                                                                     `perm:"write"`
 		ClientListImports func(ctx context.Context) ([]Import, error)                                                                                 `perm:"write"`
@@ -84,34 +101,56 @@ type FullNodeStruct struct {
 		ClientRetrieve    func(ctx context.Context, order RetrievalOrder, path string) error                                                          `perm:"admin"`
 		ClientQueryAsk    func(ctx context.Context, p peer.ID, miner address.Address) (*types.SignedStorageAsk, error)                                `perm:"read"`
 
-		StateMinerSectors          func(context.Context, address.Address) ([]*SectorInfo, error)                       `perm:"read"`
-		StateMinerProvingSet       func(context.Context, address.Address, *types.TipSet) ([]*SectorInfo, error)        `perm:"read"`
-		StateMinerPower            func(context.Context, address.Address, *types.TipSet) (MinerPower, error)           `perm:"read"`
-		StateMinerWorker           func(context.Context, address.Address, *types.TipSet) (address.Address, error)      `perm:"read"`
-		StateMinerPeerID           func(ctx context.Context, m address.Address, ts *types.TipSet) (peer.ID, error)     `perm:"read"`
-		StateMinerProvingPeriodEnd func(ctx context.Context, actor address.Address, ts *types.TipSet) (uint64, error)  `perm:"read"`
-		StateCall                  func(context.Context, *types.Message, *types.TipSet) (*types.MessageReceipt, error) `perm:"read"`
-		StateReplay                func(context.Context, *types.TipSet, cid.Cid) (*ReplayResults, error)               `perm:"read"`
-		StateGetActor              func(context.Context, address.Address, *types.TipSet) (*types.Actor, error)         `perm:"read"`
-		StateReadState             func(context.Context, *types.Actor, *types.TipSet) (*ActorState, error)             `perm:"read"`
-		StatePledgeCollateral      func(context.Context, *types.TipSet) (types.BigInt, error)                          `perm:"read"`
-		StateWaitMsg               func(context.Context, cid.Cid) (*MsgWait, error)                                    `perm:"read"`
-		StateListMiners            func(context.Context, *types.TipSet) ([]address.Address, error)                     `perm:"read"`
-		StateListActors            func(context.Context, *types.TipSet) ([]address.Address, error)                     `perm:"read"`
-
-		PaychGet                   func(ctx context.Context, from, to address.Address, ensureFunds types.BigInt) (*ChannelInfo, error)      `perm:"sign"`
-		PaychList                  func(context.Context) ([]address.Address, error)                                                         `perm:"read"`
-		PaychStatus                func(context.Context, address.Address) (*PaychStatus, error)                                             `perm:"read"`
-		PaychClose                 func(context.Context, address.Address) (cid.Cid, error)                                                  `perm:"sign"`
-		PaychAllocateLane          func(context.Context, address.Address) (uint64, error)                                                   `perm:"sign"`
-		PaychNewPayment            func(ctx context.Context, from, to address.Address, vouchers []VoucherSpec) (*PaymentInfo, error)        `perm:"sign"`
-		PaychVoucherCheck          func(context.Context, *types.SignedVoucher) error                                                        `perm:"read"`
-		PaychVoucherCheckValid     func(context.Context, address.Address, *types.SignedVoucher) error                                       `perm:"read"`
-		PaychVoucherCheckSpendable func(context.Context, address.Address, *types.SignedVoucher, []byte, []byte) (bool, error)               `perm:"read"`
-		PaychVoucherAdd            func(context.Context, address.Address, *types.SignedVoucher, []byte, types.BigInt) (types.BigInt, error) `perm:"write"`
-		PaychVoucherCreate         func(context.Context, address.Address, types.BigInt, uint64) (*types.SignedVoucher, error)               `perm:"sign"`
-		PaychVoucherList           func(context.Context, address.Address) ([]*types.SignedVoucher, error)                                   `perm:"write"`
-		PaychVoucherSubmit         func(context.Context, address.Address, *types.SignedVoucher) (cid.Cid, error)                            `perm:"sign"`
+		StateMinerSectors          func(context.Context, address.Address) ([]*SectorInfo, error)                               `perm:"read"`
+		StateMinerProvingSet       func(context.Context, address.Address, *types.TipSet) ([]*SectorInfo, error)                `perm:"read"`
+		StateMinerPower            func(context.Context, address.Address, *types.TipSet) (MinerPower, error)                   `perm:"read"`
+		StateMinerWorker           func(context.Context, address.Address, *types.TipSet) (address.Address, error)              `perm:"read"`
+		StateMinerPeerID           func(ctx context.Context, m address.Address, ts *types.TipSet) (peer.ID, error)             `perm:"read"`
+		StateMinerProvingPeriodEnd func(ctx context.Context, actor address.Address, ts *types.TipSet) (uint64, error)          `perm:"read"`
+		StateCall                  func(context.Context, *types.Message, *types.TipSet) (*types.MessageReceipt, error)         `perm:"read"`
+		StateReplay                func(context.Context, *types.TipSet, cid.Cid) (*ReplayResults, error)                       `perm:"read"`
+		StateCompute               func(context.Context, uint64, []*types.Message, *types.TipSet) (*ComputeStateOutput, error) `perm:"read"`
+		StateDecodeParams          func(context.Context, address.Address, uint64, []byte, *types.TipSet) (*DecodedCall, error) `perm:"read"`
+		StateDecodeReturn          func(context.Context, address.Address, uint64, []byte, *types.TipSet) (interface{}, error)  `perm:"read"`
+		StateEncodeParams          func(context.Context, cid.Cid, uint64, json.RawMessage) ([]byte, error)                     `perm:"read"`
+		StateGetActor              func(context.Context, address.Address, *types.TipSet) (*types.Actor, error)                 `perm:"read"`
+		StateReadState             func(context.Context, *types.Actor, *types.TipSet) (*ActorState, error)                     `perm:"read"`
+		StateMinerRewards          func(context.Context, address.Address, *types.TipSet) (*MinerRewards, error)                `perm:"read"`
+		StatePledgeCollateral      func(context.Context, *types.TipSet) (types.BigInt, error)                                  `perm:"read"`
+		StateMarketBalance         func(context.Context, address.Address, *types.TipSet) (MarketBalance, error)                `perm:"read"`
+		StateMarketDeals           func(context.Context, *types.TipSet) (map[string]MarketDeal, error)                         `perm:"read"`
+		StateWaitMsg               func(context.Context, cid.Cid) (*MsgWait, error)                                            `perm:"read"`
+		StateListMiners            func(context.Context, *types.TipSet) ([]address.Address, error)                             `perm:"read"`
+		StateListActors            func(context.Context, *types.TipSet) ([]address.Address, error)                             `perm:"read"`
+		StateListActorAddresses    func(context.Context, *types.TipSet) ([]ActorAddresses, error)                              `perm:"read"`
+		StateLookupID              func(context.Context, address.Address, *types.TipSet) (address.Address, error)              `perm:"read"`
+		StateAccountKey            func(context.Context, address.Address, *types.TipSet) (address.Address, error)              `perm:"read"`
+		StateChangedActors         func(context.Context, cid.Cid, cid.Cid) ([]*ActorChange, error)                             `perm:"read"`
+
+		MsigGetAvailableBalance func(context.Context, address.Address, *types.TipSet) (types.BigInt, error)                                                    `perm:"read"`
+		MsigGetPending          func(context.Context, address.Address, *types.TipSet) ([]*MsigTransaction, error)                                              `perm:"read"`
+		MsigCreate              func(context.Context, uint64, []address.Address, uint64, uint64, types.BigInt, types.BigInt, address.Address) (cid.Cid, error) `perm:"sign"`
+		MsigPropose             func(context.Context, address.Address, address.Address, types.BigInt, address.Address, uint64, []byte) (cid.Cid, error)        `perm:"sign"`
+		MsigApprove             func(context.Context, address.Address, uint64, address.Address) (cid.Cid, error)                                               `perm:"sign"`
+		MsigCancel              func(context.Context, address.Address, uint64, address.Address) (cid.Cid, error)                                               `perm:"sign"`
+		MsigAddSigner           func(context.Context, address.Address, address.Address, address.Address, bool) (cid.Cid, error)                                `perm:"sign"`
+		MsigSwapSigner          func(context.Context, address.Address, address.Address, address.Address, address.Address) (cid.Cid, error)                     `perm:"sign"`
+
+		PaychGet                   func(ctx context.Context, from, to address.Address, ensureFunds types.BigInt) (*ChannelInfo, error)            `perm:"sign"`
+		PaychList                  func(context.Context) ([]address.Address, error)                                                               `perm:"read"`
+		PaychStatus                func(context.Context, address.Address) (*PaychStatus, error)                                                   `perm:"read"`
+		PaychClose                 func(context.Context, address.Address) (cid.Cid, error)                                                        `perm:"sign"`
+		PaychSettle                func(context.Context, address.Address) (cid.Cid, error)                                                        `perm:"sign"`
+		PaychCollect               func(context.Context, address.Address) (cid.Cid, error)                                                        `perm:"sign"`
+		PaychAllocateLane          func(context.Context, address.Address) (uint64, error)                                                         `perm:"sign"`
+		PaychNewPayment            func(ctx context.Context, from, to address.Address, vouchers []VoucherSpec) (*PaymentInfo, error)              `perm:"sign"`
+		PaychVoucherCheck          func(context.Context, *types.SignedVoucher) error                                                              `perm:"read"`
+		PaychVoucherCheckValid     func(context.Context, address.Address, *types.SignedVoucher) error                                             `perm:"read"`
+		PaychVoucherCheckSpendable func(context.Context, address.Address, *types.SignedVoucher, []byte, []byte) (bool, error)                     `perm:"read"`
+		PaychVoucherAdd            func(context.Context, address.Address, *types.SignedVoucher, []byte, types.BigInt) (types.BigInt, error)       `perm:"write"`
+		PaychVoucherCreate         func(context.Context, address.Address, types.BigInt, uint64, *VoucherCreateOpts) (*types.SignedVoucher, error) `perm:"sign"`
+		PaychVoucherList           func(context.Context, address.Address) ([]*types.SignedVoucher, error)                                         `perm:"write"`
+		PaychVoucherSubmit         func(context.Context, address.Address, *types.SignedVoucher) (cid.Cid, error)                                  `perm:"sign"`
 	}
 }
 
@@ -135,8 +174,12 @@ func (c *CommonStruct) AuthVerify(ctx context.Context, token string) ([]string,
 	return c.Internal.AuthVerify(ctx, token)
 }
 
-func (c *CommonStruct) AuthNew(ctx context.Context, perms []string) ([]byte, error) {
-	return c.Internal.AuthNew(ctx, perms)
+func (c *CommonStruct) AuthNew(ctx context.Context, perms []string, policy *SpendPolicy) ([]byte, error) {
+	return c.Internal.AuthNew(ctx, perms, policy)
+}
+
+func (c *CommonStruct) AuthPolicy(ctx context.Context, token string) (*SpendPolicy, error) {
+	return c.Internal.AuthPolicy(ctx, token)
 }
 
 func (c *CommonStruct) NetConnectedness(ctx context.Context, pid peer.ID) (network.Connectedness, error) {
@@ -269,6 +312,26 @@ func (c *FullNodeStruct) WalletDefaultAddress(ctx context.Context) (address.Addr
 	return c.Internal.WalletDefaultAddress(ctx)
 }
 
+func (c *FullNodeStruct) WalletSetDefault(ctx context.Context, a address.Address) error {
+	return c.Internal.WalletSetDefault(ctx, a)
+}
+
+func (c *FullNodeStruct) WalletDelete(ctx context.Context, a address.Address) error {
+	return c.Internal.WalletDelete(ctx, a)
+}
+
+func (c *FullNodeStruct) WalletVerify(ctx context.Context, a address.Address, data []byte, sig *types.Signature) (bool, error) {
+	return c.Internal.WalletVerify(ctx, a, data, sig)
+}
+
+func (c *FullNodeStruct) WalletSetPolicy(ctx context.Context, a address.Address, policy *SpendPolicy) error {
+	return c.Internal.WalletSetPolicy(ctx, a, policy)
+}
+
+func (c *FullNodeStruct) WalletGetPolicy(ctx context.Context, a address.Address) (*SpendPolicy, error) {
+	return c.Internal.WalletGetPolicy(ctx, a)
+}
+
 func (c *FullNodeStruct) WalletExport(ctx context.Context, a address.Address) (*types.KeyInfo, error) {
 	return c.Internal.WalletExport(ctx, a)
 }
@@ -277,10 +340,46 @@ func (c *FullNodeStruct) WalletImport(ctx context.Context, ki *types.KeyInfo) (a
 	return c.Internal.WalletImport(ctx, ki)
 }
 
+func (c *FullNodeStruct) WalletLock(ctx context.Context) error {
+	return c.Internal.WalletLock(ctx)
+}
+
+func (c *FullNodeStruct) WalletUnlock(ctx context.Context, passphrase string) error {
+	return c.Internal.WalletUnlock(ctx, passphrase)
+}
+
+func (c *FullNodeStruct) WalletNewHD(ctx context.Context, typ string) (*HDKey, error) {
+	return c.Internal.WalletNewHD(ctx, typ)
+}
+
+func (c *FullNodeStruct) WalletRestoreHD(ctx context.Context, mnemonic string) error {
+	return c.Internal.WalletRestoreHD(ctx, mnemonic)
+}
+
+func (c *FullNodeStruct) WalletDeriveHD(ctx context.Context, typ string, index uint64) (address.Address, error) {
+	return c.Internal.WalletDeriveHD(ctx, typ, index)
+}
+
 func (c *FullNodeStruct) MpoolGetNonce(ctx context.Context, addr address.Address) (uint64, error) {
 	return c.Internal.MpoolGetNonce(ctx, addr)
 }
 
+func (c *FullNodeStruct) MpoolSub(ctx context.Context) (<-chan MpoolUpdate, error) {
+	return c.Internal.MpoolSub(ctx)
+}
+
+func (c *FullNodeStruct) MpoolStat(ctx context.Context) (*MpoolStat, error) {
+	return c.Internal.MpoolStat(ctx)
+}
+
+func (c *FullNodeStruct) GasEstimateGasLimit(ctx context.Context, msg *types.Message, ts *types.TipSet) (types.BigInt, error) {
+	return c.Internal.GasEstimateGasLimit(ctx, msg, ts)
+}
+
+func (c *FullNodeStruct) GasEstimateGasPrice(ctx context.Context, nblocks uint64) (types.BigInt, error) {
+	return c.Internal.GasEstimateGasPrice(ctx, nblocks)
+}
+
 func (c *FullNodeStruct) ChainGetBlock(ctx context.Context, b cid.Cid) (*types.BlockHeader, error) {
 	return c.Internal.ChainGetBlock(ctx, b)
 }
@@ -360,6 +459,22 @@ func (c *FullNodeStruct) StateReplay(ctx context.Context, ts *types.TipSet, mc c
 	return c.Internal.StateReplay(ctx, ts, mc)
 }
 
+func (c *FullNodeStruct) StateCompute(ctx context.Context, height uint64, msgs []*types.Message, ts *types.TipSet) (*ComputeStateOutput, error) {
+	return c.Internal.StateCompute(ctx, height, msgs, ts)
+}
+
+func (c *FullNodeStruct) StateDecodeParams(ctx context.Context, toAddr address.Address, method uint64, params []byte, ts *types.TipSet) (*DecodedCall, error) {
+	return c.Internal.StateDecodeParams(ctx, toAddr, method, params, ts)
+}
+
+func (c *FullNodeStruct) StateDecodeReturn(ctx context.Context, toAddr address.Address, method uint64, ret []byte, ts *types.TipSet) (interface{}, error) {
+	return c.Internal.StateDecodeReturn(ctx, toAddr, method, ret, ts)
+}
+
+func (c *FullNodeStruct) StateEncodeParams(ctx context.Context, toActCode cid.Cid, method uint64, params json.RawMessage) ([]byte, error) {
+	return c.Internal.StateEncodeParams(ctx, toActCode, method, params)
+}
+
 func (c *FullNodeStruct) StateGetActor(ctx context.Context, actor address.Address, ts *types.TipSet) (*types.Actor, error) {
 	return c.Internal.StateGetActor(ctx, actor, ts)
 }
@@ -368,10 +483,22 @@ func (c *FullNodeStruct) StateReadState(ctx context.Context, act *types.Actor, t
 	return c.Internal.StateReadState(ctx, act, ts)
 }
 
+func (c *FullNodeStruct) StateMinerRewards(ctx context.Context, maddr address.Address, ts *types.TipSet) (*MinerRewards, error) {
+	return c.Internal.StateMinerRewards(ctx, maddr, ts)
+}
+
 func (c *FullNodeStruct) StatePledgeCollateral(ctx context.Context, ts *types.TipSet) (types.BigInt, error) {
 	return c.Internal.StatePledgeCollateral(ctx, ts)
 }
 
+func (c *FullNodeStruct) StateMarketBalance(ctx context.Context, addr address.Address, ts *types.TipSet) (MarketBalance, error) {
+	return c.Internal.StateMarketBalance(ctx, addr, ts)
+}
+
+func (c *FullNodeStruct) StateMarketDeals(ctx context.Context, ts *types.TipSet) (map[string]MarketDeal, error) {
+	return c.Internal.StateMarketDeals(ctx, ts)
+}
+
 func (c *FullNodeStruct) StateWaitMsg(ctx context.Context, msgc cid.Cid) (*MsgWait, error) {
 	return c.Internal.StateWaitMsg(ctx, msgc)
 }
@@ -383,6 +510,54 @@ func (c *FullNodeStruct) StateListActors(ctx context.Context, ts *types.TipSet)
 	return c.Internal.StateListActors(ctx, ts)
 }
 
+func (c *FullNodeStruct) StateListActorAddresses(ctx context.Context, ts *types.TipSet) ([]ActorAddresses, error) {
+	return c.Internal.StateListActorAddresses(ctx, ts)
+}
+
+func (c *FullNodeStruct) StateLookupID(ctx context.Context, addr address.Address, ts *types.TipSet) (address.Address, error) {
+	return c.Internal.StateLookupID(ctx, addr, ts)
+}
+
+func (c *FullNodeStruct) StateAccountKey(ctx context.Context, addr address.Address, ts *types.TipSet) (address.Address, error) {
+	return c.Internal.StateAccountKey(ctx, addr, ts)
+}
+
+func (c *FullNodeStruct) StateChangedActors(ctx context.Context, oldRoot cid.Cid, newRoot cid.Cid) ([]*ActorChange, error) {
+	return c.Internal.StateChangedActors(ctx, oldRoot, newRoot)
+}
+
+func (c *FullNodeStruct) MsigGetAvailableBalance(ctx context.Context, addr address.Address, ts *types.TipSet) (types.BigInt, error) {
+	return c.Internal.MsigGetAvailableBalance(ctx, addr, ts)
+}
+
+func (c *FullNodeStruct) MsigGetPending(ctx context.Context, addr address.Address, ts *types.TipSet) ([]*MsigTransaction, error) {
+	return c.Internal.MsigGetPending(ctx, addr, ts)
+}
+
+func (c *FullNodeStruct) MsigCreate(ctx context.Context, required uint64, signers []address.Address, unlockStart uint64, unlockDuration uint64, locked types.BigInt, val types.BigInt, src address.Address) (cid.Cid, error) {
+	return c.Internal.MsigCreate(ctx, required, signers, unlockStart, unlockDuration, locked, val, src)
+}
+
+func (c *FullNodeStruct) MsigPropose(ctx context.Context, msig address.Address, to address.Address, amt types.BigInt, src address.Address, method uint64, params []byte) (cid.Cid, error) {
+	return c.Internal.MsigPropose(ctx, msig, to, amt, src, method, params)
+}
+
+func (c *FullNodeStruct) MsigApprove(ctx context.Context, msig address.Address, txID uint64, src address.Address) (cid.Cid, error) {
+	return c.Internal.MsigApprove(ctx, msig, txID, src)
+}
+
+func (c *FullNodeStruct) MsigCancel(ctx context.Context, msig address.Address, txID uint64, src address.Address) (cid.Cid, error) {
+	return c.Internal.MsigCancel(ctx, msig, txID, src)
+}
+
+func (c *FullNodeStruct) MsigAddSigner(ctx context.Context, msig address.Address, src address.Address, newSigner address.Address, increase bool) (cid.Cid, error) {
+	return c.Internal.MsigAddSigner(ctx, msig, src, newSigner, increase)
+}
+
+func (c *FullNodeStruct) MsigSwapSigner(ctx context.Context, msig address.Address, src address.Address, oldSigner address.Address, newSigner address.Address) (cid.Cid, error) {
+	return c.Internal.MsigSwapSigner(ctx, msig, src, oldSigner, newSigner)
+}
+
 func (c *FullNodeStruct) PaychGet(ctx context.Context, from, to address.Address, ensureFunds types.BigInt) (*ChannelInfo, error) {
 	return c.Internal.PaychGet(ctx, from, to, ensureFunds)
 }
@@ -407,8 +582,8 @@ func (c *FullNodeStruct) PaychVoucherAdd(ctx context.Context, addr address.Addre
 	return c.Internal.PaychVoucherAdd(ctx, addr, sv, proof, minDelta)
 }
 
-func (c *FullNodeStruct) PaychVoucherCreate(ctx context.Context, pch address.Address, amt types.BigInt, lane uint64) (*types.SignedVoucher, error) {
-	return c.Internal.PaychVoucherCreate(ctx, pch, amt, lane)
+func (c *FullNodeStruct) PaychVoucherCreate(ctx context.Context, pch address.Address, amt types.BigInt, lane uint64, opts *VoucherCreateOpts) (*types.SignedVoucher, error) {
+	return c.Internal.PaychVoucherCreate(ctx, pch, amt, lane, opts)
 }
 
 func (c *FullNodeStruct) PaychVoucherList(ctx context.Context, pch address.Address) ([]*types.SignedVoucher, error) {
@@ -419,6 +594,14 @@ func (c *FullNodeStruct) PaychClose(ctx context.Context, a address.Address) (cid
 	return c.Internal.PaychClose(ctx, a)
 }
 
+func (c *FullNodeStruct) PaychSettle(ctx context.Context, a address.Address) (cid.Cid, error) {
+	return c.Internal.PaychSettle(ctx, a)
+}
+
+func (c *FullNodeStruct) PaychCollect(ctx context.Context, a address.Address) (cid.Cid, error) {
+	return c.Internal.PaychCollect(ctx, a)
+}
+
 func (c *FullNodeStruct) PaychAllocateLane(ctx context.Context, ch address.Address) (uint64, error) {
 	return c.Internal.PaychAllocateLane(ctx, ch)
 }
@@ -458,6 +641,42 @@ func (c *StorageMinerStruct) SectorsRefs(ctx context.Context) (map[string][]Seal
 	return c.Internal.SectorsRefs(ctx)
 }
 
+type WalletStruct struct {
+	Internal struct {
+		WalletNew    func(context.Context, string) (address.Address, error)                   `perm:"write"`
+		WalletHas    func(context.Context, address.Address) (bool, error)                     `perm:"write"`
+		WalletList   func(context.Context) ([]address.Address, error)                         `perm:"write"`
+		WalletSign   func(context.Context, address.Address, []byte) (*types.Signature, error) `perm:"sign"`
+		WalletExport func(context.Context, address.Address) (*types.KeyInfo, error)           `perm:"admin"`
+		WalletImport func(context.Context, *types.KeyInfo) (address.Address, error)           `perm:"admin"`
+	}
+}
+
+func (c *WalletStruct) WalletNew(ctx context.Context, typ string) (address.Address, error) {
+	return c.Internal.WalletNew(ctx, typ)
+}
+
+func (c *WalletStruct) WalletHas(ctx context.Context, addr address.Address) (bool, error) {
+	return c.Internal.WalletHas(ctx, addr)
+}
+
+func (c *WalletStruct) WalletList(ctx context.Context) ([]address.Address, error) {
+	return c.Internal.WalletList(ctx)
+}
+
+func (c *WalletStruct) WalletSign(ctx context.Context, k address.Address, msg []byte) (*types.Signature, error) {
+	return c.Internal.WalletSign(ctx, k, msg)
+}
+
+func (c *WalletStruct) WalletExport(ctx context.Context, a address.Address) (*types.KeyInfo, error) {
+	return c.Internal.WalletExport(ctx, a)
+}
+
+func (c *WalletStruct) WalletImport(ctx context.Context, ki *types.KeyInfo) (address.Address, error) {
+	return c.Internal.WalletImport(ctx, ki)
+}
+
 var _ Common = &CommonStruct{}
 var _ FullNode = &FullNodeStruct{}
 var _ StorageMiner = &StorageMinerStruct{}
+var _ Wallet = &WalletStruct{}
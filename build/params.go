@@ -26,6 +26,23 @@ const MinDealVoucherIncrement = ProvingPeriodDuration
 
 const MaxVouchersPerDeal = 768 // roughly one voucher per 10h over a year
 
+// Blocks
+const DealProposalExpiration = 100
+
+// Blocks
+const DealActivationTimeout = 60 * 2 * 24 // one day
+
+// /////
+// Gas
+
+// Percent of the estimated gas usage added to estimated gas limits
+const GasLimitMarginPercent = 25
+
+// Tipsets
+const GasPriceLookback = 20
+
+const MinimumGasPrice = 0
+
 // /////
 // Consensus / Network
 
@@ -53,6 +70,15 @@ const ProvingPeriodDuration = 40
 // Blocks
 const PoSTChallangeTime = 20
 
+// Blocks
+const SlashablePowerDelay = 200
+
+// Blocks
+const DePledgeDelay = ProvingPeriodDuration
+
+// Percent of the collateral slashed for a storage fault paid to the reporter
+const StorageFaultSlasherShare = 10
+
 const PowerCollateralProportion = 5
 const PerCapitaCollateralProportion = 1
 const CollateralPrecision = 1000
@@ -73,6 +99,18 @@ const FilecoinPrecision = 1000000000000000000
 // Blocks
 const HalvingPeriodBlocks = 6 * 365 * 24 * 60 * 2
 
+// Percent of each block reward which is locked and vests linearly
+const MiningRewardLockedShare = 75
+
+// one week
+// Blocks
+const MiningRewardVestingPeriod = 7 * 24 * 60 * 2
+
+// Locked rewards awarded within the same bucket start vesting together at the
+// end of the bucket, which bounds the number of vesting entries per owner
+// Blocks
+const MiningRewardVestingBucket = 60 * 2
+
 // Blocks
 const AdjustmentPeriod = 7 * 24 * 60 * 2
 
@@ -23,10 +23,6 @@ var log = logging.Logger("actors")
 
 var EmptyCBOR cid.Cid
 
-const (
-	GasCreateActor = 100
-)
-
 func init() {
 
 	n, err := cbor.WrapObject(map[string]string{}, mh.SHA2_256, -1)
@@ -47,15 +43,17 @@ type InitActorState struct {
 }
 
 type iAMethods struct {
-	Exec uint64
+	Exec            uint64
+	GetIdForAddress uint64
 }
 
-var IAMethods = iAMethods{2}
+var IAMethods = iAMethods{2, 3}
 
 func (ia InitActor) Exports() []interface{} {
 	return []interface{}{
 		1: nil,
 		2: ia.Exec,
+		3: ia.GetIdForAddress,
 	}
 }
 
@@ -84,10 +82,6 @@ func (ia InitActor) Exec(act *types.Actor, vmctx types.VMContext, p *ExecParams)
 		return nil, err
 	}
 
-	if err := vmctx.ChargeGas(GasCreateActor); err != nil {
-		return nil, aerrors.Wrap(err, "run out of gas")
-	}
-
 	// Make sure that only the actors defined in the spec can be launched.
 	if !IsBuiltinActor(p.Code) {
 		return nil, aerrors.New(1,
@@ -170,7 +164,7 @@ func IsBuiltinActor(code cid.Cid) bool {
 }
 
 func IsSingletonActor(code cid.Cid) bool {
-	return code == StorageMarketActorCodeCid || code == InitActorCodeCid
+	return code == StoragePowerActorCodeCid || code == InitActorCodeCid
 }
 
 func (ias *InitActorState) AddActor(cst *hamt.CborIpldStore, addr address.Address) (address.Address, error) {
@@ -219,6 +213,57 @@ func (ias *InitActorState) Lookup(cst *hamt.CborIpldStore, addr address.Address)
 	return address.NewIDAddress(ival)
 }
 
+type GetIdForAddressParams struct {
+	Addr address.Address
+}
+
+// GetIdForAddress returns the ID address of the actor with the given address
+func (ia InitActor) GetIdForAddress(act *types.Actor, vmctx types.VMContext, p *GetIdForAddressParams) ([]byte, aerrors.ActorError) {
+	if p.Addr.Protocol() == address.ID {
+		return p.Addr.Bytes(), nil
+	}
+
+	var self InitActorState
+	if err := vmctx.Storage().Get(vmctx.Storage().GetHead(), &self); err != nil {
+		return nil, err
+	}
+
+	id, err := self.Lookup(vmctx.Ipld(), p.Addr)
+	if err != nil {
+		if xerrors.Is(err, hamt.ErrNotFound) {
+			return nil, aerrors.Newf(1, "no actor with address %s", p.Addr)
+		}
+		return nil, aerrors.Escalate(err, "failed to look up address")
+	}
+
+	return id.Bytes(), nil
+}
+
+// LookupIDAddress resolves an address to the ID address of its actor through
+// the init actor
+func LookupIDAddress(vmctx types.VMContext, addr address.Address) (address.Address, ActorError) {
+	if addr.Protocol() == address.ID {
+		return addr, nil
+	}
+
+	enc, err := SerializeParams(&GetIdForAddressParams{Addr: addr})
+	if err != nil {
+		return address.Undef, err
+	}
+
+	ret, err := vmctx.Send(InitActorAddress, IAMethods.GetIdForAddress, types.NewInt(0), enc)
+	if err != nil {
+		return address.Undef, aerrors.Wrapf(err, "failed to look up ID address of %s", addr)
+	}
+
+	id, aerr := address.NewFromBytes(ret)
+	if aerr != nil {
+		return address.Undef, aerrors.Absorb(aerr, 2, "init actor returned a malformed address")
+	}
+
+	return id, nil
+}
+
 type AccountActorState struct {
 	Address address.Address
 }
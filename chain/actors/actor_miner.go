@@ -1,6 +1,7 @@
 package actors
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 
@@ -12,8 +13,10 @@ import (
 
 	"github.com/filecoin-project/go-amt-ipld"
 	"github.com/ipfs/go-cid"
+	hamt "github.com/ipfs/go-hamt-ipld"
 	cbor "github.com/ipfs/go-ipld-cbor"
 	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/multiformats/go-multihash"
 	cbg "github.com/whyrusleeping/cbor-gen"
 	"golang.org/x/xerrors"
 )
@@ -54,15 +57,14 @@ type StorageMinerActorState struct {
 	// removal penalization is needed.
 	NextDoneSet types.BitField
 
-	// Deals this miner has been slashed for since the last post submission.
-	//TODO: unsupported map key type "Cid" (if you want to use struct keys, your atlas needs a transform to string)
-	//ArbitratedDeals map[cid.Cid]struct{}
+	// Deals this miner has been slashed for, a HAMT keyed by the deal CID.
+	ArbitratedDeals cid.Cid
 
 	// Amount of power this miner has.
 	Power types.BigInt
 
 	// List of sectors that this miner was slashed for.
-	//SlashedSet SectorSet
+	SlashedSet cid.Cid
 
 	// The height at which this miner was slashed at.
 	SlashedAt types.BigInt
@@ -120,32 +122,38 @@ type maMethods struct {
 	PaymentVerifySector    uint64
 	AddFaults              uint64
 	SlashConsensusFault    uint64
+	VerifyPieceInclusion   uint64
+	IsSectorSlashed        uint64
+	GetLastProvenEpoch     uint64
 }
 
-var MAMethods = maMethods{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18, 19, 20}
+var MAMethods = maMethods{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16, 17, 18, 19, 20, 21, 22, 23}
 
 func (sma StorageMinerActor) Exports() []interface{} {
 	return []interface{}{
-		1: sma.StorageMinerConstructor,
-		2: sma.CommitSector,
-		3: sma.SubmitPoSt,
-		//4:  sma.SlashStorageFault,
-		//5: sma.GetCurrentProvingSet,
-		//6:  sma.ArbitrateDeal,
-		//7:  sma.DePledge,
+		1:  sma.StorageMinerConstructor,
+		2:  sma.CommitSector,
+		3:  sma.SubmitPoSt,
+		4:  sma.SlashStorageFault,
+		5:  sma.GetCurrentProvingSet,
+		6:  sma.ArbitrateDeal,
+		7:  sma.DePledge,
 		8:  sma.GetOwner,
 		9:  sma.GetWorkerAddr,
 		10: sma.GetPower,
 		11: sma.GetPeerID,
 		12: sma.GetSectorSize,
 		13: sma.UpdatePeerID,
-		//14: sma.ChangeWorker,
-		//15: sma.IsSlashed,
-		//16: sma.IsLate,
+		14: sma.ChangeWorker,
+		15: sma.IsSlashed,
+		16: sma.IsLate,
 		17: sma.PaymentVerifyInclusion,
 		18: sma.PaymentVerifySector,
 		19: sma.AddFaults,
 		20: sma.SlashConsensusFault,
+		21: sma.VerifyPieceInclusion,
+		22: sma.IsSectorSlashed,
+		23: sma.GetLastProvenEpoch,
 	}
 }
 
@@ -190,8 +198,19 @@ func (sma StorageMinerActor) StorageMinerConstructor(act *types.Actor, vmctx typ
 
 	self.Sectors = scid
 	self.ProvingSet = scid
+	self.SlashedSet = scid
 	self.Info = minfocid
 
+	deals := hamt.NewNode(vmctx.Ipld())
+	if err := deals.Flush(vmctx.Context()); err != nil {
+		return nil, aerrors.HandleExternalError(err, "failed to flush arbitrated deals set")
+	}
+	dcid, derr := vmctx.Ipld().Put(vmctx.Context(), deals)
+	if derr != nil {
+		return nil, aerrors.HandleExternalError(derr, "failed to persist arbitrated deals set")
+	}
+	self.ArbitratedDeals = dcid
+
 	storage := vmctx.Storage()
 	c, err := storage.Put(&self)
 	if err != nil {
@@ -247,7 +266,8 @@ func (sma StorageMinerActor) CommitSector(act *types.Actor, vmctx types.VMContex
 	futurePower := types.BigAdd(self.Power, mi.SectorSize)
 	collateralRequired := CollateralForPower(futurePower)
 
-	if act.Balance.LessThan(collateralRequired) {
+	// collateral scheduled for withdrawal can't back new sectors
+	if types.BigSub(act.Balance, self.DePledgedCollateral).LessThan(collateralRequired) {
 		return nil, aerrors.New(3, "not enough collateral")
 	}
 
@@ -433,7 +453,7 @@ func (sma StorageMinerActor) SubmitPoSt(act *types.Actor, vmctx types.VMContext,
 		return nil, err
 	}
 
-	_, err = vmctx.Send(StorageMarketAddress, SPAMethods.UpdateStorage, types.NewInt(0), enc)
+	_, err = vmctx.Send(StoragePowerAddress, SPAMethods.UpdateStorage, types.NewInt(0), enc)
 	if err != nil {
 		return nil, err
 	}
@@ -454,6 +474,286 @@ func (sma StorageMinerActor) SubmitPoSt(act *types.Actor, vmctx types.VMContext,
 	return nil, nil
 }
 
+type MinerSlashStorageFault struct {
+	Slasher address.Address
+}
+
+// SlashStorageFault strips a miner which failed to submit a PoSt for too long
+// of its power, burning the collateral backing it and paying a share of it to
+// the slasher. It returns the removed power, and may only be called by the
+// storage power actor, which keeps track of the total network power
+func (sma StorageMinerActor) SlashStorageFault(act *types.Actor, vmctx types.VMContext, params *MinerSlashStorageFault) ([]byte, ActorError) {
+	if vmctx.Message().From != StoragePowerAddress {
+		return nil, aerrors.New(1, "SlashStorageFault may only be called by the storage power actor")
+	}
+
+	oldstate, self, aerr := loadState(vmctx)
+	if aerr != nil {
+		return nil, aerr
+	}
+
+	if isSlashed(self) {
+		return nil, aerrors.New(2, "miner already slashed")
+	}
+
+	if !isTardy(self, vmctx.BlockHeight()) {
+		return nil, aerrors.New(3, "miner is not yet tardy")
+	}
+
+	pss, lerr := amt.LoadAMT(types.WrapStorage(vmctx.Storage()), self.ProvingSet)
+	if lerr != nil {
+		return nil, aerrors.HandleExternalError(lerr, "could not load proving set node")
+	}
+
+	if pss.Count == 0 {
+		return nil, aerrors.New(4, "miner is inactive")
+	}
+
+	slashed := CollateralForPower(self.Power)
+	if act.Balance.LessThan(slashed) {
+		slashed = act.Balance
+	}
+
+	reward := types.BigDiv(types.BigMul(slashed, types.NewInt(build.StorageFaultSlasherShare)), types.NewInt(100))
+
+	if _, aerr := vmctx.Send(params.Slasher, 0, reward, nil); aerr != nil {
+		return nil, aerrors.Wrap(aerr, "failed to pay slasher")
+	}
+
+	if _, aerr := vmctx.Send(BurntFundsAddress, 0, types.BigSub(slashed, reward), nil); aerr != nil {
+		return nil, aerrors.Wrap(aerr, "failed to burn collateral")
+	}
+
+	emptySet, lerr := amt.NewAMT(types.WrapStorage(vmctx.Storage())).Flush()
+	if lerr != nil {
+		return nil, aerrors.HandleExternalError(lerr, "could not flush AMT")
+	}
+
+	power := self.Power
+
+	self.Power = types.NewInt(0)
+	self.SlashedSet = self.ProvingSet
+	self.ProvingSet = emptySet
+	self.SlashedAt = types.NewInt(vmctx.BlockHeight())
+
+	nstate, aerr := vmctx.Storage().Put(self)
+	if aerr != nil {
+		return nil, aerr
+	}
+	if err := vmctx.Storage().Commit(oldstate, nstate); err != nil {
+		return nil, err
+	}
+
+	return power.Bytes(), nil
+}
+
+func (sma StorageMinerActor) GetCurrentProvingSet(act *types.Actor, vmctx types.VMContext, params *struct{}) ([]byte, ActorError) {
+	_, self, err := loadState(vmctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return self.ProvingSet.Bytes(), nil
+}
+
+// StorageDeal is the commitment of a miner to store a piece for a client until
+// the deal expires. It is signed by the miner worker, so clients can bring it
+// to ArbitrateDeal if the miner drops the piece early
+type StorageDeal struct {
+	Client            address.Address
+	CommP             []byte
+	Size              uint64
+	SectorID          uint64
+	StartHeight       uint64
+	Expiration        uint64
+	StorageCollateral types.BigInt
+}
+
+// Cid returns the CID of the deal, which identifies it in arbitration even if
+// another deal stores the same piece
+func (sd *StorageDeal) Cid() (cid.Cid, error) {
+	buf := new(bytes.Buffer)
+	if err := sd.MarshalCBOR(buf); err != nil {
+		return cid.Undef, err
+	}
+
+	return cid.NewPrefixV1(cid.DagCBOR, multihash.BLAKE2B_MIN+31).Sum(buf.Bytes())
+}
+
+type ArbitrateDealParams struct {
+	Deal      StorageDeal
+	Signature types.Signature
+}
+
+// ArbitrateDeal slashes a miner which removed the sector holding a deal before
+// the deal expired. The pledge collateral for the deal is burned, and the
+// storage collateral is paid out to the client
+func (sma StorageMinerActor) ArbitrateDeal(act *types.Actor, vmctx types.VMContext, params *ArbitrateDealParams) ([]byte, ActorError) {
+	oldstate, self, aerr := loadState(vmctx)
+	if aerr != nil {
+		return nil, aerr
+	}
+
+	mi, aerr := loadMinerInfo(vmctx, self)
+	if aerr != nil {
+		return nil, aerr
+	}
+
+	deal := params.Deal
+
+	dealBytes, aerr := SerializeParams(&deal)
+	if aerr != nil {
+		return nil, aerr
+	}
+
+	if aerr := vmctx.VerifySignature(&params.Signature, mi.Worker, dealBytes); aerr != nil {
+		return nil, aerrors.Absorb(aerr, 1, "invalid signature on deal")
+	}
+
+	if vmctx.BlockHeight() < deal.StartHeight {
+		return nil, aerrors.New(2, "deal not yet started")
+	}
+
+	if deal.Expiration < vmctx.BlockHeight() {
+		return nil, aerrors.New(3, "deal is expired")
+	}
+
+	if !self.NextDoneSet.Has(deal.SectorID) {
+		return nil, aerrors.New(4, "sector of the deal was not removed")
+	}
+
+	ctx := vmctx.Context()
+
+	nd, err := hamt.LoadNode(ctx, vmctx.Ipld(), self.ArbitratedDeals)
+	if err != nil {
+		return nil, aerrors.HandleExternalError(err, "failed to load arbitrated deals")
+	}
+
+	dcid, err := deal.Cid()
+	if err != nil {
+		return nil, aerrors.HandleExternalError(err, "failed to compute deal CID")
+	}
+
+	dkey := dcid.KeyString()
+	err = nd.Find(ctx, dkey, nil)
+	if err == nil {
+		return nil, aerrors.New(5, "cannot slash miner twice for the same deal")
+	}
+	if !xerrors.Is(err, hamt.ErrNotFound) {
+		return nil, aerrors.HandleExternalError(err, "failed to look up arbitrated deal")
+	}
+
+	pledge := CollateralForPower(types.NewInt(deal.Size))
+	storage := deal.StorageCollateral
+
+	// pay the client first, whatever is left of the pledge is burned
+	if act.Balance.LessThan(storage) {
+		storage = act.Balance
+	}
+	if remaining := types.BigSub(act.Balance, storage); remaining.LessThan(pledge) {
+		pledge = remaining
+	}
+
+	if _, aerr := vmctx.Send(deal.Client, 0, storage, nil); aerr != nil {
+		return nil, aerrors.Wrap(aerr, "failed to pay client")
+	}
+
+	if _, aerr := vmctx.Send(BurntFundsAddress, 0, pledge, nil); aerr != nil {
+		return nil, aerrors.Wrap(aerr, "failed to burn pledge collateral")
+	}
+
+	if err := nd.Set(ctx, dkey, uint64(1)); err != nil {
+		return nil, aerrors.HandleExternalError(err, "failed to record arbitrated deal")
+	}
+
+	if err := nd.Flush(ctx); err != nil {
+		return nil, aerrors.HandleExternalError(err, "failed to flush arbitrated deals")
+	}
+
+	ncid, err := vmctx.Ipld().Put(ctx, nd)
+	if err != nil {
+		return nil, aerrors.HandleExternalError(err, "failed to persist arbitrated deals")
+	}
+	self.ArbitratedDeals = ncid
+
+	nstate, aerr := vmctx.Storage().Put(self)
+	if aerr != nil {
+		return nil, aerr
+	}
+	if err := vmctx.Storage().Commit(oldstate, nstate); err != nil {
+		return nil, err
+	}
+
+	return nil, nil
+}
+
+type DePledgeParams struct {
+	Amount types.BigInt
+}
+
+// DePledge schedules the withdrawal of collateral not backing any power.
+// Once DePledgeDelay blocks have passed, calling it again pays the requested
+// amount out to the owner, up to what was scheduled. A new withdrawal can be
+// scheduled once all of the scheduled collateral was paid out
+func (sma StorageMinerActor) DePledge(act *types.Actor, vmctx types.VMContext, params *DePledgeParams) ([]byte, ActorError) {
+	oldstate, self, aerr := loadState(vmctx)
+	if aerr != nil {
+		return nil, aerr
+	}
+
+	mi, aerr := loadMinerInfo(vmctx, self)
+	if aerr != nil {
+		return nil, aerr
+	}
+
+	if vmctx.Message().From != mi.Worker && vmctx.Message().From != mi.Owner {
+		return nil, aerrors.New(1, "not authorized to depledge collateral")
+	}
+
+	if params.Amount.Nil() || params.Amount.Sign() <= 0 {
+		return nil, aerrors.New(4, "depledge amount must be positive")
+	}
+
+	if self.DePledgeTime.GreaterThan(types.NewInt(0)) {
+		if self.DePledgeTime.GreaterThan(types.NewInt(vmctx.BlockHeight())) {
+			return nil, aerrors.New(2, "too early to withdraw collateral")
+		}
+
+		amount := params.Amount
+		if self.DePledgedCollateral.LessThan(amount) {
+			amount = self.DePledgedCollateral
+		}
+
+		if _, aerr := vmctx.Send(mi.Owner, 0, amount, nil); aerr != nil {
+			return nil, aerrors.Wrap(aerr, "failed to send collateral to owner")
+		}
+
+		self.DePledgedCollateral = types.BigSub(self.DePledgedCollateral, amount)
+		if types.BigCmp(self.DePledgedCollateral, types.NewInt(0)) == 0 {
+			self.DePledgeTime = types.NewInt(0)
+		}
+	} else {
+		locked := types.BigAdd(CollateralForPower(self.Power), self.OwedStorageCollateral)
+		available := types.BigSub(act.Balance, locked)
+		if available.LessThan(params.Amount) {
+			return nil, aerrors.Newf(3, "not enough collateral to withdraw (%s available)", available)
+		}
+
+		self.DePledgedCollateral = params.Amount
+		self.DePledgeTime = types.NewInt(vmctx.BlockHeight() + build.DePledgeDelay)
+	}
+
+	nstate, aerr := vmctx.Storage().Put(self)
+	if aerr != nil {
+		return nil, aerr
+	}
+	if err := vmctx.Storage().Commit(oldstate, nstate); err != nil {
+		return nil, err
+	}
+
+	return nil, nil
+}
+
 func (sma StorageMinerActor) GetPower(act *types.Actor, vmctx types.VMContext, params *struct{}) ([]byte, ActorError) {
 	_, self, err := loadState(vmctx)
 	if err != nil {
@@ -629,6 +929,135 @@ func (sma StorageMinerActor) GetSectorSize(act *types.Actor, vmctx types.VMConte
 	return mi.SectorSize.Bytes(), nil
 }
 
+type ChangeWorkerParams struct {
+	NewWorker address.Address
+}
+
+func (sma StorageMinerActor) ChangeWorker(act *types.Actor, vmctx types.VMContext, params *ChangeWorkerParams) ([]byte, ActorError) {
+	oldstate, self, err := loadState(vmctx)
+	if err != nil {
+		return nil, err
+	}
+
+	mi, err := loadMinerInfo(vmctx, self)
+	if err != nil {
+		return nil, err
+	}
+
+	if vmctx.Message().From != mi.Owner {
+		return nil, aerrors.New(1, "only the owner can change the worker address")
+	}
+
+	if params.NewWorker.Protocol() != address.BLS && params.NewWorker.Protocol() != address.SECP256K1 {
+		return nil, aerrors.New(2, "worker address must be a key address")
+	}
+
+	mi.Worker = params.NewWorker
+
+	mic, err := vmctx.Storage().Put(mi)
+	if err != nil {
+		return nil, err
+	}
+
+	self.Info = mic
+
+	c, err := vmctx.Storage().Put(self)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := vmctx.Storage().Commit(oldstate, c); err != nil {
+		return nil, err
+	}
+
+	return nil, nil
+}
+
+func (sma StorageMinerActor) IsSlashed(act *types.Actor, vmctx types.VMContext, params *struct{}) ([]byte, ActorError) {
+	_, self, err := loadState(vmctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return cbg.EncodeBool(isSlashed(self)), nil
+}
+
+func (sma StorageMinerActor) IsLate(act *types.Actor, vmctx types.VMContext, params *struct{}) ([]byte, ActorError) {
+	_, self, err := loadState(vmctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return cbg.EncodeBool(isLate(self, vmctx.BlockHeight())), nil
+}
+
+// GetLastProvenEpoch returns the end of the last proving period the miner
+// submitted a PoSt for
+func (sma StorageMinerActor) GetLastProvenEpoch(act *types.Actor, vmctx types.VMContext, params *struct{}) ([]byte, ActorError) {
+	_, self, err := loadState(vmctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return cbg.CborEncodeMajorType(cbg.MajUnsignedInt, lastProvenEpoch(self)), nil
+}
+
+type IsSectorSlashedParams struct {
+	SectorID uint64
+}
+
+// IsSectorSlashed returns whether the given sector was part of the proving
+// set when the miner was slashed for a storage fault
+func (sma StorageMinerActor) IsSectorSlashed(act *types.Actor, vmctx types.VMContext, params *IsSectorSlashedParams) ([]byte, ActorError) {
+	_, self, err := loadState(vmctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if !isSlashed(self) {
+		return cbg.EncodeBool(false), nil
+	}
+
+	ok, _, _, err := GetFromSectorSet(vmctx.Context(), vmctx.Storage(), self.SlashedSet, params.SectorID)
+	if err != nil {
+		return nil, err
+	}
+
+	return cbg.EncodeBool(ok), nil
+}
+
+func isSlashed(self *StorageMinerActorState) bool {
+	return self.SlashedAt.GreaterThan(types.NewInt(0))
+}
+
+// isLate returns whether the miner missed the PoSt deadline of its current
+// proving period as of the given height
+func isLate(self *StorageMinerActorState, height uint64) bool {
+	return self.ProvingPeriodEnd != 0 && height > self.ProvingPeriodEnd
+}
+
+// lastProvenEpoch returns the end of the last proving period the miner
+// submitted a PoSt for, or the height of its first commitment if it didn't
+// submit one yet
+func lastProvenEpoch(self *StorageMinerActorState) uint64 {
+	if self.ProvingPeriodEnd < build.ProvingPeriodDuration {
+		return 0
+	}
+	return self.ProvingPeriodEnd - build.ProvingPeriodDuration
+}
+
+// isTardy returns whether the miner has been late for long enough to have its
+// power slashed
+func isTardy(self *StorageMinerActorState, height uint64) bool {
+	return self.ProvingPeriodEnd != 0 && height > self.ProvingPeriodEnd+build.SlashablePowerDelay
+}
+
+// StorageFaultSlashable returns whether reporting a storage fault against the
+// miner would succeed at the given height
+func StorageFaultSlashable(self *StorageMinerActorState, height uint64) bool {
+	return !isSlashed(self) && isTardy(self, height) && self.Power.GreaterThan(types.NewInt(0))
+}
+
 type PaymentVerifyParams struct {
 	Extra []byte
 	Proof []byte
@@ -666,23 +1095,55 @@ func (sma StorageMinerActor) PaymentVerifyInclusion(act *types.Actor, vmctx type
 		return nil, aerrors.Absorb(err, 3, "failed to decode storage payment proof")
 	}
 
-	ok, _, commD, aerr := GetFromSectorSet(context.TODO(), vmctx.Storage(), self.Sectors, proof.Sector)
+	return nil, verifyPieceInclusion(vmctx, self, mi, voucherData.CommP, voucherData.PieceSize.Uint64(), proof.Sector, proof.Proof)
+}
+
+type VerifyPieceInclusionParams struct {
+	CommP     []byte
+	PieceSize uint64
+
+	SectorID uint64
+	Proof    []byte
+}
+
+// VerifyPieceInclusion checks the given piece is included in a committed
+// sector of the miner
+func (sma StorageMinerActor) VerifyPieceInclusion(act *types.Actor, vmctx types.VMContext, params *VerifyPieceInclusionParams) ([]byte, ActorError) {
+	_, self, aerr := loadState(vmctx)
+	if aerr != nil {
+		return nil, aerr
+	}
+	mi, aerr := loadMinerInfo(vmctx, self)
 	if aerr != nil {
 		return nil, aerr
 	}
+
+	return nil, verifyPieceInclusion(vmctx, self, mi, params.CommP, params.PieceSize, params.SectorID, params.Proof)
+}
+
+// VerifyPieceInclusionProof checks piece inclusion proofs for deals. It is a
+// variable so that tests can check deals against sectors which were never
+// sealed
+var VerifyPieceInclusionProof = sectorbuilder.VerifyPieceInclusionProof
+
+func verifyPieceInclusion(vmctx types.VMContext, self *StorageMinerActorState, mi *MinerInfo, commP []byte, pieceSize uint64, sectorID uint64, proof []byte) ActorError {
+	ok, _, commD, aerr := GetFromSectorSet(context.TODO(), vmctx.Storage(), self.Sectors, sectorID)
+	if aerr != nil {
+		return aerr
+	}
 	if !ok {
-		return nil, aerrors.New(4, "miner does not have required sector")
+		return aerrors.New(4, "miner does not have required sector")
 	}
 
-	ok, err := sectorbuilder.VerifyPieceInclusionProof(mi.SectorSize.Uint64(), voucherData.PieceSize.Uint64(), voucherData.CommP, commD, proof.Proof)
+	ok, err := VerifyPieceInclusionProof(mi.SectorSize.Uint64(), pieceSize, commP, commD, proof)
 	if err != nil {
-		return nil, aerrors.Absorb(err, 5, "verify piece inclusion proof failed")
+		return aerrors.Absorb(err, 5, "verify piece inclusion proof failed")
 	}
 	if !ok {
-		return nil, aerrors.New(6, "piece inclusion proof was invalid")
+		return aerrors.New(6, "piece inclusion proof was invalid")
 	}
 
-	return nil, nil
+	return nil
 }
 
 func (sma StorageMinerActor) PaymentVerifySector(act *types.Actor, vmctx types.VMContext, params *PaymentVerifyParams) ([]byte, ActorError) {
@@ -753,8 +1214,8 @@ type MinerSlashConsensusFault struct {
 }
 
 func (sma StorageMinerActor) SlashConsensusFault(act *types.Actor, vmctx types.VMContext, params *MinerSlashConsensusFault) ([]byte, ActorError) {
-	if vmctx.Message().From != StorageMarketAddress {
-		return nil, aerrors.New(1, "SlashConsensusFault may only be called by the storage market actor")
+	if vmctx.Message().From != StoragePowerAddress {
+		return nil, aerrors.New(1, "SlashConsensusFault may only be called by the storage power actor")
 	}
 
 	slashedCollateral := params.SlashedCollateral
@@ -0,0 +1,449 @@
+package actors_test
+
+import (
+	"context"
+	"testing"
+
+	amt "github.com/filecoin-project/go-amt-ipld"
+	cid "github.com/ipfs/go-cid"
+	hamt "github.com/ipfs/go-hamt-ipld"
+	"github.com/stretchr/testify/assert"
+	cbg "github.com/whyrusleeping/cbor-gen"
+
+	"github.com/filecoin-project/go-lotus/build"
+	. "github.com/filecoin-project/go-lotus/chain/actors"
+	"github.com/filecoin-project/go-lotus/chain/address"
+	"github.com/filecoin-project/go-lotus/chain/types"
+	"github.com/filecoin-project/go-lotus/chain/vm"
+)
+
+func createTestMiner(t *testing.T, h *Harness, ownerAddr, workerAddr address.Address) address.Address {
+	t.Helper()
+
+	// cheating the bootstrapping problem
+	cheatStoragePowerTotal(t, h.vm, h.cs.Blockstore())
+
+	ret, _ := h.InvokeWithValue(t, ownerAddr, StoragePowerAddress, SPAMethods.CreateStorageMiner,
+		types.NewInt(500000),
+		&CreateStorageMinerParams{
+			Owner:      ownerAddr,
+			Worker:     workerAddr,
+			SectorSize: types.NewInt(build.SectorSize),
+			PeerID:     "fakepeerid",
+		})
+	ApplyOK(t, ret)
+
+	minerAddr, err := address.NewFromBytes(ret.Return)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return minerAddr
+}
+
+func TestMinerChangeWorker(t *testing.T) {
+	var ownerAddr, workerAddr, newWorker address.Address
+	h := NewHarness(t,
+		HarnessAddr(&ownerAddr, 1000000),
+		HarnessAddr(&workerAddr, 100000),
+		HarnessAddr(&newWorker, 100000),
+	)
+
+	minerAddr := createTestMiner(t, h, ownerAddr, workerAddr)
+
+	{
+		ret, _ := h.Invoke(t, workerAddr, minerAddr, MAMethods.ChangeWorker,
+			&ChangeWorkerParams{NewWorker: newWorker})
+		assert.Equal(t, byte(1), ret.ExitCode, "only the owner should be able to change the worker")
+	}
+
+	{
+		ret, _ := h.Invoke(t, ownerAddr, minerAddr, MAMethods.ChangeWorker,
+			&ChangeWorkerParams{NewWorker: minerAddr})
+		assert.Equal(t, byte(2), ret.ExitCode, "worker must be a key address")
+	}
+
+	{
+		ret, _ := h.Invoke(t, ownerAddr, minerAddr, MAMethods.ChangeWorker,
+			&ChangeWorkerParams{NewWorker: newWorker})
+		ApplyOK(t, ret)
+	}
+
+	{
+		ret, _ := h.Invoke(t, ownerAddr, minerAddr, MAMethods.GetWorkerAddr, nil)
+		ApplyOK(t, ret)
+		w, err := address.NewFromBytes(ret.Return)
+		assert.NoError(t, err)
+		assert.Equal(t, newWorker, w)
+	}
+}
+
+func TestMinerDePledge(t *testing.T) {
+	var ownerAddr, workerAddr address.Address
+	h := NewHarness(t,
+		HarnessAddr(&ownerAddr, 1000000),
+		HarnessAddr(&workerAddr, 100000),
+	)
+
+	minerAddr := createTestMiner(t, h, ownerAddr, workerAddr)
+
+	{
+		ret, _ := h.Invoke(t, workerAddr, minerAddr, MAMethods.DePledge,
+			&DePledgeParams{Amount: types.NewInt(1000000)})
+		assert.Equal(t, byte(3), ret.ExitCode, "can't withdraw more than the miner has")
+	}
+
+	{
+		ret, _ := h.Invoke(t, workerAddr, minerAddr, MAMethods.DePledge,
+			&DePledgeParams{Amount: types.NewInt(0)})
+		assert.Equal(t, byte(4), ret.ExitCode, "can't schedule an empty withdrawal")
+
+		ret, _ = h.Invoke(t, workerAddr, minerAddr, MAMethods.DePledge,
+			&DePledgeParams{Amount: types.BigSub(types.NewInt(0), types.NewInt(1000))})
+		assert.Equal(t, byte(4), ret.ExitCode, "can't schedule a negative withdrawal")
+	}
+
+	{
+		ret, _ := h.Invoke(t, workerAddr, minerAddr, MAMethods.DePledge,
+			&DePledgeParams{Amount: types.NewInt(1000)})
+		ApplyOK(t, ret)
+	}
+
+	{
+		ret, _ := h.Invoke(t, workerAddr, minerAddr, MAMethods.DePledge,
+			&DePledgeParams{Amount: types.NewInt(1000)})
+		assert.Equal(t, byte(2), ret.ExitCode, "withdrawing before the delay passed should fail")
+	}
+
+	ownerBalance, err := h.vm.ActorBalance(ownerAddr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	h.vm.SetBlockHeight(1 + build.DePledgeDelay)
+
+	{
+		ret, _ := h.Invoke(t, workerAddr, minerAddr, MAMethods.DePledge,
+			&DePledgeParams{Amount: types.BigSub(types.NewInt(0), types.NewInt(400))})
+		assert.Equal(t, byte(4), ret.ExitCode, "can't withdraw a negative amount")
+	}
+
+	{
+		ret, _ := h.Invoke(t, workerAddr, minerAddr, MAMethods.DePledge,
+			&DePledgeParams{Amount: types.NewInt(400)})
+		ApplyOK(t, ret)
+	}
+
+	b, err := h.vm.ActorBalance(ownerAddr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, types.BigAdd(ownerBalance, types.NewInt(400)), b, "owner should receive the requested collateral")
+
+	{
+		// only the rest of the scheduled collateral is paid out
+		ret, _ := h.Invoke(t, workerAddr, minerAddr, MAMethods.DePledge,
+			&DePledgeParams{Amount: types.NewInt(1000)})
+		ApplyOK(t, ret)
+	}
+
+	b, err = h.vm.ActorBalance(ownerAddr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, types.BigAdd(ownerBalance, types.NewInt(1000)), b, "owner should receive the depledged collateral")
+
+	{
+		// the previous withdrawal is done, a new one can be scheduled
+		ret, _ := h.Invoke(t, ownerAddr, minerAddr, MAMethods.DePledge,
+			&DePledgeParams{Amount: types.NewInt(1000)})
+		ApplyOK(t, ret)
+	}
+}
+
+func TestMinerWithdrawDePledged(t *testing.T) {
+	var ownerAddr, workerAddr address.Address
+	h := NewHarness(t,
+		HarnessAddr(&ownerAddr, 1000000),
+		HarnessAddr(&workerAddr, 100000),
+	)
+
+	minerAddr := createTestMiner(t, h, ownerAddr, workerAddr)
+
+	ret, _ := h.Invoke(t, workerAddr, minerAddr, MAMethods.DePledge,
+		&DePledgeParams{Amount: types.NewInt(1000)})
+	ApplyOK(t, ret)
+
+	h.vm.SetBlockHeight(1 + build.DePledgeDelay)
+
+	ownerBalance, err := h.vm.ActorBalance(ownerAddr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// `lotus-storage-miner collateral withdraw` sends the amount scheduled in the miner state
+	scheduled := loadMinerState(t, h, minerAddr).DePledgedCollateral
+	assert.Equal(t, types.NewInt(1000), scheduled)
+
+	ret, _ = h.Invoke(t, workerAddr, minerAddr, MAMethods.DePledge,
+		&DePledgeParams{Amount: scheduled})
+	ApplyOK(t, ret)
+
+	b, err := h.vm.ActorBalance(ownerAddr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, types.BigAdd(ownerBalance, scheduled), b, "owner should receive all scheduled collateral")
+
+	mstate := loadMinerState(t, h, minerAddr)
+	assert.Equal(t, types.NewInt(0), mstate.DePledgedCollateral)
+	assert.Equal(t, types.NewInt(0), mstate.DePledgeTime, "the withdrawal should be finished")
+}
+
+func TestMinerFaultQueries(t *testing.T) {
+	var ownerAddr, workerAddr, clientAddr address.Address
+	h := NewHarness(t,
+		HarnessAddr(&ownerAddr, 1000000),
+		HarnessAddr(&workerAddr, 100000),
+		HarnessAddr(&clientAddr, 100000),
+	)
+
+	minerAddr := createTestMiner(t, h, ownerAddr, workerAddr)
+
+	for _, method := range []uint64{MAMethods.IsSlashed, MAMethods.IsLate} {
+		ret, _ := h.Invoke(t, clientAddr, minerAddr, method, nil)
+		ApplyOK(t, ret)
+		assert.Equal(t, cbg.CborBoolFalse, ret.Return)
+	}
+
+	{
+		ret, _ := h.Invoke(t, clientAddr, minerAddr, MAMethods.GetCurrentProvingSet, nil)
+		ApplyOK(t, ret)
+		_, err := cid.Cast(ret.Return)
+		assert.NoError(t, err)
+	}
+
+	{
+		ret, _ := h.Invoke(t, clientAddr, minerAddr, MAMethods.SlashStorageFault,
+			&MinerSlashStorageFault{Slasher: clientAddr})
+		assert.Equal(t, byte(1), ret.ExitCode, "storage faults must be reported through the power actor")
+	}
+
+	{
+		ret, _ := h.Invoke(t, clientAddr, StoragePowerAddress, SPAMethods.SlashStorageFault,
+			&SlashStorageFaultParams{Miner: minerAddr})
+		assert.Equal(t, byte(3), ret.ExitCode, "a miner without a proving period can't be tardy")
+	}
+
+	deal := StorageDeal{
+		Client:            clientAddr,
+		CommP:             []byte("fakecommp"),
+		Size:              1024,
+		SectorID:          1,
+		StartHeight:       0,
+		Expiration:        100,
+		StorageCollateral: types.NewInt(100),
+	}
+	dealBytes := DumpObject(t, &deal)
+
+	{
+		sig, err := h.w.Sign(context.TODO(), clientAddr, dealBytes)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		ret, _ := h.Invoke(t, clientAddr, minerAddr, MAMethods.ArbitrateDeal,
+			&ArbitrateDealParams{Deal: deal, Signature: *sig})
+		assert.Equal(t, byte(1), ret.ExitCode, "deal must be signed by the worker")
+	}
+
+	{
+		sig, err := h.w.Sign(context.TODO(), workerAddr, dealBytes)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		ret, _ := h.Invoke(t, clientAddr, minerAddr, MAMethods.ArbitrateDeal,
+			&ArbitrateDealParams{Deal: deal, Signature: *sig})
+		assert.Equal(t, byte(4), ret.ExitCode, "sector wasn't removed, so the miner can't be slashed")
+	}
+}
+
+func TestMinerArbitrateDeal(t *testing.T) {
+	var ownerAddr, workerAddr, clientAddr address.Address
+	h := NewHarness(t,
+		HarnessAddr(&ownerAddr, 1000000),
+		HarnessAddr(&workerAddr, 100000),
+		HarnessAddr(&clientAddr, 100000),
+	)
+
+	minerAddr := createTestMiner(t, h, ownerAddr, workerAddr)
+
+	// the miner reported the deal sector as done before the deals expired
+	cheatMinerState(t, h, minerAddr, func(mstate *StorageMinerActorState) {
+		mstate.NextDoneSet = types.BitFieldFromSet([]uint64{1})
+	})
+
+	arbitrate := func(deal StorageDeal) *vm.ApplyRet {
+		sig, err := h.w.Sign(context.TODO(), workerAddr, DumpObject(t, &deal))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		ret, _ := h.Invoke(t, clientAddr, minerAddr, MAMethods.ArbitrateDeal,
+			&ArbitrateDealParams{Deal: deal, Signature: *sig})
+		return ret
+	}
+
+	deal := StorageDeal{
+		Client:            clientAddr,
+		CommP:             []byte("fakecommp"),
+		Size:              1024,
+		SectorID:          1,
+		StartHeight:       0,
+		Expiration:        100,
+		StorageCollateral: types.NewInt(100),
+	}
+
+	ApplyOK(t, arbitrate(deal))
+	h.AssertBalanceChange(t, clientAddr, 100)
+
+	ret := arbitrate(deal)
+	assert.Equal(t, byte(5), ret.ExitCode, "the miner can't be slashed twice for the same deal")
+
+	// another deal for the same piece is arbitrated separately
+	other := deal
+	other.StorageCollateral = types.NewInt(50)
+	ApplyOK(t, arbitrate(other))
+	h.AssertBalanceChange(t, clientAddr, 50)
+}
+
+func loadMinerState(t *testing.T, h *Harness, maddr address.Address) *StorageMinerActorState {
+	t.Helper()
+
+	act, err := h.vm.StateTree().GetActor(maddr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var mstate StorageMinerActorState
+	if err := hamt.CSTFromBstore(h.cs.Blockstore()).Get(context.TODO(), act.Head, &mstate); err != nil {
+		t.Fatal(err)
+	}
+
+	return &mstate
+}
+
+func cheatMinerState(t *testing.T, h *Harness, maddr address.Address, cb func(*StorageMinerActorState)) {
+	t.Helper()
+
+	act, err := h.vm.StateTree().GetActor(maddr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cst := hamt.CSTFromBstore(h.cs.Blockstore())
+
+	var mstate StorageMinerActorState
+	if err := cst.Get(context.TODO(), act.Head, &mstate); err != nil {
+		t.Fatal(err)
+	}
+
+	cb(&mstate)
+
+	c, err := cst.Put(context.TODO(), &mstate)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	act.Head = c
+
+	if err := h.vm.StateTree().SetActor(maddr, act); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSlashStorageFault(t *testing.T) {
+	var ownerAddr, workerAddr, reporterAddr address.Address
+	h := NewHarness(t,
+		HarnessAddr(&ownerAddr, 1000000),
+		HarnessAddr(&workerAddr, 100000),
+		HarnessAddr(&reporterAddr, 100000),
+	)
+
+	minerAddr := createTestMiner(t, h, ownerAddr, workerAddr)
+
+	// pretend the miner proved a sector, and then stopped submitting PoSts
+	pset := amt.NewAMT(amt.WrapBlockstore(h.cs.Blockstore()))
+	if err := pset.Set(1, [][]byte{[]byte("commR"), []byte("commD")}); err != nil {
+		t.Fatal(err)
+	}
+	psetc, err := pset.Flush()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cheatMinerState(t, h, minerAddr, func(mstate *StorageMinerActorState) {
+		mstate.ProvingSet = psetc
+		mstate.Power = types.NewInt(1024)
+		mstate.ProvingPeriodEnd = 10
+	})
+
+	{
+		h.vm.SetBlockHeight(10 + build.SlashablePowerDelay)
+		ret, _ := h.Invoke(t, reporterAddr, StoragePowerAddress, SPAMethods.SlashStorageFault,
+			&SlashStorageFaultParams{Miner: minerAddr})
+		assert.Equal(t, byte(3), ret.ExitCode, "miner is still within the grace period")
+	}
+
+	{
+		ret, _ := h.Invoke(t, reporterAddr, minerAddr, MAMethods.IsLate, nil)
+		ApplyOK(t, ret)
+		assert.Equal(t, cbg.CborBoolTrue, ret.Return)
+	}
+
+	h.vm.SetBlockHeight(11 + build.SlashablePowerDelay)
+
+	{
+		ret, _ := h.Invoke(t, reporterAddr, StoragePowerAddress, SPAMethods.SlashStorageFault,
+			&SlashStorageFaultParams{Miner: minerAddr})
+		ApplyOK(t, ret)
+	}
+
+	slashed := CollateralForPower(types.NewInt(1024))
+	reward := types.BigDiv(types.BigMul(slashed, types.NewInt(build.StorageFaultSlasherShare)), types.NewInt(100))
+	h.AssertBalanceChange(t, reporterAddr, reward.Int64())
+
+	{
+		ret, _ := h.Invoke(t, reporterAddr, minerAddr, MAMethods.IsSlashed, nil)
+		ApplyOK(t, ret)
+		assert.Equal(t, cbg.CborBoolTrue, ret.Return)
+	}
+
+	{
+		ret, _ := h.Invoke(t, reporterAddr, minerAddr, MAMethods.IsSectorSlashed, &IsSectorSlashedParams{SectorID: 1})
+		ApplyOK(t, ret)
+		assert.Equal(t, cbg.CborBoolTrue, ret.Return, "the proven sector should be slashed")
+
+		ret, _ = h.Invoke(t, reporterAddr, minerAddr, MAMethods.IsSectorSlashed, &IsSectorSlashedParams{SectorID: 2})
+		ApplyOK(t, ret)
+		assert.Equal(t, cbg.CborBoolFalse, ret.Return)
+	}
+
+	{
+		ret, _ := h.Invoke(t, reporterAddr, minerAddr, MAMethods.GetPower, nil)
+		ApplyOK(t, ret)
+		assert.Equal(t, types.NewInt(0), types.BigFromBytes(ret.Return))
+	}
+
+	{
+		ret, _ := h.Invoke(t, reporterAddr, StoragePowerAddress, SPAMethods.GetTotalStorage, nil)
+		ApplyOK(t, ret)
+		assert.Equal(t, types.NewInt(10000-1024), types.BigFromBytes(ret.Return))
+	}
+
+	{
+		ret, _ := h.Invoke(t, reporterAddr, StoragePowerAddress, SPAMethods.SlashStorageFault,
+			&SlashStorageFaultParams{Miner: minerAddr})
+		assert.Equal(t, byte(2), ret.ExitCode, "miner can't be slashed twice")
+	}
+}
@@ -1,6 +1,7 @@
 package actors
 
 import (
+	"github.com/filecoin-project/go-amt-ipld"
 	"github.com/ipfs/go-cid"
 
 	"github.com/filecoin-project/go-lotus/chain/actors/aerrors"
@@ -16,8 +17,14 @@ type MultiSigActorState struct {
 	Required uint64
 	NextTxID uint64
 
-	//TODO: make this map/sharray/whatever
-	Transactions []MTransaction
+	// InitialBalance is locked at StartingBlock and unlocks linearly over
+	// UnlockDuration blocks. Nothing is locked if UnlockDuration is 0
+	InitialBalance types.BigInt
+	StartingBlock  uint64
+	UnlockDuration uint64
+
+	// Transactions is an AMT of MTransaction keyed by TxID
+	Transactions cid.Cid
 }
 
 func (msas MultiSigActorState) isSigner(addr address.Address) bool {
@@ -29,15 +36,85 @@ func (msas MultiSigActorState) isSigner(addr address.Address) bool {
 	return false
 }
 
-func (msas MultiSigActorState) getTransaction(txid uint64) *MTransaction {
-	if txid > uint64(len(msas.Transactions)) {
+// AmountLocked returns the part of the initial balance which is still locked
+// the given number of blocks after StartingBlock
+func (msas MultiSigActorState) AmountLocked(elapsed uint64) types.BigInt {
+	if elapsed >= msas.UnlockDuration {
+		return types.NewInt(0)
+	}
+
+	unlocked := types.BigDiv(types.BigMul(msas.InitialBalance, types.NewInt(elapsed)), types.NewInt(msas.UnlockDuration))
+	return types.BigSub(msas.InitialBalance, unlocked)
+}
+
+// LockedAt returns the part of the initial balance which is still locked at
+// the given height. Everything is locked until StartingBlock
+func (msas MultiSigActorState) LockedAt(height uint64) types.BigInt {
+	if msas.UnlockDuration == 0 {
+		return types.NewInt(0)
+	}
+
+	var elapsed uint64
+	if height > msas.StartingBlock {
+		elapsed = height - msas.StartingBlock
+	}
+
+	return msas.AmountLocked(elapsed)
+}
+
+// checkUnlocked fails if sending amount would take the wallet balance below
+// what is still locked
+func (msas MultiSigActorState) checkUnlocked(act *types.Actor, vmctx types.VMContext, amount types.BigInt) ActorError {
+	if msas.UnlockDuration == 0 {
 		return nil
 	}
-	return &msas.Transactions[txid]
+
+	locked := msas.LockedAt(vmctx.BlockHeight())
+	if types.BigSub(act.Balance, amount).LessThan(locked) {
+		return aerrors.Newf(5, "transaction would spend locked funds (%s still locked)", locked)
+	}
+
+	return nil
+}
+
+func (msas MultiSigActorState) getTransaction(vmctx types.VMContext, txid uint64) (*MTransaction, ActorError) {
+	txs, err := amt.LoadAMT(types.WrapStorage(vmctx.Storage()), msas.Transactions)
+	if err != nil {
+		return nil, aerrors.HandleExternalError(err, "could not load transactions")
+	}
+
+	var tx MTransaction
+	if err := txs.Get(txid, &tx); err != nil {
+		if _, ok := err.(*amt.ErrNotFound); ok {
+			return nil, aerrors.Newf(6, "no transaction with ID %d", txid)
+		}
+		return nil, aerrors.HandleExternalError(err, "could not get transaction")
+	}
+
+	return &tx, nil
+}
+
+func (msas *MultiSigActorState) putTransaction(vmctx types.VMContext, tx *MTransaction) ActorError {
+	txs, err := amt.LoadAMT(types.WrapStorage(vmctx.Storage()), msas.Transactions)
+	if err != nil {
+		return aerrors.HandleExternalError(err, "could not load transactions")
+	}
+
+	if err := txs.Set(tx.TxID, tx); err != nil {
+		return aerrors.HandleExternalError(err, "could not set transaction")
+	}
+
+	ncid, err := txs.Flush()
+	if err != nil {
+		return aerrors.HandleExternalError(err, "could not flush transactions")
+	}
+	msas.Transactions = ncid
+
+	return nil
 }
 
 type MTransaction struct {
-	Created uint64 // NOT USED ??
+	Created uint64
 	TxID    uint64
 
 	To     address.Address
@@ -92,14 +169,46 @@ func (msa MultiSigActor) Exports() []interface{} {
 type MultiSigConstructorParams struct {
 	Signers  []address.Address
 	Required uint64
+
+	// UnlockDuration, if not 0, locks InitialBalance out of the value sent to
+	// the constructor, and unlocks it linearly over that many blocks starting
+	// at StartingBlock
+	UnlockDuration uint64
+	StartingBlock  uint64
+	InitialBalance types.BigInt
 }
 
 func (MultiSigActor) MultiSigConstructor(act *types.Actor, vmctx types.VMContext,
 	params *MultiSigConstructorParams) ([]byte, ActorError) {
 	self := &MultiSigActorState{
-		Signers:  params.Signers,
-		Required: params.Required,
+		Signers:        params.Signers,
+		Required:       params.Required,
+		InitialBalance: types.NewInt(0),
 	}
+
+	if params.UnlockDuration == 0 {
+		if params.StartingBlock != 0 || !params.InitialBalance.Nil() && params.InitialBalance.GreaterThan(types.NewInt(0)) {
+			return nil, aerrors.New(1, "funds can only be locked with an unlock duration")
+		}
+	} else {
+		if params.InitialBalance.Nil() || !params.InitialBalance.GreaterThan(types.NewInt(0)) {
+			return nil, aerrors.New(1, "initial balance to lock must be positive")
+		}
+		if params.InitialBalance.GreaterThan(vmctx.Message().Value) {
+			return nil, aerrors.Newf(2, "cannot lock %s, only %s was sent to the wallet", params.InitialBalance, vmctx.Message().Value)
+		}
+
+		self.InitialBalance = params.InitialBalance
+		self.StartingBlock = params.StartingBlock
+		self.UnlockDuration = params.UnlockDuration
+	}
+
+	txs, lerr := amt.NewAMT(types.WrapStorage(vmctx.Storage())).Flush()
+	if lerr != nil {
+		return nil, aerrors.HandleExternalError(lerr, "initializing AMT")
+	}
+	self.Transactions = txs
+
 	head, err := vmctx.Storage().Put(self)
 	if err != nil {
 		return nil, aerrors.Wrap(err, "could not put new head")
@@ -165,20 +274,21 @@ func (msa MultiSigActor) Propose(act *types.Actor, vmctx types.VMContext,
 	txid := self.NextTxID
 	self.NextTxID++
 
-	{
-		tx := MTransaction{
-			TxID:     txid,
-			To:       params.To,
-			Value:    params.Value,
-			Method:   params.Method,
-			Params:   params.Params,
-			Approved: []address.Address{vmctx.Message().From},
-		}
-		self.Transactions = append(self.Transactions, tx)
+	tx := &MTransaction{
+		Created:  vmctx.BlockHeight(),
+		TxID:     txid,
+		To:       params.To,
+		Value:    params.Value,
+		Method:   params.Method,
+		Params:   params.Params,
+		Approved: []address.Address{vmctx.Message().From},
 	}
-	tx := self.getTransaction(txid)
 
 	if self.Required == 1 {
+		if err := self.checkUnlocked(act, vmctx, tx.Value); err != nil {
+			return nil, err
+		}
+
 		_, err := vmctx.Send(tx.To, tx.Method, tx.Value, tx.Params)
 		if aerrors.IsFatal(err) {
 			return nil, err
@@ -187,6 +297,10 @@ func (msa MultiSigActor) Propose(act *types.Actor, vmctx types.VMContext,
 		tx.Complete = true
 	}
 
+	if err := self.putTransaction(vmctx, tx); err != nil {
+		return nil, err
+	}
+
 	err = msa.save(vmctx, head, self)
 	if err != nil {
 		return nil, aerrors.Wrap(err, "saving state")
@@ -209,7 +323,10 @@ func (msa MultiSigActor) Approve(act *types.Actor, vmctx types.VMContext,
 		return nil, err
 	}
 
-	tx := self.getTransaction(params.TxID)
+	tx, err := self.getTransaction(vmctx, params.TxID)
+	if err != nil {
+		return nil, err
+	}
 	if err := tx.Active(); err != nil {
 		return nil, aerrors.Wrap(err, "could not approve")
 	}
@@ -221,6 +338,10 @@ func (msa MultiSigActor) Approve(act *types.Actor, vmctx types.VMContext,
 	}
 	tx.Approved = append(tx.Approved, vmctx.Message().From)
 	if uint64(len(tx.Approved)) >= self.Required {
+		if err := self.checkUnlocked(act, vmctx, tx.Value); err != nil {
+			return nil, err
+		}
+
 		_, err := vmctx.Send(tx.To, tx.Method, tx.Value, tx.Params)
 		if aerrors.IsFatal(err) {
 			return nil, err
@@ -229,6 +350,10 @@ func (msa MultiSigActor) Approve(act *types.Actor, vmctx types.VMContext,
 		tx.Complete = true
 	}
 
+	if err := self.putTransaction(vmctx, tx); err != nil {
+		return nil, err
+	}
+
 	return nil, msa.save(vmctx, head, self)
 }
 
@@ -240,7 +365,10 @@ func (msa MultiSigActor) Cancel(act *types.Actor, vmctx types.VMContext,
 		return nil, err
 	}
 
-	tx := self.getTransaction(params.TxID)
+	tx, err := self.getTransaction(vmctx, params.TxID)
+	if err != nil {
+		return nil, err
+	}
 	if err := tx.Active(); err != nil {
 		return nil, aerrors.Wrap(err, "could not cancel")
 	}
@@ -251,6 +379,10 @@ func (msa MultiSigActor) Cancel(act *types.Actor, vmctx types.VMContext,
 	}
 	tx.Canceled = true
 
+	if err := self.putTransaction(vmctx, tx); err != nil {
+		return nil, err
+	}
+
 	return nil, msa.save(vmctx, head, self)
 }
 
@@ -90,8 +90,188 @@ func TestMultiSigOps(t *testing.T) {
 			&txIDParam)
 		ApplyOK(t, ret2)
 
+		if assert.Len(t, ret2.ExecutionTrace.Subcalls, 1, "approval should send funds") {
+			sub := ret2.ExecutionTrace.Subcalls[0]
+			assert.Equal(t, multSigAddr, sub.Msg.From)
+			assert.Equal(t, outsideAddr, sub.Msg.To)
+			assert.Equal(t, types.NewInt(sendVal), sub.Msg.Value)
+			assert.Equal(t, uint8(0), sub.MsgRct.ExitCode)
+		}
+
 		h.AssertBalanceChange(t, outsideAddr, sendVal)
 		h.AssertBalanceChange(t, multSigAddr, -sendVal)
 	}
 
 }
+
+func TestMultiSigVesting(t *testing.T) {
+	var creatorAddr, outsideAddr address.Address
+	h := NewHarness(t,
+		HarnessAddr(&creatorAddr, 100000),
+		HarnessAddr(&outsideAddr, 100000),
+	)
+
+	const lockedVal = 10000
+	const unlockDuration = 100
+
+	ret, _ := h.InvokeWithValue(t, creatorAddr, actors.InitActorAddress, actors.IAMethods.Exec,
+		types.NewInt(lockedVal), &actors.ExecParams{
+			Code: actors.MultisigActorCodeCid,
+			Params: DumpObject(t, &actors.MultiSigConstructorParams{
+				Signers:        []address.Address{creatorAddr},
+				Required:       1,
+				UnlockDuration: unlockDuration,
+				StartingBlock:  1,
+				InitialBalance: types.NewInt(lockedVal),
+			}),
+		})
+	ApplyOK(t, ret)
+	multSigAddr, err := address.NewFromBytes(ret.Return)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	propose := func(value uint64) *vm.ApplyRet {
+		ret, _ := h.Invoke(t, creatorAddr, multSigAddr, actors.MultiSigMethods.Propose,
+			&actors.MultiSigProposeParams{
+				To:    outsideAddr,
+				Value: types.NewInt(value),
+			})
+		return ret
+	}
+
+	ret = propose(1)
+	assert.Equal(t, uint8(5), ret.ExitCode, "funds should be locked at the starting block")
+
+	// half of the balance unlocks half way through the unlock duration
+	h.vm.SetBlockHeight(1 + unlockDuration/2)
+
+	ret = propose(lockedVal/2 + 1)
+	assert.Equal(t, uint8(5), ret.ExitCode, "should not spend more than the unlocked funds")
+
+	ret = propose(lockedVal / 2)
+	ApplyOK(t, ret)
+	h.AssertBalanceChange(t, outsideAddr, lockedVal/2)
+
+	h.vm.SetBlockHeight(1 + unlockDuration)
+
+	ret = propose(lockedVal / 2)
+	ApplyOK(t, ret)
+	h.AssertBalanceChange(t, outsideAddr, lockedVal/2)
+}
+
+func TestMultiSigLockParams(t *testing.T) {
+	var creatorAddr, outsideAddr address.Address
+	h := NewHarness(t,
+		HarnessAddr(&creatorAddr, 100000),
+		HarnessAddr(&outsideAddr, 100000),
+	)
+
+	create := func(value uint64, params *actors.MultiSigConstructorParams) *vm.ApplyRet {
+		params.Signers = []address.Address{creatorAddr}
+		params.Required = 1
+
+		ret, _ := h.InvokeWithValue(t, creatorAddr, actors.InitActorAddress, actors.IAMethods.Exec,
+			types.NewInt(value), &actors.ExecParams{
+				Code:   actors.MultisigActorCodeCid,
+				Params: DumpObject(t, params),
+			})
+		return ret
+	}
+
+	ret := create(1000, &actors.MultiSigConstructorParams{
+		InitialBalance: types.NewInt(1000),
+	})
+	assert.Equal(t, uint8(1), ret.ExitCode, "should not lock funds without an unlock duration")
+
+	ret = create(1000, &actors.MultiSigConstructorParams{
+		UnlockDuration: 100,
+		InitialBalance: types.NewInt(0),
+	})
+	assert.Equal(t, uint8(1), ret.ExitCode, "should not create a lock without funds")
+
+	ret = create(1000, &actors.MultiSigConstructorParams{
+		UnlockDuration: 100,
+		InitialBalance: types.NewInt(1001),
+	})
+	assert.Equal(t, uint8(2), ret.ExitCode, "should not lock more than the value sent")
+
+	// only part of the value is locked, and nothing unlocks before the start
+	ret = create(1000, &actors.MultiSigConstructorParams{
+		UnlockDuration: 100,
+		StartingBlock:  50,
+		InitialBalance: types.NewInt(600),
+	})
+	ApplyOK(t, ret)
+	multSigAddr, err := address.NewFromBytes(ret.Return)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	propose := func(value uint64) *vm.ApplyRet {
+		ret, _ := h.Invoke(t, creatorAddr, multSigAddr, actors.MultiSigMethods.Propose,
+			&actors.MultiSigProposeParams{
+				To:    outsideAddr,
+				Value: types.NewInt(value),
+			})
+		return ret
+	}
+
+	h.vm.SetBlockHeight(40)
+
+	ret = propose(401)
+	assert.Equal(t, uint8(5), ret.ExitCode, "should not spend locked funds")
+
+	ret = propose(400)
+	ApplyOK(t, ret)
+	h.AssertBalanceChange(t, outsideAddr, 400)
+
+	h.vm.SetBlockHeight(100)
+
+	ret = propose(301)
+	assert.Equal(t, uint8(5), ret.ExitCode, "only half of the locked funds should have unlocked")
+
+	ret = propose(300)
+	ApplyOK(t, ret)
+	h.AssertBalanceChange(t, outsideAddr, 300)
+}
+
+func TestMultiSigCancel(t *testing.T) {
+	var creatorAddr, sig1Addr, outsideAddr address.Address
+	var multSigAddr address.Address
+	h := NewHarness(t,
+		HarnessAddr(&creatorAddr, 100000),
+		HarnessAddr(&sig1Addr, 100000),
+		HarnessAddr(&outsideAddr, 100000),
+		HarnessActor(&multSigAddr, &creatorAddr, actors.MultisigActorCodeCid,
+			func() cbg.CBORMarshaler {
+				return &actors.MultiSigConstructorParams{
+					Signers:  []address.Address{creatorAddr, sig1Addr},
+					Required: 2,
+				}
+			}),
+	)
+
+	ret, _ := h.Invoke(t, creatorAddr, multSigAddr, actors.MultiSigMethods.Approve,
+		&actors.MultiSigTxID{TxID: 5})
+	assert.Equal(t, uint8(6), ret.ExitCode, "should not approve unknown transaction")
+
+	ret, _ = h.Invoke(t, creatorAddr, multSigAddr, actors.MultiSigMethods.Propose,
+		&actors.MultiSigProposeParams{
+			To:    outsideAddr,
+			Value: types.NewInt(0),
+		})
+	ApplyOK(t, ret)
+	var txIDParam actors.MultiSigTxID
+	err := cbor.DecodeInto(ret.Return, &txIDParam.TxID)
+	assert.NoError(t, err, "decoding txid")
+
+	ret, _ = h.Invoke(t, sig1Addr, multSigAddr, actors.MultiSigMethods.Cancel, &txIDParam)
+	assert.Equal(t, uint8(4), ret.ExitCode, "should not cancel another signers transaction")
+
+	ret, _ = h.Invoke(t, creatorAddr, multSigAddr, actors.MultiSigMethods.Cancel, &txIDParam)
+	ApplyOK(t, ret)
+
+	ret, _ = h.Invoke(t, sig1Addr, multSigAddr, actors.MultiSigMethods.Approve, &txIDParam)
+	assert.Equal(t, uint8(3), ret.ExitCode, "should not approve canceled transaction")
+}
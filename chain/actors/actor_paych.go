@@ -158,7 +158,10 @@ func (pca PaymentChannelActor) UpdateChannelState(act *types.Actor, vmctx types.
 			return nil, aerrors.New(7, "voucher cannot merge its own lane")
 		}
 
-		ols := self.LaneStates[fmt.Sprint(merge.Lane)]
+		ols, ok := self.LaneStates[fmt.Sprint(merge.Lane)]
+		if !ok {
+			return nil, aerrors.Newf(11, "voucher merges lane %d which was never redeemed", merge.Lane)
+		}
 
 		if ols.Nonce >= merge.Nonce {
 			return nil, aerrors.New(8, "merge in voucher has outdated nonce, cannot redeem")
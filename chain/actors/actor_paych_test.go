@@ -91,3 +91,70 @@ func TestPaychUpdate(t *testing.T) {
 	h.AssertBalanceChange(t, targetAddr, 100)
 	h.AssertBalanceChange(t, creatorAddr, -100)
 }
+
+func TestPaychMerge(t *testing.T) {
+	var creatorAddr, targetAddr address.Address
+	h := NewHarness(t,
+		HarnessAddr(&creatorAddr, 100000),
+		HarnessAddr(&targetAddr, 100000),
+	)
+
+	ret, _ := h.CreateActor(t, creatorAddr, actors.PaymentChannelActorCodeCid,
+		&actors.PCAConstructorParams{
+			To: targetAddr,
+		})
+	ApplyOK(t, ret)
+	pch, err := address.NewFromBytes(ret.Return)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ret, _ = h.SendFunds(t, creatorAddr, pch, types.NewInt(5000))
+	ApplyOK(t, ret)
+
+	update := func(sv *types.SignedVoucher) uint8 {
+		signVoucher(t, h.w, creatorAddr, sv)
+		ret, _ := h.Invoke(t, targetAddr, pch, actors.PCAMethods.UpdateChannelState, &actors.PCAUpdateChannelStateParams{
+			Sv: *sv,
+		})
+		return ret.ExitCode
+	}
+
+	if ec := update(&types.SignedVoucher{Lane: 0, Nonce: 1, Amount: types.NewInt(100)}); ec != 0 {
+		t.Fatal("lane 0 voucher failed: ", ec)
+	}
+	if ec := update(&types.SignedVoucher{Lane: 1, Nonce: 1, Amount: types.NewInt(50)}); ec != 0 {
+		t.Fatal("lane 1 voucher failed: ", ec)
+	}
+
+	if ec := update(&types.SignedVoucher{
+		Lane:   1,
+		Nonce:  2,
+		Amount: types.NewInt(300),
+		Merges: []types.Merge{{Lane: 7, Nonce: 1}},
+	}); ec != 11 {
+		t.Fatal("merging an unknown lane should fail, got exit code ", ec)
+	}
+
+	// the merge voucher covers both lanes, so it only adds 150
+	if ec := update(&types.SignedVoucher{
+		Lane:   1,
+		Nonce:  2,
+		Amount: types.NewInt(300),
+		Merges: []types.Merge{{Lane: 0, Nonce: 2}},
+	}); ec != 0 {
+		t.Fatal("merge voucher failed: ", ec)
+	}
+
+	ret, _ = h.Invoke(t, targetAddr, pch, actors.PCAMethods.GetToSend, nil)
+	ApplyOK(t, ret)
+
+	bi := types.BigFromBytes(ret.Return)
+	if bi.String() != "300" {
+		t.Fatal("toSend amount was wrong: ", bi.String())
+	}
+
+	if ec := update(&types.SignedVoucher{Lane: 0, Nonce: 1, Amount: types.NewInt(200)}); ec != 6 {
+		t.Fatal("voucher on merged lane with old nonce should fail, got exit code ", ec)
+	}
+}
@@ -0,0 +1,297 @@
+package actors
+
+import (
+	"context"
+	"math/big"
+
+	cid "github.com/ipfs/go-cid"
+	hamt "github.com/ipfs/go-hamt-ipld"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-lotus/build"
+	"github.com/filecoin-project/go-lotus/chain/actors/aerrors"
+	"github.com/filecoin-project/go-lotus/chain/address"
+	"github.com/filecoin-project/go-lotus/chain/types"
+)
+
+type RewardActor struct{}
+
+type raMethods struct {
+	Constructor      uint64
+	AwardBlockReward uint64
+	WithdrawReward   uint64
+}
+
+var RAMethods = raMethods{1, 2, 3}
+
+func (ra RewardActor) Exports() []interface{} {
+	return []interface{}{
+		//1: ra.Constructor,
+		2: ra.AwardBlockReward,
+		3: ra.WithdrawReward,
+	}
+}
+
+type RewardActorState struct {
+	// Remaining is the amount of FIL left to be issued as block rewards. The
+	// actor balance also holds rewards which haven't been withdrawn yet
+	Remaining types.BigInt
+
+	// Rewards maps owner addresses to their vesting rewards
+	Rewards cid.Cid
+}
+
+// Reward is a part of a block reward which vests linearly over Duration
+// blocks, starting at StartHeight
+type Reward struct {
+	StartHeight     uint64
+	Duration        uint64
+	Value           types.BigInt
+	AmountWithdrawn types.BigInt
+}
+
+// AmountVested returns how much of the reward has vested at the given height
+func (r *Reward) AmountVested(height uint64) types.BigInt {
+	if height <= r.StartHeight {
+		return types.NewInt(0)
+	}
+
+	elapsed := height - r.StartHeight
+	if elapsed >= r.Duration {
+		return r.Value
+	}
+
+	return types.BigDiv(types.BigMul(r.Value, types.NewInt(elapsed)), types.NewInt(r.Duration))
+}
+
+// OwnerRewards lists the rewards still vesting for a miner owner
+type OwnerRewards struct {
+	Rewards []Reward
+}
+
+// add locks a reward awarded at the given height. Rewards from the same bucket
+// are merged into a single entry, as are rewards which have fully vested, so
+// the list stays bounded no matter how many blocks the owner mines
+func (or *OwnerRewards) add(height uint64, value types.BigInt) {
+	start := (height + build.MiningRewardVestingBucket - 1) / build.MiningRewardVestingBucket * build.MiningRewardVestingBucket
+
+	merged := make([]Reward, 0, len(or.Rewards)+1)
+	vested := -1
+	for _, r := range or.Rewards {
+		if r.StartHeight+r.Duration > height {
+			merged = append(merged, r)
+			continue
+		}
+
+		if vested < 0 {
+			vested = len(merged)
+			merged = append(merged, r)
+			continue
+		}
+
+		merged[vested].Value = types.BigAdd(merged[vested].Value, r.Value)
+		merged[vested].AmountWithdrawn = types.BigAdd(merged[vested].AmountWithdrawn, r.AmountWithdrawn)
+	}
+
+	if last := len(merged) - 1; last >= 0 && merged[last].StartHeight == start {
+		merged[last].Value = types.BigAdd(merged[last].Value, value)
+	} else {
+		merged = append(merged, Reward{
+			StartHeight:     start,
+			Duration:        build.MiningRewardVestingPeriod,
+			Value:           value,
+			AmountWithdrawn: types.NewInt(0),
+		})
+	}
+
+	or.Rewards = merged
+}
+
+var miningRewardTotal = types.FromFil(build.MiningRewardTotal)
+
+// MiningReward returns the reward for a single block given the supply left
+// to be issued. Rewards decay exponentially, InitialReward is chosen so that
+// they halve every HalvingPeriodBlocks
+func MiningReward(remainingReward types.BigInt) types.BigInt {
+	ci := big.NewInt(0).Set(remainingReward.Int)
+	res := ci.Mul(ci, build.InitialReward)
+	res = res.Div(res, miningRewardTotal.Int)
+	return types.BigInt{Int: res}
+}
+
+type AwardBlockRewardParams struct {
+	Miner address.Address
+}
+
+// AwardBlockReward pays the reward for a block mined by the given miner to
+// its owner. Only part of the reward is paid out immediately, the rest is
+// locked and vests over MiningRewardVestingPeriod blocks
+func (ra RewardActor) AwardBlockReward(act *types.Actor, vmctx types.VMContext, params *AwardBlockRewardParams) ([]byte, ActorError) {
+	if vmctx.Message().From != NetworkAddress {
+		return nil, aerrors.New(1, "block rewards can only be awarded by the network")
+	}
+
+	ret, err := vmctx.Send(params.Miner, MAMethods.GetOwner, types.NewInt(0), nil)
+	if err != nil {
+		return nil, aerrors.Wrap(err, "failed to get miner owner")
+	}
+
+	owner, oerr := address.NewFromBytes(ret)
+	if oerr != nil {
+		return nil, aerrors.Absorb(oerr, 2, "GetOwner returned a malformed address")
+	}
+
+	var self RewardActorState
+	old := vmctx.Storage().GetHead()
+	if err := vmctx.Storage().Get(old, &self); err != nil {
+		return nil, err
+	}
+
+	reward := MiningReward(self.Remaining)
+	self.Remaining = types.BigSub(self.Remaining, reward)
+
+	locked := types.BigDiv(types.BigMul(reward, types.NewInt(build.MiningRewardLockedShare)), types.NewInt(100))
+
+	if _, err := vmctx.Send(owner, 0, types.BigSub(reward, locked), nil); err != nil {
+		return nil, aerrors.Wrap(err, "failed to pay block reward")
+	}
+
+	// the owner may be given in any address form, rewards are keyed by the ID
+	// address so they can be withdrawn from any form as well
+	owner, err = LookupIDAddress(vmctx, owner)
+	if err != nil {
+		return nil, aerrors.Wrap(err, "failed to resolve miner owner")
+	}
+
+	ctx := vmctx.Context()
+
+	nd, lerr := hamt.LoadNode(ctx, vmctx.Ipld(), self.Rewards)
+	if lerr != nil {
+		return nil, aerrors.HandleExternalError(lerr, "failed to load rewards")
+	}
+
+	rewards, err := loadOwnerRewards(ctx, nd, owner)
+	if err != nil {
+		return nil, err
+	}
+
+	rewards.add(vmctx.BlockHeight(), locked)
+
+	ncid, err := storeOwnerRewards(ctx, vmctx, nd, owner, rewards)
+	if err != nil {
+		return nil, err
+	}
+	self.Rewards = ncid
+
+	nroot, err := vmctx.Storage().Put(&self)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := vmctx.Storage().Commit(old, nroot); err != nil {
+		return nil, err
+	}
+
+	return nil, nil
+}
+
+// WithdrawReward sends all rewards of the caller which have vested so far
+func (ra RewardActor) WithdrawReward(act *types.Actor, vmctx types.VMContext, params *struct{}) ([]byte, ActorError) {
+	var self RewardActorState
+	old := vmctx.Storage().GetHead()
+	if err := vmctx.Storage().Get(old, &self); err != nil {
+		return nil, err
+	}
+
+	ctx := vmctx.Context()
+	owner, err := LookupIDAddress(vmctx, vmctx.Message().From)
+	if err != nil {
+		return nil, aerrors.Wrap(err, "failed to resolve sender")
+	}
+
+	nd, lerr := hamt.LoadNode(ctx, vmctx.Ipld(), self.Rewards)
+	if lerr != nil {
+		return nil, aerrors.HandleExternalError(lerr, "failed to load rewards")
+	}
+
+	rewards, err := loadOwnerRewards(ctx, nd, owner)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(rewards.Rewards) == 0 {
+		return nil, aerrors.New(1, "no rewards to withdraw")
+	}
+
+	withdrawable := types.NewInt(0)
+	remaining := make([]Reward, 0, len(rewards.Rewards))
+	for _, r := range rewards.Rewards {
+		vested := r.AmountVested(vmctx.BlockHeight())
+		withdrawable = types.BigAdd(withdrawable, types.BigSub(vested, r.AmountWithdrawn))
+		r.AmountWithdrawn = vested
+
+		// fully withdrawn rewards are dropped
+		if r.AmountWithdrawn.LessThan(r.Value) {
+			remaining = append(remaining, r)
+		}
+	}
+	rewards.Rewards = remaining
+
+	if _, err := vmctx.Send(owner, 0, withdrawable, nil); err != nil {
+		return nil, aerrors.Wrap(err, "failed to send rewards")
+	}
+
+	ncid, err := storeOwnerRewards(ctx, vmctx, nd, owner, rewards)
+	if err != nil {
+		return nil, err
+	}
+	self.Rewards = ncid
+
+	nroot, err := vmctx.Storage().Put(&self)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := vmctx.Storage().Commit(old, nroot); err != nil {
+		return nil, err
+	}
+
+	return nil, nil
+}
+
+func loadOwnerRewards(ctx context.Context, nd *hamt.Node, owner address.Address) (*OwnerRewards, ActorError) {
+	var rewards OwnerRewards
+	err := nd.Find(ctx, string(owner.Bytes()), &rewards)
+	switch {
+	case err == nil:
+	case xerrors.Is(err, hamt.ErrNotFound):
+	default:
+		return nil, aerrors.HandleExternalError(err, "failed to look up owner rewards")
+	}
+
+	return &rewards, nil
+}
+
+func storeOwnerRewards(ctx context.Context, vmctx types.VMContext, nd *hamt.Node, owner address.Address, rewards *OwnerRewards) (cid.Cid, ActorError) {
+	key := string(owner.Bytes())
+	if len(rewards.Rewards) == 0 {
+		if err := nd.Delete(ctx, key); err != nil && !xerrors.Is(err, hamt.ErrNotFound) {
+			return cid.Undef, aerrors.HandleExternalError(err, "failed to remove owner rewards")
+		}
+	} else {
+		if err := nd.Set(ctx, key, rewards); err != nil {
+			return cid.Undef, aerrors.HandleExternalError(err, "failed to set owner rewards")
+		}
+	}
+
+	if err := nd.Flush(ctx); err != nil {
+		return cid.Undef, aerrors.HandleExternalError(err, "failed to flush rewards")
+	}
+
+	c, err := vmctx.Ipld().Put(ctx, nd)
+	if err != nil {
+		return cid.Undef, aerrors.HandleExternalError(err, "failed to persist rewards")
+	}
+
+	return c, nil
+}
@@ -0,0 +1,224 @@
+package actors_test
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"testing"
+
+	hamt "github.com/ipfs/go-hamt-ipld"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-lotus/build"
+	. "github.com/filecoin-project/go-lotus/chain/actors"
+	"github.com/filecoin-project/go-lotus/chain/address"
+	"github.com/filecoin-project/go-lotus/chain/types"
+)
+
+func TestBlockReward(t *testing.T) {
+	coffer := types.FromFil(build.MiningRewardTotal).Int
+	sum := new(big.Int)
+	N := build.HalvingPeriodBlocks
+	for i := 0; i < N; i++ {
+		a := MiningReward(types.BigInt{Int: coffer})
+		sum = sum.Add(sum, a.Int)
+		coffer = coffer.Sub(coffer, a.Int)
+	}
+
+	//sum = types.BigMul(sum, types.NewInt(60))
+
+	fmt.Println("After a halving period")
+	fmt.Printf("Total reward: %d\n", build.MiningRewardTotal)
+	fmt.Printf("Remaining: %s\n", types.BigDiv(types.BigInt{Int: coffer}, types.NewInt(build.FilecoinPrecision)))
+	fmt.Printf("Given out: %s\n", types.BigDiv(types.BigInt{Int: sum}, types.NewInt(build.FilecoinPrecision)))
+}
+
+func TestRewardVesting(t *testing.T) {
+	var ownerAddr, workerAddr address.Address
+	h := NewHarness(t,
+		HarnessAddr(&ownerAddr, 1000000),
+		HarnessAddr(&workerAddr, 100000),
+	)
+
+	minerAddr := createTestMiner(t, h, ownerAddr, workerAddr)
+
+	// balance of the owner not counting gas
+	ownerBalance := func() types.BigInt {
+		t.Helper()
+		b, err := h.vm.ActorBalance(ownerAddr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return types.BigAdd(b, h.GasCharges[ownerAddr])
+	}
+
+	{
+		ret, _ := h.Invoke(t, ownerAddr, RewardActorAddress, RAMethods.AwardBlockReward,
+			&AwardBlockRewardParams{Miner: minerAddr})
+		assert.Equal(t, byte(1), ret.ExitCode, "only the network can award block rewards")
+	}
+
+	reward := MiningReward(types.FromFil(build.MiningRewardTotal))
+	locked := types.BigDiv(types.BigMul(reward, types.NewInt(build.MiningRewardLockedShare)), types.NewInt(100))
+
+	before := ownerBalance()
+	{
+		ret, _ := h.Invoke(t, NetworkAddress, RewardActorAddress, RAMethods.AwardBlockReward,
+			&AwardBlockRewardParams{Miner: minerAddr})
+		ApplyOK(t, ret)
+	}
+	assert.Equal(t, types.BigAdd(before, types.BigSub(reward, locked)), ownerBalance(),
+		"the unlocked part of the reward should be paid out immediately")
+
+	// the reward starts vesting at the end of its bucket
+	start := uint64(build.MiningRewardVestingBucket)
+
+	h.vm.SetBlockHeight(start + build.MiningRewardVestingPeriod/2)
+
+	before = ownerBalance()
+	{
+		ret, _ := h.Invoke(t, ownerAddr, RewardActorAddress, RAMethods.WithdrawReward, nil)
+		ApplyOK(t, ret)
+	}
+	half := types.BigDiv(locked, types.NewInt(2))
+	assert.Equal(t, types.BigAdd(before, half), ownerBalance(), "half of the locked reward should have vested")
+
+	h.vm.SetBlockHeight(start + build.MiningRewardVestingPeriod)
+
+	before = ownerBalance()
+	{
+		ret, _ := h.Invoke(t, ownerAddr, RewardActorAddress, RAMethods.WithdrawReward, nil)
+		ApplyOK(t, ret)
+	}
+	assert.Equal(t, types.BigAdd(before, types.BigSub(locked, half)), ownerBalance(), "the rest of the reward should have vested")
+
+	{
+		ret, _ := h.Invoke(t, ownerAddr, RewardActorAddress, RAMethods.WithdrawReward, nil)
+		assert.Equal(t, byte(1), ret.ExitCode, "fully withdrawn rewards should be removed")
+	}
+}
+
+func loadOwnerRewards(t *testing.T, h *Harness, owner address.Address) OwnerRewards {
+	t.Helper()
+
+	st := h.vm.StateTree()
+	cst := hamt.CSTFromBstore(h.bs)
+
+	act, err := st.GetActor(RewardActorAddress)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var self RewardActorState
+	if err := cst.Get(context.TODO(), act.Head, &self); err != nil {
+		t.Fatal(err)
+	}
+
+	nd, err := hamt.LoadNode(context.TODO(), cst, self.Rewards)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var rewards OwnerRewards
+	if err := nd.Find(context.TODO(), string(owner.Bytes()), &rewards); err != nil && !xerrors.Is(err, hamt.ErrNotFound) {
+		t.Fatal(err)
+	}
+	return rewards
+}
+
+func lookupIDAddress(t *testing.T, h *Harness, addr address.Address) address.Address {
+	t.Helper()
+
+	cst := hamt.CSTFromBstore(h.bs)
+
+	act, err := h.vm.StateTree().GetActor(InitActorAddress)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var ias InitActorState
+	if err := cst.Get(context.TODO(), act.Head, &ias); err != nil {
+		t.Fatal(err)
+	}
+
+	id, err := ias.Lookup(cst, addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return id
+}
+
+func TestRewardBuckets(t *testing.T) {
+	var ownerAddr, workerAddr address.Address
+	h := NewHarness(t,
+		HarnessAddr(&ownerAddr, 1000000),
+		HarnessAddr(&workerAddr, 100000),
+	)
+
+	minerAddr := createTestMiner(t, h, ownerAddr, workerAddr)
+	ownerID := lookupIDAddress(t, h, ownerAddr)
+
+	award := func(height uint64) {
+		t.Helper()
+		h.vm.SetBlockHeight(height)
+		ret, _ := h.Invoke(t, NetworkAddress, RewardActorAddress, RAMethods.AwardBlockReward,
+			&AwardBlockRewardParams{Miner: minerAddr})
+		ApplyOK(t, ret)
+	}
+
+	const bucket = build.MiningRewardVestingBucket
+
+	// rewards from the same bucket are merged
+	for height := uint64(1); height <= bucket; height++ {
+		award(height)
+	}
+	rewards := loadOwnerRewards(t, h, ownerID)
+	if assert.Len(t, rewards.Rewards, 1) {
+		assert.Equal(t, uint64(bucket), rewards.Rewards[0].StartHeight)
+	}
+
+	award(bucket + 1)
+	rewards = loadOwnerRewards(t, h, ownerID)
+	if assert.Len(t, rewards.Rewards, 2) {
+		assert.Equal(t, uint64(2*bucket), rewards.Rewards[1].StartHeight)
+	}
+
+	// fully vested rewards are merged with each other as well
+	award(3*bucket + build.MiningRewardVestingPeriod)
+	rewards = loadOwnerRewards(t, h, ownerID)
+	assert.Len(t, rewards.Rewards, 2)
+
+	total := types.NewInt(0)
+	for _, r := range rewards.Rewards {
+		total = types.BigAdd(total, r.Value)
+	}
+
+	// rewards are withdrawn by the owner regardless of the address form used
+	before, err := h.vm.ActorBalance(ownerAddr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	h.vm.SetBlockHeight(4*bucket + 2*build.MiningRewardVestingPeriod)
+
+	nonce := h.Nonces[ownerAddr]
+	h.Nonces[ownerAddr] = nonce + 1
+	ret, _ := h.Apply(t, types.Message{
+		To:       RewardActorAddress,
+		From:     ownerID,
+		Nonce:    nonce,
+		Method:   RAMethods.WithdrawReward,
+		Value:    types.NewInt(0),
+		GasPrice: types.NewInt(1),
+		GasLimit: types.NewInt(testGasLimit),
+	})
+	ApplyOK(t, ret)
+
+	after, err := h.vm.ActorBalance(ownerAddr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, types.BigAdd(before, total), types.BigAdd(after, ret.GasUsed))
+	assert.Empty(t, loadOwnerRewards(t, h, ownerID).Rewards)
+}
@@ -0,0 +1,897 @@
+package actors
+
+import (
+	"bytes"
+	"context"
+
+	"github.com/filecoin-project/go-amt-ipld"
+	"github.com/ipfs/go-cid"
+	hamt "github.com/ipfs/go-hamt-ipld"
+	"github.com/multiformats/go-multihash"
+	cbg "github.com/whyrusleeping/cbor-gen"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-lotus/build"
+	"github.com/filecoin-project/go-lotus/chain/actors/aerrors"
+	"github.com/filecoin-project/go-lotus/chain/address"
+	"github.com/filecoin-project/go-lotus/chain/types"
+)
+
+// StorageMarketActor holds funds escrowed by storage clients and providers
+// and settles published storage deals between them
+type StorageMarketActor struct{}
+
+type smaMethods struct {
+	Constructor                uint64
+	WithdrawBalance            uint64
+	AddBalance                 uint64
+	PublishStorageDeals        uint64
+	ActivateStorageDeals       uint64
+	ProcessStorageDealsPayment uint64
+	SlashStorageDealCollateral uint64
+	ExpireStorageDeals         uint64
+}
+
+var SMAMethods = smaMethods{1, 2, 3, 4, 5, 6, 7, 8}
+
+func (sma StorageMarketActor) Exports() []interface{} {
+	return []interface{}{
+		//1: sma.StorageMarketConstructor,
+		2: sma.WithdrawBalance,
+		3: sma.AddBalance,
+		4: sma.PublishStorageDeals,
+		5: sma.ActivateStorageDeals,
+		6: sma.ProcessStorageDealsPayment,
+		7: sma.SlashStorageDealCollateral,
+		8: sma.ExpireStorageDeals,
+	}
+}
+
+type StorageMarketState struct {
+	// Balances maps participant addresses to their StorageParticipantBalance
+	Balances cid.Cid
+
+	// Deals is an AMT of OnChainDeal keyed by deal ID
+	Deals      cid.Cid
+	NextDealID uint64
+
+	// PublishedProposals is the set of the CIDs of all proposals published so
+	// far, which keeps a signed proposal from being published twice
+	PublishedProposals cid.Cid
+}
+
+type StorageParticipantBalance struct {
+	// Locked funds back published deals, as payment for clients and as
+	// collateral for providers
+	Locked    types.BigInt
+	Available types.BigInt
+}
+
+// StorageDealProposal is a deal signed by the client, which the provider
+// publishes to lock the funds of both parties
+type StorageDealProposal struct {
+	PieceRef  []byte // CommP
+	PieceSize uint64
+
+	Client   address.Address
+	Provider address.Address
+
+	// ProposalExpiration is the last height at which the proposal can be
+	// published
+	ProposalExpiration uint64
+	Duration           uint64
+
+	StoragePricePerEpoch types.BigInt
+	StorageCollateral    types.BigInt
+
+	ProposerSignature *types.Signature
+}
+
+func (sdp *StorageDealProposal) TotalStoragePrice() types.BigInt {
+	return types.BigMul(sdp.StoragePricePerEpoch, types.NewInt(sdp.Duration))
+}
+
+func (sdp *StorageDealProposal) SigningBytes() ([]byte, error) {
+	osdp := *sdp
+	osdp.ProposerSignature = nil
+
+	buf := new(bytes.Buffer)
+	if err := osdp.MarshalCBOR(buf); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// Cid returns the CID of the unsigned proposal
+func (sdp *StorageDealProposal) Cid() (cid.Cid, error) {
+	sb, err := sdp.SigningBytes()
+	if err != nil {
+		return cid.Undef, err
+	}
+
+	return cid.NewPrefixV1(cid.DagCBOR, multihash.BLAKE2B_MIN+31).Sum(sb)
+}
+
+type OnChainDeal struct {
+	Proposal StorageDealProposal
+
+	// ActivationDeadline is the last height at which the provider can
+	// activate the deal, after which the locked funds can be returned
+	ActivationDeadline uint64
+
+	// ActivationEpoch is 0 until the provider activates the deal
+	ActivationEpoch uint64
+
+	// SectorID is the sector of the provider holding the deal data, set on
+	// activation
+	SectorID uint64
+
+	// LastPaymentEpoch is the height up to which the provider was paid
+	LastPaymentEpoch uint64
+
+	// SlashedAt is set when the provider collateral was slashed
+	SlashedAt uint64
+}
+
+// End returns the height at which an active deal expires
+func (d *OnChainDeal) End() uint64 {
+	return d.ActivationEpoch + d.Proposal.Duration
+}
+
+func (d *OnChainDeal) isActive() bool {
+	return d.ActivationEpoch != 0 && d.SlashedAt == 0 && d.LastPaymentEpoch < d.End()
+}
+
+func (sma StorageMarketActor) load(vmctx types.VMContext) (cid.Cid, *StorageMarketState, ActorError) {
+	var self StorageMarketState
+	head := vmctx.Storage().GetHead()
+	if err := vmctx.Storage().Get(head, &self); err != nil {
+		return cid.Undef, nil, err
+	}
+
+	return head, &self, nil
+}
+
+func (sma StorageMarketActor) save(vmctx types.VMContext, oldHead cid.Cid, self *StorageMarketState) ActorError {
+	nroot, err := vmctx.Storage().Put(self)
+	if err != nil {
+		return err
+	}
+
+	return vmctx.Storage().Commit(oldHead, nroot)
+}
+
+type WithdrawBalanceParams struct {
+	Address address.Address
+	Balance types.BigInt
+}
+
+// WithdrawBalance sends available funds of the given participant to the
+// sender. Funds of a provider can be withdrawn by its worker
+func (sma StorageMarketActor) WithdrawBalance(act *types.Actor, vmctx types.VMContext, params *WithdrawBalanceParams) ([]byte, ActorError) {
+	head, self, err := sma.load(vmctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx := vmctx.Context()
+	from := vmctx.Message().From
+
+	fromID, err := LookupIDAddress(vmctx, from)
+	if err != nil {
+		return nil, aerrors.Wrap(err, "failed to resolve sender")
+	}
+	ownerID, err := LookupIDAddress(vmctx, params.Address)
+	if err != nil {
+		return nil, aerrors.Wrap(err, "failed to resolve balance owner")
+	}
+
+	if ownerID != fromID {
+		if err := checkProviderWorker(vmctx, params.Address); err != nil {
+			return nil, err
+		}
+	}
+
+	nd, lerr := hamt.LoadNode(ctx, vmctx.Ipld(), self.Balances)
+	if lerr != nil {
+		return nil, aerrors.HandleExternalError(lerr, "failed to load balances")
+	}
+
+	b, err := getMarketBalance(vmctx, nd, params.Address)
+	if err != nil {
+		return nil, err
+	}
+
+	if b.Available.LessThan(params.Balance) {
+		return nil, aerrors.Newf(1, "can not withdraw more funds than available (%s > %s)", params.Balance, b.Available)
+	}
+
+	b.Available = types.BigSub(b.Available, params.Balance)
+	if err := setMarketBalance(vmctx, nd, params.Address, b); err != nil {
+		return nil, err
+	}
+
+	if _, err := vmctx.Send(from, 0, params.Balance, nil); err != nil {
+		return nil, aerrors.Wrap(err, "sending funds failed")
+	}
+
+	self.Balances, err = flushMarketBalances(ctx, vmctx, nd)
+	if err != nil {
+		return nil, err
+	}
+
+	return nil, sma.save(vmctx, head, self)
+}
+
+type AddBalanceParams struct {
+	Address address.Address
+}
+
+// AddBalance adds the message value to the available balance of the given
+// participant, which allows funding the escrow of storage miners
+func (sma StorageMarketActor) AddBalance(act *types.Actor, vmctx types.VMContext, params *AddBalanceParams) ([]byte, ActorError) {
+	head, self, err := sma.load(vmctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx := vmctx.Context()
+	msg := vmctx.Message()
+
+	nd, lerr := hamt.LoadNode(ctx, vmctx.Ipld(), self.Balances)
+	if lerr != nil {
+		return nil, aerrors.HandleExternalError(lerr, "failed to load balances")
+	}
+
+	b, err := getMarketBalance(vmctx, nd, params.Address)
+	if err != nil {
+		return nil, err
+	}
+
+	b.Available = types.BigAdd(b.Available, msg.Value)
+	if err := setMarketBalance(vmctx, nd, params.Address, b); err != nil {
+		return nil, err
+	}
+
+	self.Balances, err = flushMarketBalances(ctx, vmctx, nd)
+	if err != nil {
+		return nil, err
+	}
+
+	return nil, sma.save(vmctx, head, self)
+}
+
+type PublishStorageDealsParams struct {
+	Deals []StorageDealProposal
+}
+
+type PublishStorageDealResponse struct {
+	DealIDs []uint64
+}
+
+// PublishStorageDeals is called by the worker of the provider of the given
+// deals. It locks the deal price from the client balance and the collateral
+// from the provider balance until the deals are activated or expire
+func (sma StorageMarketActor) PublishStorageDeals(act *types.Actor, vmctx types.VMContext, params *PublishStorageDealsParams) ([]byte, ActorError) {
+	head, self, err := sma.load(vmctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx := vmctx.Context()
+
+	nd, lerr := hamt.LoadNode(ctx, vmctx.Ipld(), self.Balances)
+	if lerr != nil {
+		return nil, aerrors.HandleExternalError(lerr, "failed to load balances")
+	}
+
+	published, lerr := hamt.LoadNode(ctx, vmctx.Ipld(), self.PublishedProposals)
+	if lerr != nil {
+		return nil, aerrors.HandleExternalError(lerr, "failed to load published proposals")
+	}
+
+	deals, lerr := amt.LoadAMT(types.WrapStorage(vmctx.Storage()), self.Deals)
+	if lerr != nil {
+		return nil, aerrors.HandleExternalError(lerr, "failed to load deals")
+	}
+
+	out := PublishStorageDealResponse{
+		DealIDs: make([]uint64, len(params.Deals)),
+	}
+
+	for i, deal := range params.Deals {
+		if err := sma.validateDeal(vmctx, &deal); err != nil {
+			return nil, err
+		}
+
+		pcid, perr := deal.Cid()
+		if perr != nil {
+			return nil, aerrors.Escalate(perr, "failed to compute proposal cid")
+		}
+
+		pkey := string(pcid.Bytes())
+		switch ferr := published.Find(ctx, pkey, nil); {
+		case ferr == nil:
+			return nil, aerrors.Newf(10, "deal proposal %s was already published", pcid)
+		case !xerrors.Is(ferr, hamt.ErrNotFound):
+			return nil, aerrors.HandleExternalError(ferr, "failed to look up published proposal")
+		}
+
+		if err := published.Set(ctx, pkey, uint64(1)); err != nil {
+			return nil, aerrors.HandleExternalError(err, "failed to record published proposal")
+		}
+
+		client, err := getMarketBalance(vmctx, nd, deal.Client)
+		if err != nil {
+			return nil, err
+		}
+
+		price := deal.TotalStoragePrice()
+		if client.Available.LessThan(price) {
+			return nil, aerrors.Newf(4, "client %s has not enough available funds for the deal (%s < %s)", deal.Client, client.Available, price)
+		}
+		client.Available = types.BigSub(client.Available, price)
+		client.Locked = types.BigAdd(client.Locked, price)
+
+		if err := setMarketBalance(vmctx, nd, deal.Client, client); err != nil {
+			return nil, err
+		}
+
+		provider, err := getMarketBalance(vmctx, nd, deal.Provider)
+		if err != nil {
+			return nil, err
+		}
+
+		if provider.Available.LessThan(deal.StorageCollateral) {
+			return nil, aerrors.Newf(5, "provider %s has not enough available funds for the deal collateral (%s < %s)", deal.Provider, provider.Available, deal.StorageCollateral)
+		}
+		provider.Available = types.BigSub(provider.Available, deal.StorageCollateral)
+		provider.Locked = types.BigAdd(provider.Locked, deal.StorageCollateral)
+
+		if err := setMarketBalance(vmctx, nd, deal.Provider, provider); err != nil {
+			return nil, err
+		}
+
+		id := self.NextDealID
+		self.NextDealID++
+
+		onChain := &OnChainDeal{
+			Proposal:           deal,
+			ActivationDeadline: vmctx.BlockHeight() + build.DealActivationTimeout,
+		}
+		if err := deals.Set(id, onChain); err != nil {
+			return nil, aerrors.HandleExternalError(err, "failed to store deal")
+		}
+
+		out.DealIDs[i] = id
+	}
+
+	self.Balances, err = flushMarketBalances(ctx, vmctx, nd)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := published.Flush(ctx); err != nil {
+		return nil, aerrors.HandleExternalError(err, "failed to flush published proposals")
+	}
+	pcid, lerr := vmctx.Ipld().Put(ctx, published)
+	if lerr != nil {
+		return nil, aerrors.HandleExternalError(lerr, "failed to persist published proposals")
+	}
+	self.PublishedProposals = pcid
+
+	dcid, lerr := deals.Flush()
+	if lerr != nil {
+		return nil, aerrors.HandleExternalError(lerr, "failed to flush deals")
+	}
+	self.Deals = dcid
+
+	if err := sma.save(vmctx, head, self); err != nil {
+		return nil, err
+	}
+
+	buf := new(bytes.Buffer)
+	if err := out.MarshalCBOR(buf); err != nil {
+		return nil, aerrors.Escalate(err, "failed to serialize response")
+	}
+
+	return buf.Bytes(), nil
+}
+
+func (sma StorageMarketActor) validateDeal(vmctx types.VMContext, deal *StorageDealProposal) ActorError {
+	if vmctx.BlockHeight() > deal.ProposalExpiration {
+		return aerrors.New(2, "deal proposal already expired")
+	}
+
+	if deal.Duration == 0 {
+		return aerrors.New(3, "deal duration must be greater than 0")
+	}
+
+	// escrow is moved without a transfer, nothing else stops negative amounts
+	if deal.StoragePricePerEpoch.Nil() || deal.StoragePricePerEpoch.Sign() < 0 {
+		return aerrors.New(3, "deal price must not be negative")
+	}
+
+	if deal.StorageCollateral.Nil() || deal.StorageCollateral.Sign() < 0 {
+		return aerrors.New(3, "deal collateral must not be negative")
+	}
+
+	if err := checkProviderWorker(vmctx, deal.Provider); err != nil {
+		return err
+	}
+
+	if deal.ProposerSignature == nil {
+		return aerrors.New(3, "deal proposal is not signed")
+	}
+
+	sb, err := deal.SigningBytes()
+	if err != nil {
+		return aerrors.Escalate(err, "failed to serialize deal proposal")
+	}
+
+	return vmctx.VerifySignature(deal.ProposerSignature, deal.Client, sb)
+}
+
+type ActivateStorageDealsParams struct {
+	Deals []DealActivation
+}
+
+type DealActivation struct {
+	DealID uint64
+
+	// SectorID and Proof prove the deal piece is included in a committed
+	// sector of the provider
+	SectorID uint64
+	Proof    []byte
+}
+
+// ActivateStorageDeals is called by the worker of the provider once the data
+// of the deals was sealed in a committed sector, and starts the payments
+func (sma StorageMarketActor) ActivateStorageDeals(act *types.Actor, vmctx types.VMContext, params *ActivateStorageDealsParams) ([]byte, ActorError) {
+	head, self, err := sma.load(vmctx)
+	if err != nil {
+		return nil, err
+	}
+
+	deals, lerr := amt.LoadAMT(types.WrapStorage(vmctx.Storage()), self.Deals)
+	if lerr != nil {
+		return nil, aerrors.HandleExternalError(lerr, "failed to load deals")
+	}
+
+	for _, activation := range params.Deals {
+		id := activation.DealID
+		deal, err := getMarketDeal(deals, id)
+		if err != nil {
+			return nil, err
+		}
+
+		if deal.ActivationEpoch != 0 {
+			return nil, aerrors.Newf(7, "deal %d already active", id)
+		}
+
+		if vmctx.BlockHeight() > deal.ActivationDeadline {
+			return nil, aerrors.Newf(11, "activation deadline of deal %d passed", id)
+		}
+
+		if err := checkProviderWorker(vmctx, deal.Proposal.Provider); err != nil {
+			return nil, err
+		}
+
+		enc, err := SerializeParams(&VerifyPieceInclusionParams{
+			CommP:     deal.Proposal.PieceRef,
+			PieceSize: deal.Proposal.PieceSize,
+			SectorID:  activation.SectorID,
+			Proof:     activation.Proof,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		if _, err := vmctx.Send(deal.Proposal.Provider, MAMethods.VerifyPieceInclusion, types.NewInt(0), enc); err != nil {
+			return nil, aerrors.Wrapf(err, "verifying deal %d is included in sector %d", id, activation.SectorID)
+		}
+
+		deal.ActivationEpoch = vmctx.BlockHeight()
+		deal.LastPaymentEpoch = vmctx.BlockHeight()
+		deal.SectorID = activation.SectorID
+
+		if err := deals.Set(id, deal); err != nil {
+			return nil, aerrors.HandleExternalError(err, "failed to store deal")
+		}
+	}
+
+	dcid, lerr := deals.Flush()
+	if lerr != nil {
+		return nil, aerrors.HandleExternalError(lerr, "failed to flush deals")
+	}
+	self.Deals = dcid
+
+	return nil, sma.save(vmctx, head, self)
+}
+
+type ProcessStorageDealsPaymentParams struct {
+	DealIDs []uint64
+}
+
+// ProcessStorageDealsPayment pays providers for the epochs which passed since
+// the last payment. Providers which are late with their PoSts forfeit the
+// payment for the epochs after their last proven one to the client, and the
+// collateral is unlocked once a deal expires
+func (sma StorageMarketActor) ProcessStorageDealsPayment(act *types.Actor, vmctx types.VMContext, params *ProcessStorageDealsPaymentParams) ([]byte, ActorError) {
+	head, self, err := sma.load(vmctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx := vmctx.Context()
+
+	nd, lerr := hamt.LoadNode(ctx, vmctx.Ipld(), self.Balances)
+	if lerr != nil {
+		return nil, aerrors.HandleExternalError(lerr, "failed to load balances")
+	}
+
+	deals, lerr := amt.LoadAMT(types.WrapStorage(vmctx.Storage()), self.Deals)
+	if lerr != nil {
+		return nil, aerrors.HandleExternalError(lerr, "failed to load deals")
+	}
+
+	for _, id := range params.DealIDs {
+		deal, err := getMarketDeal(deals, id)
+		if err != nil {
+			return nil, err
+		}
+
+		if !deal.isActive() {
+			return nil, aerrors.Newf(8, "deal %d is not active", id)
+		}
+
+		upto := vmctx.BlockHeight()
+		if upto > deal.End() {
+			upto = deal.End()
+		}
+		if upto <= deal.LastPaymentEpoch {
+			continue
+		}
+
+		ret, err := vmctx.Send(deal.Proposal.Provider, MAMethods.IsLate, types.NewInt(0), nil)
+		if err != nil {
+			return nil, aerrors.Wrap(err, "checking provider PoSts")
+		}
+
+		// a late provider is still paid for the epochs it proved before
+		// missing its PoSt, only the epochs since are forfeited
+		proven := upto
+		if bytes.Equal(ret, cbg.CborBoolTrue) {
+			ret, err := vmctx.Send(deal.Proposal.Provider, MAMethods.GetLastProvenEpoch, types.NewInt(0), nil)
+			if err != nil {
+				return nil, aerrors.Wrap(err, "getting last proven epoch of provider")
+			}
+			_, lastProven, lerr := cbg.CborReadHeader(bytes.NewReader(ret))
+			if lerr != nil {
+				return nil, aerrors.Absorb(lerr, 13, "decoding last proven epoch")
+			}
+
+			if lastProven < proven {
+				proven = lastProven
+			}
+			if proven < deal.LastPaymentEpoch {
+				proven = deal.LastPaymentEpoch
+			}
+		}
+
+		payment := types.BigMul(deal.Proposal.StoragePricePerEpoch, types.NewInt(proven-deal.LastPaymentEpoch))
+		forfeit := types.BigMul(deal.Proposal.StoragePricePerEpoch, types.NewInt(upto-proven))
+		deal.LastPaymentEpoch = upto
+
+		client, err := getMarketBalance(vmctx, nd, deal.Proposal.Client)
+		if err != nil {
+			return nil, err
+		}
+		client.Locked = types.BigSub(client.Locked, types.BigAdd(payment, forfeit))
+		client.Available = types.BigAdd(client.Available, forfeit)
+		if err := setMarketBalance(vmctx, nd, deal.Proposal.Client, client); err != nil {
+			return nil, err
+		}
+
+		provider, err := getMarketBalance(vmctx, nd, deal.Proposal.Provider)
+		if err != nil {
+			return nil, err
+		}
+		provider.Available = types.BigAdd(provider.Available, payment)
+		if upto == deal.End() {
+			provider.Locked = types.BigSub(provider.Locked, deal.Proposal.StorageCollateral)
+			provider.Available = types.BigAdd(provider.Available, deal.Proposal.StorageCollateral)
+		}
+		if err := setMarketBalance(vmctx, nd, deal.Proposal.Provider, provider); err != nil {
+			return nil, err
+		}
+
+		if err := deals.Set(id, deal); err != nil {
+			return nil, aerrors.HandleExternalError(err, "failed to store deal")
+		}
+	}
+
+	self.Balances, err = flushMarketBalances(ctx, vmctx, nd)
+	if err != nil {
+		return nil, err
+	}
+
+	dcid, lerr := deals.Flush()
+	if lerr != nil {
+		return nil, aerrors.HandleExternalError(lerr, "failed to flush deals")
+	}
+	self.Deals = dcid
+
+	return nil, sma.save(vmctx, head, self)
+}
+
+type SlashStorageDealCollateralParams struct {
+	DealIDs []uint64
+}
+
+// SlashStorageDealCollateral burns the collateral of active deals whose sector
+// was slashed for a storage fault, and unlocks the unpaid part of the deal
+// price for the client
+func (sma StorageMarketActor) SlashStorageDealCollateral(act *types.Actor, vmctx types.VMContext, params *SlashStorageDealCollateralParams) ([]byte, ActorError) {
+	head, self, err := sma.load(vmctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx := vmctx.Context()
+
+	nd, lerr := hamt.LoadNode(ctx, vmctx.Ipld(), self.Balances)
+	if lerr != nil {
+		return nil, aerrors.HandleExternalError(lerr, "failed to load balances")
+	}
+
+	deals, lerr := amt.LoadAMT(types.WrapStorage(vmctx.Storage()), self.Deals)
+	if lerr != nil {
+		return nil, aerrors.HandleExternalError(lerr, "failed to load deals")
+	}
+
+	burn := types.NewInt(0)
+	for _, id := range params.DealIDs {
+		deal, err := getMarketDeal(deals, id)
+		if err != nil {
+			return nil, err
+		}
+
+		if !deal.isActive() {
+			return nil, aerrors.Newf(8, "deal %d is not active", id)
+		}
+
+		enc, err := SerializeParams(&IsSectorSlashedParams{SectorID: deal.SectorID})
+		if err != nil {
+			return nil, err
+		}
+
+		ret, err := vmctx.Send(deal.Proposal.Provider, MAMethods.IsSectorSlashed, types.NewInt(0), enc)
+		if err != nil {
+			return nil, aerrors.Wrap(err, "checking if deal sector was slashed")
+		}
+		if !bytes.Equal(ret, cbg.CborBoolTrue) {
+			return nil, aerrors.Newf(9, "sector %d of deal %d was not slashed", deal.SectorID, id)
+		}
+
+		refund := types.BigMul(deal.Proposal.StoragePricePerEpoch, types.NewInt(deal.End()-deal.LastPaymentEpoch))
+		deal.SlashedAt = vmctx.BlockHeight()
+
+		client, err := getMarketBalance(vmctx, nd, deal.Proposal.Client)
+		if err != nil {
+			return nil, err
+		}
+		client.Locked = types.BigSub(client.Locked, refund)
+		client.Available = types.BigAdd(client.Available, refund)
+		if err := setMarketBalance(vmctx, nd, deal.Proposal.Client, client); err != nil {
+			return nil, err
+		}
+
+		provider, err := getMarketBalance(vmctx, nd, deal.Proposal.Provider)
+		if err != nil {
+			return nil, err
+		}
+		provider.Locked = types.BigSub(provider.Locked, deal.Proposal.StorageCollateral)
+		if err := setMarketBalance(vmctx, nd, deal.Proposal.Provider, provider); err != nil {
+			return nil, err
+		}
+		burn = types.BigAdd(burn, deal.Proposal.StorageCollateral)
+
+		if err := deals.Set(id, deal); err != nil {
+			return nil, aerrors.HandleExternalError(err, "failed to store deal")
+		}
+	}
+
+	if _, err := vmctx.Send(BurntFundsAddress, 0, burn, nil); err != nil {
+		return nil, aerrors.Wrap(err, "failed to burn deal collateral")
+	}
+
+	self.Balances, err = flushMarketBalances(ctx, vmctx, nd)
+	if err != nil {
+		return nil, err
+	}
+
+	dcid, lerr := deals.Flush()
+	if lerr != nil {
+		return nil, aerrors.HandleExternalError(lerr, "failed to flush deals")
+	}
+	self.Deals = dcid
+
+	return nil, sma.save(vmctx, head, self)
+}
+
+type ExpireStorageDealsParams struct {
+	DealIDs []uint64
+}
+
+// ExpireStorageDeals removes deals which weren't activated before their
+// activation deadline, and returns the locked deal price to the client and
+// the collateral to the provider
+func (sma StorageMarketActor) ExpireStorageDeals(act *types.Actor, vmctx types.VMContext, params *ExpireStorageDealsParams) ([]byte, ActorError) {
+	head, self, err := sma.load(vmctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx := vmctx.Context()
+
+	nd, lerr := hamt.LoadNode(ctx, vmctx.Ipld(), self.Balances)
+	if lerr != nil {
+		return nil, aerrors.HandleExternalError(lerr, "failed to load balances")
+	}
+
+	deals, lerr := amt.LoadAMT(types.WrapStorage(vmctx.Storage()), self.Deals)
+	if lerr != nil {
+		return nil, aerrors.HandleExternalError(lerr, "failed to load deals")
+	}
+
+	for _, id := range params.DealIDs {
+		deal, err := getMarketDeal(deals, id)
+		if err != nil {
+			return nil, err
+		}
+
+		if deal.ActivationEpoch != 0 {
+			return nil, aerrors.Newf(7, "deal %d was activated", id)
+		}
+
+		if vmctx.BlockHeight() <= deal.ActivationDeadline {
+			return nil, aerrors.Newf(12, "deal %d can still be activated", id)
+		}
+
+		price := deal.Proposal.TotalStoragePrice()
+
+		client, err := getMarketBalance(vmctx, nd, deal.Proposal.Client)
+		if err != nil {
+			return nil, err
+		}
+		client.Locked = types.BigSub(client.Locked, price)
+		client.Available = types.BigAdd(client.Available, price)
+		if err := setMarketBalance(vmctx, nd, deal.Proposal.Client, client); err != nil {
+			return nil, err
+		}
+
+		provider, err := getMarketBalance(vmctx, nd, deal.Proposal.Provider)
+		if err != nil {
+			return nil, err
+		}
+		provider.Locked = types.BigSub(provider.Locked, deal.Proposal.StorageCollateral)
+		provider.Available = types.BigAdd(provider.Available, deal.Proposal.StorageCollateral)
+		if err := setMarketBalance(vmctx, nd, deal.Proposal.Provider, provider); err != nil {
+			return nil, err
+		}
+
+		if err := deals.Delete(id); err != nil {
+			return nil, aerrors.HandleExternalError(err, "failed to delete deal")
+		}
+	}
+
+	self.Balances, err = flushMarketBalances(ctx, vmctx, nd)
+	if err != nil {
+		return nil, err
+	}
+
+	dcid, lerr := deals.Flush()
+	if lerr != nil {
+		return nil, aerrors.HandleExternalError(lerr, "failed to flush deals")
+	}
+	self.Deals = dcid
+
+	return nil, sma.save(vmctx, head, self)
+}
+
+// checkProviderWorker checks the message was sent by the worker of the given
+// storage miner
+func checkProviderWorker(vmctx types.VMContext, provider address.Address) ActorError {
+	enc, err := SerializeParams(&IsMinerParam{Addr: provider})
+	if err != nil {
+		return err
+	}
+
+	ret, err := vmctx.Send(StoragePowerAddress, SPAMethods.IsMiner, types.NewInt(0), enc)
+	if err != nil {
+		return aerrors.Wrap(err, "checking if provider is a miner")
+	}
+	if !bytes.Equal(ret, cbg.CborBoolTrue) {
+		return aerrors.Newf(3, "deal provider %s is not a miner", provider)
+	}
+
+	ret, err = vmctx.Send(provider, MAMethods.GetWorkerAddr, types.NewInt(0), nil)
+	if err != nil {
+		return aerrors.Wrap(err, "getting provider worker")
+	}
+
+	worker, werr := address.NewFromBytes(ret)
+	if werr != nil {
+		return aerrors.Absorb(werr, 3, "GetWorkerAddr returned malformed address")
+	}
+
+	if vmctx.Message().From != worker {
+		return aerrors.Newf(1, "sender is not the worker of provider %s", provider)
+	}
+
+	return nil
+}
+
+func getMarketDeal(deals *amt.Root, id uint64) (*OnChainDeal, ActorError) {
+	var deal OnChainDeal
+	if err := deals.Get(id, &deal); err != nil {
+		if _, ok := err.(*amt.ErrNotFound); ok {
+			return nil, aerrors.Newf(6, "no deal with ID %d", id)
+		}
+		return nil, aerrors.HandleExternalError(err, "failed to get deal")
+	}
+
+	return &deal, nil
+}
+
+// getMarketBalance returns the escrow balance of a participant. Balances are
+// keyed by ID address, so that every address form of an account shares one
+// balance
+func getMarketBalance(vmctx types.VMContext, nd *hamt.Node, addr address.Address) (StorageParticipantBalance, ActorError) {
+	b := StorageParticipantBalance{
+		Locked:    types.NewInt(0),
+		Available: types.NewInt(0),
+	}
+
+	id, aerr := LookupIDAddress(vmctx, addr)
+	if aerr != nil {
+		return StorageParticipantBalance{}, aerrors.Wrap(aerr, "failed to resolve participant")
+	}
+
+	err := nd.Find(vmctx.Context(), string(id.Bytes()), &b)
+	switch {
+	case err == nil:
+	case xerrors.Is(err, hamt.ErrNotFound):
+	default:
+		return StorageParticipantBalance{}, aerrors.HandleExternalError(err, "failed to look up balance")
+	}
+
+	return b, nil
+}
+
+func setMarketBalance(vmctx types.VMContext, nd *hamt.Node, addr address.Address, b StorageParticipantBalance) ActorError {
+	id, aerr := LookupIDAddress(vmctx, addr)
+	if aerr != nil {
+		return aerrors.Wrap(aerr, "failed to resolve participant")
+	}
+
+	if err := nd.Set(vmctx.Context(), string(id.Bytes()), &b); err != nil {
+		return aerrors.HandleExternalError(err, "failed to set balance")
+	}
+	return nil
+}
+
+func flushMarketBalances(ctx context.Context, vmctx types.VMContext, nd *hamt.Node) (cid.Cid, ActorError) {
+	if err := nd.Flush(ctx); err != nil {
+		return cid.Undef, aerrors.HandleExternalError(err, "failed to flush balances")
+	}
+
+	c, err := vmctx.Ipld().Put(ctx, nd)
+	if err != nil {
+		return cid.Undef, aerrors.HandleExternalError(err, "failed to persist balances")
+	}
+
+	return c, nil
+}
@@ -0,0 +1,582 @@
+package actors_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	amt "github.com/filecoin-project/go-amt-ipld"
+	cid "github.com/ipfs/go-cid"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/filecoin-project/go-lotus/build"
+	. "github.com/filecoin-project/go-lotus/chain/actors"
+	"github.com/filecoin-project/go-lotus/chain/address"
+	"github.com/filecoin-project/go-lotus/chain/types"
+	"github.com/filecoin-project/go-lotus/chain/wallet"
+)
+
+func signDealProposal(t *testing.T, w *wallet.Wallet, addr address.Address, p *StorageDealProposal) {
+	sb, err := p.SigningBytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sig, err := w.Sign(context.TODO(), addr, sb)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p.ProposerSignature = sig
+}
+
+// fakePieceInclusion replaces the piece inclusion verifier with one accepting
+// the proofs built by fakeInclusionProof, as the test sectors are never sealed.
+// The returned function restores the real verifier
+func fakePieceInclusion() func() {
+	verify := VerifyPieceInclusionProof
+	VerifyPieceInclusionProof = func(sectorSize uint64, pieceSize uint64, commP []byte, commD []byte, proof []byte) (bool, error) {
+		return bytes.Equal(proof, fakeInclusionProof(commP, commD)), nil
+	}
+
+	return func() {
+		VerifyPieceInclusionProof = verify
+	}
+}
+
+func fakeInclusionProof(commP []byte, commD []byte) []byte {
+	return append(append([]byte("inclusion:"), commP...), commD...)
+}
+
+// testInclusionProof proves the test piece is in a sector set up by
+// cheatMinerSector
+var testInclusionProof = fakeInclusionProof([]byte("commp"), []byte("commD"))
+
+func TestStorageMarketDeal(t *testing.T) {
+	defer fakePieceInclusion()()
+
+	var clientAddr, ownerAddr, workerAddr address.Address
+	h := NewHarness(t,
+		HarnessAddr(&clientAddr, 100000),
+		HarnessAddr(&ownerAddr, 1000000),
+		HarnessAddr(&workerAddr, 1000000),
+	)
+
+	minerAddr := createTestMiner(t, h, ownerAddr, workerAddr)
+
+	{
+		ret, _ := h.InvokeWithValue(t, clientAddr, MarketActorAddress, SMAMethods.AddBalance,
+			types.NewInt(1000), &AddBalanceParams{Address: clientAddr})
+		ApplyOK(t, ret)
+
+		ret, _ = h.InvokeWithValue(t, ownerAddr, MarketActorAddress, SMAMethods.AddBalance,
+			types.NewInt(100), &AddBalanceParams{Address: minerAddr})
+		ApplyOK(t, ret)
+	}
+	h.AssertBalanceChange(t, clientAddr, -1000)
+
+	proposal := StorageDealProposal{
+		PieceRef:             []byte("commp"),
+		PieceSize:            1024,
+		Client:               clientAddr,
+		Provider:             minerAddr,
+		ProposalExpiration:   100,
+		Duration:             10,
+		StoragePricePerEpoch: types.NewInt(20),
+		StorageCollateral:    types.NewInt(100),
+	}
+
+	{
+		ret, _ := h.Invoke(t, workerAddr, MarketActorAddress, SMAMethods.PublishStorageDeals,
+			&PublishStorageDealsParams{Deals: []StorageDealProposal{proposal}})
+		assert.Equal(t, byte(3), ret.ExitCode, "should not publish unsigned proposals")
+	}
+
+	signDealProposal(t, h.w, clientAddr, &proposal)
+
+	{
+		ret, _ := h.Invoke(t, clientAddr, MarketActorAddress, SMAMethods.PublishStorageDeals,
+			&PublishStorageDealsParams{Deals: []StorageDealProposal{proposal}})
+		assert.Equal(t, byte(1), ret.ExitCode, "only the provider worker can publish deals")
+	}
+
+	var dealID uint64
+	{
+		ret, _ := h.Invoke(t, workerAddr, MarketActorAddress, SMAMethods.PublishStorageDeals,
+			&PublishStorageDealsParams{Deals: []StorageDealProposal{proposal}})
+		ApplyOK(t, ret)
+
+		var resp PublishStorageDealResponse
+		if err := resp.UnmarshalCBOR(bytes.NewReader(ret.Return)); err != nil {
+			t.Fatal(err)
+		}
+		assert.Equal(t, 1, len(resp.DealIDs))
+		dealID = resp.DealIDs[0]
+	}
+
+	{
+		ret, _ := h.Invoke(t, workerAddr, MarketActorAddress, SMAMethods.PublishStorageDeals,
+			&PublishStorageDealsParams{Deals: []StorageDealProposal{proposal}})
+		assert.Equal(t, byte(10), ret.ExitCode, "a proposal can only be published once")
+
+		resigned := proposal
+		signDealProposal(t, h.w, clientAddr, &resigned)
+		ret, _ = h.Invoke(t, workerAddr, MarketActorAddress, SMAMethods.PublishStorageDeals,
+			&PublishStorageDealsParams{Deals: []StorageDealProposal{resigned}})
+		assert.Equal(t, byte(10), ret.ExitCode, "signing the proposal again shouldn't allow publishing it twice")
+
+		other := proposal
+		other.PieceRef = []byte("othercommp")
+		signDealProposal(t, h.w, clientAddr, &other)
+		ret, _ = h.Invoke(t, workerAddr, MarketActorAddress, SMAMethods.PublishStorageDeals,
+			&PublishStorageDealsParams{Deals: []StorageDealProposal{other}})
+		assert.Equal(t, byte(5), ret.ExitCode, "collateral should be locked by the first deal")
+	}
+
+	{
+		ret, _ := h.Invoke(t, workerAddr, MarketActorAddress, SMAMethods.ProcessStorageDealsPayment,
+			&ProcessStorageDealsPaymentParams{DealIDs: []uint64{dealID}})
+		assert.Equal(t, byte(8), ret.ExitCode, "should not pay for inactive deals")
+	}
+
+	cheatMinerSector(t, h, minerAddr, 1)
+
+	{
+		ret, _ := h.Invoke(t, workerAddr, MarketActorAddress, SMAMethods.ActivateStorageDeals,
+			&ActivateStorageDealsParams{Deals: []DealActivation{{DealID: dealID, SectorID: 2}}})
+		assert.Equal(t, byte(4), ret.ExitCode, "the deal sector must be committed")
+
+		ret, _ = h.Invoke(t, workerAddr, MarketActorAddress, SMAMethods.ActivateStorageDeals,
+			&ActivateStorageDealsParams{Deals: []DealActivation{{DealID: dealID, SectorID: 1}}})
+		assert.Equal(t, byte(6), ret.ExitCode, "the piece inclusion proof is missing")
+
+		ret, _ = h.Invoke(t, workerAddr, MarketActorAddress, SMAMethods.ActivateStorageDeals,
+			&ActivateStorageDealsParams{Deals: []DealActivation{{DealID: dealID, SectorID: 1, Proof: testInclusionProof}}})
+		ApplyOK(t, ret)
+	}
+
+	h.vm.SetBlockHeight(6)
+	{
+		ret, _ := h.Invoke(t, workerAddr, MarketActorAddress, SMAMethods.ProcessStorageDealsPayment,
+			&ProcessStorageDealsPaymentParams{DealIDs: []uint64{dealID}})
+		ApplyOK(t, ret)
+
+		ret, _ = h.Invoke(t, workerAddr, MarketActorAddress, SMAMethods.WithdrawBalance,
+			&WithdrawBalanceParams{Address: minerAddr, Balance: types.NewInt(101)})
+		assert.Equal(t, byte(1), ret.ExitCode, "collateral should stay locked until the deal ends")
+
+		ret, _ = h.Invoke(t, workerAddr, MarketActorAddress, SMAMethods.WithdrawBalance,
+			&WithdrawBalanceParams{Address: minerAddr, Balance: types.NewInt(100)})
+		ApplyOK(t, ret)
+	}
+	h.AssertBalanceChange(t, workerAddr, 100)
+
+	h.vm.SetBlockHeight(20)
+	{
+		ret, _ := h.Invoke(t, workerAddr, MarketActorAddress, SMAMethods.ProcessStorageDealsPayment,
+			&ProcessStorageDealsPaymentParams{DealIDs: []uint64{dealID}})
+		ApplyOK(t, ret)
+
+		ret, _ = h.Invoke(t, workerAddr, MarketActorAddress, SMAMethods.WithdrawBalance,
+			&WithdrawBalanceParams{Address: minerAddr, Balance: types.NewInt(200)})
+		ApplyOK(t, ret)
+
+		ret, _ = h.Invoke(t, workerAddr, MarketActorAddress, SMAMethods.ProcessStorageDealsPayment,
+			&ProcessStorageDealsPaymentParams{DealIDs: []uint64{dealID}})
+		assert.Equal(t, byte(8), ret.ExitCode, "should not pay for expired deals")
+	}
+	h.AssertBalanceChange(t, workerAddr, 200)
+
+	{
+		ret, _ := h.Invoke(t, clientAddr, MarketActorAddress, SMAMethods.WithdrawBalance,
+			&WithdrawBalanceParams{Address: minerAddr, Balance: types.NewInt(1)})
+		assert.Equal(t, byte(1), ret.ExitCode, "only the worker can withdraw provider funds")
+
+		ret, _ = h.Invoke(t, clientAddr, MarketActorAddress, SMAMethods.WithdrawBalance,
+			&WithdrawBalanceParams{Address: clientAddr, Balance: types.NewInt(801)})
+		assert.Equal(t, byte(1), ret.ExitCode, "the deal price should have been paid from the client funds")
+
+		ret, _ = h.Invoke(t, clientAddr, MarketActorAddress, SMAMethods.WithdrawBalance,
+			&WithdrawBalanceParams{Address: clientAddr, Balance: types.NewInt(800)})
+		ApplyOK(t, ret)
+	}
+	h.AssertBalanceChange(t, clientAddr, 800)
+}
+
+// cheatMinerSector adds a sector to the sector set of the miner, as if it was
+// committed
+func cheatMinerSector(t *testing.T, h *Harness, maddr address.Address, sectorID uint64) {
+	t.Helper()
+
+	cheatMinerState(t, h, maddr, func(mstate *StorageMinerActorState) {
+		sectors, err := amt.LoadAMT(amt.WrapBlockstore(h.cs.Blockstore()), mstate.Sectors)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if err := sectors.Set(sectorID, [][]byte{[]byte("commR"), []byte("commD")}); err != nil {
+			t.Fatal(err)
+		}
+
+		mstate.Sectors, err = sectors.Flush()
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+}
+
+// publishTestDeal publishes a signed deal storing the test piece for 10
+// epochs at 20 per epoch, backed by a collateral of 100
+func publishTestDeal(t *testing.T, h *Harness, clientAddr, workerAddr, minerAddr address.Address) uint64 {
+	t.Helper()
+
+	ret, _ := h.InvokeWithValue(t, clientAddr, MarketActorAddress, SMAMethods.AddBalance,
+		types.NewInt(200), &AddBalanceParams{Address: clientAddr})
+	ApplyOK(t, ret)
+
+	ret, _ = h.InvokeWithValue(t, workerAddr, MarketActorAddress, SMAMethods.AddBalance,
+		types.NewInt(100), &AddBalanceParams{Address: minerAddr})
+	ApplyOK(t, ret)
+
+	proposal := StorageDealProposal{
+		PieceRef:             []byte("commp"),
+		PieceSize:            1024,
+		Client:               clientAddr,
+		Provider:             minerAddr,
+		ProposalExpiration:   100,
+		Duration:             10,
+		StoragePricePerEpoch: types.NewInt(20),
+		StorageCollateral:    types.NewInt(100),
+	}
+	signDealProposal(t, h.w, clientAddr, &proposal)
+
+	ret, _ = h.Invoke(t, workerAddr, MarketActorAddress, SMAMethods.PublishStorageDeals,
+		&PublishStorageDealsParams{Deals: []StorageDealProposal{proposal}})
+	ApplyOK(t, ret)
+
+	var resp PublishStorageDealResponse
+	if err := resp.UnmarshalCBOR(bytes.NewReader(ret.Return)); err != nil {
+		t.Fatal(err)
+	}
+
+	return resp.DealIDs[0]
+}
+
+func TestStorageMarketExpireDeal(t *testing.T) {
+	defer fakePieceInclusion()()
+
+	var clientAddr, ownerAddr, workerAddr address.Address
+	h := NewHarness(t,
+		HarnessAddr(&clientAddr, 100000),
+		HarnessAddr(&ownerAddr, 1000000),
+		HarnessAddr(&workerAddr, 100000),
+	)
+
+	minerAddr := createTestMiner(t, h, ownerAddr, workerAddr)
+	cheatMinerSector(t, h, minerAddr, 1)
+
+	dealID := publishTestDeal(t, h, clientAddr, workerAddr, minerAddr)
+	h.AssertBalanceChange(t, clientAddr, -200)
+
+	{
+		ret, _ := h.Invoke(t, clientAddr, MarketActorAddress, SMAMethods.ExpireStorageDeals,
+			&ExpireStorageDealsParams{DealIDs: []uint64{dealID}})
+		assert.Equal(t, byte(12), ret.ExitCode, "the deal can still be activated")
+	}
+
+	h.vm.SetBlockHeight(2 + build.DealActivationTimeout)
+
+	{
+		ret, _ := h.Invoke(t, workerAddr, MarketActorAddress, SMAMethods.ActivateStorageDeals,
+			&ActivateStorageDealsParams{Deals: []DealActivation{{DealID: dealID, SectorID: 1, Proof: testInclusionProof}}})
+		assert.Equal(t, byte(11), ret.ExitCode, "the activation deadline passed")
+
+		ret, _ = h.Invoke(t, clientAddr, MarketActorAddress, SMAMethods.ExpireStorageDeals,
+			&ExpireStorageDealsParams{DealIDs: []uint64{dealID}})
+		ApplyOK(t, ret)
+
+		ret, _ = h.Invoke(t, clientAddr, MarketActorAddress, SMAMethods.ExpireStorageDeals,
+			&ExpireStorageDealsParams{DealIDs: []uint64{dealID}})
+		assert.Equal(t, byte(6), ret.ExitCode, "the expired deal should be removed")
+	}
+
+	{
+		ret, _ := h.Invoke(t, clientAddr, MarketActorAddress, SMAMethods.WithdrawBalance,
+			&WithdrawBalanceParams{Address: clientAddr, Balance: types.NewInt(200)})
+		ApplyOK(t, ret)
+
+		ret, _ = h.Invoke(t, workerAddr, MarketActorAddress, SMAMethods.WithdrawBalance,
+			&WithdrawBalanceParams{Address: minerAddr, Balance: types.NewInt(100)})
+		ApplyOK(t, ret)
+	}
+	h.AssertBalanceChange(t, clientAddr, 200)
+}
+
+func TestStorageMarketNegativeDeal(t *testing.T) {
+	var clientAddr, ownerAddr, workerAddr address.Address
+	h := NewHarness(t,
+		HarnessAddr(&clientAddr, 100000),
+		HarnessAddr(&ownerAddr, 1000000),
+		HarnessAddr(&workerAddr, 1000000),
+	)
+
+	minerAddr := createTestMiner(t, h, ownerAddr, workerAddr)
+
+	ret, _ := h.InvokeWithValue(t, clientAddr, MarketActorAddress, SMAMethods.AddBalance,
+		types.NewInt(200), &AddBalanceParams{Address: clientAddr})
+	ApplyOK(t, ret)
+
+	ret, _ = h.InvokeWithValue(t, workerAddr, MarketActorAddress, SMAMethods.AddBalance,
+		types.NewInt(100), &AddBalanceParams{Address: minerAddr})
+	ApplyOK(t, ret)
+
+	publish := func(price, collateral types.BigInt) byte {
+		proposal := StorageDealProposal{
+			PieceRef:             []byte("commp"),
+			PieceSize:            1024,
+			Client:               clientAddr,
+			Provider:             minerAddr,
+			ProposalExpiration:   100,
+			Duration:             10,
+			StoragePricePerEpoch: price,
+			StorageCollateral:    collateral,
+		}
+		signDealProposal(t, h.w, clientAddr, &proposal)
+
+		ret, _ := h.Invoke(t, workerAddr, MarketActorAddress, SMAMethods.PublishStorageDeals,
+			&PublishStorageDealsParams{Deals: []StorageDealProposal{proposal}})
+		return ret.ExitCode
+	}
+
+	negative := types.BigSub(types.NewInt(0), types.NewInt(20))
+	assert.Equal(t, byte(3), publish(negative, types.NewInt(100)), "negative price must be rejected")
+	assert.Equal(t, byte(3), publish(types.NewInt(20), negative), "negative collateral must be rejected")
+
+	// nothing was locked or credited, the escrow can be withdrawn as deposited
+	ret, _ = h.Invoke(t, clientAddr, MarketActorAddress, SMAMethods.WithdrawBalance,
+		&WithdrawBalanceParams{Address: clientAddr, Balance: types.NewInt(201)})
+	assert.Equal(t, byte(1), ret.ExitCode)
+
+	ret, _ = h.Invoke(t, clientAddr, MarketActorAddress, SMAMethods.WithdrawBalance,
+		&WithdrawBalanceParams{Address: clientAddr, Balance: types.NewInt(200)})
+	ApplyOK(t, ret)
+
+	ret, _ = h.Invoke(t, workerAddr, MarketActorAddress, SMAMethods.WithdrawBalance,
+		&WithdrawBalanceParams{Address: minerAddr, Balance: types.NewInt(100)})
+	ApplyOK(t, ret)
+}
+
+func TestStorageMarketLateProvider(t *testing.T) {
+	defer fakePieceInclusion()()
+
+	var clientAddr, ownerAddr, workerAddr address.Address
+	h := NewHarness(t,
+		HarnessAddr(&clientAddr, 100000),
+		HarnessAddr(&ownerAddr, 1000000),
+		HarnessAddr(&workerAddr, 100000),
+	)
+
+	minerAddr := createTestMiner(t, h, ownerAddr, workerAddr)
+	cheatMinerSector(t, h, minerAddr, 1)
+
+	dealID := publishTestDeal(t, h, clientAddr, workerAddr, minerAddr)
+	h.AssertBalanceChange(t, clientAddr, -200)
+
+	{
+		ret, _ := h.Invoke(t, workerAddr, MarketActorAddress, SMAMethods.ActivateStorageDeals,
+			&ActivateStorageDealsParams{Deals: []DealActivation{{DealID: dealID, SectorID: 1, Proof: testInclusionProof}}})
+		ApplyOK(t, ret)
+	}
+
+	// the provider missed its PoSt for the first 5 epochs of the deal
+	cheatMinerState(t, h, minerAddr, func(mstate *StorageMinerActorState) {
+		mstate.ProvingPeriodEnd = 1
+	})
+	h.vm.SetBlockHeight(6)
+
+	{
+		ret, _ := h.Invoke(t, workerAddr, MarketActorAddress, SMAMethods.ProcessStorageDealsPayment,
+			&ProcessStorageDealsPaymentParams{DealIDs: []uint64{dealID}})
+		ApplyOK(t, ret)
+
+		ret, _ = h.Invoke(t, workerAddr, MarketActorAddress, SMAMethods.WithdrawBalance,
+			&WithdrawBalanceParams{Address: minerAddr, Balance: types.NewInt(1)})
+		assert.Equal(t, byte(1), ret.ExitCode, "a late provider shouldn't be paid")
+
+		ret, _ = h.Invoke(t, clientAddr, MarketActorAddress, SMAMethods.WithdrawBalance,
+			&WithdrawBalanceParams{Address: clientAddr, Balance: types.NewInt(100)})
+		ApplyOK(t, ret)
+	}
+	h.AssertBalanceChange(t, clientAddr, 100)
+
+	cheatMinerState(t, h, minerAddr, func(mstate *StorageMinerActorState) {
+		mstate.ProvingPeriodEnd = 100
+	})
+
+	{
+		ret, _ := h.Invoke(t, workerAddr, MarketActorAddress, SMAMethods.ProcessStorageDealsPayment,
+			&ProcessStorageDealsPaymentParams{DealIDs: []uint64{dealID}})
+		ApplyOK(t, ret)
+
+		ret, _ = h.Invoke(t, workerAddr, MarketActorAddress, SMAMethods.WithdrawBalance,
+			&WithdrawBalanceParams{Address: minerAddr, Balance: types.NewInt(1)})
+		assert.Equal(t, byte(1), ret.ExitCode, "the forfeited epochs shouldn't be paid later")
+	}
+
+	h.vm.SetBlockHeight(11)
+
+	{
+		ret, _ := h.Invoke(t, workerAddr, MarketActorAddress, SMAMethods.ProcessStorageDealsPayment,
+			&ProcessStorageDealsPaymentParams{DealIDs: []uint64{dealID}})
+		ApplyOK(t, ret)
+
+		ret, _ = h.Invoke(t, workerAddr, MarketActorAddress, SMAMethods.WithdrawBalance,
+			&WithdrawBalanceParams{Address: minerAddr, Balance: types.NewInt(200)})
+		ApplyOK(t, ret)
+	}
+}
+
+func TestStorageMarketLateProviderKeepsProvenEpochs(t *testing.T) {
+	defer fakePieceInclusion()()
+
+	var clientAddr, ownerAddr, workerAddr, reporterAddr address.Address
+	h := NewHarness(t,
+		HarnessAddr(&clientAddr, 100000),
+		HarnessAddr(&ownerAddr, 1000000),
+		HarnessAddr(&workerAddr, 100000),
+		HarnessAddr(&reporterAddr, 100000),
+	)
+
+	minerAddr := createTestMiner(t, h, ownerAddr, workerAddr)
+	cheatMinerSector(t, h, minerAddr, 1)
+
+	dealID := publishTestDeal(t, h, clientAddr, workerAddr, minerAddr)
+	h.AssertBalanceChange(t, clientAddr, -200)
+
+	{
+		ret, _ := h.Invoke(t, workerAddr, MarketActorAddress, SMAMethods.ActivateStorageDeals,
+			&ActivateStorageDealsParams{Deals: []DealActivation{{DealID: dealID, SectorID: 1, Proof: testInclusionProof}}})
+		ApplyOK(t, ret)
+	}
+
+	// the provider proved its storage up to epoch 4, and is late with the
+	// PoSt of the next proving period
+	cheatMinerState(t, h, minerAddr, func(mstate *StorageMinerActorState) {
+		mstate.ProvingPeriodEnd = 4 + build.ProvingPeriodDuration
+	})
+	h.vm.SetBlockHeight(5 + build.ProvingPeriodDuration)
+
+	{
+		// anyone can process the payment, which shouldn't take away the
+		// epochs proven before the provider was late
+		ret, _ := h.Invoke(t, reporterAddr, MarketActorAddress, SMAMethods.ProcessStorageDealsPayment,
+			&ProcessStorageDealsPaymentParams{DealIDs: []uint64{dealID}})
+		ApplyOK(t, ret)
+
+		// 3 proven epochs are paid, along with the unlocked collateral
+		ret, _ = h.Invoke(t, workerAddr, MarketActorAddress, SMAMethods.WithdrawBalance,
+			&WithdrawBalanceParams{Address: minerAddr, Balance: types.NewInt(161)})
+		assert.Equal(t, byte(1), ret.ExitCode, "the epochs after the last proven one should be forfeited")
+
+		ret, _ = h.Invoke(t, workerAddr, MarketActorAddress, SMAMethods.WithdrawBalance,
+			&WithdrawBalanceParams{Address: minerAddr, Balance: types.NewInt(160)})
+		ApplyOK(t, ret)
+
+		ret, _ = h.Invoke(t, clientAddr, MarketActorAddress, SMAMethods.WithdrawBalance,
+			&WithdrawBalanceParams{Address: clientAddr, Balance: types.NewInt(140)})
+		ApplyOK(t, ret)
+	}
+	h.AssertBalanceChange(t, clientAddr, 140)
+}
+
+func TestStorageMarketBalanceAddressForms(t *testing.T) {
+	var clientAddr address.Address
+	h := NewHarness(t, HarnessAddr(&clientAddr, 100000))
+
+	ret, _ := h.Invoke(t, clientAddr, InitActorAddress, IAMethods.GetIdForAddress,
+		&GetIdForAddressParams{Addr: clientAddr})
+	ApplyOK(t, ret)
+	clientID, err := address.NewFromBytes(ret.Return)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ret, _ = h.InvokeWithValue(t, clientAddr, MarketActorAddress, SMAMethods.AddBalance,
+		types.NewInt(100), &AddBalanceParams{Address: clientID})
+	ApplyOK(t, ret)
+	h.AssertBalanceChange(t, clientAddr, -100)
+
+	// the balance added to the ID address belongs to the key address as well
+	ret, _ = h.Invoke(t, clientAddr, MarketActorAddress, SMAMethods.WithdrawBalance,
+		&WithdrawBalanceParams{Address: clientAddr, Balance: types.NewInt(100)})
+	ApplyOK(t, ret)
+	h.AssertBalanceChange(t, clientAddr, 100)
+}
+
+func TestStorageMarketSlashDeal(t *testing.T) {
+	defer fakePieceInclusion()()
+
+	var clientAddr, ownerAddr, workerAddr, reporterAddr address.Address
+	h := NewHarness(t,
+		HarnessAddr(&clientAddr, 100000),
+		HarnessAddr(&ownerAddr, 1000000),
+		HarnessAddr(&workerAddr, 100000),
+		HarnessAddr(&reporterAddr, 100000),
+	)
+
+	minerAddr := createTestMiner(t, h, ownerAddr, workerAddr)
+	cheatMinerSector(t, h, minerAddr, 1)
+
+	dealID := publishTestDeal(t, h, clientAddr, workerAddr, minerAddr)
+	h.AssertBalanceChange(t, clientAddr, -200)
+
+	{
+		ret, _ := h.Invoke(t, workerAddr, MarketActorAddress, SMAMethods.ActivateStorageDeals,
+			&ActivateStorageDealsParams{Deals: []DealActivation{{DealID: dealID, SectorID: 1, Proof: testInclusionProof}}})
+		ApplyOK(t, ret)
+	}
+
+	slashedSet := func(sectorID uint64) cid.Cid {
+		set := amt.NewAMT(amt.WrapBlockstore(h.cs.Blockstore()))
+		if err := set.Set(sectorID, [][]byte{[]byte("commR"), []byte("commD")}); err != nil {
+			t.Fatal(err)
+		}
+		c, err := set.Flush()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return c
+	}
+
+	// the miner was slashed for a fault of another sector
+	cheatMinerState(t, h, minerAddr, func(mstate *StorageMinerActorState) {
+		mstate.SlashedAt = types.NewInt(1)
+		mstate.SlashedSet = slashedSet(2)
+	})
+
+	{
+		ret, _ := h.Invoke(t, reporterAddr, MarketActorAddress, SMAMethods.SlashStorageDealCollateral,
+			&SlashStorageDealCollateralParams{DealIDs: []uint64{dealID}})
+		assert.Equal(t, byte(9), ret.ExitCode, "the deal sector wasn't slashed")
+	}
+
+	cheatMinerState(t, h, minerAddr, func(mstate *StorageMinerActorState) {
+		mstate.SlashedSet = slashedSet(1)
+	})
+	h.vm.SetBlockHeight(6)
+
+	{
+		ret, _ := h.Invoke(t, reporterAddr, MarketActorAddress, SMAMethods.SlashStorageDealCollateral,
+			&SlashStorageDealCollateralParams{DealIDs: []uint64{dealID}})
+		ApplyOK(t, ret)
+
+		ret, _ = h.Invoke(t, workerAddr, MarketActorAddress, SMAMethods.WithdrawBalance,
+			&WithdrawBalanceParams{Address: minerAddr, Balance: types.NewInt(1)})
+		assert.Equal(t, byte(1), ret.ExitCode, "the collateral should be burned")
+
+		ret, _ = h.Invoke(t, clientAddr, MarketActorAddress, SMAMethods.WithdrawBalance,
+			&WithdrawBalanceParams{Address: clientAddr, Balance: types.NewInt(200)})
+		ApplyOK(t, ret)
+	}
+	h.AssertBalanceChange(t, clientAddr, 200)
+}
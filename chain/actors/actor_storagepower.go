@@ -26,9 +26,10 @@ type spaMethods struct {
 	PowerLookup             uint64
 	IsMiner                 uint64
 	PledgeCollateralForSize uint64
+	SlashStorageFault       uint64
 }
 
-var SPAMethods = spaMethods{1, 2, 3, 4, 5, 6, 7, 8}
+var SPAMethods = spaMethods{1, 2, 3, 4, 5, 6, 7, 8, 9}
 
 func (spa StoragePowerActor) Exports() []interface{} {
 	return []interface{}{
@@ -40,6 +41,7 @@ func (spa StoragePowerActor) Exports() []interface{} {
 		6: spa.PowerLookup,
 		7: spa.IsMiner,
 		8: spa.PledgeCollateralForSize,
+		9: spa.SlashStorageFault,
 	}
 }
 
@@ -221,6 +223,52 @@ func (spa StoragePowerActor) ArbitrateConsensusFault(act *types.Actor, vmctx typ
 	return nil, nil
 }
 
+type SlashStorageFaultParams struct {
+	Miner address.Address
+}
+
+// SlashStorageFault removes the power of a miner which missed its proving
+// period by more than SlashablePowerDelay blocks. Anyone can report the fault,
+// the reporter is paid a share of the slashed collateral
+func (spa StoragePowerActor) SlashStorageFault(act *types.Actor, vmctx types.VMContext, params *SlashStorageFaultParams) ([]byte, ActorError) {
+	var self StoragePowerState
+	old := vmctx.Storage().GetHead()
+	if err := vmctx.Storage().Get(old, &self); err != nil {
+		return nil, err
+	}
+
+	if has, err := MinerSetHas(vmctx, self.Miners, params.Miner); err != nil {
+		return nil, aerrors.Wrapf(err, "failed to check miner in set")
+	} else if !has {
+		return nil, aerrors.New(1, "not a miner")
+	}
+
+	enc, err := SerializeParams(&MinerSlashStorageFault{
+		Slasher: vmctx.Message().From,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ret, err := vmctx.Send(params.Miner, MAMethods.SlashStorageFault, types.NewInt(0), enc)
+	if err != nil {
+		return nil, aerrors.Wrap(err, "failed to slash miner")
+	}
+
+	self.TotalStorage = types.BigSub(self.TotalStorage, types.BigFromBytes(ret))
+
+	nroot, err := vmctx.Storage().Put(&self)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := vmctx.Storage().Commit(old, nroot); err != nil {
+		return nil, err
+	}
+
+	return nil, nil
+}
+
 func cidArrContains(a []cid.Cid, b cid.Cid) bool {
 	for _, c := range a {
 		if b == c {
@@ -386,13 +434,19 @@ func pledgeCollateralForSize(vmctx types.VMContext, size, totalStorage types.Big
 		return types.EmptyInt, err
 	}
 
+	// unissued and locked block rewards aren't available either
+	rewardBalance, err := vmctx.GetBalance(RewardActorAddress)
+	if err != nil {
+		return types.EmptyInt, err
+	}
+
 	// TODO: the spec says to also grab 'total vested filecoin' and include it as available
 	// If we don't factor that in, we effectively assume all of the locked up filecoin is 'available'
 	// the blocker on that right now is that its hard to tell how much filecoin is unlocked
 
 	availableFilecoin := types.BigSub(
 		types.BigMul(types.NewInt(build.TotalFilecoin), types.NewInt(build.FilecoinPrecision)),
-		netBalance,
+		types.BigAdd(netBalance, rewardBalance),
 	)
 
 	totalPowerCollateral := types.BigDiv(
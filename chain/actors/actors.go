@@ -8,15 +8,19 @@ import (
 )
 
 var AccountActorCodeCid cid.Cid
-var StorageMarketActorCodeCid cid.Cid
+var StoragePowerActorCodeCid cid.Cid
 var StorageMinerCodeCid cid.Cid
 var MultisigActorCodeCid cid.Cid
 var InitActorCodeCid cid.Cid
 var PaymentChannelActorCodeCid cid.Cid
+var RewardActorCodeCid cid.Cid
+var MarketActorCodeCid cid.Cid
 
 var InitActorAddress = mustIDAddress(0)
 var NetworkAddress = mustIDAddress(1)
-var StorageMarketAddress = mustIDAddress(2)
+var StoragePowerAddress = mustIDAddress(2)
+var RewardActorAddress = mustIDAddress(3)
+var MarketActorAddress = mustIDAddress(4)
 var BurntFundsAddress = mustIDAddress(99)
 
 func mustIDAddress(i uint64) address.Address {
@@ -38,9 +42,11 @@ func init() {
 	}
 
 	AccountActorCodeCid = mustSum("account")
-	StorageMarketActorCodeCid = mustSum("smarket")
+	StoragePowerActorCodeCid = mustSum("spower")
 	StorageMinerCodeCid = mustSum("sminer")
 	MultisigActorCodeCid = mustSum("multisig")
 	InitActorCodeCid = mustSum("init")
 	PaymentChannelActorCodeCid = mustSum("paych")
+	RewardActorCodeCid = mustSum("reward")
+	MarketActorCodeCid = mustSum("market")
 }
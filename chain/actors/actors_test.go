@@ -53,7 +53,7 @@ func setupVMTestEnv(t *testing.T) (*vm.VM, []address.Address, bstore.Blockstore)
 	cs := store.NewChainStore(bs, nil)
 
 	// TODO: should probabaly mock out the randomness bit, nil works for now
-	vm, err := vm.NewVM(stateroot, 1, nil, maddr, cs.Blockstore())
+	vm, err := vm.NewVM(stateroot, 1, nil, maddr, cs.Blockstore(), types.NetworkVersion0)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -108,12 +108,12 @@ func TestVMInvokeMethod(t *testing.T) {
 	}
 }
 
-func TestStorageMarketActorCreateMiner(t *testing.T) {
+func TestStoragePowerActorCreateMiner(t *testing.T) {
 	vm, addrs, bs := setupVMTestEnv(t)
 	from := addrs[0]
 	maddr := addrs[1]
 
-	cheatStorageMarketTotal(t, vm, bs)
+	cheatStoragePowerTotal(t, vm, bs)
 
 	params := &StorageMinerConstructorParams{
 		Owner:      maddr,
@@ -128,7 +128,7 @@ func TestStorageMarketActorCreateMiner(t *testing.T) {
 	}
 
 	msg := &types.Message{
-		To:       StorageMarketAddress,
+		To:       StoragePowerAddress,
 		From:     from,
 		Method:   SPAMethods.CreateStorageMiner,
 		Params:   enc,
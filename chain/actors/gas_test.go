@@ -0,0 +1,264 @@
+package actors_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/filecoin-project/go-lotus/build"
+	"github.com/filecoin-project/go-lotus/chain/actors"
+	"github.com/filecoin-project/go-lotus/chain/address"
+	"github.com/filecoin-project/go-lotus/chain/types"
+	"github.com/filecoin-project/go-lotus/chain/vm"
+)
+
+func msgGas(t *testing.T, pl vm.Pricelist, ret *vm.ApplyRet) uint64 {
+	t.Helper()
+
+	b, err := ret.ExecutionTrace.Msg.Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return pl.OnChainMessage(len(b))
+}
+
+func TestGasSendFunds(t *testing.T) {
+	var from, to address.Address
+	h := NewHarness(t, HarnessAddr(&from, 100000), HarnessAddr(&to, 0))
+	pl := vm.PricelistByHeight(1)
+
+	ret, _ := h.SendFunds(t, from, to, types.NewInt(1000))
+	ApplyOK(t, ret)
+
+	expected := msgGas(t, pl, ret) + pl.OnMethodInvocation(types.NewInt(1000), 0)
+	assert.Equal(t, types.NewInt(expected), ret.GasUsed)
+}
+
+func TestGasSendFundsCreatesAccount(t *testing.T) {
+	var from address.Address
+	h := NewHarness(t, HarnessAddr(&from, 100000))
+	pl := vm.PricelistByHeight(1)
+
+	ret, _ := h.SendFunds(t, from, blsaddr(100), types.NewInt(1000))
+	ApplyOK(t, ret)
+
+	expected := msgGas(t, pl, ret) + pl.OnMethodInvocation(types.NewInt(1000), 0) + pl.OnCreateActor()
+	assert.Equal(t, types.NewInt(expected), ret.GasUsed)
+}
+
+func TestGasOutOfGas(t *testing.T) {
+	var from, to address.Address
+	h := NewHarness(t, HarnessAddr(&from, 100000), HarnessAddr(&to, 0))
+
+	ret, _ := h.Apply(t, types.Message{
+		To:       to,
+		From:     from,
+		Value:    types.NewInt(1000),
+		GasPrice: types.NewInt(1),
+		GasLimit: types.NewInt(10),
+	})
+	assert.NotEqual(t, uint8(0), ret.ExitCode, "message should run out of gas")
+	assert.Equal(t, types.NewInt(10), ret.GasUsed, "failed message should use its whole gas limit")
+}
+
+func TestGasFailedMessageChargesSender(t *testing.T) {
+	var from, to address.Address
+	h := NewHarness(t, HarnessAddr(&from, 100000), HarnessAddr(&to, 0))
+
+	ret, st := h.Apply(t, types.Message{
+		To:       to,
+		From:     from,
+		Value:    types.NewInt(1000),
+		GasPrice: types.NewInt(2),
+		GasLimit: types.NewInt(10),
+	})
+	assert.NotEqual(t, uint8(0), ret.ExitCode, "message should run out of gas")
+
+	// the transfer is reverted, but the sender still pays for the whole gas
+	// limit and the nonce is used up
+	h.AssertBalance(t, from, 100000-2*10)
+	h.AssertBalance(t, to, 0)
+
+	act, err := st.GetActor(from)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, uint64(1), act.Nonce)
+}
+
+func TestGasMultisigCreate(t *testing.T) {
+	var creator address.Address
+	h := NewHarness(t, HarnessAddr(&creator, 100000))
+	pl := vm.PricelistByHeight(1)
+
+	ret, _ := h.CreateActor(t, creator, actors.MultisigActorCodeCid,
+		&actors.MultiSigConstructorParams{
+			Signers:  []address.Address{creator},
+			Required: 1,
+		})
+	ApplyOK(t, ret)
+
+	// the exec call and the constructor invocation are both charged, along
+	// with the actor creation itself; state access is charged on top
+	min := msgGas(t, pl, ret) + pl.OnMethodInvocation(types.NewInt(0), actors.IAMethods.Exec) + pl.OnCreateActor() +
+		pl.OnMethodInvocation(types.NewInt(0), 1) + 2*pl.OnCommit()
+	if ret.GasUsed.LessThan(types.NewInt(min)) {
+		t.Errorf("expected multisig creation to use at least %d gas, used %s", min, ret.GasUsed)
+	}
+
+	if assert.Len(t, ret.ExecutionTrace.Subcalls, 1) {
+		ctor := ret.ExecutionTrace.Subcalls[0]
+		assert.Equal(t, uint64(1), ctor.Msg.Method)
+		if ctor.MsgRct.GasUsed.LessThan(types.NewInt(pl.OnMethodInvocation(types.NewInt(0), 1))) {
+			t.Error("constructor call should be charged for its invocation")
+		}
+	}
+}
+
+// harnessKey funds the account of a key with a fixed private key, so that the
+// address and the gas charged for it don't change between runs
+func harnessKey(addr *address.Address, seed byte, value uint64) HarnessOpt {
+	return func(t testing.TB, h *Harness) error {
+		if h.Stage != HarnessPreInit {
+			return nil
+		}
+
+		k, err := h.w.Import(&types.KeyInfo{
+			Type:       types.KTSecp256k1,
+			PrivateKey: bytes.Repeat([]byte{seed}, 32),
+		})
+		if err != nil {
+			return err
+		}
+
+		*addr = k
+		return HarnessAddr(addr, value)(t, h)
+	}
+}
+
+func TestGasPowerAndMinerMethods(t *testing.T) {
+	owner, worker, newWorker := blsaddr(1), blsaddr(2), blsaddr(3)
+	h := NewHarness(t,
+		HarnessAddr(&owner, 1000000),
+		HarnessAddr(&worker, 100000),
+	)
+
+	cheatStoragePowerTotal(t, h.vm, h.cs.Blockstore())
+
+	ret, _ := h.InvokeWithValue(t, owner, actors.StoragePowerAddress, actors.SPAMethods.CreateStorageMiner,
+		types.NewInt(500000),
+		&actors.CreateStorageMinerParams{
+			Owner:      owner,
+			Worker:     worker,
+			SectorSize: types.NewInt(build.SectorSize),
+			PeerID:     "fakepeerid",
+		})
+	ApplyOK(t, ret)
+	assert.Equal(t, types.NewInt(2618), ret.GasUsed, "CreateStorageMiner")
+
+	minerAddr, err := address.NewFromBytes(ret.Return)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ret, _ = h.Invoke(t, owner, actors.StoragePowerAddress, actors.SPAMethods.IsMiner,
+		&actors.IsMinerParam{Addr: minerAddr})
+	ApplyOK(t, ret)
+	assert.Equal(t, types.NewInt(239), ret.GasUsed, "IsMiner")
+
+	ret, _ = h.Invoke(t, worker, minerAddr, actors.MAMethods.UpdatePeerID,
+		&actors.UpdatePeerIDParams{PeerID: "newpeerid"})
+	ApplyOK(t, ret)
+	assert.Equal(t, types.NewInt(1316), ret.GasUsed, "UpdatePeerID")
+
+	ret, _ = h.Invoke(t, owner, minerAddr, actors.MAMethods.ChangeWorker,
+		&actors.ChangeWorkerParams{NewWorker: newWorker})
+	ApplyOK(t, ret)
+	assert.Equal(t, types.NewInt(1399), ret.GasUsed, "ChangeWorker")
+
+	ret, _ = h.Invoke(t, owner, minerAddr, actors.MAMethods.GetPower, nil)
+	ApplyOK(t, ret)
+	assert.Equal(t, types.NewInt(380), ret.GasUsed, "GetPower")
+}
+
+func TestGasMultisigMethods(t *testing.T) {
+	creator, signer, to := blsaddr(1), blsaddr(2), blsaddr(3)
+	h := NewHarness(t,
+		HarnessAddr(&creator, 100000),
+		HarnessAddr(&signer, 100000),
+	)
+
+	ret, _ := h.CreateActor(t, creator, actors.MultisigActorCodeCid,
+		&actors.MultiSigConstructorParams{
+			Signers:  []address.Address{creator, signer},
+			Required: 2,
+		})
+	ApplyOK(t, ret)
+	assert.Equal(t, types.NewInt(1846), ret.GasUsed, "multisig constructor")
+
+	msig, err := address.NewFromBytes(ret.Return)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ret, _ = h.SendFunds(t, creator, msig, types.NewInt(1000))
+	ApplyOK(t, ret)
+
+	ret, _ = h.Invoke(t, creator, msig, actors.MultiSigMethods.Propose,
+		&actors.MultiSigProposeParams{To: to, Value: types.NewInt(100)})
+	ApplyOK(t, ret)
+	assert.Equal(t, types.NewInt(1075), ret.GasUsed, "Propose")
+
+	ret, _ = h.Invoke(t, signer, msig, actors.MultiSigMethods.Approve,
+		&actors.MultiSigTxID{TxID: 0})
+	ApplyOK(t, ret)
+	assert.Equal(t, types.NewInt(1427), ret.GasUsed, "Approve")
+}
+
+func TestGasPaychMethods(t *testing.T) {
+	var from address.Address
+	to := blsaddr(2)
+	h := NewHarness(t,
+		harnessKey(&from, 1, 100000),
+		HarnessAddr(&to, 100000),
+	)
+
+	ret, _ := h.CreateActor(t, from, actors.PaymentChannelActorCodeCid,
+		&actors.PCAConstructorParams{To: to})
+	ApplyOK(t, ret)
+	assert.Equal(t, types.NewInt(1401), ret.GasUsed, "paych constructor")
+
+	pch, err := address.NewFromBytes(ret.Return)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ret, _ = h.SendFunds(t, from, pch, types.NewInt(1000))
+	ApplyOK(t, ret)
+
+	sv := &types.SignedVoucher{
+		Lane:   1,
+		Nonce:  1,
+		Amount: types.NewInt(100),
+	}
+	vb, err := sv.SigningBytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	sv.Signature, err = h.w.Sign(context.TODO(), from, vb)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ret, _ = h.Invoke(t, to, pch, actors.PCAMethods.UpdateChannelState,
+		&actors.PCAUpdateChannelStateParams{Sv: *sv})
+	ApplyOK(t, ret)
+	assert.Equal(t, types.NewInt(704), ret.GasUsed, "UpdateChannelState")
+
+	ret, _ = h.Invoke(t, to, pch, actors.PCAMethods.Close, nil)
+	ApplyOK(t, ret)
+	assert.Equal(t, types.NewInt(490), ret.GasUsed, "Close")
+}
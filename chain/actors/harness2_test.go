@@ -120,7 +120,7 @@ func HarnessCtx(ctx context.Context) HarnessOpt {
 }
 
 func NewHarness(t *testing.T, options ...HarnessOpt) *Harness {
-	w, err := wallet.NewWallet(wallet.NewMemKeyStore())
+	w, err := wallet.NewWallet(wallet.NewMemKeyStore(), dstore.NewMapDatastore())
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -158,7 +158,7 @@ func NewHarness(t *testing.T, options ...HarnessOpt) *Harness {
 		t.Fatal(err)
 	}
 	h.cs = store.NewChainStore(h.bs, nil)
-	h.vm, err = vm.NewVM(stateroot, 1, nil, h.HI.Miner, h.cs.Blockstore())
+	h.vm, err = vm.NewVM(stateroot, 1, nil, h.HI.Miner, h.cs.Blockstore(), types.NetworkVersion0)
 	if err != nil {
 		t.Fatal(err)
 	}
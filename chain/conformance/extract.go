@@ -0,0 +1,313 @@
+package conformance
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	blocks "github.com/ipfs/go-block-format"
+	"github.com/ipfs/go-cid"
+	dstore "github.com/ipfs/go-datastore"
+	bstore "github.com/ipfs/go-ipfs-blockstore"
+	logging "github.com/ipfs/go-log"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-lotus/api"
+	"github.com/filecoin-project/go-lotus/chain/address"
+	"github.com/filecoin-project/go-lotus/chain/stmgr"
+	"github.com/filecoin-project/go-lotus/chain/store"
+	"github.com/filecoin-project/go-lotus/chain/types"
+	"github.com/filecoin-project/go-lotus/chain/vm"
+)
+
+var log = logging.Logger("conformance")
+
+// ExtractAPI is the part of the full node API needed to extract vectors
+type ExtractAPI interface {
+	ChainReadObj(context.Context, cid.Cid) ([]byte, error)
+	ChainGetTipSet(context.Context, []cid.Cid) (*types.TipSet, error)
+	ChainGetBlockMessages(context.Context, cid.Cid) (*api.BlockMessages, error)
+	ChainGetRandomness(context.Context, *types.TipSet, []*types.Ticket, int) ([]byte, error)
+
+	StateWaitMsg(context.Context, cid.Cid) (*api.MsgWait, error)
+	StateReplay(context.Context, *types.TipSet, cid.Cid) (*api.ReplayResults, error)
+}
+
+// ExtractMessage creates a message vector from a message on chain. The
+// pre-state is the state right before the message was applied in the tipset
+// which included it, and the vector only carries the state blocks accessed
+// while applying the message
+func ExtractMessage(ctx context.Context, a ExtractAPI, mcid cid.Cid) (*TestVector, error) {
+	wait, err := a.StateWaitMsg(ctx, mcid)
+	if err != nil {
+		return nil, xerrors.Errorf("looking up message: %w", err)
+	}
+
+	// messages are executed by the children of the tipset including them
+	incl, err := a.ChainGetTipSet(ctx, wait.TipSet.Parents())
+	if err != nil {
+		return nil, xerrors.Errorf("loading including tipset: %w", err)
+	}
+
+	parent, err := a.ChainGetTipSet(ctx, incl.Parents())
+	if err != nil {
+		return nil, xerrors.Errorf("loading parent tipset: %w", err)
+	}
+
+	upgrades := stmgr.DefaultUpgradeSchedule()
+	for _, u := range upgrades {
+		if u.Migration != nil && u.Height > parent.Height() && u.Height <= incl.Height() {
+			return nil, xerrors.Errorf("can't extract messages from tipset %s which runs the migration to network version %d", incl.Cids(), u.Network)
+		}
+	}
+
+	bms, target, miner, err := blockMessagesBefore(ctx, a, incl, mcid)
+	if err != nil {
+		return nil, err
+	}
+
+	expected, err := a.StateReplay(ctx, incl, mcid)
+	if err != nil {
+		return nil, xerrors.Errorf("replaying message: %w", err)
+	}
+
+	bs := newRecordingBlockstore(ctx, a)
+	rand := &recordingRand{api: a, ts: incl}
+	nv := upgrades.NetworkVersion(incl.Height())
+
+	// compute the state right before the message
+	vmi, err := vm.NewVM(incl.ParentState(), incl.Height(), rand, miner, bs, nv)
+	if err != nil {
+		return nil, xerrors.Errorf("creating VM: %w", err)
+	}
+
+	if _, err := stmgr.ApplyBlocks(ctx, vmi, bms, nil); err != nil {
+		return nil, xerrors.Errorf("applying preceding messages: %w", err)
+	}
+
+	pre, err := vmi.Flush(ctx)
+	if err != nil {
+		return nil, xerrors.Errorf("flushing pre-state: %w", err)
+	}
+
+	// only record what applying the message itself needs
+	bs.reset()
+	rand.reset()
+
+	vmi, err = vm.NewVM(pre, incl.Height(), rand, miner, bs, nv)
+	if err != nil {
+		return nil, xerrors.Errorf("creating VM: %w", err)
+	}
+
+	ret, err := vmi.ApplyMessage(ctx, target)
+	if err != nil {
+		return nil, xerrors.Errorf("applying message: %w", err)
+	}
+
+	if err := checkReceipt(expected.Receipt, &ret.MessageReceipt); err != nil {
+		return nil, xerrors.Errorf("extracted execution doesn't match the chain: %w", err)
+	}
+
+	post, err := vmi.Flush(ctx)
+	if err != nil {
+		return nil, xerrors.Errorf("flushing post-state: %w", err)
+	}
+
+	carb, err := EncodeCAR(pre, bs.recorded())
+	if err != nil {
+		return nil, err
+	}
+
+	mb, err := target.Serialize()
+	if err != nil {
+		return nil, xerrors.Errorf("serializing message: %w", err)
+	}
+
+	return &TestVector{
+		Class: ClassMessage,
+		Meta: &Metadata{
+			ID:     mcid.String(),
+			Source: fmt.Sprintf("message %s in tipset %s", mcid, incl.Cids()),
+		},
+		CAR: carb,
+		Pre: &Preconditions{
+			Epoch:          incl.Height(),
+			NetworkVersion: nv,
+			Miner:          miner,
+			StateTree:      pre,
+		},
+		ApplyMessages: []Message{{Bytes: mb}},
+		Randomness:    rand.recorded,
+		Post: &Postconditions{
+			StateTree: post,
+			Receipts:  []*types.MessageReceipt{&ret.MessageReceipt},
+		},
+	}, nil
+}
+
+// blockMessagesBefore returns the messages of the tipset applied before the
+// target message, together with the target and the miner of its block. All
+// blocks are returned so that their rewards are still awarded
+func blockMessagesBefore(ctx context.Context, a ExtractAPI, ts *types.TipSet, mcid cid.Cid) ([]stmgr.BlockMessages, *types.Message, address.Address, error) {
+	out := make([]stmgr.BlockMessages, len(ts.Blocks()))
+	var target *types.Message
+	var miner address.Address
+
+	for i, b := range ts.Blocks() {
+		out[i].Miner = b.Miner
+		if target != nil {
+			continue
+		}
+
+		msgs, err := a.ChainGetBlockMessages(ctx, b.Cid())
+		if err != nil {
+			return nil, nil, address.Undef, xerrors.Errorf("getting messages of block %s: %w", b.Cid(), err)
+		}
+
+		var cms []store.ChainMsg
+		for _, m := range msgs.BlsMessages {
+			cms = append(cms, m)
+		}
+		for _, m := range msgs.SecpkMessages {
+			cms = append(cms, m)
+		}
+
+		for _, cm := range cms {
+			if cm.Cid() == mcid {
+				target = cm.VMMessage()
+				miner = b.Miner
+				break
+			}
+			out[i].Messages = append(out[i].Messages, cm)
+		}
+	}
+
+	if target == nil {
+		return nil, nil, address.Undef, xerrors.Errorf("message %s not found in tipset %s", mcid, ts.Cids())
+	}
+
+	return out, target, miner, nil
+}
+
+// recordingBlockstore fetches missing blocks from the chain through the API
+// and keeps track of all blocks read
+type recordingBlockstore struct {
+	bstore.Blockstore
+
+	ctx context.Context
+	api ExtractAPI
+
+	lk   sync.Mutex
+	read map[cid.Cid]blocks.Block
+}
+
+func newRecordingBlockstore(ctx context.Context, a ExtractAPI) *recordingBlockstore {
+	return &recordingBlockstore{
+		Blockstore: bstore.NewBlockstore(dstore.NewMapDatastore()),
+
+		ctx:  ctx,
+		api:  a,
+		read: map[cid.Cid]blocks.Block{},
+	}
+}
+
+func (rb *recordingBlockstore) Get(c cid.Cid) (blocks.Block, error) {
+	b, err := rb.Blockstore.Get(c)
+	switch err {
+	case nil:
+	case bstore.ErrNotFound:
+		data, err := rb.api.ChainReadObj(rb.ctx, c)
+		if err != nil {
+			// the VM checks for blocks it is about to write, so this is
+			// expected for new state
+			log.Debugf("fetching %s from chain: %s", c, err)
+			return nil, bstore.ErrNotFound
+		}
+
+		b, err = blocks.NewBlockWithCid(data, c)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := rb.Blockstore.Put(b); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, err
+	}
+
+	rb.lk.Lock()
+	rb.read[c] = b
+	rb.lk.Unlock()
+
+	return b, nil
+}
+
+func (rb *recordingBlockstore) Has(c cid.Cid) (bool, error) {
+	_, err := rb.Get(c)
+	switch err {
+	case nil:
+		return true, nil
+	case bstore.ErrNotFound:
+		return false, nil
+	default:
+		return false, err
+	}
+}
+
+func (rb *recordingBlockstore) GetSize(c cid.Cid) (int, error) {
+	b, err := rb.Get(c)
+	if err != nil {
+		return 0, err
+	}
+	return len(b.RawData()), nil
+}
+
+func (rb *recordingBlockstore) reset() {
+	rb.lk.Lock()
+	defer rb.lk.Unlock()
+
+	rb.read = map[cid.Cid]blocks.Block{}
+}
+
+func (rb *recordingBlockstore) recorded() []blocks.Block {
+	rb.lk.Lock()
+	defer rb.lk.Unlock()
+
+	out := make([]blocks.Block, 0, len(rb.read))
+	for _, b := range rb.read {
+		out = append(out, b)
+	}
+
+	// keep the CAR deterministic
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].Cid().KeyString() < out[j].Cid().KeyString()
+	})
+	return out
+}
+
+// recordingRand gets chain randomness through the API and records it
+type recordingRand struct {
+	api ExtractAPI
+	ts  *types.TipSet
+
+	recorded []RandomnessMatch
+}
+
+func (rr *recordingRand) GetRandomness(ctx context.Context, h int64) ([]byte, error) {
+	// same lookback as the chain randomness used by the state manager
+	lb := int64(rr.ts.Height()) - h
+
+	r, err := rr.api.ChainGetRandomness(ctx, rr.ts, nil, int(lb))
+	if err != nil {
+		return nil, err
+	}
+
+	rr.recorded = append(rr.recorded, RandomnessMatch{Epoch: h, Value: r})
+	return r, nil
+}
+
+func (rr *recordingRand) reset() {
+	rr.recorded = nil
+}
@@ -0,0 +1,188 @@
+package conformance
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ipfs/go-cid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-lotus/chain/actors"
+	"github.com/filecoin-project/go-lotus/chain/address"
+	"github.com/filecoin-project/go-lotus/chain/gen"
+	"github.com/filecoin-project/go-lotus/chain/types"
+	"github.com/filecoin-project/go-lotus/node/impl/full"
+)
+
+// extractEnv makes TestExtractMessage write the extracted vectors to the
+// given directory. testdata/corpus is generated this way
+const extractEnv = "LOTUS_CONFORMANCE_EXTRACT"
+
+type extractAPI struct {
+	full.ChainAPI
+	full.StateAPI
+}
+
+type extractChain struct {
+	t   *testing.T
+	cg  *gen.ChainGen
+	api *extractAPI
+
+	nonces map[address.Address]uint64
+}
+
+func newExtractChain(t *testing.T) *extractChain {
+	cg, err := gen.NewGenerator()
+	require.NoError(t, err)
+
+	sm := cg.StateManager()
+	return &extractChain{
+		t:  t,
+		cg: cg,
+		api: &extractAPI{
+			ChainAPI: full.ChainAPI{
+				WalletAPI: full.WalletAPI{StateManager: sm, Wallet: cg.Wallet()},
+				Chain:     sm.ChainStore(),
+			},
+			StateAPI: full.StateAPI{
+				Wallet:       cg.Wallet(),
+				StateManager: sm,
+				Chain:        sm.ChainStore(),
+			},
+		},
+		nonces: map[address.Address]uint64{},
+	}
+}
+
+// next mines a tipset including msgs and makes it the chain head
+func (ec *extractChain) next(msgs ...*types.Message) []cid.Cid {
+	var smsgs []*types.SignedMessage
+	var cids []cid.Cid
+	for _, msg := range msgs {
+		if _, ok := ec.nonces[msg.From]; !ok {
+			act, err := ec.cg.StateManager().GetActor(msg.From, nil)
+			require.NoError(ec.t, err)
+			ec.nonces[msg.From] = act.Nonce
+		}
+		msg.Nonce = ec.nonces[msg.From]
+		ec.nonces[msg.From]++
+
+		if msg.Value == types.EmptyInt {
+			msg.Value = types.NewInt(0)
+		}
+		msg.GasPrice = types.NewInt(0)
+		msg.GasLimit = types.NewInt(1000000)
+
+		sig, err := ec.cg.Wallet().Sign(context.TODO(), msg.From, msg.Cid().Bytes())
+		require.NoError(ec.t, err)
+
+		smsg := &types.SignedMessage{Message: *msg, Signature: *sig}
+		smsgs = append(smsgs, smsg)
+		if sig.TypeCode() == types.IKTBLS {
+			cids = append(cids, msg.Cid())
+		} else {
+			cids = append(cids, smsg.Cid())
+		}
+	}
+
+	mts, err := ec.cg.NextTipSetWithMessages(smsgs)
+	require.NoError(ec.t, err)
+	require.NoError(ec.t, ec.cg.StateManager().ChainStore().PutTipSet(context.TODO(), mts.TipSet.TipSet()))
+
+	return cids
+}
+
+func TestExtractMessage(t *testing.T) {
+	ctx := context.Background()
+	ec := newExtractChain(t)
+
+	banker := ec.cg.Banker()
+	receiver, err := ec.cg.Wallet().GenerateKey(types.KTSecp256k1)
+	require.NoError(t, err)
+
+	maddr := ec.cg.Miners[0]
+	worker, err := ec.api.StateMinerWorker(ctx, maddr, nil)
+	require.NoError(t, err)
+
+	msigParams, aerr := actors.SerializeParams(&actors.MultiSigConstructorParams{
+		Signers:        []address.Address{banker},
+		Required:       1,
+		InitialBalance: types.NewInt(0),
+	})
+	require.NoError(t, aerr)
+	createParams, aerr := actors.SerializeParams(&actors.ExecParams{
+		Code:   actors.MultisigActorCodeCid,
+		Params: msigParams,
+	})
+	require.NoError(t, aerr)
+
+	depledgeParams, aerr := actors.SerializeParams(&actors.DePledgeParams{Amount: types.NewInt(1)})
+	require.NoError(t, aerr)
+
+	first := ec.next(
+		&types.Message{From: banker, To: receiver, Value: types.NewInt(1000)},
+		&types.Message{From: banker, To: receiver, Method: 42},
+		&types.Message{From: banker, To: actors.InitActorAddress, Method: actors.IAMethods.Exec, Value: types.NewInt(500), Params: createParams},
+		&types.Message{From: worker, To: maddr, Method: actors.MAMethods.DePledge, Params: depledgeParams},
+	)
+	ec.next()
+
+	created, err := ec.api.StateWaitMsg(ctx, first[2])
+	require.NoError(t, err)
+	require.Equal(t, uint8(0), created.Receipt.ExitCode)
+	msig, err := address.NewFromBytes(created.Receipt.Return)
+	require.NoError(t, err)
+
+	proposeParams, aerr := actors.SerializeParams(&actors.MultiSigProposeParams{
+		To:    receiver,
+		Value: types.NewInt(100),
+	})
+	require.NoError(t, aerr)
+
+	second := ec.next(
+		&types.Message{From: banker, To: msig, Method: actors.MultiSigMethods.Propose, Params: proposeParams},
+	)
+	ec.next()
+
+	cases := []struct {
+		name   string
+		desc   string
+		mcid   cid.Cid
+		failed bool
+	}{
+		{"send", "a plain value transfer to a new account", first[0], false},
+		{"unknown-method", "a call to a method the account actor doesn't have", first[1], true},
+		{"multisig-create", "creating a multisig through the init actor", first[2], false},
+		{"miner-depledge", "a depledge scheduled by the miner worker", first[3], false},
+		{"multisig-propose", "a multisig proposal executed right away with one required signer", second[0], false},
+	}
+
+	out := os.Getenv(extractEnv)
+
+	for _, c := range cases {
+		c := c
+		t.Run(c.name, func(t *testing.T) {
+			v, err := ExtractMessage(ctx, ec.api, c.mcid)
+			require.NoError(t, err)
+			v.Meta.Description = c.desc
+
+			wait, err := ec.api.StateWaitMsg(ctx, c.mcid)
+			require.NoError(t, err)
+			assert.Equal(t, c.failed, wait.Receipt.ExitCode != 0)
+
+			// the vector only carries the state the message accessed, so it
+			// has to execute on its own
+			res, err := Execute(ctx, v)
+			require.NoError(t, err)
+			require.NoError(t, v.Check(res))
+			assert.NoError(t, checkReceipt(&wait.Receipt, res.Receipts[0]), "the vector should reproduce the chain receipt")
+
+			if out != "" {
+				require.NoError(t, v.Save(filepath.Join(out, c.name+".json")))
+			}
+		})
+	}
+}
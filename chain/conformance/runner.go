@@ -0,0 +1,154 @@
+package conformance
+
+import (
+	"bytes"
+	"context"
+
+	"github.com/ipfs/go-cid"
+	dstore "github.com/ipfs/go-datastore"
+	bstore "github.com/ipfs/go-ipfs-blockstore"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-lotus/chain/address"
+	"github.com/filecoin-project/go-lotus/chain/stmgr"
+	"github.com/filecoin-project/go-lotus/chain/types"
+	"github.com/filecoin-project/go-lotus/chain/vm"
+)
+
+// Result is the outcome of executing a test vector
+type Result struct {
+	StateTree cid.Cid
+	Receipts  []*types.MessageReceipt
+}
+
+// Execute applies the messages or tipsets of the vector to its pre-state
+// using a fresh in-memory blockstore
+func Execute(ctx context.Context, v *TestVector) (*Result, error) {
+	if v.Pre == nil {
+		return nil, xerrors.New("vector has no preconditions")
+	}
+
+	bs := bstore.NewBlockstore(dstore.NewMapDatastore())
+	if err := v.LoadCAR(bs); err != nil {
+		return nil, err
+	}
+
+	switch v.Class {
+	case ClassMessage:
+		return executeMessages(ctx, bs, v)
+	case ClassTipset:
+		return executeTipsets(ctx, bs, v)
+	default:
+		return nil, xerrors.Errorf("unknown vector class %q", v.Class)
+	}
+}
+
+func executeMessages(ctx context.Context, bs bstore.Blockstore, v *TestVector) (*Result, error) {
+	vmi, err := vm.NewVM(v.Pre.StateTree, v.Pre.Epoch, replayRand(v.Randomness), v.Pre.Miner, bs, v.Pre.NetworkVersion)
+	if err != nil {
+		return nil, xerrors.Errorf("creating VM: %w", err)
+	}
+
+	out := &Result{}
+	for i, am := range v.ApplyMessages {
+		m, err := types.DecodeMessage(am.Bytes)
+		if err != nil {
+			return nil, xerrors.Errorf("decoding message %d: %w", i, err)
+		}
+
+		if am.Epoch != nil {
+			vmi.SetBlockHeight(*am.Epoch)
+		}
+
+		ret, err := vmi.ApplyMessage(ctx, m)
+		if err != nil {
+			return nil, xerrors.Errorf("applying message %d: %w", i, err)
+		}
+
+		out.Receipts = append(out.Receipts, &ret.MessageReceipt)
+	}
+
+	out.StateTree, err = vmi.Flush(ctx)
+	if err != nil {
+		return nil, xerrors.Errorf("flushing VM: %w", err)
+	}
+
+	return out, nil
+}
+
+func executeTipsets(ctx context.Context, bs bstore.Blockstore, v *TestVector) (*Result, error) {
+	out := &Result{StateTree: v.Pre.StateTree}
+
+	for i, ts := range v.ApplyTipsets {
+		vmi, err := vm.NewVM(out.StateTree, ts.Epoch, replayRand(v.Randomness), address.Undef, bs, v.Pre.NetworkVersion)
+		if err != nil {
+			return nil, xerrors.Errorf("creating VM for tipset %d: %w", i, err)
+		}
+
+		bms := make([]stmgr.BlockMessages, len(ts.Blocks))
+		for j, b := range ts.Blocks {
+			bms[j].Miner = b.Miner
+			for k, mb := range b.Messages {
+				m, err := types.DecodeMessage(mb)
+				if err != nil {
+					return nil, xerrors.Errorf("decoding message %d of block %d in tipset %d: %w", k, j, i, err)
+				}
+				bms[j].Messages = append(bms[j].Messages, m)
+			}
+		}
+
+		rets, err := stmgr.ApplyBlocks(ctx, vmi, bms, nil)
+		if err != nil {
+			return nil, xerrors.Errorf("applying tipset %d: %w", i, err)
+		}
+		for _, r := range rets {
+			out.Receipts = append(out.Receipts, &r.MessageReceipt)
+		}
+
+		out.StateTree, err = vmi.Flush(ctx)
+		if err != nil {
+			return nil, xerrors.Errorf("flushing VM for tipset %d: %w", i, err)
+		}
+	}
+
+	return out, nil
+}
+
+// Check compares the result of executing the vector with its postconditions
+func (v *TestVector) Check(res *Result) error {
+	if v.Post == nil {
+		return xerrors.New("vector has no postconditions")
+	}
+
+	if len(res.Receipts) != len(v.Post.Receipts) {
+		return xerrors.Errorf("expected %d receipts, got %d", len(v.Post.Receipts), len(res.Receipts))
+	}
+
+	for i, expected := range v.Post.Receipts {
+		if err := checkReceipt(expected, res.Receipts[i]); err != nil {
+			return xerrors.Errorf("receipt %d: %w", i, err)
+		}
+	}
+
+	if res.StateTree != v.Post.StateTree {
+		return xerrors.Errorf("expected state root %s, got %s", v.Post.StateTree, res.StateTree)
+	}
+
+	return nil
+}
+
+func checkReceipt(expected, actual *types.MessageReceipt) error {
+	if expected.ExitCode != actual.ExitCode {
+		return xerrors.Errorf("expected exit code %d, got %d", expected.ExitCode, actual.ExitCode)
+	}
+
+	if !bytes.Equal(expected.Return, actual.Return) {
+		return xerrors.Errorf("expected return value %x, got %x", expected.Return, actual.Return)
+	}
+
+	if types.BigCmp(expected.GasUsed, actual.GasUsed) != 0 {
+		return xerrors.Errorf("expected %s gas used, got %s", expected.GasUsed, actual.GasUsed)
+	}
+
+	return nil
+}
@@ -0,0 +1,205 @@
+package conformance
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	blocks "github.com/ipfs/go-block-format"
+	dstore "github.com/ipfs/go-datastore"
+	bstore "github.com/ipfs/go-ipfs-blockstore"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/filecoin-project/go-lotus/chain/address"
+	"github.com/filecoin-project/go-lotus/chain/gen"
+	"github.com/filecoin-project/go-lotus/chain/types"
+	"github.com/filecoin-project/go-lotus/chain/vm"
+	"github.com/filecoin-project/go-lotus/chain/wallet"
+)
+
+// corpusEnv can point the corpus test to a directory of vectors other than
+// testdata/corpus
+const corpusEnv = "LOTUS_CONFORMANCE_CORPUS"
+
+func TestConformanceCorpus(t *testing.T) {
+	dir := os.Getenv(corpusEnv)
+	if dir == "" {
+		dir = filepath.Join("testdata", "corpus")
+	}
+
+	var files []string
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() && strings.HasSuffix(path, ".json") {
+			files = append(files, path)
+		}
+		return nil
+	})
+	if os.IsNotExist(err) {
+		t.Skipf("no vector corpus in %s", dir)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, f := range files {
+		f := f
+		t.Run(f, func(t *testing.T) {
+			v, err := LoadVector(f)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			res, err := Execute(context.TODO(), v)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if err := v.Check(res); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+// genesisVector returns a message vector without messages on top of a fresh
+// genesis state, together with its funded account and the blockstore the
+// state was created in
+func genesisVector(t *testing.T) (*TestVector, address.Address, bstore.Blockstore) {
+	w, err := wallet.NewWallet(wallet.NewMemKeyStore(), dstore.NewMapDatastore())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	from, err := w.GenerateKey(types.KTSecp256k1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	bs := bstore.NewBlockstore(dstore.NewMapDatastore())
+	st, err := gen.MakeInitialStateTree(bs, map[address.Address]types.BigInt{
+		from: types.NewInt(100000),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	root, err := st.Flush()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	keys, err := bs.AllKeysChan(context.TODO())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var blks []blocks.Block
+	for k := range keys {
+		b, err := bs.Get(k)
+		if err != nil {
+			t.Fatal(err)
+		}
+		blks = append(blks, b)
+	}
+
+	carb, err := EncodeCAR(root, blks)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &TestVector{
+		Class: ClassMessage,
+		CAR:   carb,
+		Pre: &Preconditions{
+			Epoch:     1,
+			Miner:     from,
+			StateTree: root,
+		},
+	}, from, bs
+}
+
+func TestMessageVectorRoundTrip(t *testing.T) {
+	v, from, bs := genesisVector(t)
+
+	to, err := address.NewIDAddress(99)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// a plain send, and a call to a method the account actor doesn't have
+	for i, method := range []uint64{0, 42} {
+		mb, err := (&types.Message{
+			From:     from,
+			To:       to,
+			Nonce:    uint64(i),
+			Method:   method,
+			Value:    types.NewInt(100),
+			GasPrice: types.NewInt(1),
+			GasLimit: types.NewInt(10000),
+		}).Serialize()
+		if err != nil {
+			t.Fatal(err)
+		}
+		v.ApplyMessages = append(v.ApplyMessages, Message{Bytes: mb})
+	}
+
+	// the expected results come from applying the messages to the original
+	// state, not from executing the vector
+	vmi, err := vm.NewVM(v.Pre.StateTree, v.Pre.Epoch, nil, v.Pre.Miner, bs, v.Pre.NetworkVersion)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	v.Post = &Postconditions{}
+	for _, am := range v.ApplyMessages {
+		m, err := types.DecodeMessage(am.Bytes)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		ret, err := vmi.ApplyMessage(context.TODO(), m)
+		if err != nil {
+			t.Fatal(err)
+		}
+		v.Post.Receipts = append(v.Post.Receipts, &ret.MessageReceipt)
+	}
+
+	v.Post.StateTree, err = vmi.Flush(context.TODO())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, uint8(0), v.Post.Receipts[0].ExitCode)
+	assert.NotEqual(t, uint8(0), v.Post.Receipts[1].ExitCode, "calling an unknown method should fail")
+
+	dir, err := ioutil.TempDir("", "conformance")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "vector.json")
+	if err := v.Save(path); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := LoadVector(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := Execute(context.TODO(), loaded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.NoError(t, loaded.Check(res))
+
+	loaded.Post.Receipts[0].ExitCode = 1
+	assert.Error(t, loaded.Check(res), "changed receipts should be detected")
+}
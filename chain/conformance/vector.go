@@ -0,0 +1,172 @@
+package conformance
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+
+	blocks "github.com/ipfs/go-block-format"
+	"github.com/ipfs/go-car"
+	"github.com/ipfs/go-car/util"
+	"github.com/ipfs/go-cid"
+	bstore "github.com/ipfs/go-ipfs-blockstore"
+	cbor "github.com/ipfs/go-ipld-cbor"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-lotus/chain/address"
+	"github.com/filecoin-project/go-lotus/chain/types"
+)
+
+// Classes of test vectors
+const (
+	// ClassMessage vectors apply messages directly to the pre-state
+	ClassMessage = "message"
+
+	// ClassTipset vectors apply tipsets to the pre-state, including block
+	// rewards and skipping of invalid messages
+	ClassTipset = "tipset"
+)
+
+// TestVector bundles a pre-state, the messages or tipsets to apply on top of
+// it and the expected results
+type TestVector struct {
+	Class string
+	Meta  *Metadata `json:",omitempty"`
+
+	// CAR holds all the state blocks accessed while applying the vector
+	CAR []byte
+
+	Pre *Preconditions
+
+	ApplyMessages []Message `json:",omitempty"`
+	ApplyTipsets  []Tipset  `json:",omitempty"`
+
+	// Randomness lists the randomness requested by actors while the vector
+	// was recorded, it is replayed when the vector is executed
+	Randomness []RandomnessMatch `json:",omitempty"`
+
+	Post *Postconditions
+}
+
+type Metadata struct {
+	ID          string
+	Description string `json:",omitempty"`
+
+	// Source describes where the vector was extracted from
+	Source string `json:",omitempty"`
+}
+
+type Preconditions struct {
+	Epoch          uint64
+	NetworkVersion types.NetworkVersion
+
+	// Miner is the block miner messages are applied with, only used by
+	// message vectors
+	Miner address.Address
+
+	StateTree cid.Cid
+}
+
+type Message struct {
+	// Bytes is the serialized unsigned message
+	Bytes []byte
+
+	// Epoch overrides the pre-state epoch when set
+	Epoch *uint64 `json:",omitempty"`
+}
+
+type Block struct {
+	Miner address.Address
+
+	// Messages are the serialized unsigned messages of the block
+	Messages [][]byte
+}
+
+type Tipset struct {
+	Epoch  uint64
+	Blocks []Block
+}
+
+type RandomnessMatch struct {
+	Epoch int64
+	Value []byte
+}
+
+type Postconditions struct {
+	StateTree cid.Cid
+	Receipts  []*types.MessageReceipt
+}
+
+// LoadVector reads a JSON encoded test vector
+func LoadVector(path string) (*TestVector, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var v TestVector
+	if err := json.Unmarshal(b, &v); err != nil {
+		return nil, xerrors.Errorf("decoding vector %s: %w", path, err)
+	}
+
+	return &v, nil
+}
+
+// Save writes the vector to path as indented JSON
+func (v *TestVector) Save(path string) error {
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(path, b, 0644)
+}
+
+// LoadCAR imports the blocks of the vector into bs
+func (v *TestVector) LoadCAR(bs bstore.Blockstore) error {
+	if _, err := car.LoadCar(bs, bytes.NewReader(v.CAR)); err != nil {
+		return xerrors.Errorf("loading vector CAR: %w", err)
+	}
+	return nil
+}
+
+// EncodeCAR serializes the given blocks as a CAR file with a single root.
+// Unlike car.WriteCar it doesn't walk the DAG, so the blocks don't need to
+// form a complete graph
+func EncodeCAR(root cid.Cid, blks []blocks.Block) ([]byte, error) {
+	buf := new(bytes.Buffer)
+
+	h := &car.CarHeader{
+		Roots:   []cid.Cid{root},
+		Version: 1,
+	}
+	hb, err := cbor.DumpObject(h)
+	if err != nil {
+		return nil, xerrors.Errorf("serializing CAR header: %w", err)
+	}
+	if err := util.LdWrite(buf, hb); err != nil {
+		return nil, xerrors.Errorf("writing CAR header: %w", err)
+	}
+
+	for _, b := range blks {
+		if err := util.LdWrite(buf, b.Cid().Bytes(), b.RawData()); err != nil {
+			return nil, xerrors.Errorf("writing block %s: %w", b.Cid(), err)
+		}
+	}
+
+	return buf.Bytes(), nil
+}
+
+// replayRand returns the randomness recorded in the vector
+type replayRand []RandomnessMatch
+
+func (r replayRand) GetRandomness(ctx context.Context, h int64) ([]byte, error) {
+	for _, m := range r {
+		if m.Epoch == h {
+			return m.Value, nil
+		}
+	}
+
+	return nil, xerrors.Errorf("no randomness recorded for epoch %d", h)
+}
@@ -25,12 +25,13 @@ func (h *Handler) SetPrice(p types.BigInt, ttlsecs int64) error {
 
 	now := time.Now().Unix()
 	ask := &types.StorageAsk{
-		Price:        p,
-		Timestamp:    now,
-		Expiry:       now + ttlsecs,
-		Miner:        h.actor,
-		SeqNo:        seqno,
-		MinPieceSize: h.minPieceSize,
+		Price:             p,
+		Timestamp:         now,
+		Expiry:            now + ttlsecs,
+		Miner:             h.actor,
+		SeqNo:             seqno,
+		MinPieceSize:      h.minPieceSize,
+		CollateralPerByte: h.collateralPerByte,
 	}
 
 	ssa, err := h.signAsk(ask)
@@ -3,6 +3,8 @@ package deals
 import (
 	"context"
 	"math"
+	"sync"
+	"time"
 
 	"github.com/ipfs/go-cid"
 	"github.com/ipfs/go-datastore"
@@ -34,10 +36,20 @@ func init() {
 	cbor.RegisterCborType(actors.PaymentInfo{})
 	cbor.RegisterCborType(api.PaymentInfo{})
 	cbor.RegisterCborType(actors.InclusionProof{})
+	cbor.RegisterCborType(actors.StorageDealProposal{})
 }
 
 var log = logging.Logger("deals")
 
+const (
+	// dealStatusPollInterval is how often the miner is asked for the status of
+	// a deal whose connection was lost
+	dealStatusPollInterval = time.Minute
+	// dealRecoveryTimeout is how long the miner may stay unreachable before
+	// the deal is failed
+	dealRecoveryTimeout = 24 * time.Hour
+)
+
 type ClientDeal struct {
 	ProposalCid cid.Cid
 	Proposal    StorageDealProposal
@@ -54,8 +66,9 @@ type Client struct {
 	dag       dtypes.ClientDAG
 	discovery *discovery.Local
 
-	deals ClientStateStore
-	conns map[cid.Cid]inet.Stream
+	deals   ClientStateStore
+	conns   map[cid.Cid]inet.Stream
+	connsLk sync.Mutex
 
 	incoming chan ClientDeal
 	updated  chan clientDealUpdate
@@ -106,16 +119,50 @@ func (c *Client) Run(ctx context.Context) {
 			}
 		}
 	}()
+
+	go c.restartDeals()
+}
+
+// restartDeals continues the deals which were in progress when the client
+// stopped. Their miner connections are gone, responses are queried with the
+// deal status protocol instead
+func (c *Client) restartDeals() {
+	deals, err := c.deals.ListClient()
+	if err != nil {
+		log.Errorf("listing deals to restart: %s", err)
+		return
+	}
+
+	for _, deal := range deals {
+		switch deal.State {
+		case api.DealUnknown, api.DealAccepted, api.DealStaged, api.DealSealing:
+		default:
+			continue
+		}
+
+		log.Infof("restarting deal %s in state %d", deal.ProposalCid, deal.State)
+		select {
+		case c.updated <- clientDealUpdate{
+			newState: deal.State,
+			id:       deal.ProposalCid,
+		}:
+		case <-c.stop:
+			return
+		}
+	}
 }
 
 func (c *Client) onIncoming(deal ClientDeal) {
 	log.Info("incoming deal")
 
+	c.connsLk.Lock()
 	if _, ok := c.conns[deal.ProposalCid]; ok {
+		c.connsLk.Unlock()
 		log.Errorf("tracking deal connection: already tracking connection for deal %s", deal.ProposalCid)
 		return
 	}
 	c.conns[deal.ProposalCid] = deal.s
+	c.connsLk.Unlock()
 
 	if err := c.deals.Begin(deal.ProposalCid, deal); err != nil {
 		// We may have re-sent the proposal
@@ -125,10 +172,13 @@ func (c *Client) onIncoming(deal ClientDeal) {
 	}
 
 	go func() {
-		c.updated <- clientDealUpdate{
+		select {
+		case c.updated <- clientDealUpdate{
 			newState: api.DealUnknown,
 			id:       deal.ProposalCid,
 			err:      nil,
+		}:
+		case <-c.stop:
 		}
 	}()
 }
@@ -189,6 +239,11 @@ func (c *Client) VerifyParams(ctx context.Context, data cid.Cid) (*actors.PieceI
 }
 
 func (c *Client) Start(ctx context.Context, p ClientDealProposal, vd *actors.PieceInclVoucherData) (cid.Cid, error) {
+	marketDeal, err := c.marketDeal(ctx, p, vd)
+	if err != nil {
+		return cid.Undef, xerrors.Errorf("creating market deal proposal: %w", err)
+	}
+
 	proposal := StorageDealProposal{
 		PieceRef:          p.Data,
 		SerializationMode: SerializationUnixFs,
@@ -199,6 +254,7 @@ func (c *Client) Start(ctx context.Context, p ClientDealProposal, vd *actors.Pie
 		Payment:           p.Payment,
 		MinerAddress:      p.MinerAddress,
 		ClientAddress:     p.ClientAddress,
+		MarketDeal:        marketDeal,
 	}
 
 	s, err := c.h.NewStream(ctx, p.MinerID, ProtocolID)
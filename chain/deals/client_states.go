@@ -15,7 +15,13 @@ type clientHandlerFunc func(ctx context.Context, deal ClientDeal) error
 func (c *Client) handle(ctx context.Context, deal ClientDeal, cb clientHandlerFunc, next api.DealState) {
 	go func() {
 		err := cb(ctx, deal)
-		if err != nil {
+		switch {
+		case err == nil:
+		case xerrors.Is(err, ErrDealExpired):
+			next = api.DealExpired
+		case xerrors.Is(err, ErrDealUnreachable):
+			next = api.DealFailed
+		default:
 			next = api.DealError
 		}
 		select {
@@ -30,7 +36,7 @@ func (c *Client) handle(ctx context.Context, deal ClientDeal, cb clientHandlerFu
 }
 
 func (c *Client) new(ctx context.Context, deal ClientDeal) error {
-	resp, err := c.readStorageDealResp(deal)
+	resp, err := c.readStorageDealResp(ctx, deal, api.DealAccepted)
 	if err != nil {
 		return err
 	}
@@ -47,7 +53,7 @@ func (c *Client) new(ctx context.Context, deal ClientDeal) error {
 func (c *Client) accepted(ctx context.Context, deal ClientDeal) error {
 	/* data transfer happens */
 
-	resp, err := c.readStorageDealResp(deal)
+	resp, err := c.readStorageDealResp(ctx, deal, api.DealStaged)
 	if err != nil {
 		return err
 	}
@@ -64,7 +70,7 @@ func (c *Client) accepted(ctx context.Context, deal ClientDeal) error {
 func (c *Client) staged(ctx context.Context, deal ClientDeal) error {
 	/* miner seals our data, hopefully */
 
-	resp, err := c.readStorageDealResp(deal)
+	resp, err := c.readStorageDealResp(ctx, deal, api.DealSealing)
 	if err != nil {
 		return err
 	}
@@ -87,7 +93,7 @@ func (c *Client) staged(ctx context.Context, deal ClientDeal) error {
 }
 
 func (c *Client) sealing(ctx context.Context, deal ClientDeal) error {
-	resp, err := c.readStorageDealResp(deal)
+	resp, err := c.readStorageDealResp(ctx, deal, api.DealComplete)
 	if err != nil {
 		return err
 	}
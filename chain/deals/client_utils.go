@@ -4,6 +4,7 @@ import (
 	"context"
 	"github.com/filecoin-project/go-lotus/lib/sectorbuilder"
 	"runtime"
+	"time"
 
 	"github.com/ipfs/go-cid"
 	files "github.com/ipfs/go-ipfs-files"
@@ -12,7 +13,11 @@ import (
 	inet "github.com/libp2p/go-libp2p-core/network"
 	"golang.org/x/xerrors"
 
+	"github.com/filecoin-project/go-lotus/api"
+	"github.com/filecoin-project/go-lotus/build"
+	"github.com/filecoin-project/go-lotus/chain/actors"
 	"github.com/filecoin-project/go-lotus/chain/address"
+	"github.com/filecoin-project/go-lotus/chain/types"
 	"github.com/filecoin-project/go-lotus/lib/cborrpc"
 )
 
@@ -22,11 +27,13 @@ func (c *Client) failDeal(id cid.Cid, cerr error) {
 		cerr = xerrors.Errorf("unknown error (fail called at %s:%d)", f, l)
 	}
 
+	c.connsLk.Lock()
 	s, ok := c.conns[id]
 	if ok {
 		_ = s.Reset()
 		delete(c.conns, id)
 	}
+	c.connsLk.Unlock()
 
 	// TODO: store in some sort of audit log
 	log.Errorf("deal %s failed: %s", id, cerr)
@@ -84,18 +91,79 @@ func (c *Client) sendProposal(s inet.Stream, proposal StorageDealProposal, from
 	return cborrpc.WriteCborRPC(s, signedProposal)
 }
 
-func (c *Client) readStorageDealResp(deal ClientDeal) (*StorageDealResponse, error) {
-	s, ok := c.conns[deal.ProposalCid]
-	if !ok {
-		// TODO: Try to re-establish the connection using query protocol
-		return nil, xerrors.Errorf("no connection to miner")
+// marketDeal creates the proposal the provider will publish to the storage
+// market actor, signed by the client. The provider collateral is taken from
+// its ask
+func (c *Client) marketDeal(ctx context.Context, p ClientDealProposal, vd *actors.PieceInclVoucherData) (*actors.StorageDealProposal, error) {
+	if p.Duration == 0 {
+		return nil, xerrors.New("deal duration must be greater than 0")
+	}
+
+	ask, err := c.QueryAsk(ctx, p.MinerID, p.MinerAddress)
+	if err != nil {
+		return nil, xerrors.Errorf("querying miner ask: %w", err)
+	}
+	if ask.Ask.CollateralPerByte.Nil() {
+		return nil, xerrors.Errorf("ask of miner %s doesn't state the deal collateral", p.MinerAddress)
+	}
+
+	head := c.sm.ChainStore().GetHeaviestTipSet()
+
+	md := &actors.StorageDealProposal{
+		PieceRef:             vd.CommP,
+		PieceSize:            vd.PieceSize.Uint64(),
+		Client:               p.ClientAddress,
+		Provider:             p.MinerAddress,
+		ProposalExpiration:   head.Height() + build.DealProposalExpiration,
+		Duration:             p.Duration,
+		StoragePricePerEpoch: types.BigDiv(p.TotalPrice, types.NewInt(p.Duration)),
+		StorageCollateral:    types.BigMul(ask.Ask.CollateralPerByte, vd.PieceSize),
+	}
+
+	sb, err := md.SigningBytes()
+	if err != nil {
+		return nil, err
 	}
 
-	var resp SignedStorageDealResponse
-	if err := cborrpc.ReadCborRPC(s, &resp); err != nil {
-		log.Errorw("failed to read StorageDealResponse message", "error", err)
+	sig, err := c.w.Sign(ctx, p.ClientAddress, sb)
+	if err != nil {
 		return nil, err
 	}
+	md.ProposerSignature = sig
+
+	return md, nil
+}
+
+// readStorageDealResp reads the response the miner sent for the deal in the
+// given state. When the deal connection is gone, e.g. after either side was
+// restarted, the response is queried with the deal status protocol instead
+func (c *Client) readStorageDealResp(ctx context.Context, deal ClientDeal, state api.DealState) (*StorageDealResponse, error) {
+	c.connsLk.Lock()
+	s, ok := c.conns[deal.ProposalCid]
+	c.connsLk.Unlock()
+
+	var resp *SignedStorageDealResponse
+	if ok {
+		var r SignedStorageDealResponse
+		if err := cborrpc.ReadCborRPC(s, &r); err != nil {
+			log.Warnw("failed to read StorageDealResponse message, querying deal status", "deal", deal.ProposalCid, "error", err)
+
+			c.connsLk.Lock()
+			_ = s.Reset()
+			delete(c.conns, deal.ProposalCid)
+			c.connsLk.Unlock()
+		} else {
+			resp = &r
+		}
+	}
+
+	if resp == nil {
+		var err error
+		resp, err = c.waitDealStatus(ctx, deal, state)
+		if err != nil {
+			return nil, err
+		}
+	}
 
 	// TODO: verify signature
 
@@ -105,3 +173,70 @@ func (c *Client) readStorageDealResp(deal ClientDeal) (*StorageDealResponse, err
 
 	return &resp.Response, nil
 }
+
+// waitDealStatus polls the miner until it sent the response for the deal in
+// the given state. The deal fails if its proposal expires before the miner
+// accepts it, or if the miner stays unreachable for dealRecoveryTimeout
+func (c *Client) waitDealStatus(ctx context.Context, deal ClientDeal, state api.DealState) (*SignedStorageDealResponse, error) {
+	lastSeen := time.Now()
+
+	for {
+		status, err := c.queryDealStatus(ctx, deal, state)
+		if err != nil {
+			if time.Since(lastSeen) > dealRecoveryTimeout {
+				return nil, xerrors.Errorf("querying status of deal %s: %s: %w", deal.ProposalCid, err, ErrDealUnreachable)
+			}
+			log.Warnf("querying status of deal %s: %s", deal.ProposalCid, err)
+		} else {
+			lastSeen = time.Now()
+
+			if status.Response != nil {
+				return status.Response, nil
+			}
+
+			// the miner tracks deals from the moment it receives the proposal,
+			// so it can't have forgotten one it already responded to
+			if status.State == api.DealUnknown && deal.State != api.DealUnknown {
+				return nil, xerrors.Errorf("miner doesn't know deal %s", deal.ProposalCid)
+			}
+		}
+
+		if state == api.DealAccepted && deal.Proposal.MarketDeal != nil {
+			exp := deal.Proposal.MarketDeal.ProposalExpiration
+			if c.sm.ChainStore().GetHeaviestTipSet().Height() >= exp {
+				return nil, xerrors.Errorf("market deal proposal expired at height %d: %w", exp, ErrDealExpired)
+			}
+		}
+
+		select {
+		case <-time.After(dealStatusPollInterval):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-c.stop:
+			return nil, xerrors.New("deal client stopped")
+		}
+	}
+}
+
+func (c *Client) queryDealStatus(ctx context.Context, deal ClientDeal, state api.DealState) (*DealStatusResponse, error) {
+	s, err := c.h.NewStream(ctx, deal.Miner, DealStatusProtocolID)
+	if err != nil {
+		return nil, err
+	}
+	defer s.Close()
+
+	req := &DealStatusRequest{
+		Proposal: deal.ProposalCid,
+		State:    state,
+	}
+	if err := cborrpc.WriteCborRPC(s, req); err != nil {
+		return nil, xerrors.Errorf("failed to send deal status request: %w", err)
+	}
+
+	var out DealStatusResponse
+	if err := cborrpc.ReadCborRPC(s, &out); err != nil {
+		return nil, xerrors.Errorf("failed to read deal status response: %w", err)
+	}
+
+	return &out, nil
+}
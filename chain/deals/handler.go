@@ -16,6 +16,7 @@ import (
 	"github.com/filecoin-project/go-lotus/api"
 	"github.com/filecoin-project/go-lotus/chain/address"
 	"github.com/filecoin-project/go-lotus/chain/types"
+	"github.com/filecoin-project/go-lotus/lib/cborrpc"
 	"github.com/filecoin-project/go-lotus/node/modules/dtypes"
 	"github.com/filecoin-project/go-lotus/storage/commitment"
 	"github.com/filecoin-project/go-lotus/storage/sectorblocks"
@@ -35,11 +36,24 @@ type MinerDeal struct {
 
 	SectorID uint64 // Set when State >= DealStaged
 
+	// DealID is the ID assigned by the storage market actor, set when the
+	// proposal carries a MarketDeal
+	DealID uint64
+
+	// Progress of the steps which must not be repeated when the deal is
+	// resumed after a restart, recorded as soon as each step is done
+	PublishMessage  *cid.Cid
+	VouchersAdded   bool
+	PieceAdded      bool
+	ActivateMessage *cid.Cid
+	CompleteSent    bool
+
 	s inet.Stream
 }
 
 type Handler struct {
 	pricePerByteBlock types.BigInt // how much we want for storing one byte for one block
+	collateralPerByte types.BigInt // how much we lock in the market for one byte of a deal
 	minPieceSize      uint64
 
 	ask   *types.SignedStorageAsk
@@ -56,7 +70,13 @@ type Handler struct {
 	deals MinerStateStore
 	ds    dtypes.MetadataDS
 
-	conns map[cid.Cid]inet.Stream
+	// responses holds the signed responses sent for each deal state, clients
+	// which missed them can query them with the deal status protocol. They
+	// are kept until the client got the final response of the deal
+	responses datastore.Datastore
+
+	conns   map[cid.Cid]inet.Stream
+	connsLk sync.Mutex
 
 	actor address.Address
 
@@ -90,6 +110,7 @@ func NewHandler(ds dtypes.MetadataDS, secst *sectorblocks.SectorBlocks, commt *c
 		full:  fullNode,
 
 		pricePerByteBlock: types.NewInt(3), // TODO: allow setting
+		collateralPerByte: types.NewInt(0), // TODO: allow setting
 		minPieceSize:      1,
 
 		conns: map[cid.Cid]inet.Stream{},
@@ -101,8 +122,9 @@ func NewHandler(ds dtypes.MetadataDS, secst *sectorblocks.SectorBlocks, commt *c
 
 		actor: minerAddress,
 
-		deals: MinerStateStore{StateStore{ds: namespace.Wrap(ds, datastore.NewKey("/deals/client"))}},
-		ds:    ds,
+		deals:     MinerStateStore{StateStore{ds: namespace.Wrap(ds, datastore.NewKey("/deals/client"))}},
+		ds:        ds,
+		responses: namespace.Wrap(ds, datastore.NewKey("/deals/responses")),
 	}
 
 	if err := h.tryLoadAsk(); err != nil {
@@ -121,8 +143,6 @@ func NewHandler(ds dtypes.MetadataDS, secst *sectorblocks.SectorBlocks, commt *c
 }
 
 func (h *Handler) Run(ctx context.Context) {
-	// TODO: restore state
-
 	go func() {
 		defer log.Warn("quitting deal handler loop")
 		defer close(h.stopped)
@@ -138,12 +158,81 @@ func (h *Handler) Run(ctx context.Context) {
 			}
 		}
 	}()
+
+	go h.restartDeals(ctx)
+}
+
+// restartDeals continues the deals which were in progress when the miner
+// stopped. Their client connections are gone, clients get the responses with
+// the deal status protocol instead
+func (h *Handler) restartDeals(ctx context.Context) {
+	deals, err := h.deals.ListMiner()
+	if err != nil {
+		log.Errorf("listing deals to restart: %s", err)
+		return
+	}
+
+	for _, deal := range deals {
+		update, ok := h.restartUpdate(ctx, deal)
+		if !ok {
+			continue
+		}
+
+		select {
+		case h.updated <- update:
+		case <-h.stop:
+			return
+		}
+	}
+}
+
+// restartUpdate returns the update which runs the state handler of the deal
+// again, or false if the deal is done
+func (h *Handler) restartUpdate(ctx context.Context, deal MinerDeal) (minerDealUpdate, bool) {
+	state := deal.State
+	switch state {
+	case api.DealUnknown:
+		// stopped right after the deal was received
+		state = api.DealAccepted
+	case api.DealAccepted, api.DealStaged, api.DealSealing:
+	case api.DealComplete:
+		// the complete response is sent last
+		if deal.CompleteSent {
+			return minerDealUpdate{}, false
+		}
+	default:
+		return minerDealUpdate{}, false
+	}
+
+	if state == api.DealAccepted && deal.Proposal.MarketDeal != nil && deal.PublishMessage == nil {
+		head, err := h.full.ChainHead(ctx)
+		if err != nil {
+			log.Errorf("restarting deal %s: %s", deal.ProposalCid, err)
+			return minerDealUpdate{}, false
+		}
+
+		if exp := deal.Proposal.MarketDeal.ProposalExpiration; head.Height() >= exp {
+			return minerDealUpdate{
+				newState: api.DealExpired,
+				id:       deal.ProposalCid,
+				err:      xerrors.Errorf("market deal proposal expired at height %d: %w", exp, ErrDealExpired),
+			}, true
+		}
+	}
+
+	log.Infof("restarting deal %s in state %d", deal.ProposalCid, state)
+	return minerDealUpdate{
+		newState: state,
+		id:       deal.ProposalCid,
+	}, true
 }
 
 func (h *Handler) onIncoming(deal MinerDeal) {
 	log.Info("incoming deal")
 
+	h.connsLk.Lock()
 	h.conns[deal.ProposalCid] = deal.s
+	h.connsLk.Unlock()
 
 	if err := h.deals.Begin(deal.ProposalCid, deal); err != nil {
 		// This can happen when client re-sends proposal
@@ -152,11 +241,19 @@ func (h *Handler) onIncoming(deal MinerDeal) {
 		return
 	}
 
+	if err := h.saveClient(deal.ProposalCid, deal.Client); err != nil {
+		h.failDeal(deal.ProposalCid, err)
+		return
+	}
+
 	go func() {
-		h.updated <- minerDealUpdate{
+		select {
+		case h.updated <- minerDealUpdate{
 			newState: api.DealAccepted,
 			id:       deal.ProposalCid,
 			err:      nil,
+		}:
+		case <-h.stop:
 		}
 	}()
 }
@@ -238,6 +335,79 @@ func (h *Handler) HandleStream(s inet.Stream) {
 	h.incoming <- deal
 }
 
+// HandleStatusStream answers deal status requests from clients which lost the
+// connection of their deal
+func (h *Handler) HandleStatusStream(s inet.Stream) {
+	defer s.Close()
+
+	var req DealStatusRequest
+	if err := cborrpc.ReadCborRPC(s, &req); err != nil {
+		log.Errorw("failed to read deal status request", "error", err)
+		return
+	}
+
+	resp, err := h.dealStatus(s.Conn().RemotePeer(), req)
+	if err != nil {
+		log.Errorf("getting status of deal %s: %s", req.Proposal, err)
+		return
+	}
+
+	if err := cborrpc.WriteCborRPC(s, resp); err != nil {
+		log.Errorf("failed to write deal status response: %s", err)
+		return
+	}
+
+	if resp.Response != nil && finalState(resp.Response.Response.State) {
+		h.forgetResponses(req.Proposal)
+	}
+}
+
+// dealStatus answers a deal status request from p. Deals of other clients are
+// reported as unknown
+func (h *Handler) dealStatus(p peer.ID, req DealStatusRequest) (*DealStatusResponse, error) {
+	out := &DealStatusResponse{
+		State: api.DealUnknown,
+	}
+
+	client, err := h.dealClient(req.Proposal)
+	if err != nil {
+		return nil, err
+	}
+	if client != p {
+		return out, nil
+	}
+
+	deal, err := h.deals.GetMiner(req.Proposal)
+	switch err {
+	case nil:
+		out.State = deal.State
+	case ErrDealNotTracked:
+	default:
+		return nil, err
+	}
+
+	resp, err := h.getResponse(req.Proposal, req.State)
+	if err != nil {
+		return nil, err
+	}
+	if resp != nil {
+		out.Response = resp
+		return out, nil
+	}
+
+	// failed deals are no longer tracked
+	resp, err = h.getResponse(req.Proposal, api.DealFailed)
+	if err != nil {
+		return nil, err
+	}
+	if resp != nil {
+		out.State = api.DealFailed
+		out.Response = resp
+	}
+
+	return out, nil
+}
+
 func (h *Handler) Stop() {
 	close(h.stop)
 	<-h.stopped
@@ -142,6 +142,98 @@ func (h *Handler) consumeVouchers(ctx context.Context, deal MinerDeal) error {
 	return nil
 }
 
+func (h *Handler) checkMarketDeal(deal MinerDeal) error {
+	md := deal.Proposal.MarketDeal
+
+	if md.Provider != h.actor {
+		return xerrors.Errorf("market deal provider didn't match miner address: '%s' != '%s'", md.Provider, h.actor)
+	}
+
+	if md.Client != deal.Proposal.ClientAddress {
+		return xerrors.Errorf("market deal client didn't match proposal client: '%s' != '%s'", md.Client, deal.Proposal.ClientAddress)
+	}
+
+	if !bytes.Equal(md.PieceRef, deal.Proposal.CommP) || md.PieceSize != deal.Proposal.Size {
+		return xerrors.New("market deal piece didn't match deal proposal")
+	}
+
+	if md.Duration != deal.Proposal.Duration {
+		return xerrors.Errorf("market deal duration didn't match deal proposal: %d != %d", md.Duration, deal.Proposal.Duration)
+	}
+
+	minPrice := types.BigMul(h.pricePerByteBlock, types.NewInt(deal.Proposal.Size))
+	if types.BigCmp(minPrice, md.StoragePricePerEpoch) > 0 {
+		return xerrors.Errorf("minimum price per epoch: %s", minPrice)
+	}
+
+	maxCollateral := types.BigMul(h.collateralPerByte, types.NewInt(deal.Proposal.Size))
+	if md.StorageCollateral.GreaterThan(maxCollateral) {
+		return xerrors.Errorf("maximum deal collateral: %s", maxCollateral)
+	}
+
+	return nil
+}
+
+// publishDeal publishes the market deal of the proposal to the storage market
+// actor, which locks the client funds. A deal resumed after a restart waits
+// for the message it already published
+func (h *Handler) publishDeal(ctx context.Context, deal MinerDeal) (uint64, error) {
+	mcid := deal.PublishMessage
+	if mcid == nil {
+		if err := h.checkMarketDeal(deal); err != nil {
+			return 0, err
+		}
+
+		worker, err := h.full.StateMinerWorker(ctx, h.actor, nil)
+		if err != nil {
+			return 0, err
+		}
+
+		params, aerr := actors.SerializeParams(&actors.PublishStorageDealsParams{
+			Deals: []actors.StorageDealProposal{*deal.Proposal.MarketDeal},
+		})
+		if aerr != nil {
+			return 0, xerrors.Errorf("serializing PublishStorageDeals params: %w", aerr)
+		}
+
+		smsg, err := h.full.MpoolPushMessage(ctx, &types.Message{
+			To:     actors.MarketActorAddress,
+			From:   worker,
+			Value:  types.NewInt(0),
+			Method: actors.SMAMethods.PublishStorageDeals,
+			Params: params,
+		})
+		if err != nil {
+			return 0, xerrors.Errorf("pushing PublishStorageDeals message: %w", err)
+		}
+
+		c := smsg.Cid()
+		mcid = &c
+		if err := h.recordProgress(deal, func(d *MinerDeal) { d.PublishMessage = mcid }); err != nil {
+			return 0, err
+		}
+	}
+
+	log.Infof("waiting for deal %s to be published in %s", deal.ProposalCid, *mcid)
+	r, err := h.full.StateWaitMsg(ctx, *mcid)
+	if err != nil {
+		return 0, xerrors.Errorf("waiting for PublishStorageDeals message: %w", err)
+	}
+	if r.Receipt.ExitCode != 0 {
+		return 0, xerrors.Errorf("publishing deal failed: exit %d", r.Receipt.ExitCode)
+	}
+
+	var resp actors.PublishStorageDealResponse
+	if err := resp.UnmarshalCBOR(bytes.NewReader(r.Receipt.Return)); err != nil {
+		return 0, xerrors.Errorf("decoding PublishStorageDeals response: %w", err)
+	}
+	if len(resp.DealIDs) != 1 {
+		return 0, xerrors.Errorf("expected 1 deal ID, got %d", len(resp.DealIDs))
+	}
+
+	return resp.DealIDs[0], nil
+}
+
 func (h *Handler) accept(ctx context.Context, deal MinerDeal) (func(*MinerDeal), error) {
 	switch deal.Proposal.SerializationMode {
 	//case SerializationRaw:
@@ -151,15 +243,31 @@ func (h *Handler) accept(ctx context.Context, deal MinerDeal) (func(*MinerDeal),
 		return nil, xerrors.Errorf("deal proposal with unsupported serialization: %s", deal.Proposal.SerializationMode)
 	}
 
-	if deal.Proposal.Payment.ChannelMessage != nil {
-		log.Info("waiting for channel message to appear on chain")
-		if _, err := h.full.StateWaitMsg(ctx, *deal.Proposal.Payment.ChannelMessage); err != nil {
-			return nil, xerrors.Errorf("waiting for paych message: %w", err)
+	var mut func(*MinerDeal)
+	if deal.Proposal.MarketDeal != nil {
+		dealID, err := h.publishDeal(ctx, deal)
+		if err != nil {
+			return nil, err
 		}
-	}
 
-	if err := h.consumeVouchers(ctx, deal); err != nil {
-		return nil, err
+		mut = func(deal *MinerDeal) {
+			deal.DealID = dealID
+		}
+	} else if !deal.VouchersAdded {
+		if deal.Proposal.Payment.ChannelMessage != nil {
+			log.Info("waiting for channel message to appear on chain")
+			if _, err := h.full.StateWaitMsg(ctx, *deal.Proposal.Payment.ChannelMessage); err != nil {
+				return nil, xerrors.Errorf("waiting for paych message: %w", err)
+			}
+		}
+
+		if err := h.consumeVouchers(ctx, deal); err != nil {
+			return nil, err
+		}
+
+		if err := h.recordProgress(deal, func(d *MinerDeal) { d.VouchersAdded = true }); err != nil {
+			return nil, err
+		}
 	}
 
 	log.Info("fetching data for a deal")
@@ -172,7 +280,7 @@ func (h *Handler) accept(ctx context.Context, deal MinerDeal) (func(*MinerDeal),
 		return nil, err
 	}
 
-	return nil, merkledag.FetchGraph(ctx, deal.Ref, h.dag)
+	return mut, merkledag.FetchGraph(ctx, deal.Ref, h.dag)
 }
 
 // STAGED
@@ -186,6 +294,11 @@ func (h *Handler) staged(ctx context.Context, deal MinerDeal) (func(*MinerDeal),
 		log.Warnf("Sending deal response failed: %s", err)
 	}
 
+	if deal.PieceAdded {
+		// resumed after a restart
+		return nil, nil
+	}
+
 	root, err := h.dag.Get(ctx, deal.Ref)
 	if err != nil {
 		return nil, xerrors.Errorf("failed to get file root for deal: %s", err)
@@ -209,9 +322,10 @@ func (h *Handler) staged(ctx context.Context, deal MinerDeal) (func(*MinerDeal),
 	}
 
 	log.Warnf("New Sector: %d", sectorID)
-	return func(deal *MinerDeal) {
+	return nil, h.recordProgress(deal, func(deal *MinerDeal) {
 		deal.SectorID = sectorID
-	}, nil
+		deal.PieceAdded = true
+	})
 }
 
 // SEALING
@@ -293,12 +407,79 @@ func (h *Handler) sealing(ctx context.Context, deal MinerDeal) (func(*MinerDeal)
 	return nil, nil
 }
 
+// activateDeal starts the payments for a published deal once its sector was
+// committed, proving the deal piece is included in the sector
+func (h *Handler) activateDeal(ctx context.Context, deal MinerDeal) error {
+	mcid := deal.ActivateMessage
+	if mcid == nil {
+		worker, err := h.full.StateMinerWorker(ctx, h.actor, nil)
+		if err != nil {
+			return err
+		}
+
+		status, err := h.waitSealed(ctx, deal)
+		if err != nil {
+			return err
+		}
+
+		// TODO: don't hardcode unixfs
+		ip, err := getInclusionProof(string(sectorblocks.SerializationUnixfs0)+deal.Ref.String(), status)
+		if err != nil {
+			return err
+		}
+
+		params, aerr := actors.SerializeParams(&actors.ActivateStorageDealsParams{
+			Deals: []actors.DealActivation{{
+				DealID:   deal.DealID,
+				SectorID: deal.SectorID,
+				Proof:    ip.ProofElements,
+			}},
+		})
+		if aerr != nil {
+			return xerrors.Errorf("serializing ActivateStorageDeals params: %w", aerr)
+		}
+
+		smsg, err := h.full.MpoolPushMessage(ctx, &types.Message{
+			To:     actors.MarketActorAddress,
+			From:   worker,
+			Value:  types.NewInt(0),
+			Method: actors.SMAMethods.ActivateStorageDeals,
+			Params: params,
+		})
+		if err != nil {
+			return xerrors.Errorf("pushing ActivateStorageDeals message: %w", err)
+		}
+
+		c := smsg.Cid()
+		mcid = &c
+		if err := h.recordProgress(deal, func(d *MinerDeal) { d.ActivateMessage = mcid }); err != nil {
+			return err
+		}
+	}
+
+	r, err := h.full.StateWaitMsg(ctx, *mcid)
+	if err != nil {
+		return xerrors.Errorf("waiting for ActivateStorageDeals message: %w", err)
+	}
+	if r.Receipt.ExitCode != 0 {
+		return xerrors.Errorf("activating deal %d failed: exit %d", deal.DealID, r.Receipt.ExitCode)
+	}
+
+	return nil
+}
+
 func (h *Handler) complete(ctx context.Context, deal MinerDeal) (func(*MinerDeal), error) {
 	mcid, err := h.commt.WaitCommit(ctx, deal.Proposal.MinerAddress, deal.SectorID)
 	if err != nil {
 		log.Warnf("Waiting for sector commitment message: %s", err)
 	}
 
+	if deal.Proposal.MarketDeal != nil {
+		if err := h.activateDeal(ctx, deal); err != nil {
+			return nil, xerrors.Errorf("activating deal: %w", err)
+		}
+	}
+
 	err = h.sendSignedResponse(StorageDealResponse{
 		State:    api.DealComplete,
 		Proposal: deal.ProposalCid,
@@ -307,7 +488,8 @@ func (h *Handler) complete(ctx context.Context, deal MinerDeal) (func(*MinerDeal
 	})
 	if err != nil {
 		log.Warnf("Sending deal response failed: %s", err)
+		return nil, nil
 	}
 
-	return nil, nil
+	return nil, h.recordProgress(deal, func(d *MinerDeal) { d.CompleteSent = true })
 }
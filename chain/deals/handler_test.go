@@ -0,0 +1,274 @@
+package deals
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/namespace"
+	dssync "github.com/ipfs/go-datastore/sync"
+	inet "github.com/libp2p/go-libp2p-core/network"
+	mocknet "github.com/libp2p/go-libp2p/p2p/net/mock"
+	"github.com/multiformats/go-multihash"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-lotus/api"
+	"github.com/filecoin-project/go-lotus/chain/actors"
+	"github.com/filecoin-project/go-lotus/chain/address"
+	"github.com/filecoin-project/go-lotus/chain/types"
+)
+
+var dummyCid cid.Cid
+
+func init() {
+	dummyCid, _ = cid.Parse("bafkqaaa")
+}
+
+// fakeNode is the full node of a miner whose deals are resumed, the messages
+// it published before the restart are already on chain
+type fakeNode struct {
+	api.FullNode
+
+	t      *testing.T
+	height uint64
+	msgs   map[cid.Cid]types.MessageReceipt
+}
+
+func (fn *fakeNode) ChainHead(context.Context) (*types.TipSet, error) {
+	a, _ := address.NewFromString("t00")
+	return types.NewTipSet([]*types.BlockHeader{
+		{
+			Height: fn.height,
+			Miner:  a,
+
+			ParentStateRoot:       dummyCid,
+			Messages:              dummyCid,
+			ParentMessageReceipts: dummyCid,
+		},
+	})
+}
+
+func (fn *fakeNode) StateWaitMsg(ctx context.Context, c cid.Cid) (*api.MsgWait, error) {
+	r, ok := fn.msgs[c]
+	if !ok {
+		return nil, xerrors.Errorf("message %s not found", c)
+	}
+	return &api.MsgWait{Receipt: r}, nil
+}
+
+func (fn *fakeNode) StateMinerWorker(context.Context, address.Address, *types.TipSet) (address.Address, error) {
+	fn.t.Fatal("resumed deals must not push messages again")
+	return address.Undef, nil
+}
+
+func (fn *fakeNode) MpoolPushMessage(context.Context, *types.Message) (*types.SignedMessage, error) {
+	fn.t.Fatal("resumed deals must not push messages again")
+	return nil, nil
+}
+
+func testHandler(t *testing.T, full api.FullNode) *Handler {
+	ds := dssync.MutexWrap(datastore.NewMapDatastore())
+
+	miner, err := address.NewIDAddress(1000)
+	require.NoError(t, err)
+
+	return &Handler{
+		full:  full,
+		actor: miner,
+
+		deals:     MinerStateStore{StateStore{ds: namespace.Wrap(ds, datastore.NewKey("/deals/client"))}},
+		responses: namespace.Wrap(ds, datastore.NewKey("/deals/responses")),
+		conns:     map[cid.Cid]inet.Stream{},
+	}
+}
+
+func testProposalCid(t *testing.T, data string) cid.Cid {
+	c, err := cid.Prefix{
+		Version:  1,
+		Codec:    cid.DagCBOR,
+		MhType:   multihash.SHA2_256,
+		MhLength: -1,
+	}.Sum([]byte(data))
+	require.NoError(t, err)
+	return c
+}
+
+func TestRestartUpdate(t *testing.T) {
+	ctx := context.Background()
+	h := testHandler(t, &fakeNode{t: t, height: 10})
+
+	deal := MinerDeal{
+		ProposalCid: testProposalCid(t, "deal"),
+		State:       api.DealAccepted,
+		Proposal: StorageDealProposal{
+			MarketDeal: &actors.StorageDealProposal{ProposalExpiration: 10},
+		},
+	}
+
+	update, ok := h.restartUpdate(ctx, deal)
+	require.True(t, ok)
+	require.Equal(t, api.DealExpired, update.newState)
+	require.True(t, xerrors.Is(update.err, ErrDealExpired))
+
+	// the deal is published already, its funds are locked
+	deal.PublishMessage = &dummyCid
+	update, ok = h.restartUpdate(ctx, deal)
+	require.True(t, ok)
+	require.Equal(t, api.DealAccepted, update.newState)
+	require.NoError(t, update.err)
+
+	deal.PublishMessage = nil
+	deal.Proposal.MarketDeal.ProposalExpiration = 11
+	update, ok = h.restartUpdate(ctx, deal)
+	require.True(t, ok)
+	require.Equal(t, api.DealAccepted, update.newState)
+
+	deal.State = api.DealComplete
+	_, ok = h.restartUpdate(ctx, deal)
+	require.True(t, ok, "the complete response wasn't sent")
+
+	deal.CompleteSent = true
+	_, ok = h.restartUpdate(ctx, deal)
+	require.False(t, ok)
+}
+
+func TestResumePublishedDeal(t *testing.T) {
+	ctx := context.Background()
+
+	publish := testProposalCid(t, "publish")
+	activate := testProposalCid(t, "activate")
+
+	buf := new(bytes.Buffer)
+	require.NoError(t, (&actors.PublishStorageDealResponse{DealIDs: []uint64{7}}).MarshalCBOR(buf))
+
+	h := testHandler(t, &fakeNode{t: t, msgs: map[cid.Cid]types.MessageReceipt{
+		publish:  {Return: buf.Bytes()},
+		activate: {},
+	}})
+
+	deal := MinerDeal{
+		ProposalCid: testProposalCid(t, "deal"),
+		State:       api.DealAccepted,
+		Proposal: StorageDealProposal{
+			MarketDeal: &actors.StorageDealProposal{ProposalExpiration: 10},
+		},
+		PublishMessage: &publish,
+	}
+
+	id, err := h.publishDeal(ctx, deal)
+	require.NoError(t, err)
+	require.Equal(t, uint64(7), id)
+
+	deal.State = api.DealSealing
+	deal.DealID = id
+	deal.ActivateMessage = &activate
+	require.NoError(t, h.activateDeal(ctx, deal))
+
+	failed := testProposalCid(t, "failed")
+	h.full.(*fakeNode).msgs[failed] = types.MessageReceipt{ExitCode: 1}
+	deal.ActivateMessage = &failed
+	require.Error(t, h.activateDeal(ctx, deal))
+}
+
+func TestWaitDealStatus(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	mn := mocknet.New(ctx)
+	mh, err := mn.GenPeer()
+	require.NoError(t, err)
+	ch, err := mn.GenPeer()
+	require.NoError(t, err)
+	oh, err := mn.GenPeer()
+	require.NoError(t, err)
+	require.NoError(t, mn.LinkAll())
+
+	h := testHandler(t, &fakeNode{t: t})
+	mh.SetStreamHandler(DealStatusProtocolID, h.HandleStatusStream)
+
+	c := &Client{h: ch, stop: make(chan struct{})}
+	other := &Client{h: oh, stop: make(chan struct{})}
+
+	proposal := testProposalCid(t, "deal")
+	require.NoError(t, h.deals.Begin(proposal, &MinerDeal{
+		Client:      ch.ID(),
+		ProposalCid: proposal,
+		State:       api.DealAccepted,
+		Proposal: StorageDealProposal{
+			PieceRef:   dummyCid,
+			TotalPrice: types.NewInt(0),
+		},
+		Ref: dummyCid,
+	}))
+	require.NoError(t, h.saveResponse(&SignedStorageDealResponse{
+		Response: StorageDealResponse{State: api.DealAccepted, Proposal: proposal},
+	}))
+
+	deal := ClientDeal{
+		ProposalCid: proposal,
+		Miner:       mh.ID(),
+	}
+
+	resp, err := c.waitDealStatus(ctx, deal, api.DealAccepted)
+	require.NoError(t, err)
+	require.Equal(t, api.DealAccepted, resp.Response.State)
+	require.Equal(t, proposal, resp.Response.Proposal)
+
+	// the miner already responded, it can't forget the deal
+	deal.State = api.DealAccepted
+	_, err = other.waitDealStatus(ctx, deal, api.DealAccepted)
+	require.Error(t, err, "deals of other clients are unknown")
+
+	status, err := other.queryDealStatus(ctx, deal, api.DealAccepted)
+	require.NoError(t, err)
+	require.Equal(t, api.DealUnknown, status.State)
+	require.Nil(t, status.Response)
+
+	// responses are kept until the client got the final one
+	require.NoError(t, h.saveResponse(&SignedStorageDealResponse{
+		Response: StorageDealResponse{State: api.DealComplete, Proposal: proposal},
+	}))
+
+	resp, err = c.waitDealStatus(ctx, deal, api.DealComplete)
+	require.NoError(t, err)
+	require.Equal(t, api.DealComplete, resp.Response.State)
+
+	require.Eventually(t, func() bool {
+		r, err := h.getResponse(proposal, api.DealAccepted)
+		require.NoError(t, err)
+		return r == nil
+	}, time.Second, 10*time.Millisecond)
+
+	r, err := h.getResponse(proposal, api.DealComplete)
+	require.NoError(t, err)
+	require.Nil(t, r)
+}
+
+func TestDealStatusFailed(t *testing.T) {
+	h := testHandler(t, &fakeNode{t: t})
+
+	client, err := mocknet.New(context.Background()).GenPeer()
+	require.NoError(t, err)
+
+	// failed deals are no longer tracked, only the response and the client
+	// are left
+	proposal := testProposalCid(t, "deal")
+	require.NoError(t, h.saveClient(proposal, client.ID()))
+	require.NoError(t, h.saveResponse(&SignedStorageDealResponse{
+		Response: StorageDealResponse{State: api.DealFailed, Proposal: proposal},
+	}))
+
+	status, err := h.dealStatus(client.ID(), DealStatusRequest{Proposal: proposal, State: api.DealStaged})
+	require.NoError(t, err)
+	require.Equal(t, api.DealFailed, status.State)
+	require.NotNil(t, status.Response)
+
+	status, err = h.dealStatus("", DealStatusRequest{Proposal: proposal, State: api.DealStaged})
+	require.NoError(t, err)
+	require.Equal(t, api.DealUnknown, status.State)
+	require.Nil(t, status.Response)
+}
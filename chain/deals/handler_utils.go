@@ -3,6 +3,7 @@ package deals
 import (
 	"context"
 	"runtime"
+	"strconv"
 
 	"github.com/filecoin-project/go-lotus/api"
 
@@ -12,8 +13,11 @@ import (
 	"github.com/filecoin-project/go-lotus/lib/cborrpc"
 
 	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/query"
 	cbor "github.com/ipfs/go-ipld-cbor"
 	inet "github.com/libp2p/go-libp2p-core/network"
+	"github.com/libp2p/go-libp2p-core/peer"
 	"golang.org/x/xerrors"
 )
 
@@ -35,11 +39,13 @@ func (h *Handler) failDeal(id cid.Cid, cerr error) {
 		Proposal: id,
 	})
 
+	h.connsLk.Lock()
 	s, ok := h.conns[id]
 	if ok {
 		_ = s.Reset()
 		delete(h.conns, id)
 	}
+	h.connsLk.Unlock()
 
 	if err != nil {
 		log.Warnf("notifying client about deal failure: %s", err)
@@ -63,12 +69,10 @@ func (h *Handler) readProposal(s inet.Stream) (proposal SignedStorageDealProposa
 	return
 }
 
+// sendSignedResponse signs and stores the response, and sends it to the client
+// if it is connected. The stored responses are deleted once the client got the
+// final one
 func (h *Handler) sendSignedResponse(resp StorageDealResponse) error {
-	s, ok := h.conns[resp.Proposal]
-	if !ok {
-		return xerrors.New("couldn't send response: not connected")
-	}
-
 	msg, err := cbor.DumpObject(&resp)
 	if err != nil {
 		return xerrors.Errorf("serializing response: %w", err)
@@ -89,13 +93,146 @@ func (h *Handler) sendSignedResponse(resp StorageDealResponse) error {
 		Signature: sig,
 	}
 
+	if err := h.saveResponse(&signedResponse); err != nil {
+		return err
+	}
+
+	h.connsLk.Lock()
+	s, ok := h.conns[resp.Proposal]
+	h.connsLk.Unlock()
+	if !ok {
+		log.Infof("client of deal %s isn't connected, it can query the response", resp.Proposal)
+		return nil
+	}
+
 	err = cborrpc.WriteCborRPC(s, signedResponse)
 	if err != nil {
-		// Assume client disconnected
+		// Assume client disconnected, it can query the response
+		log.Warnf("sending response for deal %s: %s", resp.Proposal, err)
 		s.Close()
+
+		h.connsLk.Lock()
 		delete(h.conns, resp.Proposal)
+		h.connsLk.Unlock()
+		return nil
+	}
+
+	if finalState(resp.State) {
+		h.forgetResponses(resp.Proposal)
+	}
+	return nil
+}
+
+// finalState returns whether no response follows the one for state
+func finalState(state api.DealState) bool {
+	return state == api.DealComplete || state == api.DealFailed
+}
+
+func responseKey(proposal cid.Cid, state api.DealState) datastore.Key {
+	return datastore.NewKey(proposal.String()).ChildString(strconv.Itoa(int(state)))
+}
+
+func (h *Handler) saveResponse(resp *SignedStorageDealResponse) error {
+	b, err := cbor.DumpObject(resp)
+	if err != nil {
+		return xerrors.Errorf("serializing response: %w", err)
+	}
+
+	if err := h.responses.Put(responseKey(resp.Response.Proposal, resp.Response.State), b); err != nil {
+		return xerrors.Errorf("storing response: %w", err)
+	}
+	return nil
+}
+
+// forgetResponses deletes the stored responses of the deal, once its client
+// got the final one
+func (h *Handler) forgetResponses(proposal cid.Cid) {
+	res, err := h.responses.Query(query.Query{
+		Prefix:   datastore.NewKey(proposal.String()).String(),
+		KeysOnly: true,
+	})
+	if err != nil {
+		log.Errorf("listing responses of deal %s: %s", proposal, err)
+		return
+	}
+
+	entries, err := res.Rest()
+	if err != nil {
+		log.Errorf("listing responses of deal %s: %s", proposal, err)
+		return
+	}
+
+	for _, e := range entries {
+		if err := h.responses.Delete(datastore.NewKey(e.Key)); err != nil {
+			log.Errorf("deleting response of deal %s: %s", proposal, err)
+		}
+	}
+}
+
+func clientKey(proposal cid.Cid) datastore.Key {
+	return datastore.NewKey(proposal.String()).ChildString("client")
+}
+
+// saveClient records the client of the deal, so that only it can query the
+// deal status, also once the deal failed and is no longer tracked
+func (h *Handler) saveClient(proposal cid.Cid, client peer.ID) error {
+	if err := h.responses.Put(clientKey(proposal), []byte(client)); err != nil {
+		return xerrors.Errorf("storing deal client: %w", err)
+	}
+	return nil
+}
+
+// dealClient returns the client of the deal, or an empty ID if the deal is
+// unknown
+func (h *Handler) dealClient(proposal cid.Cid) (peer.ID, error) {
+	deal, err := h.deals.GetMiner(proposal)
+	switch err {
+	case nil:
+		return deal.Client, nil
+	case ErrDealNotTracked:
+	default:
+		return "", err
+	}
+
+	b, err := h.responses.Get(clientKey(proposal))
+	if err == datastore.ErrNotFound {
+		return "", nil
+	}
+	if err != nil {
+		return "", xerrors.Errorf("getting deal client: %w", err)
+	}
+	return peer.ID(b), nil
+}
+
+// getResponse returns the response sent for the deal in the given state, or
+// nil if none was sent
+func (h *Handler) getResponse(proposal cid.Cid, state api.DealState) (*SignedStorageDealResponse, error) {
+	b, err := h.responses.Get(responseKey(proposal, state))
+	if err == datastore.ErrNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, xerrors.Errorf("getting response: %w", err)
+	}
+
+	var resp SignedStorageDealResponse
+	if err := cbor.DecodeInto(b, &resp); err != nil {
+		return nil, xerrors.Errorf("decoding response: %w", err)
+	}
+	return &resp, nil
+}
+
+// recordProgress stores progress made by a state handler right away, so that
+// the step isn't repeated if the deal is resumed after a restart
+func (h *Handler) recordProgress(deal MinerDeal, mut func(*MinerDeal)) error {
+	err := h.deals.MutateMiner(deal.ProposalCid, func(d *MinerDeal) error {
+		mut(d)
+		return nil
+	})
+	if err != nil {
+		return xerrors.Errorf("recording deal progress: %w", err)
 	}
-	return err
+	return nil
 }
 
 func (h *Handler) getWorker(miner address.Address) (address.Address, error) {
@@ -8,10 +8,24 @@ import (
 	"golang.org/x/xerrors"
 )
 
+var ErrDealNotTracked = xerrors.New("deal not tracked")
+
 type StateStore struct {
 	ds datastore.Datastore
 }
 
+func (st *StateStore) get(i cid.Cid, out interface{}) error {
+	b, err := st.ds.Get(datastore.NewKey(i.String()))
+	if err == datastore.ErrNotFound {
+		return ErrDealNotTracked
+	}
+	if err != nil {
+		return err
+	}
+
+	return cbor.DecodeInto(b, out)
+}
+
 func (st *StateStore) Begin(i cid.Cid, state interface{}) error {
 	k := datastore.NewKey(i.String())
 	has, err := st.ds.Has(k)
@@ -89,6 +103,41 @@ func minerMutator(m func(*MinerDeal) error) func([]byte) ([]byte, error) {
 	}
 }
 
+func (st *MinerStateStore) GetMiner(i cid.Cid) (*MinerDeal, error) {
+	var deal MinerDeal
+	if err := st.get(i, &deal); err != nil {
+		return nil, err
+	}
+	return &deal, nil
+}
+
+func (st *MinerStateStore) ListMiner() ([]MinerDeal, error) {
+	var out []MinerDeal
+
+	res, err := st.ds.Query(query.Query{})
+	if err != nil {
+		return nil, err
+	}
+	defer res.Close()
+
+	for {
+		res, ok := res.NextSync()
+		if !ok {
+			break
+		}
+
+		var deal MinerDeal
+		err := cbor.DecodeInto(res.Value, &deal)
+		if err != nil {
+			return nil, err
+		}
+
+		out = append(out, deal)
+	}
+
+	return out, nil
+}
+
 type ClientStateStore struct {
 	StateStore
 }
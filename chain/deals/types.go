@@ -4,6 +4,7 @@ import (
 	"github.com/filecoin-project/go-lotus/api"
 	"github.com/ipfs/go-cid"
 	cbor "github.com/ipfs/go-ipld-cbor"
+	"golang.org/x/xerrors"
 
 	"github.com/filecoin-project/go-lotus/chain/actors"
 	"github.com/filecoin-project/go-lotus/chain/address"
@@ -21,10 +22,23 @@ func init() {
 
 	cbor.RegisterCborType(AskRequest{})
 	cbor.RegisterCborType(AskResponse{})
+
+	cbor.RegisterCborType(DealStatusRequest{})
+	cbor.RegisterCborType(DealStatusResponse{})
 }
 
 const ProtocolID = "/fil/storage/mk/1.0.0"
 const AskProtocolID = "/fil/storage/ask/1.0.0"
+const DealStatusProtocolID = "/fil/storage/status/1.0.0"
+
+var (
+	// ErrDealExpired fails deals whose market deal proposal expired before it
+	// was published, putting them in DealExpired
+	ErrDealExpired = xerrors.New("deal proposal expired")
+	// ErrDealUnreachable fails deals whose counterparty couldn't be reached
+	// for dealRecoveryTimeout, putting them in DealFailed
+	ErrDealUnreachable = xerrors.New("deal counterparty unreachable")
+)
 
 type SerializationMode string
 
@@ -47,6 +61,11 @@ type StorageDealProposal struct {
 
 	MinerAddress  address.Address
 	ClientAddress address.Address
+
+	// MarketDeal is the client-signed proposal which the provider publishes
+	// to the storage market actor. Deals with a MarketDeal are paid from the
+	// client escrow instead of payment channel vouchers
+	MarketDeal *actors.StorageDealProposal
 }
 
 type SignedStorageDealProposal struct {
@@ -90,3 +109,21 @@ type AskRequest struct {
 type AskResponse struct {
 	Ask *types.SignedStorageAsk
 }
+
+// DealStatusRequest asks the miner for the response it sent for a deal in the
+// given state, so that clients can catch up on responses they missed while
+// disconnected
+type DealStatusRequest struct {
+	Proposal cid.Cid
+	State    api.DealState
+}
+
+type DealStatusResponse struct {
+	// State is the current state of the deal on the miner, DealUnknown if the
+	// miner doesn't know about it
+	State api.DealState
+
+	// Response is the response sent for the requested state, or the failure
+	// response if the deal failed. It is nil if the deal didn't get there yet
+	Response *SignedStorageDealResponse
+}
@@ -0,0 +1,238 @@
+package faults
+
+import (
+	"context"
+
+	"github.com/ipfs/go-cid"
+	hamt "github.com/ipfs/go-hamt-ipld"
+	logging "github.com/ipfs/go-log"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-lotus/chain/actors"
+	"github.com/filecoin-project/go-lotus/chain/address"
+	"github.com/filecoin-project/go-lotus/chain/state"
+	"github.com/filecoin-project/go-lotus/chain/stmgr"
+	"github.com/filecoin-project/go-lotus/chain/store"
+	"github.com/filecoin-project/go-lotus/chain/types"
+)
+
+var log = logging.Logger("faults")
+
+type MessagePusher interface {
+	MpoolPushMessage(context.Context, *types.Message) (*types.SignedMessage, error)
+}
+
+// StorageFaultWatcher follows the chain and reports miners which stopped
+// submitting PoSts to the storage power actor, collecting the slasher reward
+type StorageFaultWatcher struct {
+	sm       *stmgr.StateManager
+	mpool    MessagePusher
+	reporter address.Address
+
+	// root is the state root miners were last loaded from
+	root   cid.Cid
+	miners map[address.Address]*watchedMiner
+}
+
+// watchedMiner caches the state of a miner actor between head changes. Only
+// actors which differ between two state trees are loaded again
+type watchedMiner struct {
+	head  cid.Cid
+	state actors.StorageMinerActorState
+
+	// reported is the proving period end at which the miner was last reported,
+	// so a fault is only reported once per proving period
+	reported uint64
+}
+
+func NewStorageFaultWatcher(sm *stmgr.StateManager, mpool MessagePusher, reporter address.Address) *StorageFaultWatcher {
+	return &StorageFaultWatcher{
+		sm:       sm,
+		mpool:    mpool,
+		reporter: reporter,
+		miners:   map[address.Address]*watchedMiner{},
+	}
+}
+
+func (w *StorageFaultWatcher) Run(ctx context.Context) {
+	notifs := w.sm.ChainStore().SubHeadChanges(ctx)
+
+	for {
+		select {
+		case changes, ok := <-notifs:
+			if !ok {
+				log.Warn("storage fault watcher: head change channel closed")
+				return
+			}
+
+			var head *types.TipSet
+			for _, hc := range changes {
+				if hc.Type == store.HCApply || hc.Type == store.HCCurrent {
+					head = hc.Val
+				}
+			}
+			if head == nil {
+				continue
+			}
+
+			if err := w.checkFaults(ctx, head); err != nil {
+				log.Errorf("checking storage faults at height %d: %+v", head.Height(), err)
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (w *StorageFaultWatcher) checkFaults(ctx context.Context, ts *types.TipSet) error {
+	cst := hamt.CSTFromBstore(w.sm.ChainStore().Blockstore())
+	root := ts.ParentState()
+
+	st, err := state.LoadStateTree(cst, root)
+	if err != nil {
+		return xerrors.Errorf("loading state tree: %w", err)
+	}
+
+	if w.root == cid.Undef {
+		if err := w.loadMiners(ctx, cst, st); err != nil {
+			return err
+		}
+	} else {
+		var minersChanged bool
+		changed := map[address.Address]*types.Actor{}
+
+		err := state.DiffStateTrees(ctx, cst, w.root, root, func(addr address.Address, _, act *types.Actor) error {
+			if addr == actors.StoragePowerAddress {
+				minersChanged = true
+			} else if _, ok := w.miners[addr]; ok {
+				changed[addr] = act
+			}
+			return nil
+		})
+		if err != nil {
+			return xerrors.Errorf("diffing state trees: %w", err)
+		}
+
+		if minersChanged {
+			if err := w.loadMiners(ctx, cst, st); err != nil {
+				return err
+			}
+		} else {
+			for maddr, act := range changed {
+				if act == nil {
+					delete(w.miners, maddr)
+					continue
+				}
+				if err := w.loadMiner(ctx, cst, maddr, act); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	w.root = root
+
+	// reports will be included in the next tipset at the earliest
+	height := ts.Height() + 1
+
+	for maddr, m := range w.miners {
+		if !actors.StorageFaultSlashable(&m.state, height) {
+			continue
+		}
+
+		if m.reported == m.state.ProvingPeriodEnd {
+			continue
+		}
+
+		if err := w.report(ctx, maddr); err != nil {
+			log.Errorf("reporting storage fault of miner %s: %+v", maddr, err)
+			continue
+		}
+
+		m.reported = m.state.ProvingPeriodEnd
+	}
+
+	return nil
+}
+
+// loadMiners syncs the watched miners with the miner set of the storage power
+// actor, forgetting miners which were removed from it
+func (w *StorageFaultWatcher) loadMiners(ctx context.Context, cst *hamt.CborIpldStore, st *state.StateTree) error {
+	spact, err := st.GetActor(actors.StoragePowerAddress)
+	if err != nil {
+		return xerrors.Errorf("getting storage power actor: %w", err)
+	}
+
+	var spa actors.StoragePowerState
+	if err := cst.Get(ctx, spact.Head, &spa); err != nil {
+		return xerrors.Errorf("loading storage power actor state: %w", err)
+	}
+
+	miners, err := actors.MinerSetList(ctx, cst, spa.Miners)
+	if err != nil {
+		return xerrors.Errorf("listing miners: %w", err)
+	}
+
+	seen := make(map[address.Address]struct{}, len(miners))
+	for _, maddr := range miners {
+		seen[maddr] = struct{}{}
+
+		act, err := st.GetActor(maddr)
+		if err != nil {
+			return xerrors.Errorf("getting miner actor %s: %w", maddr, err)
+		}
+
+		if err := w.loadMiner(ctx, cst, maddr, act); err != nil {
+			return err
+		}
+	}
+
+	for maddr := range w.miners {
+		if _, ok := seen[maddr]; !ok {
+			delete(w.miners, maddr)
+		}
+	}
+
+	return nil
+}
+
+func (w *StorageFaultWatcher) loadMiner(ctx context.Context, cst *hamt.CborIpldStore, maddr address.Address, act *types.Actor) error {
+	m, ok := w.miners[maddr]
+	if !ok {
+		m = &watchedMiner{}
+		w.miners[maddr] = m
+	}
+
+	if m.head == act.Head {
+		return nil
+	}
+
+	var mas actors.StorageMinerActorState
+	if err := cst.Get(ctx, act.Head, &mas); err != nil {
+		return xerrors.Errorf("loading state of miner %s: %w", maddr, err)
+	}
+	m.head = act.Head
+	m.state = mas
+
+	return nil
+}
+
+func (w *StorageFaultWatcher) report(ctx context.Context, maddr address.Address) error {
+	enc, aerr := actors.SerializeParams(&actors.SlashStorageFaultParams{Miner: maddr})
+	if aerr != nil {
+		return aerr
+	}
+
+	smsg, err := w.mpool.MpoolPushMessage(ctx, &types.Message{
+		To:     actors.StoragePowerAddress,
+		From:   w.reporter,
+		Method: actors.SPAMethods.SlashStorageFault,
+		Params: enc,
+		Value:  types.NewInt(0),
+	})
+	if err != nil {
+		return err
+	}
+
+	log.Infof("reported storage fault of miner %s in message %s", maddr, smsg.Cid())
+	return nil
+}
@@ -74,6 +74,12 @@ func (m mybs) Get(c cid.Cid) (block.Block, error) {
 }
 
 func NewGenerator() (*ChainGen, error) {
+	return NewGeneratorWithUpgradeSchedule(stmgr.DefaultUpgradeSchedule())
+}
+
+// NewGeneratorWithUpgradeSchedule creates a generator which executes the
+// chain with the given network upgrades
+func NewGeneratorWithUpgradeSchedule(us stmgr.UpgradeSchedule) (*ChainGen, error) {
 	mr := repo.NewMemory(nil)
 	lr, err := mr.Lock()
 	if err != nil {
@@ -97,7 +103,7 @@ func NewGenerator() (*ChainGen, error) {
 		return nil, xerrors.Errorf("getting repo keystore failed: %w", err)
 	}
 
-	w, err := wallet.NewWallet(ks)
+	w, err := wallet.NewWallet(ks, ds)
 	if err != nil {
 		return nil, xerrors.Errorf("creating memrepo wallet failed: %w", err)
 	}
@@ -153,7 +159,10 @@ func NewGenerator() (*ChainGen, error) {
 		return nil, xerrors.Errorf("MakeGenesisBlock failed to set miner address")
 	}
 
-	sm := stmgr.NewStateManager(cs)
+	sm, err := stmgr.NewStateManagerWithUpgradeSchedule(cs, us)
+	if err != nil {
+		return nil, xerrors.Errorf("initializing state manager: %w", err)
+	}
 
 	gen := &ChainGen{
 		bs:           bs,
@@ -181,6 +190,20 @@ func (cg *ChainGen) Genesis() *types.BlockHeader {
 	return cg.genesis
 }
 
+func (cg *ChainGen) StateManager() *stmgr.StateManager {
+	return cg.sm
+}
+
+func (cg *ChainGen) Wallet() *wallet.Wallet {
+	return cg.w
+}
+
+// Banker returns the account funding the generated messages. Messages sent
+// from it directly must account for the nonces NextTipSet uses
+func (cg *ChainGen) Banker() address.Address {
+	return cg.banker
+}
+
 func (cg *ChainGen) GenesisCar() ([]byte, error) {
 	offl := offline.Exchange(cg.bs)
 	blkserv := blockservice.New(cg.bs, offl)
@@ -246,15 +269,31 @@ func (cg *ChainGen) NextTipSet() (*MinedTipSet, error) {
 	return mts, nil
 }
 
-func (cg *ChainGen) NextTipSetFromMiners(base *types.TipSet, miners []address.Address) (*MinedTipSet, error) {
-	var blks []*types.FullBlock
-	ticketSets := make([][]*types.Ticket, len(miners))
+// NextTipSetWithMessages mines the next tipset on top of CurTipset with msgs
+// instead of generated banker messages
+func (cg *ChainGen) NextTipSetWithMessages(msgs []*types.SignedMessage) (*MinedTipSet, error) {
+	mts, err := cg.nextTipSetFromMiners(cg.CurTipset.TipSet(), cg.Miners, msgs)
+	if err != nil {
+		return nil, err
+	}
+
+	cg.CurTipset = mts.TipSet
+	return mts, nil
+}
 
+func (cg *ChainGen) NextTipSetFromMiners(base *types.TipSet, miners []address.Address) (*MinedTipSet, error) {
 	msgs, err := cg.getRandomMessages()
 	if err != nil {
 		return nil, err
 	}
 
+	return cg.nextTipSetFromMiners(base, miners, msgs)
+}
+
+func (cg *ChainGen) nextTipSetFromMiners(base *types.TipSet, miners []address.Address, msgs []*types.SignedMessage) (*MinedTipSet, error) {
+	var blks []*types.FullBlock
+	ticketSets := make([][]*types.Ticket, len(miners))
+
 	for len(blks) == 0 {
 		for i, m := range miners {
 			proof, t, err := cg.nextBlockProof(context.TODO(), base, m, ticketSets[i])
@@ -1,7 +1,23 @@
 package gen
 
 import (
+	"bytes"
+	"context"
 	"testing"
+
+	"github.com/ipfs/go-cid"
+	hamt "github.com/ipfs/go-hamt-ipld"
+	blockstore "github.com/ipfs/go-ipfs-blockstore"
+	mh "github.com/multiformats/go-multihash"
+	cbg "github.com/whyrusleeping/cbor-gen"
+
+	"github.com/filecoin-project/go-lotus/chain/actors"
+	"github.com/filecoin-project/go-lotus/chain/actors/aerrors"
+	"github.com/filecoin-project/go-lotus/chain/address"
+	"github.com/filecoin-project/go-lotus/chain/state"
+	"github.com/filecoin-project/go-lotus/chain/stmgr"
+	"github.com/filecoin-project/go-lotus/chain/types"
+	"github.com/filecoin-project/go-lotus/chain/vm"
 )
 
 func testGeneration(t testing.TB, n int, msgs int) {
@@ -48,3 +64,277 @@ func BenchmarkChainGeneration(b *testing.B) {
 		testGeneration(b, b.N, 1000)
 	})
 }
+
+func TestComputeStateDoesNotPersist(t *testing.T) {
+	g, err := NewGenerator()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	g.msgsPerBlock = 0
+
+	for i := 0; i < 2; i++ {
+		if _, err := g.NextTipSet(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ctx := context.Background()
+	ts := g.CurTipset.TipSet()
+
+	// the second message can only succeed if the first one was applied
+	msgs := []*types.Message{
+		{
+			From:     g.banker,
+			To:       g.receivers[0],
+			Nonce:    0,
+			Value:    types.NewInt(10000),
+			GasLimit: types.NewInt(10000),
+			GasPrice: types.NewInt(0),
+		},
+		{
+			From:     g.receivers[0],
+			To:       g.receivers[1],
+			Nonce:    0,
+			Value:    types.NewInt(5000),
+			GasLimit: types.NewInt(10000),
+			GasPrice: types.NewInt(0),
+		},
+	}
+
+	root, rets, err := g.sm.ComputeState(ctx, 0, msgs, ts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, ret := range rets {
+		if ret.ExitCode != 0 {
+			t.Fatalf("message %d failed with exit code %d: %s", i, ret.ExitCode, ret.ActorErr)
+		}
+	}
+
+	has, err := g.bs.Has(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if has {
+		t.Fatal("computed state should not be written to the chain blockstore")
+	}
+
+	if _, _, err := g.sm.ComputeState(ctx, ts.Height(), msgs, ts); err == nil {
+		t.Fatal("expected error computing state at the base tipset height")
+	}
+}
+
+var testActorCodeCid = func() cid.Cid {
+	c, err := cid.NewPrefixV1(cid.Raw, mh.ID).Sum([]byte("testactor"))
+	if err != nil {
+		panic(err)
+	}
+	return c
+}()
+
+type testActor struct{}
+
+func (ta testActor) Exports() []interface{} {
+	return []interface{}{
+		1: ta.NetworkVersion,
+	}
+}
+
+func (ta testActor) NetworkVersion(act *types.Actor, vmctx types.VMContext, params *struct{}) ([]byte, aerrors.ActorError) {
+	return cbg.CborEncodeMajorType(cbg.MajUnsignedInt, uint64(vmctx.NetworkVersion())), nil
+}
+
+func TestUpgradeSwapsActorCode(t *testing.T) {
+	const upgradeHeight = 3
+
+	var g *ChainGen
+	var migrated cid.Cid
+
+	// swaps the code of the banker account for the test actor
+	migration := func(ctx context.Context, sm *stmgr.StateManager, bs blockstore.Blockstore, root cid.Cid) (cid.Cid, error) {
+		cst := hamt.CSTFromBstore(bs)
+		st, err := state.LoadStateTree(cst, root)
+		if err != nil {
+			return cid.Undef, err
+		}
+
+		if err := st.MutateActor(g.banker, func(act *types.Actor) error {
+			act.Code = testActorCodeCid
+			return nil
+		}); err != nil {
+			return cid.Undef, err
+		}
+
+		migrated, err = st.Flush()
+		return migrated, err
+	}
+
+	g, err := NewGeneratorWithUpgradeSchedule(stmgr.UpgradeSchedule{{
+		Height:    upgradeHeight,
+		Network:   1,
+		Migration: migration,
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// banker messages would be sent from a non-account actor after the upgrade
+	g.msgsPerBlock = 0
+
+	g.sm.SetVMConstructor(func(base cid.Cid, height uint64, r vm.Rand, maddr address.Address, cbs blockstore.Blockstore, nv types.NetworkVersion) (*vm.VM, error) {
+		nvm, err := vm.NewVM(base, height, r, maddr, cbs, nv)
+		if err != nil {
+			return nil, err
+		}
+
+		inv := vm.NewInvoker()
+		inv.Register(testActorCodeCid, testActor{}, struct{}{})
+		nvm.SetInvoker(inv)
+		return nvm, nil
+	})
+
+	ctx := context.Background()
+	cst := hamt.CSTFromBstore(g.bs)
+
+	for i := 0; i < 6; i++ {
+		mts, err := g.NextTipSet()
+		if err != nil {
+			t.Fatal(err)
+		}
+		ts := mts.TipSet.TipSet()
+
+		root, _, err := g.sm.TipSetState(ctx, ts)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		st, err := state.LoadStateTree(cst, root)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		act, err := st.GetActor(g.banker)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if ts.Height() == upgradeHeight-1 {
+			// computing the next tipset crosses the upgrade, so the
+			// migration has to run before the message is applied
+			_, rets, err := g.sm.ComputeState(ctx, upgradeHeight, []*types.Message{{
+				From:   actors.NetworkAddress,
+				To:     g.banker,
+				Method: 1,
+			}}, ts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if rets[0].ExitCode != 0 {
+				t.Fatalf("computing a test actor call across the upgrade failed with exit code %d", rets[0].ExitCode)
+			}
+
+			_, nv, err := cbg.CborReadHeader(bytes.NewReader(rets[0].Return))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if nv != 1 {
+				t.Fatalf("expected computed message to run with network version 1, got %d", nv)
+			}
+
+			has, err := g.bs.Has(migrated)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if has {
+				t.Fatal("state migrated by ComputeState should not be written to the chain blockstore")
+			}
+		}
+
+		if ts.Height() < upgradeHeight {
+			if act.Code != actors.AccountActorCodeCid {
+				t.Fatalf("banker code changed before the upgrade at height %d", ts.Height())
+			}
+			if g.sm.GetNtwkVersion(ts.Height()) != types.NetworkVersion0 {
+				t.Fatalf("wrong network version at height %d", ts.Height())
+			}
+			continue
+		}
+
+		if act.Code != testActorCodeCid {
+			t.Fatalf("banker code wasn't swapped at height %d", ts.Height())
+		}
+
+		ret, err := g.sm.CallRaw(ctx, &types.Message{
+			From:   actors.NetworkAddress,
+			To:     g.banker,
+			Method: 1,
+		}, root, nil, ts.Height()+1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ret.ExitCode != 0 {
+			t.Fatalf("calling test actor failed with exit code %d", ret.ExitCode)
+		}
+
+		_, nv, err := cbg.CborReadHeader(bytes.NewReader(ret.Return))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if nv != 1 {
+			t.Fatalf("expected actor to run with network version 1, got %d", nv)
+		}
+	}
+}
+
+type exhaustedRewardActor struct{}
+
+func (ra exhaustedRewardActor) Exports() []interface{} {
+	exports := make([]interface{}, actors.RAMethods.AwardBlockReward+1)
+	exports[actors.RAMethods.AwardBlockReward] = ra.AwardBlockReward
+	return exports
+}
+
+func (ra exhaustedRewardActor) AwardBlockReward(act *types.Actor, vmctx types.VMContext, params *actors.AwardBlockRewardParams) ([]byte, aerrors.ActorError) {
+	return nil, aerrors.New(1, "no rewards left")
+}
+
+func TestFailedBlockRewardKeepsChainGoing(t *testing.T) {
+	g, err := NewGenerator()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	g.sm.SetVMConstructor(func(base cid.Cid, height uint64, r vm.Rand, maddr address.Address, cbs blockstore.Blockstore, nv types.NetworkVersion) (*vm.VM, error) {
+		nvm, err := vm.NewVM(base, height, r, maddr, cbs, nv)
+		if err != nil {
+			return nil, err
+		}
+
+		inv := vm.NewInvoker()
+		inv.Register(actors.RewardActorCodeCid, exhaustedRewardActor{}, actors.RewardActorState{})
+		nvm.SetInvoker(inv)
+		return nvm, nil
+	})
+
+	for i := 0; i < 3; i++ {
+		mts, err := g.NextTipSet()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, _, err := g.sm.TipSetState(context.Background(), mts.TipSet.TipSet()); err != nil {
+			t.Fatalf("computing tipset state with a failing reward actor: %+v", err)
+		}
+	}
+}
+
+func TestUpgradeScheduleValidate(t *testing.T) {
+	if _, err := NewGeneratorWithUpgradeSchedule(stmgr.UpgradeSchedule{
+		{Height: 10, Network: 1},
+		{Height: 5, Network: 2},
+	}); err == nil {
+		t.Fatal("expected out of order upgrade schedule to be rejected")
+	}
+}
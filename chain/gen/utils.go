@@ -89,16 +89,34 @@ func MakeInitialStateTree(bs bstore.Blockstore, actmap map[address.Address]types
 		return nil, xerrors.Errorf("set init actor: %w", err)
 	}
 
-	smact, err := SetupStorageMarketActor(bs)
+	spact, err := SetupStoragePowerActor(bs)
 	if err != nil {
-		return nil, xerrors.Errorf("setup storage market actor: %w", err)
+		return nil, xerrors.Errorf("setup storage power actor: %w", err)
 	}
 
-	if err := state.SetActor(actors.StorageMarketAddress, smact); err != nil {
-		return nil, xerrors.Errorf("set storage market actor: %w", err)
+	if err := state.SetActor(actors.StoragePowerAddress, spact); err != nil {
+		return nil, xerrors.Errorf("set storage power actor: %w", err)
 	}
 
-	netAmt := types.FromFil(build.TotalFilecoin)
+	ract, err := SetupRewardActor(bs)
+	if err != nil {
+		return nil, xerrors.Errorf("setup reward actor: %w", err)
+	}
+
+	if err := state.SetActor(actors.RewardActorAddress, ract); err != nil {
+		return nil, xerrors.Errorf("set reward actor: %w", err)
+	}
+
+	mact, err := SetupMarketActor(bs)
+	if err != nil {
+		return nil, xerrors.Errorf("setup market actor: %w", err)
+	}
+
+	if err := state.SetActor(actors.MarketActorAddress, mact); err != nil {
+		return nil, xerrors.Errorf("set market actor: %w", err)
+	}
+
+	netAmt := types.BigSub(types.FromFil(build.TotalFilecoin), ract.Balance)
 	for _, amt := range actmap {
 		netAmt = types.BigSub(netAmt, amt)
 	}
@@ -122,10 +140,15 @@ func MakeInitialStateTree(bs bstore.Blockstore, actmap map[address.Address]types
 	}
 
 	for a, v := range actmap {
+		head, err := cst.Put(context.TODO(), &actors.AccountActorState{Address: a})
+		if err != nil {
+			return nil, xerrors.Errorf("putting account state: %w", err)
+		}
+
 		err = state.SetActor(a, &types.Actor{
 			Code:    actors.AccountActorCodeCid,
 			Balance: v,
-			Head:    emptyobject,
+			Head:    head,
 		})
 		if err != nil {
 			return nil, xerrors.Errorf("setting account from actmap: %w", err)
@@ -135,7 +158,7 @@ func MakeInitialStateTree(bs bstore.Blockstore, actmap map[address.Address]types
 	return state, nil
 }
 
-func SetupStorageMarketActor(bs bstore.Blockstore) (*types.Actor, error) {
+func SetupStoragePowerActor(bs bstore.Blockstore) (*types.Actor, error) {
 	cst := hamt.CSTFromBstore(bs)
 	nd := hamt.NewNode(cst)
 	emptyhamt, err := cst.Put(context.TODO(), nd)
@@ -154,7 +177,68 @@ func SetupStorageMarketActor(bs bstore.Blockstore) (*types.Actor, error) {
 	}
 
 	return &types.Actor{
-		Code:    actors.StorageMarketActorCodeCid,
+		Code:    actors.StoragePowerActorCodeCid,
+		Head:    stcid,
+		Nonce:   0,
+		Balance: types.NewInt(0),
+	}, nil
+}
+
+func SetupRewardActor(bs bstore.Blockstore) (*types.Actor, error) {
+	cst := hamt.CSTFromBstore(bs)
+	nd := hamt.NewNode(cst)
+	emptyhamt, err := cst.Put(context.TODO(), nd)
+	if err != nil {
+		return nil, err
+	}
+
+	supply := types.FromFil(build.MiningRewardTotal)
+
+	rst := &actors.RewardActorState{
+		Remaining: supply,
+		Rewards:   emptyhamt,
+	}
+
+	stcid, err := cst.Put(context.TODO(), rst)
+	if err != nil {
+		return nil, err
+	}
+
+	return &types.Actor{
+		Code:    actors.RewardActorCodeCid,
+		Head:    stcid,
+		Nonce:   0,
+		Balance: supply,
+	}, nil
+}
+
+func SetupMarketActor(bs bstore.Blockstore) (*types.Actor, error) {
+	cst := hamt.CSTFromBstore(bs)
+	nd := hamt.NewNode(cst)
+	emptyhamt, err := cst.Put(context.TODO(), nd)
+	if err != nil {
+		return nil, err
+	}
+
+	emptyamt, err := amt.FromArray(amt.WrapBlockstore(bs), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	mst := &actors.StorageMarketState{
+		Balances:           emptyhamt,
+		Deals:              emptyamt,
+		NextDealID:         0,
+		PublishedProposals: emptyhamt,
+	}
+
+	stcid, err := cst.Put(context.TODO(), mst)
+	if err != nil {
+		return nil, err
+	}
+
+	return &types.Actor{
+		Code:    actors.MarketActorCodeCid,
 		Head:    stcid,
 		Nonce:   0,
 		Balance: types.NewInt(0),
@@ -183,7 +267,7 @@ func mustEnc(i cbg.CBORMarshaler) []byte {
 }
 
 func SetupStorageMiners(ctx context.Context, cs *store.ChainStore, sroot cid.Cid, gmcfg *GenMinerCfg) (cid.Cid, error) {
-	vm, err := vm.NewVM(sroot, 0, nil, actors.NetworkAddress, cs.Blockstore())
+	vm, err := vm.NewVM(sroot, 0, nil, actors.NetworkAddress, cs.Blockstore(), types.NetworkVersion0)
 	if err != nil {
 		return cid.Undef, xerrors.Errorf("failed to create NewVM: %w", err)
 	}
@@ -202,7 +286,7 @@ func SetupStorageMiners(ctx context.Context, cs *store.ChainStore, sroot cid.Cid
 
 		// TODO: hardcoding 7000000 here is a little fragile, it changes any
 		// time anyone changes the initial account allocations
-		rval, err := doExecValue(ctx, vm, actors.StorageMarketAddress, owner, types.FromFil(6500), actors.SPAMethods.CreateStorageMiner, params)
+		rval, err := doExecValue(ctx, vm, actors.StoragePowerAddress, owner, types.FromFil(6500), actors.SPAMethods.CreateStorageMiner, params)
 		if err != nil {
 			return cid.Undef, xerrors.Errorf("failed to create genesis miner: %w", err)
 		}
@@ -216,7 +300,7 @@ func SetupStorageMiners(ctx context.Context, cs *store.ChainStore, sroot cid.Cid
 
 		params = mustEnc(&actors.UpdateStorageParams{Delta: types.NewInt(5000)})
 
-		_, err = doExec(ctx, vm, actors.StorageMarketAddress, maddr, actors.SPAMethods.UpdateStorage, params)
+		_, err = doExec(ctx, vm, actors.StoragePowerAddress, maddr, actors.SPAMethods.UpdateStorage, params)
 		if err != nil {
 			return cid.Undef, xerrors.Errorf("failed to update total storage: %w", err)
 		}
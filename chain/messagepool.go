@@ -1,14 +1,21 @@
 package chain
 
 import (
+	"context"
 	"fmt"
+	"sort"
 	"sync"
 
+	"github.com/ipfs/go-cid"
+	hamt "github.com/ipfs/go-hamt-ipld"
 	pubsub "github.com/libp2p/go-libp2p-pubsub"
 	"github.com/pkg/errors"
+	lps "github.com/whyrusleeping/pubsub"
 	"golang.org/x/xerrors"
 
+	"github.com/filecoin-project/go-lotus/api"
 	"github.com/filecoin-project/go-lotus/chain/address"
+	"github.com/filecoin-project/go-lotus/chain/state"
 	"github.com/filecoin-project/go-lotus/chain/stmgr"
 	"github.com/filecoin-project/go-lotus/chain/types"
 )
@@ -23,21 +30,119 @@ var (
 	ErrNotEnoughFunds = fmt.Errorf("not enough funds to execute transaction")
 
 	ErrInvalidToAddr = fmt.Errorf("message had invalid to address")
+
+	ErrGasPriceTooLow = fmt.Errorf("replacement message gas price too low")
 )
 
+const localUpdates = "update"
+
+// Provider gives the pool access to the chain and the network
+type Provider interface {
+	SubscribeHeadChanges(func(rev, app []*types.TipSet) error)
+	PutMessage(m *types.SignedMessage) (cid.Cid, error)
+	PubSubPublish(topic string, data []byte) error
+	HeadState() (ActorState, error)
+	MessagesForBlock(b *types.BlockHeader) ([]*types.Message, []*types.SignedMessage, error)
+}
+
+// ActorState looks up actors in a state tree
+type ActorState interface {
+	GetActor(addr address.Address) (*types.Actor, error)
+}
+
+type mpoolProvider struct {
+	sm *stmgr.StateManager
+	ps *pubsub.PubSub
+
+	// headTs and headRoot cache the state root of the heaviest tipset, so
+	// that it is only computed once per head change
+	headLk   sync.Mutex
+	headTs   *types.TipSet
+	headRoot cid.Cid
+}
+
+// SubscribeHeadChanges computes the state of each new head before passing the
+// change on to cb
+func (mpp *mpoolProvider) SubscribeHeadChanges(cb func(rev, app []*types.TipSet) error) {
+	mpp.sm.ChainStore().SubscribeHeadChanges(func(rev, app []*types.TipSet) error {
+		if len(app) > 0 {
+			if _, err := mpp.headStateRoot(app[len(app)-1]); err != nil {
+				log.Warnf("computing state of new head: %s", err)
+			}
+		}
+		return cb(rev, app)
+	})
+}
+
+func (mpp *mpoolProvider) PutMessage(m *types.SignedMessage) (cid.Cid, error) {
+	return mpp.sm.ChainStore().PutMessage(m)
+}
+
+func (mpp *mpoolProvider) PubSubPublish(topic string, data []byte) error {
+	return mpp.ps.Publish(topic, data)
+}
+
+// HeadState loads the state resulting from executing the heaviest tipset, so
+// that messages included in it are already accounted for
+func (mpp *mpoolProvider) HeadState() (ActorState, error) {
+	st, err := mpp.headStateRoot(mpp.sm.ChainStore().GetHeaviestTipSet())
+	if err != nil {
+		return nil, err
+	}
+
+	cst := hamt.CSTFromBstore(mpp.sm.ChainStore().Blockstore())
+	return state.LoadStateTree(cst, st)
+}
+
+// headStateRoot returns the state root of ts, reusing the cached root if ts is
+// the cached head
+func (mpp *mpoolProvider) headStateRoot(ts *types.TipSet) (cid.Cid, error) {
+	mpp.headLk.Lock()
+	defer mpp.headLk.Unlock()
+
+	if mpp.headTs != nil && mpp.headTs.Equals(ts) {
+		return mpp.headRoot, nil
+	}
+
+	st, _, err := mpp.sm.TipSetState(context.TODO(), ts)
+	if err != nil {
+		return cid.Undef, xerrors.Errorf("computing head state: %w", err)
+	}
+
+	mpp.headTs = ts
+	mpp.headRoot = st
+	return st, nil
+}
+
+func (mpp *mpoolProvider) MessagesForBlock(b *types.BlockHeader) ([]*types.Message, []*types.SignedMessage, error) {
+	return mpp.sm.ChainStore().MessagesForBlock(b)
+}
+
 type MessagePool struct {
 	lk sync.Mutex
 
 	pending      map[address.Address]*msgSet
 	pendingCount int
 
-	sm *stmgr.StateManager
-
-	ps *pubsub.PubSub
+	api Provider
 
 	minGasPrice types.BigInt
 
 	maxTxPoolSize int
+
+	changes *lps.PubSub
+	// queued holds the updates made while lk is held, they are published by
+	// unlock so that subscribers can call back into the pool
+	queued []api.MpoolUpdate
+
+	stats mpoolStats
+}
+
+// mpoolStats counts messages which left the pool since it was created
+type mpoolStats struct {
+	included    uint64
+	prunedNonce uint64
+	prunedFunds uint64
 }
 
 type msgSet struct {
@@ -52,34 +157,57 @@ func newMsgSet() *msgSet {
 	}
 }
 
-func (ms *msgSet) add(m *types.SignedMessage) error {
+// add inserts m into the set. If a different message with the same nonce is
+// already present, m replaces it only when it offers a higher gas price; the
+// replaced message is returned so that subscribers can be notified.
+func (ms *msgSet) add(m *types.SignedMessage) (*types.SignedMessage, error) {
+	exms, has := ms.msgs[m.Message.Nonce]
+	if has {
+		if m.Cid() == exms.Cid() {
+			return nil, nil
+		}
+		if !m.Message.GasPrice.GreaterThan(exms.Message.GasPrice) {
+			log.Info("add with duplicate nonce")
+			return nil, xerrors.Errorf("message from %s with nonce %d already in mpool: %w", m.Message.From, m.Message.Nonce, ErrGasPriceTooLow)
+		}
+	}
+
 	if len(ms.msgs) == 0 || m.Message.Nonce >= ms.nextNonce {
 		ms.nextNonce = m.Message.Nonce + 1
 	}
-	if _, has := ms.msgs[m.Message.Nonce]; has {
-		if m.Cid() != ms.msgs[m.Message.Nonce].Cid() {
-			log.Error("Add with duplicate nonce")
-			return xerrors.Errorf("message to %s with nonce %d already in mpool")
-		}
-	}
 	ms.msgs[m.Message.Nonce] = m
 
-	return nil
+	return exms, nil
 }
 
 func NewMessagePool(sm *stmgr.StateManager, ps *pubsub.PubSub) *MessagePool {
+	return newMessagePool(&mpoolProvider{sm: sm, ps: ps})
+}
+
+func newMessagePool(api Provider) *MessagePool {
 	mp := &MessagePool{
 		pending:       make(map[address.Address]*msgSet),
-		sm:            sm,
-		ps:            ps,
+		api:           api,
 		minGasPrice:   types.NewInt(0),
 		maxTxPoolSize: 100000,
+		changes:       lps.New(50),
 	}
-	sm.ChainStore().SubscribeHeadChanges(mp.HeadChange)
+	api.SubscribeHeadChanges(mp.HeadChange)
 
 	return mp
 }
 
+// unlock releases mp.lk and publishes the updates made while it was held
+func (mp *MessagePool) unlock() {
+	updates := mp.queued
+	mp.queued = nil
+	mp.lk.Unlock()
+
+	for _, u := range updates {
+		mp.changes.Pub(u, localUpdates)
+	}
+}
+
 func (mp *MessagePool) Push(m *types.SignedMessage) error {
 	msgb, err := m.Serialize()
 	if err != nil {
@@ -90,7 +218,7 @@ func (mp *MessagePool) Push(m *types.SignedMessage) error {
 		return err
 	}
 
-	return mp.ps.Publish("/fil/messages", msgb)
+	return mp.api.PubSubPublish("/fil/messages", msgb)
 }
 
 func (mp *MessagePool) Add(m *types.SignedMessage) error {
@@ -131,7 +259,7 @@ func (mp *MessagePool) Add(m *types.SignedMessage) error {
 	}
 
 	mp.lk.Lock()
-	defer mp.lk.Unlock()
+	defer mp.unlock()
 
 	return mp.addLocked(m)
 }
@@ -139,7 +267,7 @@ func (mp *MessagePool) Add(m *types.SignedMessage) error {
 func (mp *MessagePool) addLocked(m *types.SignedMessage) error {
 	log.Debugf("mpooladd: %s %s", m.Message.From, m.Message.Nonce)
 
-	if _, err := mp.sm.ChainStore().PutMessage(m); err != nil {
+	if _, err := mp.api.PutMessage(m); err != nil {
 		log.Warnf("mpooladd cs.PutMessage failed: %s", err)
 		return err
 	}
@@ -150,7 +278,22 @@ func (mp *MessagePool) addLocked(m *types.SignedMessage) error {
 		mp.pending[m.Message.From] = mset
 	}
 
-	mset.add(m)
+	replaced, err := mset.add(m)
+	if err != nil {
+		return err
+	}
+
+	if replaced != nil {
+		mp.queued = append(mp.queued, api.MpoolUpdate{
+			Type:    api.MpoolRemove,
+			Message: replaced,
+		})
+	}
+
+	mp.queued = append(mp.queued, api.MpoolUpdate{
+		Type:    api.MpoolAdd,
+		Message: m,
+	})
 	return nil
 }
 
@@ -171,7 +314,12 @@ func (mp *MessagePool) getNonceLocked(addr address.Address) (uint64, error) {
 }
 
 func (mp *MessagePool) getStateNonce(addr address.Address) (uint64, error) {
-	act, err := mp.sm.GetActor(addr, nil)
+	st, err := mp.api.HeadState()
+	if err != nil {
+		return 0, err
+	}
+
+	act, err := st.GetActor(addr)
 	if err != nil {
 		return 0, err
 	}
@@ -180,7 +328,12 @@ func (mp *MessagePool) getStateNonce(addr address.Address) (uint64, error) {
 }
 
 func (mp *MessagePool) getStateBalance(addr address.Address) (types.BigInt, error) {
-	act, err := mp.sm.GetActor(addr, nil)
+	st, err := mp.api.HeadState()
+	if err != nil {
+		return types.EmptyInt, err
+	}
+
+	act, err := st.GetActor(addr)
 	if err != nil {
 		return types.EmptyInt, err
 	}
@@ -190,7 +343,7 @@ func (mp *MessagePool) getStateBalance(addr address.Address) (types.BigInt, erro
 
 func (mp *MessagePool) PushWithNonce(addr address.Address, cb func(uint64) (*types.SignedMessage, error)) (*types.SignedMessage, error) {
 	mp.lk.Lock()
-	defer mp.lk.Unlock()
+	defer mp.unlock()
 
 	nonce, err := mp.getNonceLocked(addr)
 	if err != nil {
@@ -211,25 +364,45 @@ func (mp *MessagePool) PushWithNonce(addr address.Address, cb func(uint64) (*typ
 		return nil, err
 	}
 
-	return msg, mp.ps.Publish("/fil/messages", msgb)
+	return msg, mp.api.PubSubPublish("/fil/messages", msgb)
 }
 
 func (mp *MessagePool) Remove(from address.Address, nonce uint64) {
 	mp.lk.Lock()
-	defer mp.lk.Unlock()
+	defer mp.unlock()
 
+	if mp.removeLocked(from, nonce) {
+		mp.stats.included++
+	}
+}
+
+// removeLocked drops the message with the given nonce from the sender's set,
+// returning false if there was no such message
+func (mp *MessagePool) removeLocked(from address.Address, nonce uint64) bool {
 	mset, ok := mp.pending[from]
 	if !ok {
-		return
+		return false
 	}
 
+	m, ok := mset.msgs[nonce]
+	if !ok {
+		return false
+	}
+
+	mp.queued = append(mp.queued, api.MpoolUpdate{
+		Type:    api.MpoolRemove,
+		Message: m,
+	})
+
 	// NB: This deletes any message with the given nonce. This makes sense
 	// as two messages with the same sender cannot have the same nonce
 	delete(mset.msgs, nonce)
 
 	if len(mset.msgs) == 0 {
-		// FIXME: This is racy
-		//delete(mp.pending, from)
+		// Nonces are looked up in the state of the heaviest tipset once the
+		// sender has no pending messages left, which already accounts for
+		// every message included so far
+		delete(mp.pending, from)
 	} else {
 		var max uint64
 		for nonce := range mset.msgs {
@@ -239,6 +412,8 @@ func (mp *MessagePool) Remove(from address.Address, nonce uint64) {
 		}
 		mset.nextNonce = max + 1
 	}
+
+	return true
 }
 
 func (mp *MessagePool) Pending() []*types.SignedMessage {
@@ -263,25 +438,66 @@ func (mp *MessagePool) Pending() []*types.SignedMessage {
 	return out
 }
 
+// PendingFor returns the pending messages of a sender, ordered by nonce
+func (mp *MessagePool) PendingFor(a address.Address) []*types.SignedMessage {
+	mp.lk.Lock()
+	defer mp.lk.Unlock()
+
+	mset, ok := mp.pending[a]
+	if !ok {
+		return nil
+	}
+
+	out := make([]*types.SignedMessage, 0, len(mset.msgs))
+	for _, m := range mset.msgs {
+		out = append(out, m)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].Message.Nonce < out[j].Message.Nonce
+	})
+
+	return out
+}
+
 func (mp *MessagePool) HeadChange(revert []*types.TipSet, apply []*types.TipSet) error {
+	rmsgs := make(map[address.Address]map[uint64]*types.SignedMessage)
+	add := func(m *types.SignedMessage) {
+		s, ok := rmsgs[m.Message.From]
+		if !ok {
+			s = make(map[uint64]*types.SignedMessage)
+			rmsgs[m.Message.From] = s
+		}
+		s[m.Message.Nonce] = m
+	}
+	rm := func(from address.Address, nonce uint64) {
+		s, ok := rmsgs[from]
+		if !ok {
+			mp.Remove(from, nonce)
+			return
+		}
+
+		if _, ok := s[nonce]; ok {
+			delete(s, nonce)
+			return
+		}
+
+		mp.Remove(from, nonce)
+	}
+
 	for _, ts := range revert {
 		for _, b := range ts.Blocks() {
-			bmsgs, smsgs, err := mp.sm.ChainStore().MessagesForBlock(b)
+			bmsgs, smsgs, err := mp.api.MessagesForBlock(b)
 			if err != nil {
 				return errors.Wrapf(err, "failed to get messages for revert block %s(height %d)", b.Cid(), b.Height)
 			}
 			for _, msg := range smsgs {
-				if err := mp.Add(msg); err != nil {
-					return err
-				}
+				add(msg)
 			}
 
 			for _, msg := range bmsgs {
 				smsg := mp.RecoverSig(msg)
 				if smsg != nil {
-					if err := mp.Add(smsg); err != nil {
-						return err
-					}
+					add(smsg)
 				} else {
 					log.Warnf("could not recover signature for bls message %s during a reorg revert", msg.Cid())
 				}
@@ -291,16 +507,86 @@ func (mp *MessagePool) HeadChange(revert []*types.TipSet, apply []*types.TipSet)
 
 	for _, ts := range apply {
 		for _, b := range ts.Blocks() {
-			bmsgs, smsgs, err := mp.sm.ChainStore().MessagesForBlock(b)
+			bmsgs, smsgs, err := mp.api.MessagesForBlock(b)
 			if err != nil {
 				return errors.Wrapf(err, "failed to get messages for apply block %s(height %d) (msgroot = %s)", b.Cid(), b.Height, b.Messages)
 			}
 			for _, msg := range smsgs {
-				mp.Remove(msg.Message.From, msg.Message.Nonce)
+				rm(msg.Message.From, msg.Message.Nonce)
 			}
 
 			for _, msg := range bmsgs {
-				mp.Remove(msg.From, msg.Nonce)
+				rm(msg.From, msg.Nonce)
+			}
+		}
+	}
+
+	// messages from reverted blocks which weren't included again are put back
+	// into the pool, unless they are no longer valid against the new head
+	for _, s := range rmsgs {
+		for _, msg := range s {
+			if err := mp.Add(msg); err != nil {
+				log.Warnf("failed to readd message %s from reverted block: %s", msg.Cid(), err)
+			}
+		}
+	}
+
+	return mp.revalidate()
+}
+
+// revalidate checks every pending message against the state of the heaviest
+// tipset, dropping messages whose nonce was already used on chain, and from
+// the first message their sender can no longer afford on
+func (mp *MessagePool) revalidate() error {
+	st, err := mp.api.HeadState()
+	if err != nil {
+		return xerrors.Errorf("revalidating mpool: %w", err)
+	}
+
+	mp.lk.Lock()
+	defer mp.unlock()
+
+	for from, mset := range mp.pending {
+		act, err := st.GetActor(from)
+		if xerrors.Is(err, types.ErrActorNotFound) {
+			// e.g. after a reorg dropped the message creating the sender,
+			// without an actor it has nothing to pay with
+			for nonce := range mset.msgs {
+				mp.removeLocked(from, nonce)
+				mp.stats.prunedFunds++
+			}
+			continue
+		}
+		if err != nil {
+			log.Warnf("revalidating mpool: failed to load actor %s: %s", from, err)
+			continue
+		}
+
+		nonces := make([]uint64, 0, len(mset.msgs))
+		for nonce := range mset.msgs {
+			nonces = append(nonces, nonce)
+		}
+		sort.Slice(nonces, func(i, j int) bool {
+			return nonces[i] < nonces[j]
+		})
+
+		required := types.NewInt(0)
+		for i, nonce := range nonces {
+			if nonce < act.Nonce {
+				mp.removeLocked(from, nonce)
+				mp.stats.prunedNonce++
+				continue
+			}
+
+			required = types.BigAdd(required, mset.msgs[nonce].Message.RequiredFunds())
+			if act.Balance.LessThan(required) {
+				// messages after this one can't be included without it, so
+				// they are dropped as well instead of leaving a nonce gap
+				for _, nonce := range nonces[i:] {
+					mp.removeLocked(from, nonce)
+					mp.stats.prunedFunds++
+				}
+				break
 			}
 		}
 	}
@@ -308,6 +594,50 @@ func (mp *MessagePool) HeadChange(revert []*types.TipSet, apply []*types.TipSet)
 	return nil
 }
 
+func (mp *MessagePool) Stat() *api.MpoolStat {
+	mp.lk.Lock()
+	defer mp.lk.Unlock()
+
+	out := &api.MpoolStat{
+		Senders:     len(mp.pending),
+		Included:    mp.stats.included,
+		PrunedNonce: mp.stats.prunedNonce,
+		PrunedFunds: mp.stats.prunedFunds,
+	}
+	for _, mset := range mp.pending {
+		out.Pending += len(mset.msgs)
+	}
+
+	return out
+}
+
+// Updates returns a channel with a notification for each message added to or
+// removed from the pool. The channel is closed when ctx is cancelled.
+func (mp *MessagePool) Updates(ctx context.Context) (<-chan api.MpoolUpdate, error) {
+	out := make(chan api.MpoolUpdate, 20)
+	sub := mp.changes.Sub(localUpdates)
+
+	go func() {
+		defer close(out)
+		defer mp.changes.Unsub(sub, localUpdates)
+
+		for {
+			select {
+			case u := <-sub:
+				select {
+				case out <- u.(api.MpoolUpdate):
+				case <-ctx.Done():
+					return
+				}
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return out, nil
+}
+
 func (mp *MessagePool) RecoverSig(msg *types.Message) *types.SignedMessage {
 	// TODO: persist signatures for BLS messages for a little while in case of reorgs
 	return nil
@@ -0,0 +1,308 @@
+package chain
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/filecoin-project/go-lotus/api"
+	"github.com/filecoin-project/go-lotus/chain/address"
+	"github.com/filecoin-project/go-lotus/chain/types"
+	"github.com/filecoin-project/go-lotus/chain/wallet"
+	"github.com/filecoin-project/go-lotus/node/repo"
+)
+
+type testMpoolAPI struct {
+	lk     sync.Mutex
+	actors map[address.Address]types.Actor
+}
+
+func newTestMpoolAPI() *testMpoolAPI {
+	return &testMpoolAPI{
+		actors: map[address.Address]types.Actor{},
+	}
+}
+
+func (tma *testMpoolAPI) setActor(addr address.Address, nonce uint64, balance uint64) {
+	tma.lk.Lock()
+	defer tma.lk.Unlock()
+
+	tma.actors[addr] = types.Actor{
+		Nonce:   nonce,
+		Balance: types.NewInt(balance),
+	}
+}
+
+func (tma *testMpoolAPI) removeActor(addr address.Address) {
+	tma.lk.Lock()
+	defer tma.lk.Unlock()
+
+	delete(tma.actors, addr)
+}
+
+func (tma *testMpoolAPI) SubscribeHeadChanges(func(rev, app []*types.TipSet) error) {}
+
+func (tma *testMpoolAPI) PutMessage(m *types.SignedMessage) (cid.Cid, error) {
+	return m.Cid(), nil
+}
+
+func (tma *testMpoolAPI) PubSubPublish(string, []byte) error {
+	return nil
+}
+
+func (tma *testMpoolAPI) HeadState() (ActorState, error) {
+	return tma, nil
+}
+
+func (tma *testMpoolAPI) GetActor(addr address.Address) (*types.Actor, error) {
+	tma.lk.Lock()
+	defer tma.lk.Unlock()
+
+	act, ok := tma.actors[addr]
+	if !ok {
+		return nil, types.ErrActorNotFound
+	}
+	return &act, nil
+}
+
+func (tma *testMpoolAPI) MessagesForBlock(*types.BlockHeader) ([]*types.Message, []*types.SignedMessage, error) {
+	return nil, nil, nil
+}
+
+func testWallet(t *testing.T) *wallet.Wallet {
+	lr, err := repo.NewMemory(nil).Lock()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ks, err := lr.KeyStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mds, err := lr.Datastore("/metadata")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := wallet.NewWallet(ks, mds)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return w
+}
+
+func mustSignMessage(t *testing.T, w *wallet.Wallet, from address.Address, nonce uint64, value uint64, gasPrice uint64) *types.SignedMessage {
+	to, err := address.NewIDAddress(1001)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	msg := types.Message{
+		To:       to,
+		From:     from,
+		Nonce:    nonce,
+		Value:    types.NewInt(value),
+		GasPrice: types.NewInt(gasPrice),
+		GasLimit: types.NewInt(1),
+	}
+
+	sig, err := w.Sign(context.TODO(), from, msg.Cid().Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &types.SignedMessage{
+		Message:   msg,
+		Signature: *sig,
+	}
+}
+
+func nextUpdate(t *testing.T, updates <-chan api.MpoolUpdate) api.MpoolUpdate {
+	t.Helper()
+
+	select {
+	case u, ok := <-updates:
+		if !ok {
+			t.Fatal("updates channel closed")
+		}
+		return u
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for mpool update")
+	}
+	return api.MpoolUpdate{}
+}
+
+func TestMessagePoolUpdates(t *testing.T) {
+	tma := newTestMpoolAPI()
+	w := testWallet(t)
+
+	sender, err := w.GenerateKey(types.KTSecp256k1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tma.setActor(sender, 0, 1000000)
+
+	mp := newMessagePool(tma)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	updates, err := mp.Updates(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	m0 := mustSignMessage(t, w, sender, 0, 1, 1)
+	if err := mp.Add(m0); err != nil {
+		t.Fatal(err)
+	}
+
+	u := nextUpdate(t, updates)
+	if u.Type != api.MpoolAdd || u.Message.Cid() != m0.Cid() {
+		t.Fatalf("expected add of %s, got %d of %s", m0.Cid(), u.Type, u.Message.Cid())
+	}
+
+	// replacing a message removes the old one first
+	m0r := mustSignMessage(t, w, sender, 0, 1, 2)
+	if err := mp.Add(m0r); err != nil {
+		t.Fatal(err)
+	}
+
+	u = nextUpdate(t, updates)
+	if u.Type != api.MpoolRemove || u.Message.Cid() != m0.Cid() {
+		t.Fatalf("expected remove of %s, got %d of %s", m0.Cid(), u.Type, u.Message.Cid())
+	}
+	u = nextUpdate(t, updates)
+	if u.Type != api.MpoolAdd || u.Message.Cid() != m0r.Cid() {
+		t.Fatalf("expected add of %s, got %d of %s", m0r.Cid(), u.Type, u.Message.Cid())
+	}
+
+	mp.Remove(sender, 0)
+	u = nextUpdate(t, updates)
+	if u.Type != api.MpoolRemove || u.Message.Cid() != m0r.Cid() {
+		t.Fatalf("expected remove of %s, got %d of %s", m0r.Cid(), u.Type, u.Message.Cid())
+	}
+
+	// more updates than the subscription buffers, with the subscriber calling
+	// back into the pool while reading them
+	const n = 200
+	msgs := make([]*types.SignedMessage, n)
+	for i := range msgs {
+		msgs[i] = mustSignMessage(t, w, sender, uint64(i), 1, 1)
+	}
+
+	addErr := make(chan error, 1)
+	go func() {
+		for _, m := range msgs {
+			if err := mp.Add(m); err != nil {
+				addErr <- err
+				return
+			}
+		}
+		addErr <- nil
+	}()
+
+	for i := 0; i < n; i++ {
+		u := nextUpdate(t, updates)
+		if u.Type != api.MpoolAdd {
+			t.Fatalf("expected add, got %d", u.Type)
+		}
+		mp.Pending()
+	}
+	if err := <-addErr; err != nil {
+		t.Fatal(err)
+	}
+
+	cancel()
+	select {
+	case _, ok := <-updates:
+		if ok {
+			t.Fatal("expected no more updates")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("updates channel not closed after cancel")
+	}
+}
+
+func pendingNonces(mp *MessagePool, from address.Address) []uint64 {
+	var out []uint64
+	for _, m := range mp.Pending() {
+		if m.Message.From == from {
+			out = append(out, m.Message.Nonce)
+		}
+	}
+	return out
+}
+
+func TestMessagePoolRevalidate(t *testing.T) {
+	tma := newTestMpoolAPI()
+	w := testWallet(t)
+
+	sender, err := w.GenerateKey(types.KTSecp256k1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tma.setActor(sender, 0, 100)
+
+	mp := newMessagePool(tma)
+
+	for i, value := range []uint64{10, 10, 50, 5, 5} {
+		if err := mp.Add(mustSignMessage(t, w, sender, uint64(i), value, 0)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// messages included on chain are pruned by nonce
+	tma.setActor(sender, 1, 90)
+	if err := mp.HeadChange(nil, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, []uint64{1, 2, 3, 4}, pendingNonces(mp, sender))
+	assert.Equal(t, uint64(1), mp.Stat().PrunedNonce)
+
+	// a balance drop prunes the first unaffordable message and everything
+	// after it, even messages which would be affordable on their own
+	tma.setActor(sender, 1, 40)
+	if err := mp.HeadChange(nil, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Equal(t, []uint64{1}, pendingNonces(mp, sender))
+	assert.Equal(t, uint64(3), mp.Stat().PrunedFunds)
+
+	nonce, err := mp.GetNonce(sender)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(2), nonce, "next nonce follows the remaining messages")
+
+	// the sender can fill the gap again
+	assert.NoError(t, mp.Add(mustSignMessage(t, w, sender, 2, 5, 0)))
+	assert.Equal(t, []uint64{1, 2}, pendingNonces(mp, sender))
+
+	// once everything is unaffordable the nonce comes from the state again
+	tma.setActor(sender, 1, 1)
+	if err := mp.HeadChange(nil, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Empty(t, pendingNonces(mp, sender))
+	nonce, err = mp.GetNonce(sender)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1), nonce)
+
+	// a sender without an actor in the head state can't pay for anything
+	assert.NoError(t, mp.Add(mustSignMessage(t, w, sender, 1, 0, 0)))
+	pruned := mp.Stat().PrunedFunds
+
+	tma.removeActor(sender)
+	if err := mp.HeadChange(nil, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Empty(t, pendingNonces(mp, sender))
+	assert.Equal(t, pruned+1, mp.Stat().PrunedFunds)
+}
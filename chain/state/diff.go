@@ -0,0 +1,188 @@
+package state
+
+import (
+	"bytes"
+	"context"
+
+	"github.com/ipfs/go-cid"
+	hamt "github.com/ipfs/go-hamt-ipld"
+	cbg "github.com/whyrusleeping/cbor-gen"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-lotus/chain/address"
+	"github.com/filecoin-project/go-lotus/chain/types"
+)
+
+// ActorDiffFunc is called for every actor that differs between two state
+// trees. oldAct is nil for created actors, newAct is nil for deleted ones
+type ActorDiffFunc func(addr address.Address, oldAct, newAct *types.Actor) error
+
+// DiffStateTrees walks the state trees at oldRoot and newRoot in parallel and
+// calls cb for each actor that was created, deleted or changed. Subtrees that
+// are shared between the two trees are skipped without being loaded
+func DiffStateTrees(ctx context.Context, cst *hamt.CborIpldStore, oldRoot, newRoot cid.Cid, cb ActorDiffFunc) error {
+	if oldRoot == newRoot {
+		return nil
+	}
+
+	oldNd, err := hamt.LoadNode(ctx, cst, oldRoot)
+	if err != nil {
+		return xerrors.Errorf("loading old state tree: %w", err)
+	}
+
+	newNd, err := hamt.LoadNode(ctx, cst, newRoot)
+	if err != nil {
+		return xerrors.Errorf("loading new state tree: %w", err)
+	}
+
+	return diffNodes(ctx, cst, oldNd, newNd, cb)
+}
+
+func diffNodes(ctx context.Context, cst *hamt.CborIpldStore, a, b *hamt.Node, cb ActorDiffFunc) error {
+	width := a.Bitfield.BitLen()
+	if bw := b.Bitfield.BitLen(); bw > width {
+		width = bw
+	}
+
+	for i := 0; i < width; i++ {
+		pa := pointerAt(a, i)
+		pb := pointerAt(b, i)
+
+		if pa == nil && pb == nil {
+			continue
+		}
+
+		if pa != nil && pb != nil && pa.Link.Defined() && pb.Link.Defined() {
+			if pa.Link == pb.Link {
+				continue
+			}
+
+			na, err := hamt.LoadNode(ctx, cst, pa.Link)
+			if err != nil {
+				return err
+			}
+			nb, err := hamt.LoadNode(ctx, cst, pb.Link)
+			if err != nil {
+				return err
+			}
+
+			if err := diffNodes(ctx, cst, na, nb, cb); err != nil {
+				return err
+			}
+			continue
+		}
+
+		// one side is a leaf bucket, compare the entries directly
+		ea, err := pointerEntries(ctx, cst, pa)
+		if err != nil {
+			return err
+		}
+		eb, err := pointerEntries(ctx, cst, pb)
+		if err != nil {
+			return err
+		}
+
+		if err := diffEntries(ea, eb, cb); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func diffEntries(a, b map[string][]byte, cb ActorDiffFunc) error {
+	for k, va := range a {
+		vb, ok := b[k]
+		if ok && bytes.Equal(va, vb) {
+			continue
+		}
+
+		if err := callDiff(k, va, vb, ok, cb); err != nil {
+			return err
+		}
+	}
+
+	for k, vb := range b {
+		if _, ok := a[k]; ok {
+			continue
+		}
+
+		if err := callDiff(k, nil, vb, true, cb); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func callDiff(k string, va, vb []byte, hasNew bool, cb ActorDiffFunc) error {
+	addr, err := address.NewFromBytes([]byte(k))
+	if err != nil {
+		return xerrors.Errorf("address in state tree was not valid: %w", err)
+	}
+
+	var oldAct, newAct *types.Actor
+	if va != nil {
+		oldAct = new(types.Actor)
+		if err := oldAct.UnmarshalCBOR(bytes.NewReader(va)); err != nil {
+			return xerrors.Errorf("decoding old actor %s: %w", addr, err)
+		}
+	}
+	if hasNew {
+		newAct = new(types.Actor)
+		if err := newAct.UnmarshalCBOR(bytes.NewReader(vb)); err != nil {
+			return xerrors.Errorf("decoding new actor %s: %w", addr, err)
+		}
+	}
+
+	return cb(addr, oldAct, newAct)
+}
+
+func pointerAt(n *hamt.Node, i int) *hamt.Pointer {
+	if n.Bitfield.Bit(i) == 0 {
+		return nil
+	}
+
+	// pointers are stored densely, index by the number of set bits below i
+	var idx int
+	for j := 0; j < i; j++ {
+		if n.Bitfield.Bit(j) == 1 {
+			idx++
+		}
+	}
+
+	return n.Pointers[idx]
+}
+
+func pointerEntries(ctx context.Context, cst *hamt.CborIpldStore, p *hamt.Pointer) (map[string][]byte, error) {
+	out := make(map[string][]byte)
+	if p == nil {
+		return out, nil
+	}
+
+	if !p.Link.Defined() {
+		for _, kv := range p.KVs {
+			out[kv.Key] = kv.Value.Raw
+		}
+		return out, nil
+	}
+
+	nd, err := hamt.LoadNode(ctx, cst, p.Link)
+	if err != nil {
+		return nil, err
+	}
+
+	err = nd.ForEach(ctx, func(k string, val interface{}) error {
+		d, ok := val.(*cbg.Deferred)
+		if !ok {
+			return xerrors.Errorf("unexpected value type in state tree: %T", val)
+		}
+		out[k] = d.Raw
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return out, nil
+}
@@ -0,0 +1,102 @@
+package state
+
+import (
+	"context"
+	"testing"
+
+	hamt "github.com/ipfs/go-hamt-ipld"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/filecoin-project/go-lotus/chain/actors"
+	"github.com/filecoin-project/go-lotus/chain/address"
+	"github.com/filecoin-project/go-lotus/chain/types"
+)
+
+func TestDiffStateTrees(t *testing.T) {
+	ctx := context.Background()
+	cst := hamt.NewCborStore()
+
+	st, err := NewStateTree(cst)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// enough actors for the hamt to grow child nodes
+	for i := uint64(100); i < 600; i++ {
+		a, err := address.NewIDAddress(i)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := st.SetActor(a, &types.Actor{
+			Balance: types.NewInt(i),
+			Code:    actors.AccountActorCodeCid,
+			Head:    actors.AccountActorCodeCid,
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	oldRoot, err := st.Flush()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	changed, _ := address.NewIDAddress(150)
+	deleted, _ := address.NewIDAddress(420)
+	created, _ := address.NewIDAddress(1000)
+
+	if err := st.MutateActor(changed, func(act *types.Actor) error {
+		act.Nonce++
+		return nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := st.root.Delete(ctx, string(deleted.Bytes())); err != nil {
+		t.Fatal(err)
+	}
+	if err := st.SetActor(created, &types.Actor{
+		Balance: types.NewInt(5),
+		Code:    actors.AccountActorCodeCid,
+		Head:    actors.AccountActorCodeCid,
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	newRoot, err := st.Flush()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type change struct {
+		old, new *types.Actor
+	}
+	diff := map[address.Address]change{}
+	err = DiffStateTrees(ctx, cst, oldRoot, newRoot, func(addr address.Address, oldAct, newAct *types.Actor) error {
+		diff[addr] = change{oldAct, newAct}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	assert.Len(t, diff, 3)
+
+	if c, ok := diff[changed]; assert.True(t, ok, "changed actor missing") {
+		assert.Equal(t, uint64(0), c.old.Nonce)
+		assert.Equal(t, uint64(1), c.new.Nonce)
+	}
+	if c, ok := diff[deleted]; assert.True(t, ok, "deleted actor missing") {
+		assert.NotNil(t, c.old)
+		assert.Nil(t, c.new)
+	}
+	if c, ok := diff[created]; assert.True(t, ok, "created actor missing") {
+		assert.Nil(t, c.old)
+		assert.Equal(t, types.NewInt(5), c.new.Balance)
+	}
+
+	err = DiffStateTrees(ctx, cst, newRoot, newRoot, func(address.Address, *types.Actor, *types.Actor) error {
+		t.Fatal("identical trees should have no changes")
+		return nil
+	})
+	assert.NoError(t, err)
+}
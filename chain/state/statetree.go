@@ -181,6 +181,7 @@ func (st *StateTree) Revert() error {
 	}
 
 	st.root = nd
+	st.actorcache = make(map[address.Address]*types.Actor)
 	return nil
 }
 
@@ -11,16 +11,25 @@ import (
 	"github.com/filecoin-project/go-lotus/chain/store"
 	"github.com/filecoin-project/go-lotus/chain/types"
 	"github.com/filecoin-project/go-lotus/chain/vm"
+	"github.com/filecoin-project/go-lotus/lib/bufbstore"
 )
 
+// callGasLimit is the gas limit given to calls and computed messages which
+// don't set one
+const callGasLimit = 10000000000
+
+// CallRaw executes msg on top of bstate, always with the sender's nonce in
+// bstate. Messages which need to run with their own nonce, like the ones the
+// gas estimator applies after the sender's pending messages, go through
+// ComputeState instead
 func (sm *StateManager) CallRaw(ctx context.Context, msg *types.Message, bstate cid.Cid, r vm.Rand, bheight uint64) (*types.MessageReceipt, error) {
-	vmi, err := vm.NewVM(bstate, bheight, r, actors.NetworkAddress, sm.cs.Blockstore())
+	vmi, err := sm.newVM(bstate, bheight, r, actors.NetworkAddress, sm.cs.Blockstore(), sm.GetNtwkVersion(bheight))
 	if err != nil {
 		return nil, xerrors.Errorf("failed to set up vm: %w", err)
 	}
 
 	if msg.GasLimit == types.EmptyInt {
-		msg.GasLimit = types.NewInt(10000000000)
+		msg.GasLimit = types.NewInt(callGasLimit)
 	}
 	if msg.GasPrice == types.EmptyInt {
 		msg.GasPrice = types.NewInt(0)
@@ -54,7 +63,21 @@ func (sm *StateManager) Call(ctx context.Context, msg *types.Message, ts *types.
 		ts = sm.cs.GetHeaviestTipSet()
 	}
 
+	// the parent state only becomes the state of ts once the upgrades between
+	// the two heights ran, otherwise the state and the network version of
+	// the call could disagree
 	state := ts.ParentState()
+	if ts.Height() > 0 {
+		pts, err := sm.cs.LoadTipSet(ts.Parents())
+		if err != nil {
+			return nil, xerrors.Errorf("loading parent tipset: %w", err)
+		}
+
+		state, err = sm.handleStateForks(ctx, sm.cs.Blockstore(), state, pts.Height(), ts.Height())
+		if err != nil {
+			return nil, xerrors.Errorf("error handling state forks: %w", err)
+		}
+	}
 
 	r := store.NewChainRand(sm.cs, ts.Cids(), ts.Height(), nil)
 
@@ -81,3 +104,68 @@ func (sm *StateManager) Replay(ctx context.Context, ts *types.TipSet, mcid cid.C
 
 	return outm, outr, nil
 }
+
+// ComputeState applies msgs, in order, on top of the state computed for ts
+// as if they were included in a block at the given height. Each message must
+// carry the correct nonce for its sender. All state changes are kept in a
+// buffer, nothing is written to the chain blockstore.
+func (sm *StateManager) ComputeState(ctx context.Context, height uint64, msgs []*types.Message, ts *types.TipSet) (cid.Cid, []*vm.ApplyRet, error) {
+	if ts == nil {
+		ts = sm.cs.GetHeaviestTipSet()
+	}
+
+	if height == 0 {
+		height = ts.Height() + 1
+	}
+	if height <= ts.Height() {
+		return cid.Undef, nil, xerrors.Errorf("height %d must be above base tipset height %d", height, ts.Height())
+	}
+
+	base, _, err := sm.TipSetState(ctx, ts)
+	if err != nil {
+		return cid.Undef, nil, xerrors.Errorf("computing base tipset state: %w", err)
+	}
+
+	buf := bufbstore.NewBufferedBstore(sm.cs.Blockstore())
+
+	// run the upgrades scheduled up to height, so that the base state matches
+	// the network version the messages are executed with
+	base, err = sm.handleStateForks(ctx, buf, base, ts.Height(), height)
+	if err != nil {
+		return cid.Undef, nil, xerrors.Errorf("error handling state forks: %w", err)
+	}
+
+	r := store.NewChainRand(sm.cs, ts.Cids(), height, nil)
+
+	vmi, err := sm.newVM(base, height, r, ts.Blocks()[0].Miner, buf, sm.GetNtwkVersion(height))
+	if err != nil {
+		return cid.Undef, nil, xerrors.Errorf("failed to set up vm: %w", err)
+	}
+
+	out := make([]*vm.ApplyRet, len(msgs))
+	for i, msg := range msgs {
+		if msg.GasLimit == types.EmptyInt {
+			msg.GasLimit = types.NewInt(callGasLimit)
+		}
+		if msg.GasPrice == types.EmptyInt {
+			msg.GasPrice = types.NewInt(0)
+		}
+		if msg.Value == types.EmptyInt {
+			msg.Value = types.NewInt(0)
+		}
+
+		ret, err := vmi.ApplyMessage(ctx, msg)
+		if err != nil {
+			return cid.Undef, nil, xerrors.Errorf("applying message %d (%s): %w", i, msg.Cid(), err)
+		}
+		out[i] = ret
+	}
+
+	// flushing only moves the new state into buf
+	root, err := vmi.Flush(ctx)
+	if err != nil {
+		return cid.Undef, nil, xerrors.Errorf("flushing vm: %w", err)
+	}
+
+	return root, out, nil
+}
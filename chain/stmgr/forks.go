@@ -0,0 +1,101 @@
+package stmgr
+
+import (
+	"context"
+
+	"github.com/ipfs/go-cid"
+	blockstore "github.com/ipfs/go-ipfs-blockstore"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-lotus/chain/address"
+	"github.com/filecoin-project/go-lotus/chain/types"
+	"github.com/filecoin-project/go-lotus/chain/vm"
+)
+
+// UpgradeFunc migrates the state tree at root to the rules of a new network
+// version, returning the new state root. All new state must be written to bs,
+// which isn't necessarily the chain blockstore
+type UpgradeFunc func(ctx context.Context, sm *StateManager, bs blockstore.Blockstore, root cid.Cid) (cid.Cid, error)
+
+// Upgrade switches the network to a new version at a given height. The
+// migration (which may be nil) runs on the parent state of the first tipset
+// at or after that height, before any of its messages are applied
+type Upgrade struct {
+	Height    uint64
+	Network   types.NetworkVersion
+	Migration UpgradeFunc
+}
+
+// UpgradeSchedule lists network upgrades ordered by height
+type UpgradeSchedule []Upgrade
+
+// DefaultUpgradeSchedule returns the upgrades of the current network
+func DefaultUpgradeSchedule() UpgradeSchedule {
+	return UpgradeSchedule{}
+}
+
+// Validate checks that both heights and network versions strictly increase
+func (us UpgradeSchedule) Validate() error {
+	for i := 1; i < len(us); i++ {
+		prev, cur := us[i-1], us[i]
+		if cur.Height <= prev.Height {
+			return xerrors.Errorf("upgrade to version %d at height %d doesn't come after upgrade at height %d", cur.Network, cur.Height, prev.Height)
+		}
+		if cur.Network <= prev.Network {
+			return xerrors.Errorf("upgrade at height %d to version %d doesn't increase network version %d", cur.Height, cur.Network, prev.Network)
+		}
+	}
+
+	return nil
+}
+
+// VMConstructor creates the VM used to execute messages
+type VMConstructor func(base cid.Cid, height uint64, r vm.Rand, maddr address.Address, cbs blockstore.Blockstore, nv types.NetworkVersion) (*vm.VM, error)
+
+// SetVMConstructor replaces the function used to create VMs. Mainly useful
+// for tests which need to run custom actor code
+func (sm *StateManager) SetVMConstructor(nvm VMConstructor) {
+	sm.newVM = nvm
+}
+
+// GetNtwkVersion returns the network version in effect at the given height
+func (sm *StateManager) GetNtwkVersion(height uint64) types.NetworkVersion {
+	return sm.upgrades.NetworkVersion(height)
+}
+
+// NetworkVersion returns the network version the schedule puts in effect at
+// the given height
+func (us UpgradeSchedule) NetworkVersion(height uint64) types.NetworkVersion {
+	nv := types.NetworkVersion0
+	for _, u := range us {
+		if u.Height > height {
+			break
+		}
+		nv = u.Network
+	}
+	return nv
+}
+
+// handleStateForks runs the migrations of all upgrades scheduled after
+// parentHeight, up to and including height, writing the migrated state to bs
+func (sm *StateManager) handleStateForks(ctx context.Context, bs blockstore.Blockstore, root cid.Cid, parentHeight, height uint64) (cid.Cid, error) {
+	for _, u := range sm.upgrades {
+		if u.Height <= parentHeight || u.Height > height {
+			continue
+		}
+
+		if u.Migration == nil {
+			continue
+		}
+
+		log.Infof("running migration to network version %d at height %d", u.Network, height)
+
+		nroot, err := u.Migration(ctx, sm, bs, root)
+		if err != nil {
+			return cid.Undef, xerrors.Errorf("upgrade to network version %d failed: %w", u.Network, err)
+		}
+		root = nroot
+	}
+
+	return root, nil
+}
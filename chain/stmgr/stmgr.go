@@ -27,15 +27,34 @@ var log = logging.Logger("statemgr")
 type StateManager struct {
 	cs *store.ChainStore
 
+	upgrades UpgradeSchedule
+	newVM    VMConstructor
+
 	stCache map[string][]cid.Cid
 	stlk    sync.Mutex
 }
 
 func NewStateManager(cs *store.ChainStore) *StateManager {
-	return &StateManager{
-		cs:      cs,
-		stCache: make(map[string][]cid.Cid),
+	sm, err := NewStateManagerWithUpgradeSchedule(cs, DefaultUpgradeSchedule())
+	if err != nil {
+		panic(err)
+	}
+	return sm
+}
+
+func NewStateManagerWithUpgradeSchedule(cs *store.ChainStore, us UpgradeSchedule) (*StateManager, error) {
+	if err := us.Validate(); err != nil {
+		return nil, xerrors.Errorf("invalid upgrade schedule: %w", err)
 	}
+
+	cs.SetNetworkVersionFunc(us.NetworkVersion)
+
+	return &StateManager{
+		cs:       cs,
+		upgrades: us,
+		newVM:    vm.NewVM,
+		stCache:  make(map[string][]cid.Cid),
+	}, nil
 }
 
 func cidsToKey(cids []cid.Cid) string {
@@ -89,99 +108,50 @@ func (sm *StateManager) computeTipSetState(ctx context.Context, blks []*types.Bl
 		cids[i] = v.Cid()
 	}
 
-	r := store.NewChainRand(sm.cs, cids, blks[0].Height, nil)
-
-	vmi, err := vm.NewVM(pstate, blks[0].Height, r, address.Undef, sm.cs.Blockstore())
+	pts, err := sm.cs.LoadTipSet(blks[0].Parents)
 	if err != nil {
-		return cid.Undef, cid.Undef, xerrors.Errorf("instantiating VM failed: %w", err)
+		return cid.Undef, cid.Undef, xerrors.Errorf("loading parent tipset: %w", err)
 	}
 
-	netact, err := vmi.StateTree().GetActor(actors.NetworkAddress)
+	pstate, err = sm.handleStateForks(ctx, sm.cs.Blockstore(), pstate, pts.Height(), blks[0].Height)
 	if err != nil {
-		return cid.Undef, cid.Undef, xerrors.Errorf("failed to get network actor: %w", err)
+		return cid.Undef, cid.Undef, xerrors.Errorf("error handling state forks: %w", err)
 	}
 
-	reward := vm.MiningReward(netact.Balance)
-	for _, b := range blks {
-		owner, err := GetMinerOwner(ctx, sm, pstate, b.Miner)
-		if err != nil {
-			return cid.Undef, cid.Undef, xerrors.Errorf("failed to get owner for miner %s: %w", b.Miner, err)
-		}
-
-		act, err := vmi.StateTree().GetActor(owner)
-		if err != nil {
-			return cid.Undef, cid.Undef, xerrors.Errorf("failed to get miner owner actor")
-		}
-
-		if err := vm.Transfer(netact, act, reward); err != nil {
-			return cid.Undef, cid.Undef, xerrors.Errorf("failed to deduct funds from network actor: %w", err)
-		}
-
-	}
-
-	// TODO: can't use method from chainstore because it doesnt let us know who the block miners were
-	applied := make(map[address.Address]uint64)
-	balances := make(map[address.Address]types.BigInt)
-
-	preloadAddr := func(a address.Address) error {
-		if _, ok := applied[a]; !ok {
-			act, err := vmi.StateTree().GetActor(a)
-			if err != nil {
-				return err
-			}
+	r := store.NewChainRand(sm.cs, cids, blks[0].Height, nil)
 
-			applied[a] = act.Nonce
-			balances[a] = act.Balance
-		}
-		return nil
+	vmi, err := sm.newVM(pstate, blks[0].Height, r, address.Undef, sm.cs.Blockstore(), sm.GetNtwkVersion(blks[0].Height))
+	if err != nil {
+		return cid.Undef, cid.Undef, xerrors.Errorf("instantiating VM failed: %w", err)
 	}
 
-	var receipts []cbg.CBORMarshaler
-	for _, b := range blks {
-		vmi.SetBlockMiner(b.Miner)
-
-		bms, sms, err := sm.cs.MessagesForBlock(b)
+	bms := make([]BlockMessages, len(blks))
+	for i, b := range blks {
+		blsMsgs, secpkMsgs, err := sm.cs.MessagesForBlock(b)
 		if err != nil {
 			return cid.Undef, cid.Undef, xerrors.Errorf("failed to get messages for block: %w", err)
 		}
 
-		cmsgs := make([]store.ChainMsg, 0, len(bms)+len(sms))
-		for _, m := range bms {
-			cmsgs = append(cmsgs, m)
+		bms[i] = BlockMessages{
+			Miner:    b.Miner,
+			Messages: make([]store.ChainMsg, 0, len(blsMsgs)+len(secpkMsgs)),
 		}
-		for _, sm := range sms {
-			cmsgs = append(cmsgs, sm)
+		for _, m := range blsMsgs {
+			bms[i].Messages = append(bms[i].Messages, m)
 		}
+		for _, m := range secpkMsgs {
+			bms[i].Messages = append(bms[i].Messages, m)
+		}
+	}
 
-		for _, cm := range cmsgs {
-			m := cm.VMMessage()
-			if err := preloadAddr(m.From); err != nil {
-				return cid.Undef, cid.Undef, err
-			}
-
-			if applied[m.From] != m.Nonce {
-				continue
-			}
-			applied[m.From]++
-
-			if balances[m.From].LessThan(m.RequiredFunds()) {
-				continue
-			}
-			balances[m.From] = types.BigSub(balances[m.From], m.RequiredFunds())
-
-			r, err := vmi.ApplyMessage(ctx, m)
-			if err != nil {
-				return cid.Undef, cid.Undef, err
-			}
-
-			receipts = append(receipts, &r.MessageReceipt)
+	rets, err := ApplyBlocks(ctx, vmi, bms, cb)
+	if err != nil {
+		return cid.Undef, cid.Undef, err
+	}
 
-			if cb != nil {
-				if err := cb(cm.Cid(), m, r); err != nil {
-					return cid.Undef, cid.Undef, err
-				}
-			}
-		}
+	receipts := make([]cbg.CBORMarshaler, len(rets))
+	for i, r := range rets {
+		receipts[i] = &r.MessageReceipt
 	}
 
 	bs := amt.WrapBlockstore(sm.cs.Blockstore())
@@ -240,6 +210,23 @@ func (sm *StateManager) LoadActorState(ctx context.Context, a address.Address, o
 
 	return act, nil
 }
+
+// LookupID resolves addr to the ID address of its actor through the init
+// actor in the parent state of ts
+func (sm *StateManager) LookupID(ctx context.Context, addr address.Address, ts *types.TipSet) (address.Address, error) {
+	if addr.Protocol() == address.ID {
+		return addr, nil
+	}
+
+	var ias actors.InitActorState
+	if _, err := sm.LoadActorState(ctx, actors.InitActorAddress, &ias, ts); err != nil {
+		return address.Undef, xerrors.Errorf("loading init actor state: %w", err)
+	}
+
+	cst := hamt.CSTFromBstore(sm.cs.Blockstore())
+	return ias.Lookup(cst, addr)
+}
+
 func (sm *StateManager) ResolveToKeyAddress(ctx context.Context, addr address.Address, ts *types.TipSet) (address.Address, error) {
 	switch addr.Protocol() {
 	case address.BLS, address.SECP256K1:
@@ -459,3 +446,113 @@ func (sm *StateManager) ListAllActors(ctx context.Context, ts *types.TipSet) ([]
 
 	return out, nil
 }
+
+// BlockMessages are the messages of a single block in a tipset
+type BlockMessages struct {
+	Miner    address.Address
+	Messages []store.ChainMsg
+}
+
+// ApplyBlocks awards the block rewards of a tipset and then applies the
+// messages of its blocks in order. Messages with an unexpected nonce, or whose
+// sender can't cover them, are skipped like duplicates across blocks
+func ApplyBlocks(ctx context.Context, vmi *vm.VM, bms []BlockMessages, cb func(cid.Cid, *types.Message, *vm.ApplyRet) error) ([]*vm.ApplyRet, error) {
+	for _, b := range bms {
+		if err := awardBlockReward(ctx, vmi, b.Miner); err != nil {
+			return nil, xerrors.Errorf("failed to award block reward to %s: %w", b.Miner, err)
+		}
+	}
+
+	// TODO: can't use method from chainstore because it doesnt let us know who the block miners were
+	applied := make(map[address.Address]uint64)
+	balances := make(map[address.Address]types.BigInt)
+
+	preloadAddr := func(a address.Address) error {
+		if _, ok := applied[a]; !ok {
+			act, err := vmi.StateTree().GetActor(a)
+			if err != nil {
+				return err
+			}
+
+			applied[a] = act.Nonce
+			balances[a] = act.Balance
+		}
+		return nil
+	}
+
+	var out []*vm.ApplyRet
+	for _, b := range bms {
+		vmi.SetBlockMiner(b.Miner)
+
+		for _, cm := range b.Messages {
+			m := cm.VMMessage()
+			if err := preloadAddr(m.From); err != nil {
+				return nil, err
+			}
+
+			if applied[m.From] != m.Nonce {
+				continue
+			}
+			applied[m.From]++
+
+			if balances[m.From].LessThan(m.RequiredFunds()) {
+				continue
+			}
+			balances[m.From] = types.BigSub(balances[m.From], m.RequiredFunds())
+
+			r, err := vmi.ApplyMessage(ctx, m)
+			if err != nil {
+				return nil, err
+			}
+
+			out = append(out, r)
+
+			if cb != nil {
+				if err := cb(cm.Cid(), m, r); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+
+	return out, nil
+}
+
+// awardBlockReward has the reward actor pay the block reward for a block mined
+// by maddr, through an implicit message sent by the network actor. Only VM
+// errors are returned, the reward actor failing is logged
+func awardBlockReward(ctx context.Context, vmi *vm.VM, maddr address.Address) error {
+	netact, err := vmi.StateTree().GetActor(actors.NetworkAddress)
+	if err != nil {
+		return xerrors.Errorf("failed to get network actor: %w", err)
+	}
+
+	enc, aerr := actors.SerializeParams(&actors.AwardBlockRewardParams{Miner: maddr})
+	if aerr != nil {
+		return aerr
+	}
+
+	vmi.SetBlockMiner(maddr)
+
+	ret, err := vmi.ApplyMessage(ctx, &types.Message{
+		From:     actors.NetworkAddress,
+		To:       actors.RewardActorAddress,
+		Nonce:    netact.Nonce,
+		Method:   actors.RAMethods.AwardBlockReward,
+		Params:   enc,
+		Value:    types.NewInt(0),
+		GasPrice: types.NewInt(0),
+		GasLimit: types.NewInt(1 << 30),
+	})
+	if err != nil {
+		return err
+	}
+
+	// a failing reward actor must not stop the chain, the block just goes
+	// without a reward
+	if ret.ExitCode != 0 {
+		log.Errorf("awarding block reward to %s failed with exit code %d: %+v", maddr, ret.ExitCode, ret.ActorErr)
+	}
+
+	return nil
+}
@@ -50,6 +50,14 @@ type ChainStore struct {
 
 	reorgCh          chan<- reorg
 	headChangeNotifs []func(rev, app []*types.TipSet) error
+
+	ntwkVersion func(height uint64) types.NetworkVersion
+}
+
+// SetNetworkVersionFunc sets the function used to look up the network version
+// in effect at a given height when the chain store executes messages itself
+func (cs *ChainStore) SetNetworkVersionFunc(nv func(height uint64) types.NetworkVersion) {
+	cs.ntwkVersion = nv
 }
 
 func NewChainStore(bs bstore.Blockstore, ds dstore.Batching) *ChainStore {
@@ -58,6 +66,10 @@ func NewChainStore(bs bstore.Blockstore, ds dstore.Batching) *ChainStore {
 		ds:       ds,
 		bestTips: pubsub.New(64),
 		tipsets:  make(map[uint64][]cid.Cid),
+
+		ntwkVersion: func(uint64) types.NetworkVersion {
+			return types.NetworkVersion0
+		},
 	}
 
 	cs.reorgCh = cs.reorgWorker(context.TODO())
@@ -23,8 +23,8 @@ func (cs *ChainStore) Weight(ctx context.Context, ts *types.TipSet) (types.BigIn
 	// >>> wFunction(totalPowerAtTipset(ts)) * 2^8 <<< + (wFunction(totalPowerAtTipset(ts)) * len(ts.blocks) * wRatio_num * 2^8) / (e * wRatio_den)
 
 	ret, err := cs.call(ctx, &types.Message{
-		From:   actors.StorageMarketAddress,
-		To:     actors.StorageMarketAddress,
+		From:   actors.StoragePowerAddress,
+		To:     actors.StoragePowerAddress,
 		Method: actors.SPAMethods.GetTotalStorage,
 	}, ts)
 	if err != nil {
@@ -59,7 +59,7 @@ func (cs *ChainStore) call(ctx context.Context, msg *types.Message, ts *types.Ti
 
 	r := NewChainRand(cs, ts.Cids(), ts.Height(), nil)
 
-	vmi, err := vm.NewVM(bstate, ts.Height(), r, actors.NetworkAddress, cs.bs)
+	vmi, err := vm.NewVM(bstate, ts.Height(), r, actors.NetworkAddress, cs.bs, cs.ntwkVersion(ts.Height()))
 	if err != nil {
 		return nil, xerrors.Errorf("failed to set up vm: %w", err)
 	}
@@ -398,7 +398,7 @@ func (syncer *Syncer) minerIsValid(ctx context.Context, maddr address.Address, b
 	}
 
 	ret, err := syncer.sm.Call(ctx, &types.Message{
-		To:     actors.StorageMarketAddress,
+		To:     actors.StoragePowerAddress,
 		From:   maddr,
 		Method: actors.SPAMethods.IsMiner,
 		Params: enc,
@@ -408,7 +408,7 @@ func (syncer *Syncer) minerIsValid(ctx context.Context, maddr address.Address, b
 	}
 
 	if ret.ExitCode != 0 {
-		return xerrors.Errorf("StorageMarket.IsMiner check failed (exit code %d)", ret.ExitCode)
+		return xerrors.Errorf("StoragePower.IsMiner check failed (exit code %d)", ret.ExitCode)
 	}
 
 	// TODO: ensure the miner is currently not late on their PoSt submission (this hasnt landed in the spec yet)
@@ -22,4 +22,8 @@ type StorageAsk struct {
 	Timestamp    int64
 	Expiry       int64
 	SeqNo        uint64
+
+	// CollateralPerByte is what the miner locks in the storage market for
+	// each byte of a deal piece
+	CollateralPerByte BigInt
 }
@@ -0,0 +1,58 @@
+package types
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"golang.org/x/xerrors"
+)
+
+// MessageEnvelopeVersion is the current version of the message envelope
+// format
+const MessageEnvelopeVersion = 1
+
+// MessageEnvelope is the file format used to move messages between an online
+// node and an offline signer. It holds either an unsigned message, or the
+// signed message ready to be pushed to the message pool
+type MessageEnvelope struct {
+	Version uint64
+
+	Message       *Message       `json:",omitempty"`
+	SignedMessage *SignedMessage `json:",omitempty"`
+}
+
+// DecodeMessageEnvelope decodes an envelope in either its JSON or CBOR
+// encoding
+func DecodeMessageEnvelope(b []byte) (*MessageEnvelope, error) {
+	var env MessageEnvelope
+
+	if tb := bytes.TrimSpace(b); len(tb) > 0 && tb[0] == '{' {
+		if err := json.Unmarshal(tb, &env); err != nil {
+			return nil, xerrors.Errorf("decoding json envelope: %w", err)
+		}
+	} else if err := env.UnmarshalCBOR(bytes.NewReader(b)); err != nil {
+		return nil, xerrors.Errorf("decoding cbor envelope: %w", err)
+	}
+
+	if env.Version != MessageEnvelopeVersion {
+		return nil, xerrors.Errorf("unsupported envelope version %d", env.Version)
+	}
+	if (env.Message == nil) == (env.SignedMessage == nil) {
+		return nil, xerrors.New("envelope must hold exactly one of an unsigned or signed message")
+	}
+
+	return &env, nil
+}
+
+func (env *MessageEnvelope) Serialize() ([]byte, error) {
+	buf := new(bytes.Buffer)
+	if err := env.MarshalCBOR(buf); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// SerializeJSON returns the indented JSON encoding of the envelope
+func (env *MessageEnvelope) SerializeJSON() ([]byte, error) {
+	return json.MarshalIndent(env, "", "  ")
+}
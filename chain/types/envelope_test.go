@@ -0,0 +1,65 @@
+package types
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMessageEnvelopeRoundtrip(t *testing.T) {
+	msg := &Message{
+		To:       blsaddr(1),
+		From:     blsaddr(2),
+		Nonce:    3,
+		Value:    NewInt(1000),
+		Method:   2,
+		Params:   []byte("params"),
+		GasLimit: NewInt(126723),
+		GasPrice: NewInt(1776234),
+	}
+
+	env := &MessageEnvelope{
+		Version: MessageEnvelopeVersion,
+		Message: msg,
+	}
+
+	cb, err := env.Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	jb, err := env.SerializeJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, b := range [][]byte{cb, jb} {
+		out, err := DecodeMessageEnvelope(b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assert.Nil(t, out.SignedMessage)
+		assert.Equal(t, msg.Cid(), out.Message.Cid())
+	}
+
+	signed := &MessageEnvelope{
+		Version: MessageEnvelopeVersion,
+		SignedMessage: &SignedMessage{
+			Message:   *msg,
+			Signature: Signature{Type: KTSecp256k1, Data: []byte("sig")},
+		},
+	}
+	jb, err = signed.SerializeJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := DecodeMessageEnvelope(jb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, signed.SignedMessage.Cid(), out.SignedMessage.Cid())
+
+	_, err = DecodeMessageEnvelope([]byte(`{"Version": 2}`))
+	assert.Error(t, err)
+	_, err = DecodeMessageEnvelope([]byte(`{"Version": 1}`))
+	assert.Error(t, err)
+}
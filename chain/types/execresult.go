@@ -0,0 +1,11 @@
+package types
+
+// ExecutionTrace records a single actor method invocation along with all the
+// invocations it made through VMContext.Send
+type ExecutionTrace struct {
+	Msg    *Message
+	MsgRct *MessageReceipt
+	Error  string
+
+	Subcalls []*ExecutionTrace
+}
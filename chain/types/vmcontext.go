@@ -11,6 +11,12 @@ import (
 	cbg "github.com/whyrusleeping/cbor-gen"
 )
 
+// NetworkVersion identifies the set of actor code and VM rules in effect at a
+// given height. It is bumped by every network upgrade
+type NetworkVersion uint64
+
+const NetworkVersion0 = NetworkVersion(0)
+
 type Storage interface {
 	Put(cbg.CBORMarshaler) (cid.Cid, aerrors.ActorError)
 	Get(cid.Cid, cbg.CBORUnmarshaler) aerrors.ActorError
@@ -33,6 +39,7 @@ type VMContext interface {
 	Ipld() *hamt.CborIpldStore
 	Send(to address.Address, method uint64, value BigInt, params []byte) ([]byte, aerrors.ActorError)
 	BlockHeight() uint64
+	NetworkVersion() NetworkVersion
 	GasUsed() BigInt
 	Storage() Storage
 	StateTree() (StateTree, aerrors.ActorError)
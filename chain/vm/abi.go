@@ -0,0 +1,211 @@
+package vm
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"runtime"
+	"strings"
+
+	"github.com/ipfs/go-cid"
+	cbor "github.com/ipfs/go-ipld-cbor"
+	"github.com/libp2p/go-libp2p-core/peer"
+	cbg "github.com/whyrusleeping/cbor-gen"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-lotus/chain/actors"
+	"github.com/filecoin-project/go-lotus/chain/address"
+	"github.com/filecoin-project/go-lotus/chain/types"
+)
+
+// MethodMeta describes a single exported method of a built-in actor
+type MethodMeta struct {
+	Name string
+
+	// Params is the type the method parameters decode into
+	Params reflect.Type
+
+	// Ret is the type of the value returned by the method, nil if the method
+	// doesn't return anything
+	Ret reflect.Type
+}
+
+var (
+	tAddress = reflect.TypeOf(address.Address{})
+	tBigInt  = reflect.TypeOf(types.BigInt{})
+	tPeerID  = reflect.TypeOf(peer.ID(""))
+	tBool    = reflect.TypeOf(false)
+	tUint64  = reflect.TypeOf(uint64(0))
+	tCid     = reflect.TypeOf(cid.Cid{})
+)
+
+// builtInReturns lists the return types of built-in actor methods. Methods
+// return raw bytes, so this can't be derived from the Exports tables
+var builtInReturns = map[cid.Cid]map[uint64]reflect.Type{
+	actors.InitActorCodeCid: {
+		actors.IAMethods.Exec:            tAddress,
+		actors.IAMethods.GetIdForAddress: tAddress,
+	},
+	actors.StoragePowerActorCodeCid: {
+		actors.SPAMethods.CreateStorageMiner:      tAddress,
+		actors.SPAMethods.GetTotalStorage:         tBigInt,
+		actors.SPAMethods.PowerLookup:             tBigInt,
+		actors.SPAMethods.IsMiner:                 tBool,
+		actors.SPAMethods.PledgeCollateralForSize: tBigInt,
+	},
+	actors.StorageMinerCodeCid: {
+		actors.MAMethods.GetOwner:             tAddress,
+		actors.MAMethods.GetWorkerAddr:        tAddress,
+		actors.MAMethods.GetPower:             tBigInt,
+		actors.MAMethods.GetPeerID:            tPeerID,
+		actors.MAMethods.GetSectorSize:        tBigInt,
+		actors.MAMethods.SlashStorageFault:    tBigInt,
+		actors.MAMethods.GetCurrentProvingSet: tCid,
+		actors.MAMethods.IsSlashed:            tBool,
+		actors.MAMethods.IsLate:               tBool,
+		actors.MAMethods.IsSectorSlashed:      tBool,
+		actors.MAMethods.GetLastProvenEpoch:   tUint64,
+	},
+	actors.MultisigActorCodeCid: {
+		actors.MultiSigMethods.Propose: tUint64,
+	},
+	actors.PaymentChannelActorCodeCid: {
+		actors.PCAMethods.GetOwner:  tAddress,
+		actors.PCAMethods.GetToSend: tBigInt,
+	},
+	actors.MarketActorCodeCid: {
+		actors.SMAMethods.PublishStorageDeals: reflect.TypeOf(actors.PublishStorageDealResponse{}),
+	},
+}
+
+func methodMetas(c cid.Cid, instance Invokee) []MethodMeta {
+	exports := instance.Exports()
+	out := make([]MethodMeta, len(exports))
+	for i, m := range exports {
+		if m == nil {
+			continue
+		}
+
+		meth := reflect.ValueOf(m)
+
+		// method values are named like `pkg.Type.Method-fm`
+		name := runtime.FuncForPC(meth.Pointer()).Name()
+		name = strings.TrimSuffix(name, "-fm")
+		name = name[strings.LastIndex(name, ".")+1:]
+
+		out[i] = MethodMeta{
+			Name:   name,
+			Params: meth.Type().In(2).Elem(),
+			Ret:    builtInReturns[c][uint64(i)],
+		}
+	}
+	return out
+}
+
+// builtIns holds the method tables of all built-in actors, which never change
+// after startup
+var builtIns = NewInvoker()
+
+// LookupMethod returns the metadata of the given method on a built-in actor
+func LookupMethod(code cid.Cid, method uint64) (*MethodMeta, error) {
+	methods, ok := builtIns.builtInMethods[code]
+	if !ok {
+		return nil, xerrors.Errorf("no methods registered for actor code %s", code)
+	}
+
+	if method >= uint64(len(methods)) || methods[method].Params == nil {
+		return nil, xerrors.Errorf("no method %d on actor %s", method, code)
+	}
+
+	return &methods[method], nil
+}
+
+// DecodeMethodParams decodes the CBOR encoded parameters of a built-in actor
+// method call, returning a pointer to the native params type
+func DecodeMethodParams(code cid.Cid, method uint64, params []byte) (interface{}, error) {
+	meta, err := LookupMethod(code, method)
+	if err != nil {
+		return nil, err
+	}
+
+	rv := reflect.New(meta.Params)
+	if len(params) == 0 {
+		return rv.Interface(), nil
+	}
+
+	if err := DecodeParams(params, rv.Interface()); err != nil {
+		return nil, xerrors.Errorf("decoding params of %s: %w", meta.Name, err)
+	}
+
+	return rv.Interface(), nil
+}
+
+// EncodeMethodParams parses JSON encoded parameters of a built-in actor
+// method and serializes them the way the actor expects
+func EncodeMethodParams(code cid.Cid, method uint64, params json.RawMessage) ([]byte, error) {
+	meta, err := LookupMethod(code, method)
+	if err != nil {
+		return nil, err
+	}
+
+	if meta.Params.Kind() != reflect.Struct {
+		return nil, xerrors.Errorf("params of %s are not a struct (%s)", meta.Name, meta.Params)
+	}
+
+	if meta.Params.NumField() == 0 {
+		return nil, nil
+	}
+
+	rv := reflect.New(meta.Params)
+	if err := json.Unmarshal(params, rv.Interface()); err != nil {
+		return nil, xerrors.Errorf("parsing params of %s: %w", meta.Name, err)
+	}
+
+	m, ok := rv.Interface().(cbg.CBORMarshaler)
+	if !ok {
+		return nil, xerrors.Errorf("params of %s do not implement MarshalCBOR", meta.Name)
+	}
+
+	buf := new(bytes.Buffer)
+	if err := m.MarshalCBOR(buf); err != nil {
+		return nil, xerrors.Errorf("encoding params of %s: %w", meta.Name, err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// DecodeMethodReturn decodes the value returned by a built-in actor method
+func DecodeMethodReturn(code cid.Cid, method uint64, ret []byte) (interface{}, error) {
+	meta, err := LookupMethod(code, method)
+	if err != nil {
+		return nil, err
+	}
+
+	if meta.Ret == nil || len(ret) == 0 {
+		return nil, nil
+	}
+
+	switch meta.Ret {
+	case tAddress:
+		return address.NewFromBytes(ret)
+	case tBigInt:
+		return types.BigFromBytes(ret), nil
+	case tPeerID:
+		return peer.IDFromBytes(ret)
+	case tCid:
+		return cid.Cast(ret)
+	}
+
+	rv := reflect.New(meta.Ret)
+	if um, ok := rv.Interface().(cbg.CBORUnmarshaler); ok {
+		if err := um.UnmarshalCBOR(bytes.NewReader(ret)); err != nil {
+			return nil, xerrors.Errorf("decoding return of %s: %w", meta.Name, err)
+		}
+		return rv.Elem().Interface(), nil
+	}
+
+	if err := cbor.DecodeInto(ret, rv.Interface()); err != nil {
+		return nil, xerrors.Errorf("decoding return of %s: %w", meta.Name, err)
+	}
+	return rv.Elem().Interface(), nil
+}
@@ -0,0 +1,111 @@
+package vm
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/ipfs/go-cid"
+	mh "github.com/multiformats/go-multihash"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/filecoin-project/go-lotus/chain/actors"
+	"github.com/filecoin-project/go-lotus/chain/actors/aerrors"
+	"github.com/filecoin-project/go-lotus/chain/address"
+	"github.com/filecoin-project/go-lotus/chain/types"
+)
+
+func TestLookupMethod(t *testing.T) {
+	meta, err := LookupMethod(actors.MultisigActorCodeCid, actors.MultiSigMethods.Propose)
+	assert.NoError(t, err)
+	assert.Equal(t, "Propose", meta.Name)
+	assert.Equal(t, tUint64, meta.Ret)
+
+	meta, err = LookupMethod(actors.StorageMinerCodeCid, actors.MAMethods.GetWorkerAddr)
+	assert.NoError(t, err)
+	assert.Equal(t, "GetWorkerAddr", meta.Name)
+
+	_, err = LookupMethod(actors.StoragePowerActorCodeCid, 1)
+	assert.Error(t, err, "method 1 isn't exported by the storage power actor")
+
+	_, err = LookupMethod(actors.AccountActorCodeCid, 1)
+	assert.Error(t, err, "account actors have no methods")
+}
+
+func TestMethodParamsRoundtrip(t *testing.T) {
+	to, err := address.NewIDAddress(100)
+	assert.NoError(t, err)
+
+	pj, err := json.Marshal(&actors.MultiSigProposeParams{
+		To:    to,
+		Value: types.NewInt(1000),
+	})
+	assert.NoError(t, err)
+
+	enc, err := EncodeMethodParams(actors.MultisigActorCodeCid, actors.MultiSigMethods.Propose, pj)
+	assert.NoError(t, err)
+
+	expected, aerr := actors.SerializeParams(&actors.MultiSigProposeParams{
+		To:    to,
+		Value: types.NewInt(1000),
+	})
+	assert.NoError(t, aerr)
+	assert.Equal(t, expected, enc)
+
+	dec, err := DecodeMethodParams(actors.MultisigActorCodeCid, actors.MultiSigMethods.Propose, enc)
+	assert.NoError(t, err)
+
+	p, ok := dec.(*actors.MultiSigProposeParams)
+	if assert.True(t, ok, "decoded params have the wrong type: %T", dec) {
+		assert.Equal(t, to, p.To)
+		assert.Equal(t, types.NewInt(1000), p.Value)
+	}
+}
+
+type uintParamsActor struct{}
+
+func (a uintParamsActor) Exports() []interface{} {
+	return []interface{}{
+		1: a.Set,
+	}
+}
+
+func (uintParamsActor) Set(act *types.Actor, vmctx types.VMContext, params *uint64) ([]byte, aerrors.ActorError) {
+	return nil, nil
+}
+
+func TestEncodeMethodParamsNotStruct(t *testing.T) {
+	code, err := cid.Prefix{
+		Version:  1,
+		Codec:    cid.Raw,
+		MhType:   mh.IDENTITY,
+		MhLength: -1,
+	}.Sum([]byte("uintparams"))
+	assert.NoError(t, err)
+
+	builtIns.Register(code, uintParamsActor{}, struct{}{})
+	defer func() {
+		delete(builtIns.builtInCode, code)
+		delete(builtIns.builtInState, code)
+		delete(builtIns.builtInMethods, code)
+	}()
+
+	_, err = EncodeMethodParams(code, 1, json.RawMessage("5"))
+	assert.Error(t, err)
+}
+
+func TestDecodeMethodReturn(t *testing.T) {
+	addr, err := address.NewIDAddress(101)
+	assert.NoError(t, err)
+
+	ret, err := DecodeMethodReturn(actors.InitActorCodeCid, actors.IAMethods.Exec, addr.Bytes())
+	assert.NoError(t, err)
+	assert.Equal(t, addr, ret)
+
+	ret, err = DecodeMethodReturn(actors.PaymentChannelActorCodeCid, actors.PCAMethods.GetToSend, types.NewInt(42).Bytes())
+	assert.NoError(t, err)
+	assert.Equal(t, types.NewInt(42), ret)
+
+	ret, err = DecodeMethodReturn(actors.MultisigActorCodeCid, actors.MultiSigMethods.Approve, nil)
+	assert.NoError(t, err)
+	assert.Nil(t, ret)
+}
@@ -0,0 +1,147 @@
+package vm
+
+import (
+	"github.com/filecoin-project/go-lotus/chain/types"
+)
+
+// Pricelist provides prices for operations in the VM.
+//
+// Price lists are keyed by the height they take effect at in prices. Changing
+// a price means adding a new entry at the upgrade height, never editing an
+// existing one, otherwise old tipsets would compute to a different state.
+type Pricelist interface {
+	// OnChainMessage returns the gas used for storing a message of a given size in the chain.
+	OnChainMessage(msgSize int) uint64
+
+	// OnMethodInvocation returns the gas used when invoking a method.
+	OnMethodInvocation(value types.BigInt, methodNum uint64) uint64
+
+	// OnIpldGet returns the gas used for reading an object of a given size from the state.
+	OnIpldGet(dataSize int) uint64
+	// OnIpldPut returns the gas used for storing an object of a given size in the state.
+	OnIpldPut(dataSize int) uint64
+	// OnCommit returns the gas used for updating the head of an actor.
+	OnCommit() uint64
+
+	// OnCreateActor returns the gas used for creating an actor
+	OnCreateActor() uint64
+
+	// OnVerifySignature returns the gas used for verifying a signature of the
+	// given type over plaintext of the given size
+	OnVerifySignature(sigType string, planTextSize int) uint64
+}
+
+var prices = map[uint64]Pricelist{
+	0: &pricelistV0{
+		onChainMessageBase:    0,
+		onChainMessagePerByte: 2,
+
+		sendBase:               5,
+		sendTransferFunds:      10,
+		sendInvokeMethod:       5,
+		ipldGetBase:            10,
+		ipldGetPerByte:         1,
+		ipldPutBase:            20,
+		ipldPutPerByte:         2,
+		commit:                 50,
+		createActor:            100,
+		verifySignaturePerByte: 1,
+		verifySignature: map[string]uint64{
+			types.KTSecp256k1: 50,
+			types.KTBLS:       100,
+		},
+	},
+}
+
+// PricelistByHeight finds the latest prices for the given height
+func PricelistByHeight(height uint64) Pricelist {
+	// since we are storing the prices as map or height to price
+	// we need to get the price with the highest height that is lower or equal to the `height`
+	bestHeight := uint64(0)
+	bestPrice := prices[bestHeight]
+	for h, pl := range prices {
+		// if `height` happened after `h` and `h` is closer to `height` than `bestHeight`
+		if height >= h && h > bestHeight {
+			bestHeight = h
+			bestPrice = pl
+		}
+	}
+
+	return bestPrice
+}
+
+// pricelistV0 is the gas schedule in effect from genesis
+type pricelistV0 struct {
+	// Gas cost charged to the originator of an on-chain message (regardless of
+	// whether it succeeds or fails in application) is given by:
+	//   OnChainMessageBase + len(serialized message)*OnChainMessagePerByte
+	onChainMessageBase    uint64
+	onChainMessagePerByte uint64
+
+	// Gas cost for any message send execution (including the top-level one
+	// initiated by an on-chain message), charged on top of the below
+	sendBase uint64
+	// Gas cost charged, in addition to sendBase, if a message send
+	// transfers funds
+	sendTransferFunds uint64
+	// Gas cost charged, in addition to sendBase, if a message invokes
+	// a method on the receiver
+	sendInvokeMethod uint64
+
+	// Gas cost for any Get operation to the IPLD store in the runtime VM context
+	ipldGetBase    uint64
+	ipldGetPerByte uint64
+
+	// Gas cost for any Put operation to the IPLD store in the runtime VM context
+	ipldPutBase    uint64
+	ipldPutPerByte uint64
+
+	// Gas cost for updating the head of an actor
+	commit uint64
+
+	// Gas cost for creating a new actor, either through the init actor or
+	// by sending funds to a previously unknown key address
+	createActor uint64
+
+	// Gas cost for verifying a signature, by signature type, charged on top
+	// of verifySignaturePerByte for every byte of plaintext
+	verifySignature        map[string]uint64
+	verifySignaturePerByte uint64
+}
+
+var _ Pricelist = (*pricelistV0)(nil)
+
+func (pl *pricelistV0) OnChainMessage(msgSize int) uint64 {
+	return pl.onChainMessageBase + pl.onChainMessagePerByte*uint64(msgSize)
+}
+
+func (pl *pricelistV0) OnMethodInvocation(value types.BigInt, methodNum uint64) uint64 {
+	ret := pl.sendBase
+	if value != types.EmptyInt && value.Int.Sign() != 0 {
+		ret += pl.sendTransferFunds
+	}
+	if methodNum != 0 {
+		ret += pl.sendInvokeMethod
+	}
+	return ret
+}
+
+func (pl *pricelistV0) OnIpldGet(dataSize int) uint64 {
+	return pl.ipldGetBase + pl.ipldGetPerByte*uint64(dataSize)
+}
+
+func (pl *pricelistV0) OnIpldPut(dataSize int) uint64 {
+	return pl.ipldPutBase + pl.ipldPutPerByte*uint64(dataSize)
+}
+
+func (pl *pricelistV0) OnCommit() uint64 {
+	return pl.commit
+}
+
+func (pl *pricelistV0) OnCreateActor() uint64 {
+	return pl.createActor
+}
+
+func (pl *pricelistV0) OnVerifySignature(sigType string, planTextSize int) uint64 {
+	return pl.verifySignature[sigType] + pl.verifySignaturePerByte*uint64(planTextSize)
+}
@@ -0,0 +1,63 @@
+package vm
+
+import (
+	"testing"
+
+	"github.com/filecoin-project/go-lotus/chain/types"
+)
+
+func TestPricelistByHeight(t *testing.T) {
+	v1 := &pricelistV0{createActor: 1}
+	v2 := &pricelistV0{createActor: 2}
+
+	old := prices
+	prices = map[uint64]Pricelist{
+		0:   prices[0],
+		100: v1,
+		200: v2,
+	}
+	defer func() {
+		prices = old
+	}()
+
+	if PricelistByHeight(0) != old[0] {
+		t.Error("expected genesis prices at height 0")
+	}
+	if PricelistByHeight(99) != old[0] {
+		t.Error("expected genesis prices before the first upgrade")
+	}
+	if PricelistByHeight(100) != v1 {
+		t.Error("expected new prices at the upgrade height")
+	}
+	if PricelistByHeight(199) != v1 {
+		t.Error("expected first upgrade prices before the second upgrade")
+	}
+	if PricelistByHeight(1000) != v2 {
+		t.Error("expected latest prices after the last upgrade")
+	}
+}
+
+func TestPricelistV0(t *testing.T) {
+	pl := PricelistByHeight(0)
+	v0 := pl.(*pricelistV0)
+
+	if pl.OnMethodInvocation(types.NewInt(0), 0) != v0.sendBase {
+		t.Error("plain send should only be charged the base price")
+	}
+	if pl.OnMethodInvocation(types.NewInt(1), 0) != v0.sendBase+v0.sendTransferFunds {
+		t.Error("transfer should be charged for moving funds")
+	}
+	if pl.OnMethodInvocation(types.NewInt(1), 2) != v0.sendBase+v0.sendTransferFunds+v0.sendInvokeMethod {
+		t.Error("method call with value should be charged for both")
+	}
+	if pl.OnChainMessage(100) != v0.onChainMessageBase+100*v0.onChainMessagePerByte {
+		t.Error("unexpected on chain message price")
+	}
+	if pl.OnIpldGet(10) != v0.ipldGetBase+10*v0.ipldGetPerByte || pl.OnIpldPut(10) != v0.ipldPutBase+10*v0.ipldPutPerByte {
+		t.Error("unexpected ipld prices")
+	}
+	if pl.OnVerifySignature(types.KTSecp256k1, 32) != v0.verifySignature[types.KTSecp256k1]+32*v0.verifySignaturePerByte ||
+		pl.OnVerifySignature(types.KTBLS, 32) != v0.verifySignature[types.KTBLS]+32*v0.verifySignaturePerByte {
+		t.Error("unexpected signature verification prices")
+	}
+}
@@ -14,31 +14,36 @@ import (
 	"github.com/filecoin-project/go-lotus/chain/types"
 )
 
-type invoker struct {
-	builtInCode  map[cid.Cid]nativeCode
-	builtInState map[cid.Cid]reflect.Type
+type Invoker struct {
+	builtInCode    map[cid.Cid]nativeCode
+	builtInState   map[cid.Cid]reflect.Type
+	builtInMethods map[cid.Cid][]MethodMeta
 }
 
 type invokeFunc func(act *types.Actor, vmctx types.VMContext, params []byte) ([]byte, aerrors.ActorError)
 type nativeCode []invokeFunc
 
-func newInvoker() *invoker {
-	inv := &invoker{
-		builtInCode:  make(map[cid.Cid]nativeCode),
-		builtInState: make(map[cid.Cid]reflect.Type),
+// NewInvoker returns an invoker with all built-in actors registered
+func NewInvoker() *Invoker {
+	inv := &Invoker{
+		builtInCode:    make(map[cid.Cid]nativeCode),
+		builtInState:   make(map[cid.Cid]reflect.Type),
+		builtInMethods: make(map[cid.Cid][]MethodMeta),
 	}
 
-	// add builtInCode using: register(cid, singleton)
-	inv.register(actors.InitActorCodeCid, actors.InitActor{}, actors.InitActorState{})
-	inv.register(actors.StorageMarketActorCodeCid, actors.StoragePowerActor{}, actors.StoragePowerState{})
-	inv.register(actors.StorageMinerCodeCid, actors.StorageMinerActor{}, actors.StorageMinerActorState{})
-	inv.register(actors.MultisigActorCodeCid, actors.MultiSigActor{}, actors.MultiSigActorState{})
-	inv.register(actors.PaymentChannelActorCodeCid, actors.PaymentChannelActor{}, actors.PaymentChannelActorState{})
+	// add builtInCode using: Register(cid, singleton)
+	inv.Register(actors.InitActorCodeCid, actors.InitActor{}, actors.InitActorState{})
+	inv.Register(actors.StoragePowerActorCodeCid, actors.StoragePowerActor{}, actors.StoragePowerState{})
+	inv.Register(actors.StorageMinerCodeCid, actors.StorageMinerActor{}, actors.StorageMinerActorState{})
+	inv.Register(actors.MultisigActorCodeCid, actors.MultiSigActor{}, actors.MultiSigActorState{})
+	inv.Register(actors.PaymentChannelActorCodeCid, actors.PaymentChannelActor{}, actors.PaymentChannelActorState{})
+	inv.Register(actors.RewardActorCodeCid, actors.RewardActor{}, actors.RewardActorState{})
+	inv.Register(actors.MarketActorCodeCid, actors.StorageMarketActor{}, actors.StorageMarketState{})
 
 	return inv
 }
 
-func (inv *invoker) Invoke(act *types.Actor, vmctx types.VMContext, method uint64, params []byte) ([]byte, aerrors.ActorError) {
+func (inv *Invoker) Invoke(act *types.Actor, vmctx types.VMContext, method uint64, params []byte) ([]byte, aerrors.ActorError) {
 
 	code, ok := inv.builtInCode[act.Code]
 	if !ok {
@@ -51,13 +56,15 @@ func (inv *invoker) Invoke(act *types.Actor, vmctx types.VMContext, method uint6
 
 }
 
-func (inv *invoker) register(c cid.Cid, instance Invokee, state interface{}) {
+// Register adds the code of an actor, replacing any code registered for c
+func (inv *Invoker) Register(c cid.Cid, instance Invokee, state interface{}) {
 	code, err := inv.transform(instance)
 	if err != nil {
 		panic(err)
 	}
 	inv.builtInCode[c] = code
 	inv.builtInState[c] = reflect.TypeOf(state)
+	inv.builtInMethods[c] = methodMetas(c, instance)
 }
 
 type Invokee interface {
@@ -67,7 +74,7 @@ type Invokee interface {
 var tVMContext = reflect.TypeOf((*types.VMContext)(nil)).Elem()
 var tAError = reflect.TypeOf((*aerrors.ActorError)(nil)).Elem()
 
-func (*invoker) transform(instance Invokee) (nativeCode, error) {
+func (*Invoker) transform(instance Invokee) (nativeCode, error) {
 	itype := reflect.TypeOf(instance)
 	exports := instance.Exports()
 	for i, m := range exports {
@@ -152,7 +159,7 @@ func DecodeParams(b []byte, out interface{}) error {
 }
 
 func DumpActorState(code cid.Cid, b []byte) (interface{}, error) {
-	i := newInvoker() // TODO: register builtins in init block
+	i := NewInvoker() // TODO: register builtins in init block
 
 	typ, ok := i.builtInState[code]
 	if !ok {
@@ -74,7 +74,7 @@ func (basicContract) InvokeSomething10(act *types.Actor, vmctx types.VMContext,
 }
 
 func TestInvokerBasic(t *testing.T) {
-	inv := invoker{}
+	inv := Invoker{}
 	code, err := inv.transform(basicContract{})
 	assert.NoError(t, err)
 
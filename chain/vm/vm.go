@@ -4,9 +4,7 @@ import (
 	"context"
 	"fmt"
 	blockstore "github.com/ipfs/go-ipfs-blockstore"
-	"math/big"
 
-	"github.com/filecoin-project/go-lotus/build"
 	"github.com/filecoin-project/go-lotus/chain/actors"
 	"github.com/filecoin-project/go-lotus/chain/actors/aerrors"
 	"github.com/filecoin-project/go-lotus/chain/address"
@@ -28,18 +26,6 @@ import (
 
 var log = logging.Logger("vm")
 
-const (
-	gasFundTransfer = 10
-	gasInvoke       = 5
-
-	gasGetObj         = 10
-	gasGetPerByte     = 1
-	gasPutObj         = 20
-	gasPutPerByte     = 2
-	gasCommit         = 50
-	gasPerMessageByte = 2
-)
-
 const (
 	outOfGasErrCode = 200
 )
@@ -61,6 +47,9 @@ type VMContext struct {
 
 	// address that started invokation chain
 	origin address.Address
+
+	// calls made to other actors from this invocation, in order
+	internalExecutions []*types.ExecutionTrace
 }
 
 // Message is the message that kicked off the current invocation
@@ -106,7 +95,7 @@ func (vmc *VMContext) GetHead() cid.Cid {
 }
 
 func (vmc *VMContext) Commit(oldh, newh cid.Cid) aerrors.ActorError {
-	if err := vmc.ChargeGas(gasCommit); err != nil {
+	if err := vmc.ChargeGas(vmc.vm.pricelist.OnCommit()); err != nil {
 		return aerrors.Wrap(err, "out of gas")
 	}
 	if vmc.sroot != oldh {
@@ -153,15 +142,47 @@ func (vmc *VMContext) Send(to address.Address, method uint64, value types.BigInt
 		GasLimit: vmc.gasAvailable,
 	}
 
-	ret, err, _ := vmc.vm.send(ctx, msg, vmc, 0)
+	gasBefore := vmc.gasUsed
+	ret, err, sub := vmc.vm.send(ctx, msg, vmc, 0)
+
+	var subcalls []*types.ExecutionTrace
+	if sub != nil {
+		subcalls = sub.internalExecutions
+	}
+
+	vmc.internalExecutions = append(vmc.internalExecutions, newExecutionTrace(msg, ret, err, types.BigSub(vmc.gasUsed, gasBefore), subcalls))
 	return ret, err
 }
 
+func newExecutionTrace(msg *types.Message, ret []byte, err aerrors.ActorError, gasUsed types.BigInt, subcalls []*types.ExecutionTrace) *types.ExecutionTrace {
+	var errstr string
+	if err != nil {
+		errstr = err.Error()
+	}
+
+	return &types.ExecutionTrace{
+		Msg: msg,
+		MsgRct: &types.MessageReceipt{
+			ExitCode: aerrors.RetCode(err),
+			Return:   ret,
+			GasUsed:  gasUsed,
+		},
+		Error:    errstr,
+		Subcalls: subcalls,
+	}
+}
+
 // BlockHeight returns the height of the block this message was added to the chain in
 func (vmc *VMContext) BlockHeight() uint64 {
 	return vmc.height
 }
 
+// NetworkVersion returns the version of the network rules the message is
+// executed under
+func (vmc *VMContext) NetworkVersion() types.NetworkVersion {
+	return vmc.vm.ntwkVersion
+}
+
 func (vmc *VMContext) GasUsed() types.BigInt {
 	return vmc.gasUsed
 }
@@ -183,10 +204,8 @@ func (vmc *VMContext) StateTree() (types.StateTree, aerrors.ActorError) {
 	return vmc.state, nil
 }
 
-const GasVerifySignature = 50
-
 func (vmctx *VMContext) VerifySignature(sig *types.Signature, act address.Address, data []byte) aerrors.ActorError {
-	if err := vmctx.ChargeGas(GasVerifySignature); err != nil {
+	if err := vmctx.ChargeGas(vmctx.vm.pricelist.OnVerifySignature(sig.Type, len(data))); err != nil {
 		return err
 	}
 
@@ -252,18 +271,16 @@ var _ hBlocks = (*gasChargingBlocks)(nil)
 
 type gasChargingBlocks struct {
 	chargeGas func(uint64) aerrors.ActorError
+	pricelist Pricelist
 	under     hBlocks
 }
 
 func (bs *gasChargingBlocks) GetBlock(ctx context.Context, c cid.Cid) (block.Block, error) {
-	if err := bs.chargeGas(gasGetObj); err != nil {
-		return nil, err
-	}
 	blk, err := bs.under.GetBlock(ctx, c)
 	if err != nil {
 		return nil, err
 	}
-	if err := bs.chargeGas(uint64(len(blk.RawData())) * gasGetPerByte); err != nil {
+	if err := bs.chargeGas(bs.pricelist.OnIpldGet(len(blk.RawData()))); err != nil {
 		return nil, err
 	}
 
@@ -271,7 +288,7 @@ func (bs *gasChargingBlocks) GetBlock(ctx context.Context, c cid.Cid) (block.Blo
 }
 
 func (bs *gasChargingBlocks) AddBlock(blk block.Block) error {
-	if err := bs.chargeGas(gasPutObj + uint64(len(blk.RawData()))*gasPutPerByte); err != nil {
+	if err := bs.chargeGas(bs.pricelist.OnIpldPut(len(blk.RawData()))); err != nil {
 		return err
 	}
 	return bs.under.AddBlock(blk)
@@ -291,7 +308,7 @@ func (vm *VM) makeVMContext(ctx context.Context, sroot cid.Cid, msg *types.Messa
 		gasAvailable: msg.GasLimit,
 	}
 	vmc.cst = &hamt.CborIpldStore{
-		Blocks: &gasChargingBlocks{vmc.ChargeGas, vm.cst.Blocks},
+		Blocks: &gasChargingBlocks{vmc.ChargeGas, vm.pricelist, vm.cst.Blocks},
 		Atlas:  vm.cst.Atlas,
 	}
 	return vmc
@@ -304,11 +321,13 @@ type VM struct {
 	buf         *bufbstore.BufferedBS
 	blockHeight uint64
 	blockMiner  address.Address
-	inv         *invoker
+	inv         *Invoker
 	rand        Rand
+	pricelist   Pricelist
+	ntwkVersion types.NetworkVersion
 }
 
-func NewVM(base cid.Cid, height uint64, r Rand, maddr address.Address, cbs blockstore.Blockstore) (*VM, error) {
+func NewVM(base cid.Cid, height uint64, r Rand, maddr address.Address, cbs blockstore.Blockstore, nv types.NetworkVersion) (*VM, error) {
 	buf := bufbstore.NewBufferedBstore(cbs)
 	cst := hamt.CSTFromBstore(buf)
 	state, err := state.LoadStateTree(cst, base)
@@ -323,8 +342,10 @@ func NewVM(base cid.Cid, height uint64, r Rand, maddr address.Address, cbs block
 		buf:         buf,
 		blockHeight: height,
 		blockMiner:  maddr,
-		inv:         newInvoker(),
+		inv:         NewInvoker(),
 		rand:        r,
+		pricelist:   PricelistByHeight(height),
+		ntwkVersion: nv,
 	}, nil
 }
 
@@ -335,6 +356,8 @@ type Rand interface {
 type ApplyRet struct {
 	types.MessageReceipt
 	ActorErr aerrors.ActorError
+
+	ExecutionTrace types.ExecutionTrace
 }
 
 func (vm *VM) send(ctx context.Context, msg *types.Message, parent *VMContext,
@@ -346,6 +369,7 @@ func (vm *VM) send(ctx context.Context, msg *types.Message, parent *VMContext,
 		return nil, aerrors.Absorb(err, 1, "could not find source actor"), nil
 	}
 
+	var createdActor bool
 	toActor, err := st.GetActor(msg.To)
 	if err != nil {
 		if xerrors.Is(err, types.ErrActorNotFound) {
@@ -354,6 +378,7 @@ func (vm *VM) send(ctx context.Context, msg *types.Message, parent *VMContext,
 				return nil, aerrors.Absorb(err, 1, "could not create account"), nil
 			}
 			toActor = a
+			createdActor = true
 		} else {
 			return nil, aerrors.Escalate(err, "getting actor"), nil
 		}
@@ -372,11 +397,19 @@ func (vm *VM) send(ctx context.Context, msg *types.Message, parent *VMContext,
 		}()
 	}
 
-	if types.BigCmp(msg.Value, types.NewInt(0)) != 0 {
-		if aerr := vmctx.ChargeGas(gasFundTransfer); aerr != nil {
-			return nil, aerrors.Wrap(aerr, "sending funds"), nil
+	if aerr := vmctx.ChargeGas(vm.pricelist.OnMethodInvocation(msg.Value, msg.Method)); aerr != nil {
+		return nil, aerrors.Wrap(aerr, "not enough gas to send message"), vmctx
+	}
+
+	// actors can be created either implicitly, by sending to an unknown key
+	// address, or explicitly through the init actor
+	if createdActor || (msg.To == actors.InitActorAddress && msg.Method == actors.IAMethods.Exec) {
+		if aerr := vmctx.ChargeGas(vm.pricelist.OnCreateActor()); aerr != nil {
+			return nil, aerrors.Wrap(aerr, "not enough gas to create actor"), vmctx
 		}
+	}
 
+	if types.BigCmp(msg.Value, types.NewInt(0)) != 0 {
 		if err := Transfer(fromActor, toActor, msg.Value); err != nil {
 			return nil, aerrors.Absorb(err, 1, "failed to transfer funds"), nil
 		}
@@ -436,7 +469,7 @@ func (vm *VM) ApplyMessage(ctx context.Context, msg *types.Message) (*ApplyRet,
 	if err != nil {
 		return nil, xerrors.Errorf("could not serialize message: %w", err)
 	}
-	msgGasCost := uint64(len(serMsg)) * gasPerMessageByte
+	msgGasCost := vm.pricelist.OnChainMessage(len(serMsg))
 
 	gascost := types.BigMul(msg.GasLimit, msg.GasPrice)
 	totalCost := types.BigAdd(gascost, msg.Value)
@@ -472,6 +505,15 @@ func (vm *VM) ApplyMessage(ctx context.Context, msg *types.Message) (*ApplyRet,
 		if err := st.Revert(); err != nil {
 			return nil, xerrors.Errorf("revert state failed: %w", err)
 		}
+
+		// the sender was reverted as well, it still pays for gas and uses up
+		// the nonce
+		fromActor, err = st.GetActor(msg.From)
+		if err != nil {
+			return nil, xerrors.Errorf("from actor not found after revert: %w", err)
+		}
+		fromActor.Balance = types.BigSub(fromActor.Balance, gascost)
+		fromActor.Nonce++
 	} else {
 		// refund unused gas
 		gasUsed = vmctx.GasUsed()
@@ -495,13 +537,19 @@ func (vm *VM) ApplyMessage(ctx context.Context, msg *types.Message) (*ApplyRet,
 		return nil, xerrors.Errorf("gas handling math is wrong")
 	}
 
+	var subcalls []*types.ExecutionTrace
+	if vmctx != nil {
+		subcalls = vmctx.internalExecutions
+	}
+
 	return &ApplyRet{
 		MessageReceipt: types.MessageReceipt{
 			ExitCode: errcode,
 			Return:   ret,
 			GasUsed:  gasUsed,
 		},
-		ActorErr: actorErr,
+		ActorErr:       actorErr,
+		ExecutionTrace: *newExecutionTrace(msg, ret, actorErr, gasUsed, subcalls),
 	}, nil
 }
 
@@ -599,6 +647,16 @@ func (vm *VM) TransferFunds(from, to address.Address, amt types.BigInt) error {
 
 func (vm *VM) SetBlockHeight(h uint64) {
 	vm.blockHeight = h
+	vm.pricelist = PricelistByHeight(h)
+}
+
+// SetInvoker replaces the actor code available to the VM
+func (vm *VM) SetInvoker(i *Invoker) {
+	vm.inv = i
+}
+
+func (vm *VM) NetworkVersion() types.NetworkVersion {
+	return vm.ntwkVersion
 }
 
 func (vm *VM) Invoke(act *types.Actor, vmctx *VMContext, method uint64, params []byte) ([]byte, aerrors.ActorError) {
@@ -610,9 +668,6 @@ func (vm *VM) Invoke(act *types.Actor, vmctx *VMContext, method uint64, params [
 	defer func() {
 		vmctx.ctx = oldCtx
 	}()
-	if err := vmctx.ChargeGas(gasInvoke); err != nil {
-		return nil, aerrors.Wrap(err, "invokeing")
-	}
 	ret, err := vm.inv.Invoke(act, vmctx, method, params)
 	if err != nil {
 		return nil, err
@@ -644,14 +699,3 @@ func deductFunds(act *types.Actor, amt types.BigInt) error {
 func depositFunds(act *types.Actor, amt types.BigInt) {
 	act.Balance = types.BigAdd(act.Balance, amt)
 }
-
-var miningRewardTotal = types.FromFil(build.MiningRewardTotal)
-
-// MiningReward returns correct mining reward
-//   coffer is amount of FIL in NetworkAddress
-func MiningReward(remainingReward types.BigInt) types.BigInt {
-	ci := big.NewInt(0).Set(remainingReward.Int)
-	res := ci.Mul(ci, build.InitialReward)
-	res = res.Div(res, miningRewardTotal.Int)
-	return types.BigInt{res}
-}
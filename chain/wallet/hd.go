@@ -0,0 +1,253 @@
+package wallet
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/binary"
+	"io"
+	"math/big"
+
+	secp256k1 "github.com/ipsn/go-secp256k1"
+	"github.com/tyler-smith/go-bip39"
+	"golang.org/x/crypto/hkdf"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-lotus/chain/types"
+)
+
+const (
+	// KTHDSeed is the type of the keystore entry holding the HD wallet seed
+	KTHDSeed = "hd-seed"
+	// KTHDIndex is the type of the keystore entry holding the next
+	// derivation index of each key type
+	KTHDIndex = "hd-index"
+
+	// HDSeedName is the keystore name of the HD wallet seed. It is written
+	// once, so that updating the index can't lose the seed
+	HDSeedName = "hd-seed"
+	// HDIndexName is the keystore name of the next derivation indexes
+	HDIndexName = "hd-index"
+
+	// mnemonicBits is the entropy of new mnemonics, giving 24 words
+	mnemonicBits = 256
+
+	hardened = 0x80000000
+
+	// filecoinCoinType is the SLIP-44 coin type of Filecoin
+	filecoinCoinType = 461
+
+	// hdGapLimit is how far past the next derivation index keys can be
+	// derived, all keys up to the derived one are kept in memory
+	hdGapLimit = 20
+)
+
+// ErrNoHDSeed is returned when deriving keys before a HD seed was set up
+var ErrNoHDSeed = xerrors.New("wallet has no HD seed")
+
+var (
+	secp256k1N = secp256k1.S256().Params().N
+
+	// blsR is the order of the BLS12-381 scalar field
+	blsR, _ = new(big.Int).SetString("73eda753299d7d483339d80809a1d80553bda402fffe5bfeffffffff00000001", 16)
+)
+
+// hdPaths are the derivation paths of each key type, the key index is
+// appended to them. All secp256k1 path elements are hardened, EIP-2333 only
+// has hardened derivation
+var hdPaths = map[string][]uint32{
+	types.KTSecp256k1: {44, filecoinCoinType, 0, 0},
+	types.KTBLS:       {12381, filecoinCoinType, 0},
+}
+
+// NewMnemonic generates a random BIP-39 mnemonic
+func NewMnemonic() (string, error) {
+	entropy, err := bip39.NewEntropy(mnemonicBits)
+	if err != nil {
+		return "", err
+	}
+
+	return bip39.NewMnemonic(entropy)
+}
+
+// SeedFromMnemonic returns the BIP-39 seed of the mnemonic, checking that
+// the mnemonic is valid
+func SeedFromMnemonic(mnemonic string) ([]byte, error) {
+	seed, err := bip39.NewSeedWithErrorChecking(mnemonic, "")
+	if err != nil {
+		return nil, xerrors.Errorf("invalid mnemonic: %w", err)
+	}
+	return seed, nil
+}
+
+// DeriveKey deterministically derives the key of the given type at index from
+// the seed. secp256k1 keys use BIP-32 along m/44'/461'/0'/0'/index', BLS keys
+// use EIP-2333 along the EIP-2334 path m/12381/461/0/index
+func DeriveKey(seed []byte, typ string, index uint64) (*Key, error) {
+	path, ok := hdPaths[typ]
+	if !ok {
+		return nil, xerrors.Errorf("invalid key type: %s", typ)
+	}
+	if index >= hardened {
+		return nil, xerrors.Errorf("derivation index %d too large", index)
+	}
+
+	path = append(append([]uint32{}, path...), uint32(index))
+
+	var priv []byte
+	var err error
+	switch typ {
+	case types.KTSecp256k1:
+		priv, err = bip32Derive(seed, path)
+	case types.KTBLS:
+		priv, err = eip2333Derive(seed, path)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return NewKey(types.KeyInfo{
+		Type:       typ,
+		PrivateKey: priv,
+	})
+}
+
+func bip32Derive(seed []byte, path []uint32) ([]byte, error) {
+	k, c, err := bip32Master(seed)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, i := range path {
+		k, c, err = bip32Child(k, c, i)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return k, nil
+}
+
+func bip32Master(seed []byte) ([]byte, []byte, error) {
+	mac := hmac.New(sha512.New, []byte("Bitcoin seed"))
+	mac.Write(seed) //nolint:errcheck
+	I := mac.Sum(nil)
+
+	il := new(big.Int).SetBytes(I[:32])
+	if il.Sign() == 0 || il.Cmp(secp256k1N) >= 0 {
+		return nil, nil, xerrors.New("seed gives an invalid master key")
+	}
+
+	return I[:32], I[32:], nil
+}
+
+// bip32Child derives the hardened child i of the private key k with chain
+// code c
+func bip32Child(k, c []byte, i uint32) ([]byte, []byte, error) {
+	data := make([]byte, 37)
+	copy(data[1:33], k)
+	binary.BigEndian.PutUint32(data[33:], i+hardened)
+
+	mac := hmac.New(sha512.New, c)
+	mac.Write(data) //nolint:errcheck
+	I := mac.Sum(nil)
+
+	il := new(big.Int).SetBytes(I[:32])
+	if il.Cmp(secp256k1N) >= 0 {
+		return nil, nil, xerrors.Errorf("invalid key at derivation index %d", i)
+	}
+
+	il.Add(il, new(big.Int).SetBytes(k))
+	il.Mod(il, secp256k1N)
+	if il.Sign() == 0 {
+		return nil, nil, xerrors.Errorf("invalid key at derivation index %d", i)
+	}
+
+	child := make([]byte, 32)
+	ib := il.Bytes()
+	copy(child[32-len(ib):], ib)
+
+	return child, I[32:], nil
+}
+
+// eip2333Derive derives the BLS private key at path from the seed with
+// EIP-2333. BLS private keys are scalars stored in little endian
+func eip2333Derive(seed []byte, path []uint32) ([]byte, error) {
+	if len(seed) < 32 {
+		return nil, xerrors.New("seed must be at least 32 bytes")
+	}
+
+	sk, err := hkdfModR(seed)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, i := range path {
+		sk, err = hkdfModR(lamportPK(sk, i))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	out := make([]byte, 32)
+	be := sk.Bytes()
+	for i, b := range be {
+		out[len(be)-1-i] = b
+	}
+	return out, nil
+}
+
+// hkdfModR is HKDF_mod_r of EIP-2333
+func hkdfModR(ikm []byte) (*big.Int, error) {
+	const l = 48
+
+	salt := []byte("BLS-SIG-KEYGEN-SALT-")
+	sk := new(big.Int)
+	for sk.Sign() == 0 {
+		h := sha256.Sum256(salt)
+		salt = h[:]
+
+		prk := hkdf.Extract(sha256.New, append(append([]byte{}, ikm...), 0), salt)
+		okm := make([]byte, l)
+		if _, err := io.ReadFull(hkdf.Expand(sha256.New, prk, []byte{0, l}), okm); err != nil {
+			return nil, err
+		}
+
+		sk.SetBytes(okm)
+		sk.Mod(sk, blsR)
+	}
+
+	return sk, nil
+}
+
+// lamportPK is parent_SK_to_lamport_PK of EIP-2333, it returns the compressed
+// lamport public key of the child index of the parent key
+func lamportPK(parent *big.Int, index uint32) []byte {
+	salt := make([]byte, 4)
+	binary.BigEndian.PutUint32(salt, index)
+
+	ikm := make([]byte, 32)
+	pb := parent.Bytes()
+	copy(ikm[32-len(pb):], pb)
+
+	notIkm := make([]byte, 32)
+	for i, b := range ikm {
+		notIkm[i] = ^b
+	}
+
+	pk := sha256.New()
+	for _, k := range [][]byte{ikm, notIkm} {
+		// IKM_to_lamport_SK gives 255 chunks of 32 bytes
+		lsk := hkdf.New(sha256.New, k, salt, nil)
+		chunk := make([]byte, 32)
+		for i := 0; i < 255; i++ {
+			if _, err := io.ReadFull(lsk, chunk); err != nil {
+				panic(err) // can't read past the HKDF limit of 255 chunks
+			}
+			h := sha256.Sum256(chunk)
+			pk.Write(h[:]) //nolint:errcheck
+		}
+	}
+
+	return pk.Sum(nil)
+}
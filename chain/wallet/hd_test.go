@@ -0,0 +1,144 @@
+package wallet
+
+import (
+	"context"
+	"encoding/hex"
+	"testing"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/filecoin-project/go-lotus/chain/address"
+	"github.com/filecoin-project/go-lotus/chain/types"
+)
+
+func TestBIP32Vector(t *testing.T) {
+	// test vector 1 from BIP-32
+	seed, _ := hex.DecodeString("000102030405060708090a0b0c0d0e0f")
+
+	k, c, err := bip32Master(seed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "e8f32e723decf4051aefac8e2c93c9c5b214313817cdb01a1494b917c8436b35", hex.EncodeToString(k))
+	assert.Equal(t, "873dff81c02f525623fd1fe5167eac3a55a049de3d314bb42ee227ffed37d508", hex.EncodeToString(c))
+
+	// m/0'
+	k, c, err = bip32Child(k, c, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "edb2e14f9ee77d26dd93b4ecede8d16ed408ce149b6cd80b0715a2d911a0afea", hex.EncodeToString(k))
+	assert.Equal(t, "47fdacbd0f1097043b78c63c20c34ef4ed9a111d980047ad16282c7ae6236141", hex.EncodeToString(c))
+}
+
+func TestHDWallet(t *testing.T) {
+	mnemonic, err := NewMnemonic()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ks := NewMemKeyStore()
+	ds := datastore.NewMapDatastore()
+	w, err := NewWallet(ks, ds)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, _, err = w.GenerateHDKey(types.KTSecp256k1)
+	assert.Equal(t, ErrNoHDSeed, err)
+
+	assert.Error(t, w.InitHD("not a mnemonic"))
+	assert.NoError(t, w.InitHD(mnemonic))
+	assert.Error(t, w.InitHD(mnemonic), "seed can only be set up once")
+
+	a0, i0, err := w.GenerateHDKey(types.KTSecp256k1)
+	assert.NoError(t, err)
+	a1, i1, err := w.GenerateHDKey(types.KTSecp256k1)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(0), i0)
+	assert.Equal(t, uint64(1), i1)
+	assert.NotEqual(t, a0, a1)
+
+	has, err := w.HasKey(a1)
+	assert.NoError(t, err)
+	assert.True(t, has)
+
+	// only the seed and the index are stored
+	names, err := ks.List()
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{HDSeedName, HDIndexName}, names)
+
+	// restoring from the mnemonic gives the same keys
+	r, err := NewWallet(NewMemKeyStore(), datastore.NewMapDatastore())
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.NoError(t, r.InitHD(mnemonic))
+
+	addrs, err := r.ListAddrs()
+	assert.NoError(t, err)
+	assert.Empty(t, addrs)
+
+	ra1, err := r.DeriveHDKey(types.KTSecp256k1, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, a1, ra1)
+
+	addrs, err = r.ListAddrs()
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []address.Address{a0, a1}, addrs)
+
+	// a wallet opened later loads the derived keys
+	w2, err := NewWallet(ks, ds)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sig, err := w2.Sign(context.TODO(), a0, []byte("data"))
+	assert.NoError(t, err)
+	assert.Equal(t, types.KTSecp256k1, sig.Type)
+}
+
+func TestEIP2333Vector(t *testing.T) {
+	// test case 0 from EIP-2333
+	seed, _ := hex.DecodeString("c55257c360c07c72029aebc1b53c05ed0362ada38ead3e3e9efa3708e53495531f09a6987599d18264c1e1c92f2cf141630c7a3c4ab7c81b2f001698e7463b04")
+
+	master, err := hkdfModR(seed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "6083874454709270928345386274498605044986640685124978867557563392430687146096", master.String())
+
+	child, err := hkdfModR(lamportPK(master, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, "20397789859736650942317412262472558107875392172444076792671091975210932703118", child.String())
+}
+
+func TestHDGapLimit(t *testing.T) {
+	mnemonic, err := NewMnemonic()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := NewWallet(NewMemKeyStore(), datastore.NewMapDatastore())
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.NoError(t, w.InitHD(mnemonic))
+
+	_, err = w.DeriveHDKey(types.KTBLS, hdGapLimit)
+	assert.Error(t, err, "deriving too far past the next index")
+
+	_, err = w.DeriveHDKey(types.KTBLS, hdGapLimit-1)
+	assert.NoError(t, err)
+
+	// the gap is counted from the new next index
+	_, err = w.DeriveHDKey(types.KTBLS, 2*hdGapLimit-1)
+	assert.NoError(t, err)
+
+	addrs, err := w.ListAddrs()
+	assert.NoError(t, err)
+	assert.Len(t, addrs, 2*hdGapLimit)
+}
@@ -0,0 +1,302 @@
+package wallet
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/namespace"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-lotus/api"
+	"github.com/filecoin-project/go-lotus/chain/actors"
+	"github.com/filecoin-project/go-lotus/chain/address"
+	"github.com/filecoin-project/go-lotus/chain/types"
+	"github.com/filecoin-project/go-lotus/node/modules/dtypes"
+)
+
+// ErrPolicyViolation is returned when a message is not allowed by a spend
+// policy
+var ErrPolicyViolation = xerrors.New("spend policy violation")
+
+// PolicyStore keeps the spend policies of wallet addresses, and the value
+// spent under each policy per day.
+//
+// Entries are stored as JSON, which unlike cbor keeps unset limits apart from
+// zero ones
+type PolicyStore struct {
+	lk sync.Mutex
+
+	ds datastore.Datastore
+
+	// now is replaced in tests
+	now func() time.Time
+}
+
+// AddressResolver resolves an address to the ID address of its actor. It
+// returns the address unchanged if there is no actor for it yet
+type AddressResolver func(address.Address) (address.Address, error)
+
+// spendRecord is the value spent under a policy on Day, counted in days since
+// the unix epoch
+type spendRecord struct {
+	Day   int64
+	Spent types.BigInt
+}
+
+func NewPolicyStore(ds dtypes.MetadataDS) *PolicyStore {
+	return &PolicyStore{
+		ds:  namespace.Wrap(ds, datastore.NewKey("/wallet/")),
+		now: time.Now,
+	}
+}
+
+func policyKey(addr address.Address) datastore.Key {
+	return datastore.NewKey("/policy/" + addr.String())
+}
+
+func spendKey(id string) datastore.Key {
+	return datastore.NewKey("/spent/" + id)
+}
+
+// AddressPolicy returns the spend policy of addr, or nil if it has none
+func (ps *PolicyStore) AddressPolicy(addr address.Address) (*api.SpendPolicy, error) {
+	ps.lk.Lock()
+	defer ps.lk.Unlock()
+
+	b, err := ps.ds.Get(policyKey(addr))
+	if err == datastore.ErrNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, xerrors.Errorf("getting spend policy of %s: %w", addr, err)
+	}
+
+	var p api.SpendPolicy
+	if err := json.Unmarshal(b, &p); err != nil {
+		return nil, xerrors.Errorf("decoding spend policy of %s: %w", addr, err)
+	}
+
+	return &p, nil
+}
+
+// SetAddressPolicy sets the spend policy of addr, a nil policy removes it.
+// Setting a policy resets its daily spending
+func (ps *PolicyStore) SetAddressPolicy(addr address.Address, p *api.SpendPolicy) error {
+	ps.lk.Lock()
+	defer ps.lk.Unlock()
+
+	id := "addr-" + addr.String()
+	if err := ps.ds.Delete(spendKey(id)); err != nil && err != datastore.ErrNotFound {
+		return xerrors.Errorf("resetting spending of %s: %w", addr, err)
+	}
+
+	if p == nil {
+		if err := ps.ds.Delete(policyKey(addr)); err != nil && err != datastore.ErrNotFound {
+			return xerrors.Errorf("removing spend policy of %s: %w", addr, err)
+		}
+		return nil
+	}
+
+	pc := *p
+	pc.ID = id
+
+	b, err := json.Marshal(&pc)
+	if err != nil {
+		return err
+	}
+
+	return ps.ds.Put(policyKey(addr), b)
+}
+
+// Spend checks that the message is allowed by all the policies, and adds its
+// cost to their daily spending. toCode is the code of the receiving actor, or
+// cid.Undef if it doesn't exist yet. Receivers are compared by ID address
+// through resolve. Nothing is recorded when a policy rejects the message
+func (ps *PolicyStore) Spend(msg *types.Message, toCode cid.Cid, resolve AddressResolver, policies ...*api.SpendPolicy) error {
+	ps.lk.Lock()
+	defer ps.lk.Unlock()
+
+	day := ps.today()
+	cost := messageCost(msg)
+
+	records := make([]*spendRecord, len(policies))
+	for i, p := range policies {
+		if err := checkMessage(p, msg, toCode, resolve, cost); err != nil {
+			return err
+		}
+
+		if p.DailyLimit.Nil() {
+			continue
+		}
+
+		rec, err := ps.spent(p.ID, day)
+		if err != nil {
+			return err
+		}
+
+		total := types.BigAdd(rec.Spent, cost)
+		if total.GreaterThan(p.DailyLimit) {
+			return xerrors.Errorf("cost %s exceeds the remaining daily limit of %s: %w", cost, types.BigSub(p.DailyLimit, rec.Spent), ErrPolicyViolation)
+		}
+
+		rec.Spent = total
+		records[i] = rec
+	}
+
+	for i, rec := range records {
+		if rec == nil {
+			continue
+		}
+
+		b, err := json.Marshal(rec)
+		if err != nil {
+			return err
+		}
+
+		if err := ps.ds.Put(spendKey(policies[i].ID), b); err != nil {
+			return xerrors.Errorf("recording spending: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// Refund takes the cost of a message recorded by Spend off the daily spending
+// of the policies, for messages which were signed but couldn't be sent
+func (ps *PolicyStore) Refund(msg *types.Message, policies ...*api.SpendPolicy) error {
+	ps.lk.Lock()
+	defer ps.lk.Unlock()
+
+	day := ps.today()
+	cost := messageCost(msg)
+
+	for _, p := range policies {
+		if p.DailyLimit.Nil() {
+			continue
+		}
+
+		rec, err := ps.spent(p.ID, day)
+		if err != nil {
+			return err
+		}
+
+		rec.Spent = types.BigSub(rec.Spent, cost)
+		if rec.Spent.Sign() < 0 {
+			rec.Spent = types.NewInt(0)
+		}
+
+		b, err := json.Marshal(rec)
+		if err != nil {
+			return err
+		}
+
+		if err := ps.ds.Put(spendKey(p.ID), b); err != nil {
+			return xerrors.Errorf("recording spending: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// today returns the current day, counted in days since the unix epoch
+func (ps *PolicyStore) today() int64 {
+	return ps.now().Unix() / int64(24*time.Hour/time.Second)
+}
+
+// messageCost is the most the message can take from the sender, its value and
+// the gas it can pay for
+func messageCost(msg *types.Message) types.BigInt {
+	m := *msg
+	for _, v := range []*types.BigInt{&m.Value, &m.GasPrice, &m.GasLimit} {
+		if v.Nil() {
+			*v = types.NewInt(0)
+		}
+	}
+	return m.RequiredFunds()
+}
+
+// spent returns the value spent under the policy on day. Must be called with
+// ps.lk held
+func (ps *PolicyStore) spent(id string, day int64) (*spendRecord, error) {
+	rec := &spendRecord{
+		Day:   day,
+		Spent: types.NewInt(0),
+	}
+
+	b, err := ps.ds.Get(spendKey(id))
+	if err == datastore.ErrNotFound {
+		return rec, nil
+	}
+	if err != nil {
+		return nil, xerrors.Errorf("getting spending: %w", err)
+	}
+
+	var stored spendRecord
+	if err := json.Unmarshal(b, &stored); err != nil {
+		return nil, xerrors.Errorf("decoding spending: %w", err)
+	}
+	if stored.Day == day {
+		rec.Spent = stored.Spent
+	}
+
+	return rec, nil
+}
+
+// checkMessage checks the limits of the policy which don't depend on past
+// spending
+func checkMessage(p *api.SpendPolicy, msg *types.Message, toCode cid.Cid, resolve AddressResolver, cost types.BigInt) error {
+	if !p.MaxValue.Nil() && cost.GreaterThan(p.MaxValue) {
+		return xerrors.Errorf("cost %s exceeds the limit of %s per message: %w", cost, p.MaxValue, ErrPolicyViolation)
+	}
+
+	// multisig and payment channel actors move funds the message value
+	// doesn't show, so they can't be used within value limits
+	if !p.MaxValue.Nil() || !p.DailyLimit.Nil() {
+		if toCode == actors.MultisigActorCodeCid || toCode == actors.PaymentChannelActorCodeCid {
+			return xerrors.Errorf("sending to %s is not allowed with value limits, it can move funds: %w", msg.To, ErrPolicyViolation)
+		}
+	}
+
+	if len(p.To) > 0 {
+		// the receiver and the allowed addresses may be given in different
+		// forms of the same account
+		msgTo, err := resolve(msg.To)
+		if err != nil {
+			return xerrors.Errorf("resolving receiver %s: %w", msg.To, err)
+		}
+
+		allowed := false
+		for _, to := range p.To {
+			to, err := resolve(to)
+			if err != nil {
+				return xerrors.Errorf("resolving allowed receiver: %w", err)
+			}
+
+			if to == msgTo {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return xerrors.Errorf("sending to %s is not allowed: %w", msg.To, ErrPolicyViolation)
+		}
+	}
+
+	if len(p.Methods) > 0 {
+		allowed := false
+		for _, m := range p.Methods {
+			if m == msg.Method {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return xerrors.Errorf("calling method %d is not allowed: %w", msg.Method, ErrPolicyViolation)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,159 @@
+package wallet
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ipfs/go-cid"
+	"github.com/ipfs/go-datastore"
+	dssync "github.com/ipfs/go-datastore/sync"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-lotus/api"
+	"github.com/filecoin-project/go-lotus/chain/actors"
+	"github.com/filecoin-project/go-lotus/chain/address"
+	"github.com/filecoin-project/go-lotus/chain/types"
+)
+
+func TestSpendPolicy(t *testing.T) {
+	ps := NewPolicyStore(dssync.MutexWrap(datastore.NewMapDatastore()))
+
+	now := time.Date(2019, 9, 1, 12, 0, 0, 0, time.UTC)
+	ps.now = func() time.Time { return now }
+
+	from, _ := address.NewIDAddress(100)
+	to, _ := address.NewIDAddress(101)
+	other, _ := address.NewIDAddress(102)
+
+	msg := func(to address.Address, value uint64, method uint64) *types.Message {
+		return &types.Message{
+			From:   from,
+			To:     to,
+			Value:  types.NewInt(value),
+			Method: method,
+		}
+	}
+
+	assert.NoError(t, ps.SetAddressPolicy(from, &api.SpendPolicy{
+		MaxValue:   types.NewInt(10),
+		DailyLimit: types.NewInt(25),
+		To:         []address.Address{to},
+		Methods:    []uint64{0},
+	}))
+
+	p, err := ps.AddressPolicy(from)
+	assert.NoError(t, err)
+	assert.Equal(t, "addr-"+from.String(), p.ID)
+	assert.Equal(t, 0, types.BigCmp(p.MaxValue, types.NewInt(10)))
+
+	none, err := ps.AddressPolicy(to)
+	assert.NoError(t, err)
+	assert.Nil(t, none)
+
+	violation := func(err error) {
+		t.Helper()
+		assert.True(t, xerrors.Is(err, ErrPolicyViolation), "expected policy violation, got %v", err)
+	}
+
+	violation(ps.Spend(msg(to, 11, 0), cid.Undef, noResolve, p))
+	violation(ps.Spend(msg(other, 1, 0), cid.Undef, noResolve, p))
+	violation(ps.Spend(msg(to, 1, 2), cid.Undef, noResolve, p))
+
+	assert.NoError(t, ps.Spend(msg(to, 10, 0), cid.Undef, noResolve, p))
+	assert.NoError(t, ps.Spend(msg(to, 10, 0), cid.Undef, noResolve, p))
+	violation(ps.Spend(msg(to, 10, 0), cid.Undef, noResolve, p))
+	assert.NoError(t, ps.Spend(msg(to, 5, 0), cid.Undef, noResolve, p))
+
+	// a rejection by one policy records nothing for the others
+	token := &api.SpendPolicy{ID: "token", MaxValue: types.NewInt(1), DailyLimit: types.NewInt(100)}
+	violation(ps.Spend(msg(to, 2, 0), cid.Undef, noResolve, token, p))
+	violation(ps.Spend(msg(to, 1, 0), cid.Undef, noResolve, p, token))
+
+	// the limit resets the next day
+	now = now.Add(24 * time.Hour)
+	assert.NoError(t, ps.Spend(msg(to, 1, 0), cid.Undef, noResolve, p, token))
+
+	// unset limits don't apply
+	assert.NoError(t, ps.Spend(msg(other, 1000, 5), cid.Undef, noResolve, &api.SpendPolicy{ID: "unlimited"}))
+
+	assert.NoError(t, ps.SetAddressPolicy(from, nil))
+	p, err = ps.AddressPolicy(from)
+	assert.NoError(t, err)
+	assert.Nil(t, p)
+}
+
+func TestSpendPolicyCost(t *testing.T) {
+	ps := NewPolicyStore(dssync.MutexWrap(datastore.NewMapDatastore()))
+
+	from, _ := address.NewIDAddress(100)
+	to, _ := address.NewIDAddress(101)
+
+	msg := func(value, gasLimit uint64) *types.Message {
+		return &types.Message{
+			From:     from,
+			To:       to,
+			Value:    types.NewInt(value),
+			GasPrice: types.NewInt(2),
+			GasLimit: types.NewInt(gasLimit),
+		}
+	}
+
+	p := &api.SpendPolicy{ID: "token", MaxValue: types.NewInt(20), DailyLimit: types.NewInt(30)}
+
+	violation := func(err error) {
+		t.Helper()
+		assert.True(t, xerrors.Is(err, ErrPolicyViolation), "expected policy violation, got %v", err)
+	}
+
+	// the gas the message can pay for counts
+	violation(ps.Spend(msg(10, 6), cid.Undef, noResolve, p))
+	assert.NoError(t, ps.Spend(msg(10, 5), cid.Undef, noResolve, p))
+	violation(ps.Spend(msg(10, 1), cid.Undef, noResolve, p))
+
+	// refunded messages don't count
+	assert.NoError(t, ps.Refund(msg(10, 5), p))
+	assert.NoError(t, ps.Spend(msg(10, 5), cid.Undef, noResolve, p))
+	assert.NoError(t, ps.Spend(msg(6, 2), cid.Undef, noResolve, p))
+	violation(ps.Spend(msg(0, 1), cid.Undef, noResolve, p))
+
+	// multisig and payment channel actors can move funds past the limits
+	violation(ps.Spend(msg(0, 0), actors.MultisigActorCodeCid, noResolve, &api.SpendPolicy{ID: "max", MaxValue: types.NewInt(20)}))
+	violation(ps.Spend(msg(0, 0), actors.PaymentChannelActorCodeCid, noResolve, &api.SpendPolicy{ID: "daily", DailyLimit: types.NewInt(20)}))
+	assert.NoError(t, ps.Spend(msg(0, 0), actors.MultisigActorCodeCid, noResolve, &api.SpendPolicy{ID: "to", To: []address.Address{to}}))
+}
+
+func noResolve(addr address.Address) (address.Address, error) {
+	return addr, nil
+}
+
+func TestSpendPolicyResolvesReceivers(t *testing.T) {
+	ps := NewPolicyStore(dssync.MutexWrap(datastore.NewMapDatastore()))
+
+	from, _ := address.NewIDAddress(100)
+	toID, _ := address.NewIDAddress(101)
+	toKey, err := address.NewSecp256k1Address([]byte("to"))
+	assert.NoError(t, err)
+	otherKey, err := address.NewSecp256k1Address([]byte("other"))
+	assert.NoError(t, err)
+
+	resolve := func(addr address.Address) (address.Address, error) {
+		if addr == toKey {
+			return toID, nil
+		}
+		return addr, nil
+	}
+
+	msg := func(to address.Address) *types.Message {
+		return &types.Message{From: from, To: to, Value: types.NewInt(1)}
+	}
+
+	byID := &api.SpendPolicy{ID: "by-id", To: []address.Address{toID}}
+	byKey := &api.SpendPolicy{ID: "by-key", To: []address.Address{toKey}}
+
+	assert.NoError(t, ps.Spend(msg(toKey), cid.Undef, resolve, byID))
+	assert.NoError(t, ps.Spend(msg(toID), cid.Undef, resolve, byKey))
+
+	err = ps.Spend(msg(otherKey), cid.Undef, resolve, byID)
+	assert.True(t, xerrors.Is(err, ErrPolicyViolation), "expected policy violation, got %v", err)
+}
@@ -1,7 +1,9 @@
 package wallet
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"sort"
 	"strings"
 	"sync"
@@ -9,35 +11,159 @@ import (
 	"github.com/filecoin-project/go-bls-sigs"
 	"github.com/filecoin-project/go-lotus/node/repo"
 
+	"github.com/ipfs/go-datastore"
+	"github.com/ipfs/go-datastore/namespace"
+	logging "github.com/ipfs/go-log"
 	"github.com/minio/blake2b-simd"
 	"golang.org/x/xerrors"
 
+	"github.com/filecoin-project/go-lotus/api"
 	"github.com/filecoin-project/go-lotus/chain/address"
 	"github.com/filecoin-project/go-lotus/chain/types"
 	"github.com/filecoin-project/go-lotus/lib/crypto"
+	"github.com/filecoin-project/go-lotus/node/modules/dtypes"
 )
 
+var log = logging.Logger("wallet")
+
 const (
 	KNamePrefix = "wallet-"
+	// KTrashPrefix is the keystore namespace deleted keys are moved to
+	KTrashPrefix = "trash-"
 )
 
+// defaultKey is the metadata datastore key of the default wallet address
+var defaultKey = datastore.NewKey("/default")
+
 type Wallet struct {
 	keys     map[address.Address]*Key
 	keystore types.KeyStore
+	// ds keeps the wallet settings which aren't secret
+	ds datastore.Datastore
+
+	// remote is an optional backend, preferred for the keys it holds
+	remote api.Wallet
+	// localKeys keeps new and imported keys in the local keystore when a
+	// remote is used
+	localKeys bool
+
+	// hd is the HD wallet state, loaded from the keystore on first use. The
+	// derived keys are kept in keys, and their addresses in hdAddrs
+	hd      *hdState
+	hdAddrs []address.Address
 
 	lk sync.Mutex
 }
 
-func NewWallet(keystore types.KeyStore) (*Wallet, error) {
+// hdState is kept in the HD seed and index keystore entries, derived keys are
+// never stored
+type hdState struct {
+	Seed []byte
+
+	// Next is the next derivation index of each key type
+	Next map[string]uint64
+}
+
+func NewWallet(keystore types.KeyStore, ds dtypes.MetadataDS) (*Wallet, error) {
 	w := &Wallet{
 		keys:     make(map[address.Address]*Key),
 		keystore: keystore,
+		ds:       namespace.Wrap(ds, datastore.NewKey("/wallet/")),
+	}
+
+	return w, nil
+}
+
+// NewRemoteBackedWallet creates a wallet which signs with the keys of the
+// remote backend, falling back to the local keystore for keys the remote
+// doesn't hold. New and imported keys are stored by the remote, unless
+// localKeys is set
+func NewRemoteBackedWallet(keystore types.KeyStore, ds dtypes.MetadataDS, remote api.Wallet, localKeys bool) (*Wallet, error) {
+	w, err := NewWallet(keystore, ds)
+	if err != nil {
+		return nil, err
 	}
 
+	w.remote = remote
+	w.localKeys = localKeys
 	return w, nil
 }
 
+// storesRemotely returns whether new and imported keys go to the remote
+func (w *Wallet) storesRemotely() bool {
+	return w.remote != nil && !w.localKeys
+}
+
+// remoteHas returns whether the remote backend holds the key. Errors talking
+// to the remote are logged, so that local keys can still be used
+func (w *Wallet) remoteHas(ctx context.Context, addr address.Address) bool {
+	if w.remote == nil {
+		return false
+	}
+
+	has, err := w.remote.WalletHas(ctx, addr)
+	if err != nil {
+		log.Warnf("checking remote wallet for key %s: %s", addr, err)
+		return false
+	}
+	return has
+}
+
+// LockableKeyStore is a keystore which can be locked, like
+// repo.EncryptedKeyStore
+type LockableKeyStore interface {
+	types.KeyStore
+
+	Lock()
+	Unlock(passphrase []byte) error
+}
+
+// Lock clears all keys from memory and locks the keystore. Signing fails until
+// the wallet is unlocked again
+func (w *Wallet) Lock() error {
+	lks, ok := w.keystore.(LockableKeyStore)
+	if !ok {
+		return xerrors.New("wallet keystore can't be locked, it isn't encrypted")
+	}
+
+	w.lk.Lock()
+	defer w.lk.Unlock()
+
+	for addr, k := range w.keys {
+		for i := range k.PrivateKey {
+			k.PrivateKey[i] = 0
+		}
+		delete(w.keys, addr)
+	}
+
+	if w.hd != nil {
+		for i := range w.hd.Seed {
+			w.hd.Seed[i] = 0
+		}
+		w.hd = nil
+		w.hdAddrs = nil
+	}
+
+	lks.Lock()
+	return nil
+}
+
+// Unlock unlocks the keystore with the passphrase, keys are loaded again when
+// they are used
+func (w *Wallet) Unlock(passphrase []byte) error {
+	lks, ok := w.keystore.(LockableKeyStore)
+	if !ok {
+		return xerrors.New("wallet keystore can't be unlocked, it isn't encrypted")
+	}
+
+	return lks.Unlock(passphrase)
+}
+
 func (w *Wallet) Sign(ctx context.Context, addr address.Address, msg []byte) (*types.Signature, error) {
+	if w.remoteHas(ctx, addr) {
+		return w.remote.WalletSign(ctx, addr, msg)
+	}
+
 	ki, err := w.findKey(addr)
 	if err != nil {
 		return nil, err
@@ -81,6 +207,16 @@ func (w *Wallet) findKey(addr address.Address) (*Key, error) {
 	if ok {
 		return k, nil
 	}
+
+	if _, err := w.loadHD(); err != nil {
+		return nil, err
+	}
+
+	// loading the HD state may have derived the key
+	k, ok = w.keys[addr]
+	if ok {
+		return k, nil
+	}
 	ki, err := w.keystore.Get(KNamePrefix + addr.String())
 	if err != nil {
 		if xerrors.Is(err, repo.ErrKeyNotFound) {
@@ -97,15 +233,31 @@ func (w *Wallet) findKey(addr address.Address) (*Key, error) {
 }
 
 func (w *Wallet) Export(addr address.Address) (*types.KeyInfo, error) {
+	if w.remoteHas(context.TODO(), addr) {
+		return w.remote.WalletExport(context.TODO(), addr)
+	}
+
 	k, err := w.findKey(addr)
 	if err != nil {
 		return nil, xerrors.Errorf("failed to find key to export: %w", err)
 	}
+	if k == nil {
+		return nil, xerrors.Errorf("exporting key '%s': %w", addr, repo.ErrKeyNotFound)
+	}
 
 	return &k.KeyInfo, nil
 }
 
 func (w *Wallet) Import(ki *types.KeyInfo) (address.Address, error) {
+	if w.storesRemotely() {
+		addr, err := w.remote.WalletImport(context.TODO(), ki)
+		if err != nil {
+			// lotus-wallet only accepts imports when run with --allow-export
+			return address.Undef, xerrors.Errorf("remote wallet refused to import the key (run lotus-wallet with --allow-export, or set Wallet.LocalKeys to keep new keys in the local keystore): %w", err)
+		}
+		return addr, nil
+	}
+
 	w.lk.Lock()
 	defer w.lk.Unlock()
 
@@ -127,8 +279,6 @@ func (w *Wallet) ListAddrs() ([]address.Address, error) {
 		return nil, xerrors.Errorf("listing keystore: %w", err)
 	}
 
-	sort.Strings(all)
-
 	out := make([]address.Address, 0, len(all))
 	for _, a := range all {
 		if strings.HasPrefix(a, KNamePrefix) {
@@ -141,6 +291,35 @@ func (w *Wallet) ListAddrs() ([]address.Address, error) {
 		}
 	}
 
+	w.lk.Lock()
+	_, err = w.loadHD()
+	out = append(out, w.hdAddrs...)
+	w.lk.Unlock()
+	if err != nil && !xerrors.Is(err, repo.ErrKeyStoreLocked) {
+		return nil, err
+	}
+
+	if w.remote != nil {
+		raddrs, err := w.remote.WalletList(context.TODO())
+		if err != nil {
+			return nil, xerrors.Errorf("listing remote wallet: %w", err)
+		}
+
+		local := make(map[address.Address]bool, len(out))
+		for _, addr := range out {
+			local[addr] = true
+		}
+		for _, addr := range raddrs {
+			if !local[addr] {
+				out = append(out, addr)
+			}
+		}
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].String() < out[j].String()
+	})
+
 	return out, nil
 }
 
@@ -171,6 +350,14 @@ func GenerateKey(typ string) (*Key, error) {
 }
 
 func (w *Wallet) GenerateKey(typ string) (address.Address, error) {
+	if w.storesRemotely() {
+		addr, err := w.remote.WalletNew(context.TODO(), typ)
+		if err != nil {
+			return address.Undef, xerrors.Errorf("remote wallet refused to create a key (set Wallet.LocalKeys to keep new keys in the local keystore): %w", err)
+		}
+		return addr, nil
+	}
+
 	w.lk.Lock()
 	defer w.lk.Unlock()
 
@@ -186,7 +373,192 @@ func (w *Wallet) GenerateKey(typ string) (address.Address, error) {
 	return k.Address, nil
 }
 
+// InitHD stores the seed of the mnemonic, after which keys can be derived
+// from it with GenerateHDKey and DeriveHDKey
+func (w *Wallet) InitHD(mnemonic string) error {
+	seed, err := SeedFromMnemonic(mnemonic)
+	if err != nil {
+		return err
+	}
+
+	w.lk.Lock()
+	defer w.lk.Unlock()
+
+	st, err := w.loadHD()
+	if err != nil {
+		return err
+	}
+	if st != nil {
+		return xerrors.New("wallet already has a HD seed")
+	}
+
+	st = &hdState{
+		Seed: seed,
+		Next: map[string]uint64{},
+	}
+	if err := w.keystore.Put(HDSeedName, types.KeyInfo{Type: KTHDSeed, PrivateKey: seed}); err != nil {
+		return xerrors.Errorf("saving HD seed: %w", err)
+	}
+	if err := w.saveHD(st); err != nil {
+		return err
+	}
+
+	w.hd = st
+	return nil
+}
+
+// GenerateHDKey derives the key of the given type at the next derivation
+// index
+func (w *Wallet) GenerateHDKey(typ string) (address.Address, uint64, error) {
+	w.lk.Lock()
+	defer w.lk.Unlock()
+
+	st, err := w.loadHD()
+	if err != nil {
+		return address.Undef, 0, err
+	}
+	if st == nil {
+		return address.Undef, 0, ErrNoHDSeed
+	}
+
+	index := st.Next[typ]
+	addr, err := w.deriveHD(st, typ, index)
+	if err != nil {
+		return address.Undef, 0, err
+	}
+
+	return addr, index, nil
+}
+
+// DeriveHDKey derives the key of the given type at index, and makes all keys
+// up to it usable. The index can be at most hdGapLimit past the next index
+func (w *Wallet) DeriveHDKey(typ string, index uint64) (address.Address, error) {
+	w.lk.Lock()
+	defer w.lk.Unlock()
+
+	st, err := w.loadHD()
+	if err != nil {
+		return address.Undef, err
+	}
+	if st == nil {
+		return address.Undef, ErrNoHDSeed
+	}
+
+	return w.deriveHD(st, typ, index)
+}
+
+// deriveHD must be called with w.lk held
+func (w *Wallet) deriveHD(st *hdState, typ string, index uint64) (address.Address, error) {
+	next := st.Next[typ]
+	if index < next {
+		k, err := DeriveKey(st.Seed, typ, index)
+		if err != nil {
+			return address.Undef, err
+		}
+		return k.Address, nil
+	}
+
+	if index-next >= hdGapLimit {
+		return address.Undef, xerrors.Errorf("derivation index %d is more than %d past the next index %d", index, hdGapLimit, next)
+	}
+
+	var keys []*Key
+	for i := next; i <= index; i++ {
+		k, err := DeriveKey(st.Seed, typ, i)
+		if err != nil {
+			return address.Undef, xerrors.Errorf("deriving %s key %d: %w", typ, i, err)
+		}
+		keys = append(keys, k)
+	}
+
+	st.Next[typ] = index + 1
+	if err := w.saveHD(st); err != nil {
+		st.Next[typ] = next
+		return address.Undef, err
+	}
+
+	for _, k := range keys {
+		w.keys[k.Address] = k
+		w.hdAddrs = append(w.hdAddrs, k.Address)
+	}
+
+	return keys[len(keys)-1].Address, nil
+}
+
+// loadHD loads the HD state and derives its keys if it wasn't loaded yet. It
+// returns nil if the wallet has no HD seed. Must be called with w.lk held
+func (w *Wallet) loadHD() (*hdState, error) {
+	if w.hd != nil {
+		return w.hd, nil
+	}
+
+	ki, err := w.keystore.Get(HDSeedName)
+	if err != nil {
+		if xerrors.Is(err, repo.ErrKeyNotFound) {
+			return nil, nil
+		}
+		return nil, xerrors.Errorf("getting HD seed from keystore: %w", err)
+	}
+
+	st := hdState{
+		Seed: ki.PrivateKey,
+		Next: map[string]uint64{},
+	}
+
+	iki, err := w.keystore.Get(HDIndexName)
+	switch {
+	case err == nil:
+		if err := json.Unmarshal(iki.PrivateKey, &st.Next); err != nil {
+			return nil, xerrors.Errorf("decoding HD derivation index: %w", err)
+		}
+	case xerrors.Is(err, repo.ErrKeyNotFound):
+		// keys can be derived again with DeriveHDKey
+		log.Warn("HD derivation index is missing")
+	default:
+		return nil, xerrors.Errorf("getting HD derivation index from keystore: %w", err)
+	}
+
+	var addrs []address.Address
+	for typ := range hdPaths {
+		for i := uint64(0); i < st.Next[typ]; i++ {
+			k, err := DeriveKey(st.Seed, typ, i)
+			if err != nil {
+				return nil, xerrors.Errorf("deriving %s key %d: %w", typ, i, err)
+			}
+			w.keys[k.Address] = k
+			addrs = append(addrs, k.Address)
+		}
+	}
+
+	w.hd = &st
+	w.hdAddrs = addrs
+	return w.hd, nil
+}
+
+// saveHD replaces the HD derivation index in the keystore. Must be called with
+// w.lk held
+func (w *Wallet) saveHD(st *hdState) error {
+	b, err := json.Marshal(st.Next)
+	if err != nil {
+		return err
+	}
+
+	if err := w.keystore.Delete(HDIndexName); err != nil && !xerrors.Is(err, repo.ErrKeyNotFound) {
+		return xerrors.Errorf("removing old HD derivation index: %w", err)
+	}
+
+	if err := w.keystore.Put(HDIndexName, types.KeyInfo{Type: KTHDIndex, PrivateKey: b}); err != nil {
+		return xerrors.Errorf("saving HD derivation index: %w", err)
+	}
+
+	return nil
+}
+
 func (w *Wallet) HasKey(addr address.Address) (bool, error) {
+	if w.remoteHas(context.TODO(), addr) {
+		return true, nil
+	}
+
 	k, err := w.findKey(addr)
 	if err != nil {
 		return false, err
@@ -194,6 +566,124 @@ func (w *Wallet) HasKey(addr address.Address) (bool, error) {
 	return k != nil, nil
 }
 
+// GetDefault returns the default address. Until one is set it is the first
+// address in the wallet
+func (w *Wallet) GetDefault() (address.Address, error) {
+	b, err := w.ds.Get(defaultKey)
+	switch err {
+	case nil:
+		return address.NewFromBytes(b)
+	case datastore.ErrNotFound:
+	default:
+		return address.Undef, xerrors.Errorf("getting default address: %w", err)
+	}
+
+	addrs, err := w.ListAddrs()
+	if err != nil {
+		return address.Undef, err
+	}
+	if len(addrs) == 0 {
+		return address.Undef, xerrors.New("no addresses in wallet")
+	}
+
+	return addrs[0], nil
+}
+
+// SetDefault makes addr, which must be in the wallet, the default address
+func (w *Wallet) SetDefault(addr address.Address) error {
+	has, err := w.HasKey(addr)
+	if err != nil {
+		return err
+	}
+	if !has {
+		return xerrors.Errorf("setting default address '%s': %w", addr, repo.ErrKeyNotFound)
+	}
+
+	if err := w.ds.Put(defaultKey, addr.Bytes()); err != nil {
+		return xerrors.Errorf("saving default address: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteKey moves the key to the trash namespace of the keystore, from where
+// it can still be recovered by hand. Keys derived from the HD seed and keys of
+// the remote backend can't be deleted
+func (w *Wallet) DeleteKey(addr address.Address) error {
+	if w.remoteHas(context.TODO(), addr) {
+		return xerrors.Errorf("key '%s' is held by the remote wallet", addr)
+	}
+
+	w.lk.Lock()
+	defer w.lk.Unlock()
+
+	if _, err := w.loadHD(); err != nil && !xerrors.Is(err, repo.ErrKeyStoreLocked) {
+		return err
+	}
+	for _, a := range w.hdAddrs {
+		if a == addr {
+			return xerrors.Errorf("key '%s' is derived from the HD seed and can't be deleted", addr)
+		}
+	}
+
+	ki, err := w.keystore.Get(KNamePrefix + addr.String())
+	if err != nil {
+		return xerrors.Errorf("deleting key '%s': %w", addr, err)
+	}
+
+	// a key deleted earlier may have been imported again
+	if err := w.keystore.Delete(KTrashPrefix + addr.String()); err != nil && !xerrors.Is(err, repo.ErrKeyNotFound) {
+		return xerrors.Errorf("removing old trashed key: %w", err)
+	}
+	if err := w.keystore.Put(KTrashPrefix+addr.String(), ki); err != nil {
+		return xerrors.Errorf("moving key to trash: %w", err)
+	}
+	if err := w.keystore.Delete(KNamePrefix + addr.String()); err != nil {
+		return xerrors.Errorf("deleting key from keystore: %w", err)
+	}
+
+	delete(w.keys, addr)
+
+	// the default address must always be in the wallet
+	def, err := w.ds.Get(defaultKey)
+	if err == nil && bytes.Equal(def, addr.Bytes()) {
+		if err := w.ds.Delete(defaultKey); err != nil {
+			return xerrors.Errorf("unsetting default address: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// The following methods implement api.Wallet, which allows serving the local
+// wallet with lotus-wallet
+
+func (w *Wallet) WalletNew(ctx context.Context, typ string) (address.Address, error) {
+	return w.GenerateKey(typ)
+}
+
+func (w *Wallet) WalletHas(ctx context.Context, addr address.Address) (bool, error) {
+	return w.HasKey(addr)
+}
+
+func (w *Wallet) WalletList(ctx context.Context) ([]address.Address, error) {
+	return w.ListAddrs()
+}
+
+func (w *Wallet) WalletSign(ctx context.Context, addr address.Address, msg []byte) (*types.Signature, error) {
+	return w.Sign(ctx, addr, msg)
+}
+
+func (w *Wallet) WalletExport(ctx context.Context, addr address.Address) (*types.KeyInfo, error) {
+	return w.Export(addr)
+}
+
+func (w *Wallet) WalletImport(ctx context.Context, ki *types.KeyInfo) (address.Address, error) {
+	return w.Import(ki)
+}
+
+var _ api.Wallet = (*Wallet)(nil)
+
 type Key struct {
 	types.KeyInfo
 
@@ -0,0 +1,256 @@
+package wallet
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ipfs/go-datastore"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-lotus/api"
+	"github.com/filecoin-project/go-lotus/chain/address"
+	"github.com/filecoin-project/go-lotus/chain/types"
+	"github.com/filecoin-project/go-lotus/node/repo"
+)
+
+func memRepoWallet(t *testing.T) (*Wallet, types.KeyStore) {
+	lr, err := repo.NewMemory(nil).Lock()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ks, err := lr.KeyStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mds, err := lr.Datastore("/metadata")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	w, err := NewWallet(ks, mds)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return w, ks
+}
+
+func TestWalletDefault(t *testing.T) {
+	w, ks := memRepoWallet(t)
+
+	_, err := w.GetDefault()
+	assert.Error(t, err, "empty wallet has no default")
+
+	a1, err := w.GenerateKey(types.KTSecp256k1)
+	assert.NoError(t, err)
+	a2, err := w.GenerateKey(types.KTSecp256k1)
+	assert.NoError(t, err)
+
+	def, err := w.GetDefault()
+	assert.NoError(t, err)
+	assert.Contains(t, []address.Address{a1, a2}, def)
+
+	assert.NoError(t, w.SetDefault(a2))
+	def, err = w.GetDefault()
+	assert.NoError(t, err)
+	assert.Equal(t, a2, def)
+
+	assert.NoError(t, w.SetDefault(a1))
+	def, err = w.GetDefault()
+	assert.NoError(t, err)
+	assert.Equal(t, a1, def)
+
+	// the default is kept out of the keystore
+	names, err := ks.List()
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{KNamePrefix + a1.String(), KNamePrefix + a2.String()}, names)
+
+	unknown, err := GenerateKey(types.KTSecp256k1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = w.SetDefault(unknown.Address)
+	assert.True(t, xerrors.Is(err, repo.ErrKeyNotFound))
+}
+
+func TestWalletDelete(t *testing.T) {
+	w, ks := memRepoWallet(t)
+
+	a1, err := w.GenerateKey(types.KTSecp256k1)
+	assert.NoError(t, err)
+	a2, err := w.GenerateKey(types.KTSecp256k1)
+	assert.NoError(t, err)
+	assert.NoError(t, w.SetDefault(a1))
+
+	ki, err := w.Export(a1)
+	assert.NoError(t, err)
+
+	assert.NoError(t, w.DeleteKey(a1))
+
+	has, err := w.HasKey(a1)
+	assert.NoError(t, err)
+	assert.False(t, has)
+
+	addrs, err := w.ListAddrs()
+	assert.NoError(t, err)
+	assert.Equal(t, []address.Address{a2}, addrs)
+
+	// the default moves on to a key still in the wallet
+	def, err := w.GetDefault()
+	assert.NoError(t, err)
+	assert.Equal(t, a2, def)
+
+	trashed, err := ks.Get(KTrashPrefix + a1.String())
+	assert.NoError(t, err)
+	assert.Equal(t, *ki, trashed)
+
+	_, err = w.Sign(context.TODO(), a1, []byte("data"))
+	assert.True(t, xerrors.Is(err, repo.ErrKeyNotFound))
+
+	// deleting again after importing the key replaces the trashed copy
+	_, err = w.Import(&trashed)
+	assert.NoError(t, err)
+	assert.NoError(t, w.DeleteKey(a1))
+
+	assert.Error(t, w.DeleteKey(a1))
+}
+
+func TestWalletSignVerify(t *testing.T) {
+	w, _ := memRepoWallet(t)
+
+	a1, err := w.GenerateKey(types.KTSecp256k1)
+	assert.NoError(t, err)
+	a2, err := w.GenerateKey(types.KTSecp256k1)
+	assert.NoError(t, err)
+
+	sig, err := w.Sign(context.TODO(), a1, []byte("data"))
+	assert.NoError(t, err)
+
+	assert.NoError(t, sig.Verify(a1, []byte("data")))
+	assert.Error(t, sig.Verify(a1, []byte("other data")))
+	assert.Error(t, sig.Verify(a2, []byte("data")))
+}
+
+// unreachableRemote is a remote wallet backend which can't be reached
+type unreachableRemote struct {
+	api.Wallet
+}
+
+func (unreachableRemote) WalletHas(context.Context, address.Address) (bool, error) {
+	return false, xerrors.New("connection refused")
+}
+
+func (unreachableRemote) WalletList(context.Context) ([]address.Address, error) {
+	return nil, xerrors.New("connection refused")
+}
+
+func TestRemoteBackedWallet(t *testing.T) {
+	remote, _ := memRepoWallet(t)
+	remoteAddr, err := remote.GenerateKey(types.KTSecp256k1)
+	assert.NoError(t, err)
+
+	local, ks := memRepoWallet(t)
+	localAddr, err := local.GenerateKey(types.KTSecp256k1)
+	assert.NoError(t, err)
+
+	w, err := NewRemoteBackedWallet(ks, datastore.NewMapDatastore(), remote, false)
+	assert.NoError(t, err)
+
+	// keys held by the remote are used from it, others from the keystore
+	for _, addr := range []address.Address{remoteAddr, localAddr} {
+		has, err := w.HasKey(addr)
+		assert.NoError(t, err)
+		assert.True(t, has)
+
+		sig, err := w.Sign(context.TODO(), addr, []byte("data"))
+		assert.NoError(t, err)
+		assert.NoError(t, sig.Verify(addr, []byte("data")))
+	}
+
+	addrs, err := w.ListAddrs()
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []address.Address{remoteAddr, localAddr}, addrs)
+
+	ki, err := w.Export(remoteAddr)
+	assert.NoError(t, err)
+	rki, err := remote.Export(remoteAddr)
+	assert.NoError(t, err)
+	assert.Equal(t, rki, ki)
+
+	assert.Error(t, w.DeleteKey(remoteAddr), "remote keys can't be deleted")
+
+	// new keys are stored by the remote
+	newAddr, err := w.GenerateKey(types.KTSecp256k1)
+	assert.NoError(t, err)
+	has, err := remote.HasKey(newAddr)
+	assert.NoError(t, err)
+	assert.True(t, has)
+	_, err = ks.Get(KNamePrefix + newAddr.String())
+	assert.True(t, xerrors.Is(err, repo.ErrKeyNotFound))
+
+	// local keys can still be used while the remote is down
+	w, err = NewRemoteBackedWallet(ks, datastore.NewMapDatastore(), unreachableRemote{}, false)
+	assert.NoError(t, err)
+
+	sig, err := w.Sign(context.TODO(), localAddr, []byte("data"))
+	assert.NoError(t, err)
+	assert.NoError(t, sig.Verify(localAddr, []byte("data")))
+
+	_, err = w.Sign(context.TODO(), remoteAddr, []byte("data"))
+	assert.True(t, xerrors.Is(err, repo.ErrKeyNotFound))
+
+	_, err = w.ListAddrs()
+	assert.Error(t, err)
+}
+
+// refusingRemote is a remote wallet backend which doesn't allow imports, like
+// lotus-wallet run without --allow-export
+type refusingRemote struct {
+	api.Wallet
+}
+
+func (refusingRemote) WalletImport(context.Context, *types.KeyInfo) (address.Address, error) {
+	return address.Undef, xerrors.New("missing permission to invoke 'WalletImport' (need 'admin')")
+}
+
+func TestRemoteBackedWalletLocalKeys(t *testing.T) {
+	remote, _ := memRepoWallet(t)
+	_, ks := memRepoWallet(t)
+
+	k, err := GenerateKey(types.KTSecp256k1)
+	assert.NoError(t, err)
+
+	w, err := NewRemoteBackedWallet(ks, datastore.NewMapDatastore(), refusingRemote{remote}, false)
+	assert.NoError(t, err)
+
+	_, err = w.Import(&k.KeyInfo)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "--allow-export")
+
+	// with local keys, new and imported keys stay in the keystore
+	w, err = NewRemoteBackedWallet(ks, datastore.NewMapDatastore(), refusingRemote{remote}, true)
+	assert.NoError(t, err)
+
+	addr, err := w.Import(&k.KeyInfo)
+	assert.NoError(t, err)
+	assert.Equal(t, k.Address, addr)
+
+	newAddr, err := w.GenerateKey(types.KTSecp256k1)
+	assert.NoError(t, err)
+
+	for _, a := range []address.Address{addr, newAddr} {
+		_, err = ks.Get(KNamePrefix + a.String())
+		assert.NoError(t, err)
+
+		has, err := remote.HasKey(a)
+		assert.NoError(t, err)
+		assert.False(t, has)
+
+		sig, err := w.Sign(context.TODO(), a, []byte("data"))
+		assert.NoError(t, err)
+		assert.NoError(t, sig.Verify(a, []byte("data")))
+	}
+}
@@ -1,11 +1,15 @@
 package cli
 
 import (
+	"encoding/json"
 	"fmt"
 
+	"golang.org/x/xerrors"
 	"gopkg.in/urfave/cli.v2"
 
 	"github.com/filecoin-project/go-lotus/api"
+	"github.com/filecoin-project/go-lotus/chain/address"
+	"github.com/filecoin-project/go-lotus/chain/types"
 )
 
 var authCmd = &cli.Command{
@@ -13,6 +17,8 @@ var authCmd = &cli.Command{
 	Usage: "Manage RPC permissions",
 	Subcommands: []*cli.Command{
 		authCreateAdminToken,
+		authCreateToken,
+		authPolicy,
 	},
 }
 
@@ -30,7 +36,7 @@ var authCreateAdminToken = &cli.Command{
 
 		// TODO: Probably tell the user how powerful this token is
 
-		token, err := napi.AuthNew(ctx, api.AllPermissions)
+		token, err := napi.AuthNew(ctx, api.AllPermissions, nil)
 		if err != nil {
 			return err
 		}
@@ -41,3 +47,153 @@ var authCreateAdminToken = &cli.Command{
 		return nil
 	},
 }
+
+var authCreateToken = &cli.Command{
+	Name:  "create-token",
+	Usage: "Create a token with the given permission and all lower ones, optionally limiting what it can spend",
+	Flags: append([]cli.Flag{
+		&cli.StringFlag{
+			Name:  "perm",
+			Usage: "permission to give the token, one of read, write, sign or admin",
+			Value: api.PermRead,
+		},
+	}, spendPolicyFlags...),
+	Action: func(cctx *cli.Context) error {
+		napi, closer, err := GetFullNodeAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		ctx := ReqContext(cctx)
+
+		var perms []string
+		for i, p := range api.AllPermissions {
+			if p == cctx.String("perm") {
+				perms = api.AllPermissions[:i+1]
+				break
+			}
+		}
+		if perms == nil {
+			return xerrors.Errorf("unknown permission: %s", cctx.String("perm"))
+		}
+
+		policy, err := parseSpendPolicy(cctx)
+		if err != nil {
+			return err
+		}
+
+		token, err := napi.AuthNew(ctx, perms, policy)
+		if err != nil {
+			return err
+		}
+
+		fmt.Println(string(token))
+		return nil
+	},
+}
+
+var authPolicy = &cli.Command{
+	Name:      "policy",
+	Usage:     "Print the spend policy of a token",
+	ArgsUsage: "<token>",
+	Action: func(cctx *cli.Context) error {
+		napi, closer, err := GetFullNodeAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		if !cctx.Args().Present() {
+			return fmt.Errorf("must specify the token")
+		}
+
+		policy, err := napi.AuthPolicy(ReqContext(cctx), cctx.Args().First())
+		if err != nil {
+			return err
+		}
+
+		return printSpendPolicy(policy)
+	},
+}
+
+var spendPolicyFlags = []cli.Flag{
+	&cli.StringFlag{
+		Name:  "max-value",
+		Usage: "maximum value of each message",
+	},
+	&cli.StringFlag{
+		Name:  "daily-limit",
+		Usage: "maximum total value of messages per UTC day",
+	},
+	&cli.StringSliceFlag{
+		Name:  "to",
+		Usage: "address messages may be sent to, can be repeated",
+	},
+	&cli.IntSliceFlag{
+		Name:  "method",
+		Usage: "actor method which may be called, 0 being a plain transfer, can be repeated",
+	},
+}
+
+// parseSpendPolicy returns the policy given with spendPolicyFlags, or nil if
+// no limits were set
+func parseSpendPolicy(cctx *cli.Context) (*api.SpendPolicy, error) {
+	var p api.SpendPolicy
+	set := false
+
+	if s := cctx.String("max-value"); s != "" {
+		v, err := types.BigFromString(s)
+		if err != nil {
+			return nil, xerrors.Errorf("parsing max value: %w", err)
+		}
+		p.MaxValue = v
+		set = true
+	}
+
+	if s := cctx.String("daily-limit"); s != "" {
+		v, err := types.BigFromString(s)
+		if err != nil {
+			return nil, xerrors.Errorf("parsing daily limit: %w", err)
+		}
+		p.DailyLimit = v
+		set = true
+	}
+
+	for _, s := range cctx.StringSlice("to") {
+		addr, err := address.NewFromString(s)
+		if err != nil {
+			return nil, err
+		}
+		p.To = append(p.To, addr)
+		set = true
+	}
+
+	for _, m := range cctx.IntSlice("method") {
+		if m < 0 {
+			return nil, xerrors.Errorf("invalid method number: %d", m)
+		}
+		p.Methods = append(p.Methods, uint64(m))
+		set = true
+	}
+
+	if !set {
+		return nil, nil
+	}
+	return &p, nil
+}
+
+func printSpendPolicy(p *api.SpendPolicy) error {
+	if p == nil {
+		fmt.Println("No spend policy")
+		return nil
+	}
+
+	b, err := json.MarshalIndent(p, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(string(b))
+	return nil
+}
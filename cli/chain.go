@@ -196,6 +196,7 @@ var chainGetMsgCmd = &cli.Command{
 				return xerrors.Errorf("failed to decode object as a message: %w", err)
 			}
 			i = sm
+			m = &sm.Message
 		} else {
 			i = m
 		}
@@ -206,6 +207,24 @@ var chainGetMsgCmd = &cli.Command{
 		}
 
 		fmt.Println(string(enc))
+
+		if m.Method == 0 {
+			return nil
+		}
+
+		dec, err := api.StateDecodeParams(ctx, m.To, m.Method, m.Params, nil)
+		if err != nil {
+			fmt.Printf("\nfailed to decode params: %s\n", err)
+			return nil
+		}
+
+		penc, err := json.MarshalIndent(dec.Params, "", "  ")
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("\nMethod: %s\n", dec.Method)
+		fmt.Printf("Params: %s\n", string(penc))
 		return nil
 	},
 }
@@ -190,7 +190,7 @@ var clientRetrieveCmd = &cli.Command{
 
 		var payer address.Address
 		if cctx.String("address") != "" {
-			payer, err = address.NewFromString(cctx.String("address"))
+			payer, err = ParseKeyAddress(ctx, api, cctx.String("address"))
 		} else {
 			payer, err = api.WalletDefaultAddress(ctx)
 		}
@@ -117,6 +117,7 @@ var Commands = []*cli.Command{
 	createMinerCmd,
 	fetchParamCmd,
 	mpoolCmd,
+	multisigCmd,
 	netCmd,
 	paychCmd,
 	sendCmd,
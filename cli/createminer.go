@@ -28,14 +28,16 @@ var createMinerCmd = &cli.Command{
 		}
 		defer closer()
 
+		ctx := ReqContext(cctx)
+
 		args := cctx.Args().Slice()
 
-		worker, err := address.NewFromString(args[0])
+		worker, err := ParseKeyAddress(ctx, api, args[0])
 		if err != nil {
 			return err
 		}
 
-		owner, err := address.NewFromString(args[1])
+		owner, err := ParseKeyAddress(ctx, api, args[1])
 		if err != nil {
 			return err
 		}
@@ -57,7 +59,6 @@ var createMinerCmd = &cli.Command{
 			PeerID:     pid,
 		}
 
-		ctx := ReqContext(cctx)
 		addr, err := api.WalletDefaultAddress(ctx)
 		if err != nil {
 			return xerrors.Errorf("failed to get default address: %w", err)
@@ -69,7 +70,7 @@ var createMinerCmd = &cli.Command{
 		}
 
 		msg := &types.Message{
-			To:       actors.StorageMarketAddress,
+			To:       actors.StoragePowerAddress,
 			From:     addr,
 			Method:   actors.SPAMethods.CreateStorageMiner,
 			Params:   params,
@@ -0,0 +1,120 @@
+package cli
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"golang.org/x/crypto/ssh/terminal"
+	"golang.org/x/xerrors"
+	"gopkg.in/urfave/cli.v2"
+
+	"github.com/filecoin-project/go-lotus/chain/wallet"
+	"github.com/filecoin-project/go-lotus/node/modules/dtypes"
+	"github.com/filecoin-project/go-lotus/node/repo"
+)
+
+const KeyStorePassphraseEnv = "LOTUS_KEYSTORE_PASSPHRASE"
+
+var KeyStorePassphraseFileFlag = &cli.StringFlag{
+	Name:  "keystore-passphrase-file",
+	Usage: fmt.Sprintf("file holding the keystore passphrase, %s can be used instead", KeyStorePassphraseEnv),
+}
+
+// KeyStorePassphrase reads the passphrase from the file given with
+// --keystore-passphrase-file, the environment or the terminal, in that order
+func KeyStorePassphrase(cctx *cli.Context, confirm bool) dtypes.KeyStorePassphrase {
+	return func() ([]byte, error) {
+		if path := cctx.String(KeyStorePassphraseFileFlag.Name); path != "" {
+			b, err := ioutil.ReadFile(path)
+			if err != nil {
+				return nil, xerrors.Errorf("reading passphrase file: %w", err)
+			}
+			return bytes.TrimRight(b, "\r\n"), nil
+		}
+
+		if pp, ok := os.LookupEnv(KeyStorePassphraseEnv); ok {
+			return []byte(pp), nil
+		}
+
+		fd := int(os.Stdin.Fd())
+		if !terminal.IsTerminal(fd) {
+			return nil, xerrors.Errorf("no keystore passphrase given, use --%s or %s", KeyStorePassphraseFileFlag.Name, KeyStorePassphraseEnv)
+		}
+
+		fmt.Print("Keystore passphrase: ")
+		pp, err := terminal.ReadPassword(fd)
+		fmt.Println()
+		if err != nil {
+			return nil, xerrors.Errorf("reading passphrase: %w", err)
+		}
+
+		if confirm {
+			fmt.Print("Repeat passphrase: ")
+			again, err := terminal.ReadPassword(fd)
+			fmt.Println()
+			if err != nil {
+				return nil, xerrors.Errorf("reading passphrase: %w", err)
+			}
+			if !bytes.Equal(pp, again) {
+				return nil, xerrors.New("passphrases don't match")
+			}
+		}
+
+		return pp, nil
+	}
+}
+
+// openLocalWallet opens the wallet of the repo directly, unlocking its
+// keystore if it is encrypted. The daemon must not be running
+func openLocalWallet(cctx *cli.Context) (*wallet.Wallet, func() error, error) {
+	r, err := repo.NewFS(cctx.String("repo"))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	lr, err := r.Lock()
+	if err != nil {
+		return nil, nil, xerrors.Errorf("locking repo, is the daemon running?: %w", err)
+	}
+
+	w, err := localWallet(cctx, lr)
+	if err != nil {
+		lr.Close() //nolint:errcheck
+		return nil, nil, err
+	}
+
+	return w, lr.Close, nil
+}
+
+func localWallet(cctx *cli.Context, lr repo.LockedRepo) (*wallet.Wallet, error) {
+	mds, err := lr.Datastore("/metadata")
+	if err != nil {
+		return nil, err
+	}
+
+	ks, err := lr.KeyStore()
+	if err != nil {
+		return nil, err
+	}
+
+	eks := repo.NewEncryptedKeyStore(ks)
+	enc, err := eks.Encrypted()
+	if err != nil {
+		return nil, err
+	}
+	if !enc {
+		return wallet.NewWallet(ks, mds)
+	}
+
+	pp, err := KeyStorePassphrase(cctx, false)()
+	if err != nil {
+		return nil, err
+	}
+	if err := eks.Unlock(pp); err != nil {
+		return nil, xerrors.Errorf("unlocking keystore: %w", err)
+	}
+
+	return wallet.NewWallet(eks, mds)
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 
+	"golang.org/x/xerrors"
 	"gopkg.in/urfave/cli.v2"
 )
 
@@ -12,6 +13,9 @@ var mpoolCmd = &cli.Command{
 	Usage: "Manage message pool",
 	Subcommands: []*cli.Command{
 		mpoolPending,
+		mpoolSub,
+		mpoolStat,
+		mpoolPush,
 	},
 }
 
@@ -43,3 +47,96 @@ var mpoolPending = &cli.Command{
 		return nil
 	},
 }
+
+var mpoolSub = &cli.Command{
+	Name:  "sub",
+	Usage: "Subscribe to mpool changes",
+	Action: func(cctx *cli.Context) error {
+		api, closer, err := GetFullNodeAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		ctx := ReqContext(cctx)
+
+		sub, err := api.MpoolSub(ctx)
+		if err != nil {
+			return err
+		}
+
+		for {
+			select {
+			case update, ok := <-sub:
+				if !ok {
+					return nil
+				}
+				out, err := json.MarshalIndent(update, "", "  ")
+				if err != nil {
+					return err
+				}
+				fmt.Println(string(out))
+			case <-ctx.Done():
+				return nil
+			}
+		}
+	},
+}
+
+var mpoolStat = &cli.Command{
+	Name:  "stat",
+	Usage: "Print mpool statistics",
+	Action: func(cctx *cli.Context) error {
+		api, closer, err := GetFullNodeAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		ctx := ReqContext(cctx)
+
+		st, err := api.MpoolStat(ctx)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Pending: %d messages from %d senders\n", st.Pending, st.Senders)
+		fmt.Printf("Included: %d\n", st.Included)
+		fmt.Printf("Pruned (nonce used): %d\n", st.PrunedNonce)
+		fmt.Printf("Pruned (insufficient funds): %d\n", st.PrunedFunds)
+
+		return nil
+	},
+}
+
+var mpoolPush = &cli.Command{
+	Name:      "push",
+	Usage:     "Push a message signed with 'wallet sign-message'",
+	ArgsUsage: "<signed message file>",
+	Action: func(cctx *cli.Context) error {
+		if !cctx.Args().Present() {
+			return fmt.Errorf("must specify the message file")
+		}
+
+		env, err := readEnvelope(cctx.Args().First())
+		if err != nil {
+			return xerrors.Errorf("reading message: %w", err)
+		}
+		if env.SignedMessage == nil {
+			return xerrors.New("message is not signed")
+		}
+
+		api, closer, err := GetFullNodeAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		if err := api.MpoolPush(ReqContext(cctx), env.SignedMessage); err != nil {
+			return err
+		}
+
+		fmt.Println(env.SignedMessage.Cid())
+		return nil
+	},
+}
@@ -0,0 +1,484 @@
+package cli
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	cbor "github.com/ipfs/go-ipld-cbor"
+	"golang.org/x/xerrors"
+	"gopkg.in/urfave/cli.v2"
+
+	lapi "github.com/filecoin-project/go-lotus/api"
+	"github.com/filecoin-project/go-lotus/chain/address"
+	"github.com/filecoin-project/go-lotus/chain/types"
+)
+
+var multisigCmd = &cli.Command{
+	Name:  "msig",
+	Usage: "Interact with a multisig wallet",
+	Subcommands: []*cli.Command{
+		msigCreateCmd,
+		msigInspectCmd,
+		msigProposeCmd,
+		msigApproveCmd,
+		msigCancelCmd,
+		msigAddSignerCmd,
+		msigSwapSignerCmd,
+	},
+}
+
+var msigFromFlag = &cli.StringFlag{
+	Name:  "from",
+	Usage: "account to send the message from, defaults to the default wallet address",
+}
+
+var msigCreateCmd = &cli.Command{
+	Name:      "create",
+	Usage:     "Create a new multisig wallet",
+	ArgsUsage: "<signer addresses...>",
+	Flags: []cli.Flag{
+		&cli.Uint64Flag{
+			Name:  "required",
+			Usage: "number of approvals required to execute a transaction, defaults to all signers",
+		},
+		&cli.StringFlag{
+			Name:  "value",
+			Usage: "initial funds to give to the wallet",
+			Value: "0",
+		},
+		&cli.Uint64Flag{
+			Name:  "unlock-duration",
+			Usage: "lock the initial funds and unlock them linearly over this many blocks",
+		},
+		&cli.Uint64Flag{
+			Name:  "unlock-start",
+			Usage: "block at which the locked funds start unlocking, defaults to the current height",
+		},
+		&cli.StringFlag{
+			Name:  "locked",
+			Usage: "part of the initial funds to lock, defaults to all of them",
+		},
+		msigFromFlag,
+	},
+	Action: func(cctx *cli.Context) error {
+		if !cctx.Args().Present() {
+			return fmt.Errorf("must specify at least one signer")
+		}
+
+		api, closer, err := GetFullNodeAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		ctx := ReqContext(cctx)
+
+		var signers []address.Address
+		for _, a := range cctx.Args().Slice() {
+			addr, err := ParseKeyAddress(ctx, api, a)
+			if err != nil {
+				return xerrors.Errorf("parsing signer address %q: %w", a, err)
+			}
+			signers = append(signers, addr)
+		}
+
+		required := cctx.Uint64("required")
+		if required == 0 {
+			required = uint64(len(signers))
+		}
+
+		val, err := types.BigFromString(cctx.String("value"))
+		if err != nil {
+			return xerrors.Errorf("parsing value: %w", err)
+		}
+
+		from, err := msigSource(cctx, api)
+		if err != nil {
+			return err
+		}
+
+		locked := types.NewInt(0)
+		var unlockStart uint64
+		if cctx.Uint64("unlock-duration") != 0 {
+			locked = val
+			if cctx.IsSet("locked") {
+				locked, err = types.BigFromString(cctx.String("locked"))
+				if err != nil {
+					return xerrors.Errorf("parsing locked amount: %w", err)
+				}
+			}
+
+			unlockStart = cctx.Uint64("unlock-start")
+			if !cctx.IsSet("unlock-start") {
+				head, err := api.ChainHead(ctx)
+				if err != nil {
+					return err
+				}
+				unlockStart = head.Height()
+			}
+		}
+
+		mcid, err := api.MsigCreate(ctx, required, signers, unlockStart, cctx.Uint64("unlock-duration"), locked, val, from)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Waiting for message %s\n", mcid)
+		wait, err := api.StateWaitMsg(ctx, mcid)
+		if err != nil {
+			return err
+		}
+
+		if wait.Receipt.ExitCode != 0 {
+			return xerrors.Errorf("wallet creation failed with exit code %d", wait.Receipt.ExitCode)
+		}
+
+		addr, err := address.NewFromBytes(wait.Receipt.Return)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Created new multisig: %s\n", addr)
+		return nil
+	},
+}
+
+var msigInspectCmd = &cli.Command{
+	Name:      "inspect",
+	Usage:     "Print the signers, balance and pending transactions of a multisig wallet",
+	ArgsUsage: "<multisig address>",
+	Action: func(cctx *cli.Context) error {
+		if !cctx.Args().Present() {
+			return fmt.Errorf("must specify multisig address")
+		}
+
+		api, closer, err := GetFullNodeAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		ctx := ReqContext(cctx)
+
+		maddr, err := address.NewFromString(cctx.Args().First())
+		if err != nil {
+			return err
+		}
+
+		act, err := api.StateGetActor(ctx, maddr, nil)
+		if err != nil {
+			return err
+		}
+
+		ast, err := api.StateReadState(ctx, act, nil)
+		if err != nil {
+			return err
+		}
+
+		// the decoded state comes back as generic json, round trip it to get
+		// the actual type
+		b, err := json.Marshal(ast.State)
+		if err != nil {
+			return err
+		}
+
+		var st lapi.MsigActorState
+		if err := json.Unmarshal(b, &st); err != nil {
+			return xerrors.Errorf("decoding multisig state: %w", err)
+		}
+
+		avail, err := api.MsigGetAvailableBalance(ctx, maddr, nil)
+		if err != nil {
+			return err
+		}
+
+		pending, err := api.MsigGetPending(ctx, maddr, nil)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Balance: %s\n", act.Balance)
+		fmt.Printf("Spendable: %s\n", avail)
+		if st.UnlockDuration != 0 {
+			fmt.Printf("Unlocking: %s from block %d over %d blocks\n", st.InitialBalance, st.StartingBlock, st.UnlockDuration)
+			fmt.Printf("Locked: %s\n", st.Locked)
+		}
+		fmt.Printf("Threshold: %d / %d\n", st.Required, len(st.Signers))
+
+		fmt.Println("Signers:")
+		for _, s := range st.Signers {
+			fmt.Printf("\t%s\n", s)
+		}
+
+		fmt.Printf("Pending transactions: %d\n", len(pending))
+		if len(pending) == 0 {
+			return nil
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 8, 4, 0, ' ', 0)
+		fmt.Fprintf(w, "ID\tTo\tValue\tMethod\tParams\tApprovals\n")
+		for _, tx := range pending {
+			fmt.Fprintf(w, "%d\t%s\t%s\t%d\t%s\t%d/%d\n", tx.ID, tx.To, tx.Value, tx.Method, hex.EncodeToString(tx.Params), len(tx.Approved), st.Required)
+			for _, a := range tx.Approved {
+				fmt.Fprintf(w, "\t\t\t\t\t%s\n", a)
+			}
+		}
+		return w.Flush()
+	},
+}
+
+var msigProposeCmd = &cli.Command{
+	Name:      "propose",
+	Usage:     "Propose a multisig transaction",
+	ArgsUsage: "<multisig address> <destination> <value>",
+	Flags: []cli.Flag{
+		&cli.Uint64Flag{
+			Name:  "method",
+			Usage: "actor method to invoke",
+		},
+		&cli.StringFlag{
+			Name:  "params",
+			Usage: "hex encoded method params",
+		},
+		msigFromFlag,
+	},
+	Action: func(cctx *cli.Context) error {
+		if cctx.Args().Len() != 3 {
+			return fmt.Errorf("must pass multisig address, destination and value")
+		}
+
+		api, closer, err := GetFullNodeAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		ctx := ReqContext(cctx)
+
+		msig, err := address.NewFromString(cctx.Args().Get(0))
+		if err != nil {
+			return err
+		}
+
+		dest, err := address.NewFromString(cctx.Args().Get(1))
+		if err != nil {
+			return err
+		}
+
+		val, err := types.BigFromString(cctx.Args().Get(2))
+		if err != nil {
+			return err
+		}
+
+		params, err := hex.DecodeString(cctx.String("params"))
+		if err != nil {
+			return xerrors.Errorf("decoding params: %w", err)
+		}
+
+		from, err := msigSource(cctx, api)
+		if err != nil {
+			return err
+		}
+
+		mcid, err := api.MsigPropose(ctx, msig, dest, val, from, cctx.Uint64("method"), params)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Waiting for message %s\n", mcid)
+		wait, err := api.StateWaitMsg(ctx, mcid)
+		if err != nil {
+			return err
+		}
+
+		if wait.Receipt.ExitCode != 0 {
+			return xerrors.Errorf("proposal failed with exit code %d", wait.Receipt.ExitCode)
+		}
+
+		var txid uint64
+		if err := cbor.DecodeInto(wait.Receipt.Return, &txid); err != nil {
+			return xerrors.Errorf("decoding transaction ID: %w", err)
+		}
+
+		fmt.Printf("Transaction ID: %d\n", txid)
+		return nil
+	},
+}
+
+var msigApproveCmd = &cli.Command{
+	Name:      "approve",
+	Usage:     "Approve a pending multisig transaction",
+	ArgsUsage: "<multisig address> <transaction ID>",
+	Flags: []cli.Flag{
+		msigFromFlag,
+	},
+	Action: func(cctx *cli.Context) error {
+		return msigTxAction(cctx, func(api lapi.FullNode, msig address.Address, txid uint64, from address.Address) error {
+			mcid, err := api.MsigApprove(ReqContext(cctx), msig, txid, from)
+			if err != nil {
+				return err
+			}
+
+			fmt.Println(mcid)
+			return nil
+		})
+	},
+}
+
+var msigCancelCmd = &cli.Command{
+	Name:      "cancel",
+	Usage:     "Cancel a pending multisig transaction",
+	ArgsUsage: "<multisig address> <transaction ID>",
+	Flags: []cli.Flag{
+		msigFromFlag,
+	},
+	Action: func(cctx *cli.Context) error {
+		return msigTxAction(cctx, func(api lapi.FullNode, msig address.Address, txid uint64, from address.Address) error {
+			mcid, err := api.MsigCancel(ReqContext(cctx), msig, txid, from)
+			if err != nil {
+				return err
+			}
+
+			fmt.Println(mcid)
+			return nil
+		})
+	},
+}
+
+var msigAddSignerCmd = &cli.Command{
+	Name:      "add-signer",
+	Usage:     "Propose adding a signer to a multisig wallet",
+	ArgsUsage: "<multisig address> <signer address>",
+	Flags: []cli.Flag{
+		&cli.BoolFlag{
+			Name:  "increase-threshold",
+			Usage: "also increase the number of required approvals",
+		},
+		msigFromFlag,
+	},
+	Action: func(cctx *cli.Context) error {
+		if cctx.Args().Len() != 2 {
+			return fmt.Errorf("must pass multisig address and signer address")
+		}
+
+		api, closer, err := GetFullNodeAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		msig, err := address.NewFromString(cctx.Args().Get(0))
+		if err != nil {
+			return err
+		}
+
+		signer, err := ParseKeyAddress(ReqContext(cctx), api, cctx.Args().Get(1))
+		if err != nil {
+			return err
+		}
+
+		from, err := msigSource(cctx, api)
+		if err != nil {
+			return err
+		}
+
+		mcid, err := api.MsigAddSigner(ReqContext(cctx), msig, from, signer, cctx.Bool("increase-threshold"))
+		if err != nil {
+			return err
+		}
+
+		fmt.Println(mcid)
+		return nil
+	},
+}
+
+var msigSwapSignerCmd = &cli.Command{
+	Name:      "swap-signer",
+	Usage:     "Propose replacing a signer of a multisig wallet",
+	ArgsUsage: "<multisig address> <old signer> <new signer>",
+	Flags: []cli.Flag{
+		msigFromFlag,
+	},
+	Action: func(cctx *cli.Context) error {
+		if cctx.Args().Len() != 3 {
+			return fmt.Errorf("must pass multisig address, old signer and new signer")
+		}
+
+		api, closer, err := GetFullNodeAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		msig, err := address.NewFromString(cctx.Args().Get(0))
+		if err != nil {
+			return err
+		}
+
+		oldSigner, err := ParseKeyAddress(ReqContext(cctx), api, cctx.Args().Get(1))
+		if err != nil {
+			return err
+		}
+
+		newSigner, err := ParseKeyAddress(ReqContext(cctx), api, cctx.Args().Get(2))
+		if err != nil {
+			return err
+		}
+
+		from, err := msigSource(cctx, api)
+		if err != nil {
+			return err
+		}
+
+		mcid, err := api.MsigSwapSigner(ReqContext(cctx), msig, from, oldSigner, newSigner)
+		if err != nil {
+			return err
+		}
+
+		fmt.Println(mcid)
+		return nil
+	},
+}
+
+// msigTxAction parses the multisig address and transaction ID arguments
+// shared by approve and cancel
+func msigTxAction(cctx *cli.Context, cb func(api lapi.FullNode, msig address.Address, txid uint64, from address.Address) error) error {
+	if cctx.Args().Len() != 2 {
+		return fmt.Errorf("must pass multisig address and transaction ID")
+	}
+
+	api, closer, err := GetFullNodeAPI(cctx)
+	if err != nil {
+		return err
+	}
+	defer closer()
+
+	msig, err := address.NewFromString(cctx.Args().Get(0))
+	if err != nil {
+		return err
+	}
+
+	txid, err := strconv.ParseUint(cctx.Args().Get(1), 10, 64)
+	if err != nil {
+		return xerrors.Errorf("parsing transaction ID: %w", err)
+	}
+
+	from, err := msigSource(cctx, api)
+	if err != nil {
+		return err
+	}
+
+	return cb(api, msig, txid, from)
+}
+
+func msigSource(cctx *cli.Context, api lapi.FullNode) (address.Address, error) {
+	if from := cctx.String("from"); from != "" {
+		return ParseKeyAddress(ReqContext(cctx), api, from)
+	}
+
+	return api.WalletDefaultAddress(ReqContext(cctx))
+}
@@ -3,6 +3,7 @@ package cli
 import (
 	"fmt"
 
+	lapi "github.com/filecoin-project/go-lotus/api"
 	"github.com/filecoin-project/go-lotus/chain/address"
 	types "github.com/filecoin-project/go-lotus/chain/types"
 	"gopkg.in/urfave/cli.v2"
@@ -14,6 +15,9 @@ var paychCmd = &cli.Command{
 	Subcommands: []*cli.Command{
 		paychGetCmd,
 		paychListCmd,
+		paychStatusCmd,
+		paychSettleCmd,
+		paychCollectCmd,
 		paychVoucherCmd,
 	},
 }
@@ -26,16 +30,6 @@ var paychGetCmd = &cli.Command{
 			return fmt.Errorf("must pass three arguments: <from> <to> <available funds>")
 		}
 
-		from, err := address.NewFromString(cctx.Args().Get(0))
-		if err != nil {
-			return fmt.Errorf("failed to parse from address: %s", err)
-		}
-
-		to, err := address.NewFromString(cctx.Args().Get(1))
-		if err != nil {
-			return fmt.Errorf("failed to parse to address: %s", err)
-		}
-
 		amt, err := types.BigFromString(cctx.Args().Get(2))
 		if err != nil {
 			return fmt.Errorf("parsing amount failed: %s", err)
@@ -49,6 +43,17 @@ var paychGetCmd = &cli.Command{
 
 		ctx := ReqContext(cctx)
 
+		// channels are tracked by the key addresses of both parties
+		from, err := ParseKeyAddress(ctx, api, cctx.Args().Get(0))
+		if err != nil {
+			return fmt.Errorf("failed to parse from address: %s", err)
+		}
+
+		to, err := ParseKeyAddress(ctx, api, cctx.Args().Get(1))
+		if err != nil {
+			return fmt.Errorf("failed to parse to address: %s", err)
+		}
+
 		info, err := api.PaychGet(ctx, from, to, amt)
 		if err != nil {
 			return err
@@ -83,6 +88,108 @@ var paychListCmd = &cli.Command{
 	},
 }
 
+var paychStatusCmd = &cli.Command{
+	Name:      "status",
+	Usage:     "Show the on chain state of a payment channel",
+	ArgsUsage: "<channel>",
+	Action: func(cctx *cli.Context) error {
+		if cctx.Args().Len() != 1 {
+			return fmt.Errorf("must pass payment channel address")
+		}
+
+		ch, err := address.NewFromString(cctx.Args().First())
+		if err != nil {
+			return err
+		}
+
+		api, closer, err := GetFullNodeAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		st, err := api.PaychStatus(ReqContext(cctx), ch)
+		if err != nil {
+			return err
+		}
+
+		dir := "outbound"
+		if st.Direction == lapi.PCHInbound {
+			dir = "inbound"
+		}
+
+		fmt.Printf("Control: %s (%s)\n", st.ControlAddr, dir)
+		fmt.Printf("To send: %s\n", st.ToSend)
+		if st.ClosingAt != 0 {
+			fmt.Printf("Settling, collectable at: %d\n", st.ClosingAt)
+		}
+		for _, ls := range st.Lanes {
+			fmt.Printf("Lane %d: redeemed %s, nonce %d, closed %t\n", ls.Lane, ls.Redeemed, ls.Nonce, ls.Closed)
+		}
+		return nil
+	},
+}
+
+var paychSettleCmd = &cli.Command{
+	Name:      "settle",
+	Usage:     "Settle a payment channel",
+	ArgsUsage: "<channel>",
+	Action: func(cctx *cli.Context) error {
+		if cctx.Args().Len() != 1 {
+			return fmt.Errorf("must pass payment channel address")
+		}
+
+		ch, err := address.NewFromString(cctx.Args().First())
+		if err != nil {
+			return err
+		}
+
+		api, closer, err := GetFullNodeAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		mcid, err := api.PaychSettle(ReqContext(cctx), ch)
+		if err != nil {
+			return err
+		}
+
+		fmt.Println(mcid)
+		return nil
+	},
+}
+
+var paychCollectCmd = &cli.Command{
+	Name:      "collect",
+	Usage:     "Wait for a settling payment channel to close and collect its funds",
+	ArgsUsage: "<channel>",
+	Action: func(cctx *cli.Context) error {
+		if cctx.Args().Len() != 1 {
+			return fmt.Errorf("must pass payment channel address")
+		}
+
+		ch, err := address.NewFromString(cctx.Args().First())
+		if err != nil {
+			return err
+		}
+
+		api, closer, err := GetFullNodeAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		mcid, err := api.PaychCollect(ReqContext(cctx), ch)
+		if err != nil {
+			return err
+		}
+
+		fmt.Println(mcid)
+		return nil
+	},
+}
+
 var paychVoucherCmd = &cli.Command{
 	Name:  "voucher",
 	Usage: "Interact with payment channel vouchers",
@@ -105,6 +212,10 @@ var paychVoucherCreateCmd = &cli.Command{
 			Value: 0,
 			Usage: "specify payment channel lane to use",
 		},
+		&cli.IntSliceFlag{
+			Name:  "merge",
+			Usage: "merge the given lanes into the voucher lane, the amount must include what was redeemed on them",
+		},
 	},
 	Action: func(cctx *cli.Context) error {
 		if cctx.Args().Len() != 2 {
@@ -131,7 +242,15 @@ var paychVoucherCreateCmd = &cli.Command{
 
 		ctx := ReqContext(cctx)
 
-		sv, err := api.PaychVoucherCreate(ctx, ch, amt, uint64(lane))
+		var opts *lapi.VoucherCreateOpts
+		if merges := cctx.IntSlice("merge"); len(merges) > 0 {
+			opts = &lapi.VoucherCreateOpts{}
+			for _, ml := range merges {
+				opts.MergeLanes = append(opts.MergeLanes, uint64(ml))
+			}
+		}
+
+		sv, err := api.PaychVoucherCreate(ctx, ch, amt, uint64(lane), opts)
 		if err != nil {
 			return err
 		}
@@ -1,11 +1,19 @@
 package cli
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
+	"io/ioutil"
+	"path/filepath"
 
+	"golang.org/x/xerrors"
+	"gopkg.in/urfave/cli.v2"
+
+	"github.com/filecoin-project/go-lotus/api"
+	"github.com/filecoin-project/go-lotus/build"
 	"github.com/filecoin-project/go-lotus/chain/address"
 	"github.com/filecoin-project/go-lotus/chain/types"
-	"gopkg.in/urfave/cli.v2"
 )
 
 var sendCmd = &cli.Command{
@@ -17,6 +25,18 @@ var sendCmd = &cli.Command{
 			Name:  "source",
 			Usage: "optinally specifiy the account to send funds from",
 		},
+		&cli.Uint64Flag{
+			Name:  "method",
+			Usage: "specify the actor method to invoke",
+		},
+		&cli.StringFlag{
+			Name:  "params-json",
+			Usage: "specify invocation parameters in json",
+		},
+		&cli.StringFlag{
+			Name:  "unsigned-out",
+			Usage: "write the unsigned message to a file for offline signing instead of sending it, files ending in .cbor are written as cbor",
+		},
 	},
 	Action: func(cctx *cli.Context) error {
 		api, closer, err := GetFullNodeAPI(cctx)
@@ -50,7 +70,7 @@ var sendCmd = &cli.Command{
 
 			fromAddr = defaddr
 		} else {
-			addr, err := address.NewFromString(from)
+			addr, err := ParseKeyAddress(ctx, api, from)
 			if err != nil {
 				return err
 			}
@@ -58,12 +78,42 @@ var sendCmd = &cli.Command{
 			fromAddr = addr
 		}
 
+		method := cctx.Uint64("method")
+
+		var params []byte
+		if pj := cctx.String("params-json"); pj != "" {
+			if method == 0 {
+				return xerrors.Errorf("params can only be passed to actor methods, specify --method")
+			}
+
+			act, err := api.StateGetActor(ctx, toAddr, nil)
+			if err != nil {
+				return xerrors.Errorf("getting target actor: %w", err)
+			}
+
+			params, err = api.StateEncodeParams(ctx, act.Code, method, json.RawMessage(pj))
+			if err != nil {
+				return xerrors.Errorf("encoding params: %w", err)
+			}
+		}
+
 		msg := &types.Message{
-			From:     fromAddr,
-			To:       toAddr,
-			Value:    val,
-			GasLimit: types.NewInt(1000),
-			GasPrice: types.NewInt(0),
+			From:   fromAddr,
+			To:     toAddr,
+			Value:  val,
+			Method: method,
+			Params: params,
+		}
+
+		if out := cctx.String("unsigned-out"); out != "" {
+			if err := fillMessage(ctx, api, msg); err != nil {
+				return err
+			}
+
+			return writeEnvelope(out, &types.MessageEnvelope{
+				Version: types.MessageEnvelopeVersion,
+				Message: msg,
+			})
 		}
 
 		_, err = api.MpoolPushMessage(ctx, msg)
@@ -74,3 +124,57 @@ var sendCmd = &cli.Command{
 		return nil
 	},
 }
+
+// fillMessage sets the nonce and gas of the message like MpoolPushMessage
+// would, so that it can be signed elsewhere
+func fillMessage(ctx context.Context, api api.FullNode, msg *types.Message) error {
+	// the signer only knows the key address of the sender
+	from, err := api.StateAccountKey(ctx, msg.From, nil)
+	if err != nil {
+		return xerrors.Errorf("resolving sender key address: %w", err)
+	}
+	msg.From = from
+
+	msg.Nonce, err = api.MpoolGetNonce(ctx, msg.From)
+	if err != nil {
+		return xerrors.Errorf("getting nonce: %w", err)
+	}
+
+	msg.GasPrice, err = api.GasEstimateGasPrice(ctx, build.GasPriceLookback)
+	if err != nil {
+		return xerrors.Errorf("estimating gas price: %w", err)
+	}
+
+	msg.GasLimit, err = api.GasEstimateGasLimit(ctx, msg, nil)
+	if err != nil {
+		return xerrors.Errorf("estimating gas limit: %w", err)
+	}
+
+	return nil
+}
+
+// writeEnvelope writes the envelope to path, as cbor if the file name ends in
+// .cbor and as json otherwise
+func writeEnvelope(path string, env *types.MessageEnvelope) error {
+	var b []byte
+	var err error
+	if filepath.Ext(path) == ".cbor" {
+		b, err = env.Serialize()
+	} else {
+		b, err = env.SerializeJSON()
+	}
+	if err != nil {
+		return xerrors.Errorf("encoding envelope: %w", err)
+	}
+
+	return ioutil.WriteFile(path, b, 0644)
+}
+
+func readEnvelope(path string) (*types.MessageEnvelope, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return types.DecodeMessageEnvelope(b)
+}
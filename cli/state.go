@@ -1,12 +1,19 @@
 package cli
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
 	"fmt"
+	"sort"
+	"strings"
 
+	"github.com/filecoin-project/go-lotus/api"
 	"github.com/filecoin-project/go-lotus/chain/address"
 	"github.com/filecoin-project/go-lotus/chain/types"
 
 	"github.com/ipfs/go-cid"
+	"golang.org/x/xerrors"
 	"gopkg.in/urfave/cli.v2"
 )
 
@@ -19,7 +26,10 @@ var stateCmd = &cli.Command{
 		stateProvingSetCmd,
 		statePledgeCollateralCmd,
 		stateListActorsCmd,
+		stateLookupCmd,
 		stateListMinersCmd,
+		stateReplaySetCmd,
+		stateDiffCmd,
 	},
 }
 
@@ -128,6 +138,12 @@ var stateProvingSetCmd = &cli.Command{
 var stateReplaySetCmd = &cli.Command{
 	Name:  "replay",
 	Usage: "Replay a particular message within a tipset",
+	Flags: []cli.Flag{
+		&cli.BoolFlag{
+			Name:  "trace",
+			Usage: "print the calls made by the message to other actors",
+		},
+	},
 	Action: func(cctx *cli.Context) error {
 		if cctx.Args().Len() < 2 {
 			fmt.Println("usage: <tipset> <message cid>")
@@ -174,12 +190,227 @@ var stateReplaySetCmd = &cli.Command{
 			return err
 		}
 
-		api.StateReplay(ctx, ts, mcid)
+		res, err := api.StateReplay(ctx, ts, mcid)
+		if err != nil {
+			return xerrors.Errorf("replay call failed: %w", err)
+		}
+
+		fmt.Println("Replay receipt:")
+		fmt.Printf("Exit code: %d\n", res.Receipt.ExitCode)
+		fmt.Printf("Return: %s\n", formatReturn(ctx, api, res.Msg, res.Receipt.Return))
+		fmt.Printf("Gas Used: %s\n", res.Receipt.GasUsed)
+		if res.Receipt.ExitCode != 0 {
+			fmt.Printf("Error message: %q\n", res.Error)
+		}
+
+		if cctx.Bool("trace") {
+			fmt.Println()
+			fmt.Println("Execution trace:")
+			printExecutionTrace(ctx, api, 0, &res.ExecutionTrace)
+		}
+
+		return nil
+	},
+}
+
+func printExecutionTrace(ctx context.Context, api api.FullNode, depth int, et *types.ExecutionTrace) {
+	indent := strings.Repeat("  ", depth)
+
+	fmt.Printf("%s%s -> %s (%s, value %s)\n", indent, et.Msg.From, et.Msg.To, formatMethod(ctx, api, et.Msg), et.Msg.Value)
+	if len(et.Msg.Params) > 0 {
+		fmt.Printf("%s  params: %s\n", indent, formatParams(ctx, api, et.Msg))
+	}
+	fmt.Printf("%s  exit code: %d, gas used: %s\n", indent, et.MsgRct.ExitCode, et.MsgRct.GasUsed)
+	if len(et.MsgRct.Return) > 0 {
+		fmt.Printf("%s  return: %s\n", indent, formatReturn(ctx, api, et.Msg, et.MsgRct.Return))
+	}
+	if et.Error != "" {
+		fmt.Printf("%s  error: %s\n", indent, et.Error)
+	}
+
+	for _, sub := range et.Subcalls {
+		printExecutionTrace(ctx, api, depth+1, sub)
+	}
+}
+
+// formatMethod, formatParams and formatReturn fall back to printing raw
+// values when the target actor isn't a known built-in actor
+
+func formatMethod(ctx context.Context, api api.FullNode, msg *types.Message) string {
+	if msg.Method == 0 {
+		return "send"
+	}
+
+	dec, err := api.StateDecodeParams(ctx, msg.To, msg.Method, msg.Params, nil)
+	if err != nil {
+		return fmt.Sprintf("method %d", msg.Method)
+	}
+
+	return fmt.Sprintf("%s (method %d)", dec.Method, msg.Method)
+}
+
+func formatParams(ctx context.Context, api api.FullNode, msg *types.Message) string {
+	if msg.Method == 0 {
+		return fmt.Sprintf("%x", msg.Params)
+	}
+
+	dec, err := api.StateDecodeParams(ctx, msg.To, msg.Method, msg.Params, nil)
+	if err != nil {
+		return fmt.Sprintf("%x", msg.Params)
+	}
+
+	b, err := json.Marshal(dec.Params)
+	if err != nil {
+		return fmt.Sprintf("%x", msg.Params)
+	}
+
+	return string(b)
+}
+
+func formatReturn(ctx context.Context, api api.FullNode, msg *types.Message, ret []byte) string {
+	if msg.Method == 0 || len(ret) == 0 {
+		return fmt.Sprintf("%x", ret)
+	}
+
+	dec, err := api.StateDecodeReturn(ctx, msg.To, msg.Method, ret, nil)
+	if err != nil || dec == nil {
+		return fmt.Sprintf("%x", ret)
+	}
+
+	b, err := json.Marshal(dec)
+	if err != nil {
+		return fmt.Sprintf("%x", ret)
+	}
+
+	return string(b)
+}
+
+var stateDiffCmd = &cli.Command{
+	Name:      "diff",
+	Usage:     "List actors changed between the parent states of two tipsets",
+	ArgsUsage: "<tipset1> <tipset2> (block cids separated by commas)",
+	Action: func(cctx *cli.Context) error {
+		if cctx.Args().Len() != 2 {
+			return fmt.Errorf("'diff' expects two arguments, the tipsets to compare")
+		}
+
+		api, closer, err := GetFullNodeAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		ctx := ReqContext(cctx)
+
+		ts1, err := parseTipSet(api, ctx, strings.Split(cctx.Args().Get(0), ","))
+		if err != nil {
+			return xerrors.Errorf("parsing first tipset: %w", err)
+		}
+		ts2, err := parseTipSet(api, ctx, strings.Split(cctx.Args().Get(1), ","))
+		if err != nil {
+			return xerrors.Errorf("parsing second tipset: %w", err)
+		}
+
+		changes, err := api.StateChangedActors(ctx, ts1.ParentState(), ts2.ParentState())
+		if err != nil {
+			return err
+		}
+
+		for _, c := range changes {
+			switch {
+			case c.Old == nil:
+				fmt.Printf("+ %s (code %s, balance %s, nonce %d)\n", c.Address, c.New.Code, c.New.Balance, c.New.Nonce)
+			case c.New == nil:
+				fmt.Printf("- %s (code %s, balance %s, nonce %d)\n", c.Address, c.Old.Code, c.Old.Balance, c.Old.Nonce)
+			default:
+				fmt.Printf("~ %s\n", c.Address)
+				if types.BigCmp(c.Old.Balance, c.New.Balance) != 0 {
+					fmt.Printf("    balance: %s -> %s\n", c.Old.Balance, c.New.Balance)
+				}
+				if c.Old.Nonce != c.New.Nonce {
+					fmt.Printf("    nonce: %d -> %d\n", c.Old.Nonce, c.New.Nonce)
+				}
+				if c.Old.Code != c.New.Code {
+					fmt.Printf("    code: %s -> %s\n", c.Old.Code, c.New.Code)
+				}
+				if c.Old.Head != c.New.Head {
+					fmt.Printf("    head: %s -> %s\n", c.Old.Head, c.New.Head)
+					if c.Old.Code == c.New.Code {
+						if err := printStateDiff(ctx, api, c.Old, c.New, ts1, ts2); err != nil {
+							fmt.Printf("    state: %s\n", err)
+						}
+					}
+				}
+			}
+		}
 
 		return nil
 	},
 }
 
+// printStateDiff prints the top level fields of the decoded actor state that
+// differ between the two actors, sorted by name
+func printStateDiff(ctx context.Context, api api.FullNode, oldAct, newAct *types.Actor, ts1, ts2 *types.TipSet) error {
+	oldSt, err := api.StateReadState(ctx, oldAct, ts1)
+	if err != nil {
+		return xerrors.Errorf("reading old state: %w", err)
+	}
+	newSt, err := api.StateReadState(ctx, newAct, ts2)
+	if err != nil {
+		return xerrors.Errorf("reading new state: %w", err)
+	}
+
+	oldFields, err := jsonFields(oldSt.State)
+	if err != nil {
+		return xerrors.Errorf("decoding old state: %w", err)
+	}
+	newFields, err := jsonFields(newSt.State)
+	if err != nil {
+		return xerrors.Errorf("decoding new state: %w", err)
+	}
+
+	// fields may only exist on one side, e.g. when the state is a map
+	keys := make([]string, 0, len(oldFields))
+	for k := range oldFields {
+		keys = append(keys, k)
+	}
+	for k := range newFields {
+		if _, ok := oldFields[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		ov, nv := oldFields[k], newFields[k]
+		if !bytes.Equal(ov, nv) {
+			fmt.Printf("    state.%s: %s -> %s\n", k, fieldOrNone(ov), fieldOrNone(nv))
+		}
+	}
+
+	return nil
+}
+
+func fieldOrNone(v json.RawMessage) string {
+	if v == nil {
+		return "<none>"
+	}
+	return string(v)
+}
+
+func jsonFields(v interface{}) (map[string]json.RawMessage, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	out := map[string]json.RawMessage{}
+	if err := json.Unmarshal(b, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 var statePledgeCollateralCmd = &cli.Command{
 	Name:  "pledge-collateral",
 	Usage: "Get minimum miner pledge collateral",
@@ -230,6 +461,12 @@ var stateListMinersCmd = &cli.Command{
 var stateListActorsCmd = &cli.Command{
 	Name:  "list-actors",
 	Usage: "list all actors in the network",
+	Flags: []cli.Flag{
+		&cli.BoolFlag{
+			Name:  "keys",
+			Usage: "also print the key addresses of account actors",
+		},
+	},
 	Action: func(cctx *cli.Context) error {
 		api, closer, err := GetFullNodeAPI(cctx)
 		if err != nil {
@@ -239,6 +476,23 @@ var stateListActorsCmd = &cli.Command{
 
 		ctx := ReqContext(cctx)
 
+		if cctx.Bool("keys") {
+			addrs, err := api.StateListActorAddresses(ctx, nil)
+			if err != nil {
+				return err
+			}
+
+			for _, a := range addrs {
+				if a.Key == address.Undef {
+					fmt.Println(a.ID.String())
+					continue
+				}
+				fmt.Printf("%s\t%s\n", a.ID, a.Key)
+			}
+
+			return nil
+		}
+
 		actors, err := api.StateListActors(ctx, nil)
 		if err != nil {
 			return err
@@ -251,3 +505,62 @@ var stateListActorsCmd = &cli.Command{
 		return nil
 	},
 }
+
+var stateLookupCmd = &cli.Command{
+	Name:      "lookup",
+	Usage:     "Find the ID address of an actor",
+	ArgsUsage: "<address>",
+	Flags: []cli.Flag{
+		&cli.BoolFlag{
+			Name:  "reverse",
+			Usage: "find the key address of an account actor given its ID address",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		if !cctx.Args().Present() {
+			return xerrors.New("must specify an address")
+		}
+
+		addr, err := address.NewFromString(cctx.Args().First())
+		if err != nil {
+			return err
+		}
+
+		api, closer, err := GetFullNodeAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		ctx := ReqContext(cctx)
+
+		var out address.Address
+		if cctx.Bool("reverse") {
+			out, err = api.StateAccountKey(ctx, addr, nil)
+		} else {
+			out, err = api.StateLookupID(ctx, addr, nil)
+		}
+		if err != nil {
+			return err
+		}
+
+		fmt.Println(out.String())
+		return nil
+	},
+}
+
+// ParseKeyAddress parses an address given in either form, resolving ID
+// addresses of account actors to their key address. Used wherever the
+// address has to match a wallet key or a message sender
+func ParseKeyAddress(ctx context.Context, api api.FullNode, s string) (address.Address, error) {
+	addr, err := address.NewFromString(s)
+	if err != nil {
+		return address.Undef, err
+	}
+
+	if addr.Protocol() != address.ID {
+		return addr, nil
+	}
+
+	return api.StateAccountKey(ctx, addr, nil)
+}
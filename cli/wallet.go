@@ -1,15 +1,20 @@
 package cli
 
 import (
+	"bufio"
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
+	"strconv"
 	"strings"
 
 	"github.com/filecoin-project/go-lotus/chain/address"
 	types "github.com/filecoin-project/go-lotus/chain/types"
+	"golang.org/x/crypto/ssh/terminal"
+	"golang.org/x/xerrors"
 	"gopkg.in/urfave/cli.v2"
 )
 
@@ -22,6 +27,17 @@ var walletCmd = &cli.Command{
 		walletBalance,
 		walletExport,
 		walletImport,
+		walletLock,
+		walletUnlock,
+		walletRestore,
+		walletDerive,
+		walletSignMessage,
+		walletSetDefault,
+		walletDelete,
+		walletSign,
+		walletVerify,
+		walletSetPolicy,
+		walletPolicy,
 	},
 }
 
@@ -29,6 +45,12 @@ var walletNew = &cli.Command{
 	Name:      "new",
 	Usage:     "Generate a new key of the given type",
 	ArgsUsage: "[bls|secp256k1]",
+	Flags: []cli.Flag{
+		&cli.BoolFlag{
+			Name:  "hd",
+			Usage: "derive the key from the wallet HD seed, creating the seed if needed",
+		},
+	},
 	Action: func(cctx *cli.Context) error {
 		api, closer, err := GetFullNodeAPI(cctx)
 		if err != nil {
@@ -42,6 +64,21 @@ var walletNew = &cli.Command{
 			t = "bls"
 		}
 
+		if cctx.Bool("hd") {
+			hk, err := api.WalletNewHD(ctx, t)
+			if err != nil {
+				return err
+			}
+
+			if hk.Mnemonic != "" {
+				fmt.Fprintln(os.Stderr, "Created a new HD seed, write down its mnemonic, it is the only backup of all HD keys:")
+				fmt.Fprintf(os.Stderr, "\n%s\n\n", hk.Mnemonic)
+			}
+
+			fmt.Println(hk.Address.String())
+			return nil
+		}
+
 		nk, err := api.WalletNew(ctx, t)
 		if err != nil {
 			return err
@@ -90,7 +127,7 @@ var walletBalance = &cli.Command{
 
 		var addr address.Address
 		if cctx.Args().First() != "" {
-			addr, err = address.NewFromString(cctx.Args().First())
+			addr, err = ParseKeyAddress(ctx, api, cctx.Args().First())
 		} else {
 			addr, err = api.WalletDefaultAddress(ctx)
 		}
@@ -123,7 +160,7 @@ var walletExport = &cli.Command{
 			return fmt.Errorf("must specify key to export")
 		}
 
-		addr, err := address.NewFromString(cctx.Args().First())
+		addr, err := ParseKeyAddress(ctx, api, cctx.Args().First())
 		if err != nil {
 			return err
 		}
@@ -188,3 +225,351 @@ var walletImport = &cli.Command{
 		return nil
 	},
 }
+
+var walletLock = &cli.Command{
+	Name:  "lock",
+	Usage: "clear wallet keys from memory and lock the encrypted keystore",
+	Action: func(cctx *cli.Context) error {
+		api, closer, err := GetFullNodeAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		return api.WalletLock(ReqContext(cctx))
+	},
+}
+
+var walletUnlock = &cli.Command{
+	Name:  "unlock",
+	Usage: "unlock the encrypted keystore, reading the passphrase from the terminal",
+	Action: func(cctx *cli.Context) error {
+		api, closer, err := GetFullNodeAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		fmt.Print("Keystore passphrase: ")
+		pp, err := terminal.ReadPassword(int(os.Stdin.Fd()))
+		fmt.Println()
+		if err != nil {
+			return err
+		}
+
+		return api.WalletUnlock(ReqContext(cctx), string(pp))
+	},
+}
+
+var walletRestore = &cli.Command{
+	Name:  "restore",
+	Usage: "restore the HD seed from a mnemonic read from stdin",
+	Action: func(cctx *cli.Context) error {
+		api, closer, err := GetFullNodeAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		if terminal.IsTerminal(int(os.Stdin.Fd())) {
+			fmt.Print("Mnemonic: ")
+		}
+
+		mnemonic, err := bufio.NewReader(os.Stdin).ReadString('\n')
+		if err != nil && err != io.EOF {
+			return err
+		}
+
+		if err := api.WalletRestoreHD(ReqContext(cctx), strings.Join(strings.Fields(mnemonic), " ")); err != nil {
+			return err
+		}
+
+		fmt.Println("HD seed restored, use 'lotus wallet derive' to recover keys")
+		return nil
+	},
+}
+
+var walletDerive = &cli.Command{
+	Name:      "derive",
+	Usage:     "derive the key at the given index from the HD seed",
+	ArgsUsage: "<index>",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "type",
+			Usage: "key type, bls or secp256k1",
+			Value: "bls",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		if !cctx.Args().Present() {
+			return fmt.Errorf("must specify the derivation index")
+		}
+
+		index, err := strconv.ParseUint(cctx.Args().First(), 10, 64)
+		if err != nil {
+			return xerrors.Errorf("parsing index: %w", err)
+		}
+
+		api, closer, err := GetFullNodeAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		addr, err := api.WalletDeriveHD(ReqContext(cctx), cctx.String("type"), index)
+		if err != nil {
+			return err
+		}
+
+		fmt.Println(addr.String())
+		return nil
+	},
+}
+
+var walletSignMessage = &cli.Command{
+	Name:      "sign-message",
+	Usage:     "Sign a message written by 'send --unsigned-out' using the local keystore, without a running daemon",
+	ArgsUsage: "<unsigned message file>",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "out",
+			Usage: "file to write the signed message to, files ending in .cbor are written as cbor",
+			Value: "signed.json",
+		},
+		KeyStorePassphraseFileFlag,
+	},
+	Action: func(cctx *cli.Context) error {
+		if !cctx.Args().Present() {
+			return fmt.Errorf("must specify the message file")
+		}
+
+		env, err := readEnvelope(cctx.Args().First())
+		if err != nil {
+			return xerrors.Errorf("reading message: %w", err)
+		}
+		if env.Message == nil {
+			return xerrors.New("message is already signed")
+		}
+
+		w, closer, err := openLocalWallet(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer() //nolint:errcheck
+
+		msg := env.Message
+		sig, err := w.Sign(ReqContext(cctx), msg.From, msg.Cid().Bytes())
+		if err != nil {
+			return xerrors.Errorf("signing message: %w", err)
+		}
+
+		err = writeEnvelope(cctx.String("out"), &types.MessageEnvelope{
+			Version: types.MessageEnvelopeVersion,
+			SignedMessage: &types.SignedMessage{
+				Message:   *msg,
+				Signature: *sig,
+			},
+		})
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Signed message from %s with nonce %d\n", msg.From, msg.Nonce)
+		return nil
+	},
+}
+
+var walletSetDefault = &cli.Command{
+	Name:      "set-default",
+	Usage:     "Set the default wallet address",
+	ArgsUsage: "<address>",
+	Action: func(cctx *cli.Context) error {
+		api, closer, err := GetFullNodeAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := ReqContext(cctx)
+
+		if !cctx.Args().Present() {
+			return fmt.Errorf("must specify address to set as default")
+		}
+
+		addr, err := ParseKeyAddress(ctx, api, cctx.Args().First())
+		if err != nil {
+			return err
+		}
+
+		return api.WalletSetDefault(ctx, addr)
+	},
+}
+
+var walletDelete = &cli.Command{
+	Name:      "delete",
+	Usage:     "Delete a key, it is moved to the trash namespace of the keystore",
+	ArgsUsage: "<address>",
+	Action: func(cctx *cli.Context) error {
+		api, closer, err := GetFullNodeAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := ReqContext(cctx)
+
+		if !cctx.Args().Present() {
+			return fmt.Errorf("must specify key to delete")
+		}
+
+		addr, err := ParseKeyAddress(ctx, api, cctx.Args().First())
+		if err != nil {
+			return err
+		}
+
+		return api.WalletDelete(ctx, addr)
+	},
+}
+
+var walletSign = &cli.Command{
+	Name:      "sign",
+	Usage:     "Sign hex encoded data, the signature is printed as hex with its type byte first",
+	ArgsUsage: "<address> <hex data>",
+	Action: func(cctx *cli.Context) error {
+		api, closer, err := GetFullNodeAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := ReqContext(cctx)
+
+		if cctx.Args().Len() != 2 {
+			return fmt.Errorf("'sign' expects two arguments, address and data")
+		}
+
+		addr, err := ParseKeyAddress(ctx, api, cctx.Args().Get(0))
+		if err != nil {
+			return err
+		}
+
+		data, err := hex.DecodeString(cctx.Args().Get(1))
+		if err != nil {
+			return xerrors.Errorf("decoding data: %w", err)
+		}
+
+		sig, err := api.WalletSign(ctx, addr, data)
+		if err != nil {
+			return err
+		}
+
+		fmt.Println(hex.EncodeToString(append([]byte{byte(sig.TypeCode())}, sig.Data...)))
+		return nil
+	},
+}
+
+var walletVerify = &cli.Command{
+	Name:      "verify",
+	Usage:     "Verify a signature made with 'wallet sign'",
+	ArgsUsage: "<address> <hex data> <hex signature>",
+	Action: func(cctx *cli.Context) error {
+		api, closer, err := GetFullNodeAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := ReqContext(cctx)
+
+		if cctx.Args().Len() != 3 {
+			return fmt.Errorf("'verify' expects three arguments, address, data and signature")
+		}
+
+		addr, err := ParseKeyAddress(ctx, api, cctx.Args().Get(0))
+		if err != nil {
+			return err
+		}
+
+		data, err := hex.DecodeString(cctx.Args().Get(1))
+		if err != nil {
+			return xerrors.Errorf("decoding data: %w", err)
+		}
+
+		sigb, err := hex.DecodeString(cctx.Args().Get(2))
+		if err != nil {
+			return xerrors.Errorf("decoding signature: %w", err)
+		}
+		sig, err := types.SignatureFromBytes(sigb)
+		if err != nil {
+			return err
+		}
+
+		ok, err := api.WalletVerify(ctx, addr, data, &sig)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return xerrors.New("invalid signature")
+		}
+
+		fmt.Println("valid")
+		return nil
+	},
+}
+
+var walletSetPolicy = &cli.Command{
+	Name:      "set-policy",
+	Usage:     "Limit what can be spent from an address through the API, without limits the policy is removed",
+	ArgsUsage: "<address>",
+	Flags:     spendPolicyFlags,
+	Action: func(cctx *cli.Context) error {
+		api, closer, err := GetFullNodeAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := ReqContext(cctx)
+
+		if !cctx.Args().Present() {
+			return fmt.Errorf("must specify the address")
+		}
+
+		addr, err := ParseKeyAddress(ctx, api, cctx.Args().First())
+		if err != nil {
+			return err
+		}
+
+		policy, err := parseSpendPolicy(cctx)
+		if err != nil {
+			return err
+		}
+
+		return api.WalletSetPolicy(ctx, addr, policy)
+	},
+}
+
+var walletPolicy = &cli.Command{
+	Name:      "policy",
+	Usage:     "Print the spend policy of an address",
+	ArgsUsage: "<address>",
+	Action: func(cctx *cli.Context) error {
+		api, closer, err := GetFullNodeAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+		ctx := ReqContext(cctx)
+
+		if !cctx.Args().Present() {
+			return fmt.Errorf("must specify the address")
+		}
+
+		addr, err := ParseKeyAddress(ctx, api, cctx.Args().First())
+		if err != nil {
+			return err
+		}
+
+		policy, err := api.WalletGetPolicy(ctx, addr)
+		if err != nil {
+			return err
+		}
+
+		return printSpendPolicy(policy)
+	},
+}
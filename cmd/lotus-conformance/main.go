@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/ipfs/go-cid"
+	logging "github.com/ipfs/go-log"
+	"golang.org/x/xerrors"
+	"gopkg.in/urfave/cli.v2"
+
+	"github.com/filecoin-project/go-lotus/build"
+	"github.com/filecoin-project/go-lotus/chain/conformance"
+	lcli "github.com/filecoin-project/go-lotus/cli"
+)
+
+var log = logging.Logger("lotus-conformance")
+
+func main() {
+	logging.SetLogLevel("*", "INFO")
+
+	app := &cli.App{
+		Name:    "lotus-conformance",
+		Usage:   "Extract and run VM conformance test vectors",
+		Version: build.Version,
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:    "repo",
+				EnvVars: []string{"LOTUS_PATH"},
+				Hidden:  true,
+				Value:   "~/.lotus", // TODO: Consider XDG_DATA_HOME
+			},
+		},
+		Commands: []*cli.Command{
+			extractCmd,
+			runCmd,
+		},
+	}
+
+	if err := app.Run(os.Args); err != nil {
+		log.Warnf("%+v", err)
+		os.Exit(1)
+	}
+}
+
+var extractCmd = &cli.Command{
+	Name:      "extract",
+	Usage:     "Extract a message vector from a message on the local chain",
+	ArgsUsage: "<message cid>",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "out",
+			Usage: "file to write the vector to",
+			Value: "vector.json",
+		},
+		&cli.StringFlag{
+			Name:  "description",
+			Usage: "description of what the vector tests",
+		},
+	},
+	Action: func(cctx *cli.Context) error {
+		if !cctx.Args().Present() {
+			return xerrors.New("must specify the cid of the message to extract")
+		}
+
+		mcid, err := cid.Decode(cctx.Args().First())
+		if err != nil {
+			return xerrors.Errorf("message cid was invalid: %w", err)
+		}
+
+		api, closer, err := lcli.GetFullNodeAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		ctx := lcli.ReqContext(cctx)
+
+		v, err := conformance.ExtractMessage(ctx, api, mcid)
+		if err != nil {
+			return err
+		}
+		v.Meta.Description = cctx.String("description")
+
+		if err := v.Save(cctx.String("out")); err != nil {
+			return xerrors.Errorf("saving vector: %w", err)
+		}
+
+		fmt.Printf("Wrote vector for %s to %s (%d bytes of state)\n", mcid, cctx.String("out"), len(v.CAR))
+		return nil
+	},
+}
+
+var runCmd = &cli.Command{
+	Name:      "run",
+	Usage:     "Execute test vectors and check their results",
+	ArgsUsage: "<vector file>...",
+	Action: func(cctx *cli.Context) error {
+		if !cctx.Args().Present() {
+			return xerrors.New("must specify at least one vector file")
+		}
+
+		var failed int
+		for _, path := range cctx.Args().Slice() {
+			if err := runVector(context.TODO(), path); err != nil {
+				fmt.Printf("FAIL %s: %s\n", path, err)
+				failed++
+				continue
+			}
+			fmt.Printf("PASS %s\n", path)
+		}
+
+		if failed > 0 {
+			return xerrors.Errorf("%d of %d vectors failed", failed, cctx.Args().Len())
+		}
+		return nil
+	},
+}
+
+func runVector(ctx context.Context, path string) error {
+	v, err := conformance.LoadVector(path)
+	if err != nil {
+		return err
+	}
+
+	res, err := conformance.Execute(ctx, v)
+	if err != nil {
+		return err
+	}
+
+	return v.Check(res)
+}
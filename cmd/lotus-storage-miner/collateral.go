@@ -0,0 +1,204 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+
+	cbg "github.com/whyrusleeping/cbor-gen"
+	"golang.org/x/xerrors"
+	"gopkg.in/urfave/cli.v2"
+
+	"github.com/filecoin-project/go-lotus/api"
+	"github.com/filecoin-project/go-lotus/chain/actors"
+	"github.com/filecoin-project/go-lotus/chain/address"
+	"github.com/filecoin-project/go-lotus/chain/types"
+	lcli "github.com/filecoin-project/go-lotus/cli"
+)
+
+var collateralCmd = &cli.Command{
+	Name:  "collateral",
+	Usage: "Manage miner collateral",
+	Subcommands: []*cli.Command{
+		collateralDePledgeCmd,
+		collateralWithdrawCmd,
+	},
+}
+
+var collateralDePledgeCmd = &cli.Command{
+	Name:      "depledge",
+	Usage:     "Schedule withdrawal of collateral not backing any power",
+	ArgsUsage: "<amount>",
+	Action: func(cctx *cli.Context) error {
+		if !cctx.Args().Present() {
+			return fmt.Errorf("must specify amount to depledge")
+		}
+
+		amt, err := types.BigFromString(cctx.Args().First())
+		if err != nil {
+			return err
+		}
+
+		return sendMinerMessage(cctx, actors.MAMethods.DePledge, false, &actors.DePledgeParams{Amount: amt})
+	},
+}
+
+var collateralWithdrawCmd = &cli.Command{
+	Name:  "withdraw",
+	Usage: "Send depledged collateral to the owner once the depledge delay has passed",
+	Action: func(cctx *cli.Context) error {
+		nodeApi, closer, err := lcli.GetStorageMinerAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		api, acloser, err := lcli.GetFullNodeAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer acloser()
+
+		ctx := lcli.ReqContext(cctx)
+
+		maddr, err := nodeApi.ActorAddress(ctx)
+		if err != nil {
+			return err
+		}
+
+		scheduled, err := depledgedCollateral(ctx, api, maddr)
+		if err != nil {
+			return err
+		}
+
+		if scheduled.Sign() == 0 {
+			return xerrors.Errorf("no collateral was depledged")
+		}
+
+		fmt.Printf("Withdrawing %s\n", scheduled)
+		return sendMinerMessage(cctx, actors.MAMethods.DePledge, false, &actors.DePledgeParams{Amount: scheduled})
+	},
+}
+
+// depledgedCollateral returns the collateral scheduled for withdrawal in the
+// miner actor state
+func depledgedCollateral(ctx context.Context, api api.FullNode, maddr address.Address) (types.BigInt, error) {
+	act, err := api.StateGetActor(ctx, maddr, nil)
+	if err != nil {
+		return types.EmptyInt, xerrors.Errorf("failed to get miner actor: %w", err)
+	}
+
+	raw, err := api.ChainReadObj(ctx, act.Head)
+	if err != nil {
+		return types.EmptyInt, xerrors.Errorf("failed to read miner state: %w", err)
+	}
+
+	var mst actors.StorageMinerActorState
+	if err := mst.UnmarshalCBOR(bytes.NewReader(raw)); err != nil {
+		return types.EmptyInt, xerrors.Errorf("failed to decode miner state: %w", err)
+	}
+
+	return mst.DePledgedCollateral, nil
+}
+
+var setWorkerCmd = &cli.Command{
+	Name:      "set-worker",
+	Usage:     "Change the worker address of the miner",
+	ArgsUsage: "<address>",
+	Action: func(cctx *cli.Context) error {
+		if !cctx.Args().Present() {
+			return fmt.Errorf("must specify new worker address")
+		}
+
+		api, closer, err := lcli.GetFullNodeAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		waddr, err := lcli.ParseKeyAddress(lcli.ReqContext(cctx), api, cctx.Args().First())
+		if err != nil {
+			return err
+		}
+
+		return sendMinerMessage(cctx, actors.MAMethods.ChangeWorker, true, &actors.ChangeWorkerParams{NewWorker: waddr})
+	},
+}
+
+// sendMinerMessage sends a message to the miner actor from either its worker
+// or owner address, and waits for it to be executed
+func sendMinerMessage(cctx *cli.Context, method uint64, fromOwner bool, params cbg.CBORMarshaler) error {
+	nodeApi, closer, err := lcli.GetStorageMinerAPI(cctx)
+	if err != nil {
+		return err
+	}
+	defer closer()
+
+	api, acloser, err := lcli.GetFullNodeAPI(cctx)
+	if err != nil {
+		return err
+	}
+	defer acloser()
+
+	ctx := lcli.ReqContext(cctx)
+
+	maddr, err := nodeApi.ActorAddress(ctx)
+	if err != nil {
+		return err
+	}
+
+	var from address.Address
+	if fromOwner {
+		from, err = minerOwner(ctx, api, maddr)
+	} else {
+		from, err = api.StateMinerWorker(ctx, maddr, nil)
+	}
+	if err != nil {
+		return err
+	}
+
+	enc, aerr := actors.SerializeParams(params)
+	if aerr != nil {
+		return aerr
+	}
+
+	smsg, err := api.MpoolPushMessage(ctx, &types.Message{
+		To:     maddr,
+		From:   from,
+		Method: method,
+		Params: enc,
+		Value:  types.NewInt(0),
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Waiting for message %s\n", smsg.Cid())
+	ret, err := api.StateWaitMsg(ctx, smsg.Cid())
+	if err != nil {
+		return err
+	}
+
+	if ret.Receipt.ExitCode != 0 {
+		return xerrors.Errorf("message failed with exit code %d", ret.Receipt.ExitCode)
+	}
+
+	return nil
+}
+
+func minerOwner(ctx context.Context, api api.FullNode, maddr address.Address) (address.Address, error) {
+	recp, err := api.StateCall(ctx, &types.Message{
+		To:     maddr,
+		From:   maddr,
+		Method: actors.MAMethods.GetOwner,
+	}, nil)
+	if err != nil {
+		return address.Undef, xerrors.Errorf("failed to get owner address: %w", err)
+	}
+
+	if recp.ExitCode != 0 {
+		return address.Undef, xerrors.Errorf("getOwner returned exit code %d", recp.ExitCode)
+	}
+
+	return address.NewFromBytes(recp.Return)
+}
@@ -246,11 +246,11 @@ func configureStorageMiner(ctx context.Context, api api.FullNode, addr address.A
 }
 
 func createStorageMiner(ctx context.Context, api api.FullNode, peerid peer.ID, cctx *cli.Context) (addr address.Address, err error) {
-	log.Info("Creating StorageMarket.CreateStorageMiner message")
+	log.Info("Creating StoragePower.CreateStorageMiner message")
 
 	var owner address.Address
 	if cctx.String("owner") != "" {
-		owner, err = address.NewFromString(cctx.String("owner"))
+		owner, err = lcli.ParseKeyAddress(ctx, api, cctx.String("owner"))
 	} else {
 		owner, err = api.WalletDefaultAddress(ctx)
 	}
@@ -260,7 +260,7 @@ func createStorageMiner(ctx context.Context, api api.FullNode, peerid peer.ID, c
 
 	worker := owner
 	if cctx.String("worker") != "" {
-		worker, err = address.NewFromString(cctx.String("worker"))
+		worker, err = lcli.ParseKeyAddress(ctx, api, cctx.String("worker"))
 	} else if cctx.Bool("create-worker-key") { // TODO: Do we need to force this if owner is Secpk?
 		worker, err = api.WalletNew(ctx, types.KTBLS)
 	}
@@ -285,7 +285,7 @@ func createStorageMiner(ctx context.Context, api api.FullNode, peerid peer.ID, c
 	}
 
 	createStorageMinerMsg := &types.Message{
-		To:    actors.StorageMarketAddress,
+		To:    actors.StoragePowerAddress,
 		From:  owner,
 		Value: collateral,
 
@@ -301,7 +301,7 @@ func createStorageMiner(ctx context.Context, api api.FullNode, peerid peer.ID, c
 		return address.Undef, err
 	}
 
-	log.Infof("Pushed StorageMarket.CreateStorageMiner, %s to Mpool", signed.Cid())
+	log.Infof("Pushed StoragePower.CreateStorageMiner, %s to Mpool", signed.Cid())
 	log.Infof("Waiting for confirmation")
 
 	mw, err := api.StateWaitMsg(ctx, signed.Cid())
@@ -24,6 +24,9 @@ func main() {
 		infoCmd,
 		storeGarbageCmd,
 		sectorsCmd,
+		collateralCmd,
+		setWorkerCmd,
+		rewardsCmd,
 	}
 	jaeger := tracing.SetupJaegerTracing("lotus")
 	defer func() {
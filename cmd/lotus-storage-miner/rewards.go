@@ -0,0 +1,102 @@
+package main
+
+import (
+	"fmt"
+
+	"golang.org/x/xerrors"
+	"gopkg.in/urfave/cli.v2"
+
+	"github.com/filecoin-project/go-lotus/chain/actors"
+	"github.com/filecoin-project/go-lotus/chain/types"
+	lcli "github.com/filecoin-project/go-lotus/cli"
+)
+
+var rewardsCmd = &cli.Command{
+	Name:  "rewards",
+	Usage: "Print block rewards of the miner owner held by the reward actor",
+	Subcommands: []*cli.Command{
+		rewardsWithdrawCmd,
+	},
+	Action: func(cctx *cli.Context) error {
+		nodeApi, closer, err := lcli.GetStorageMinerAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		api, acloser, err := lcli.GetFullNodeAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer acloser()
+
+		ctx := lcli.ReqContext(cctx)
+
+		maddr, err := nodeApi.ActorAddress(ctx)
+		if err != nil {
+			return err
+		}
+
+		rewards, err := api.StateMinerRewards(ctx, maddr, nil)
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Owner:\t%s\n", rewards.Owner)
+		fmt.Printf("Vested:\t%s\n", rewards.Vested)
+		fmt.Printf("Locked:\t%s\n", rewards.Locked)
+		return nil
+	},
+}
+
+var rewardsWithdrawCmd = &cli.Command{
+	Name:  "withdraw",
+	Usage: "Withdraw vested block rewards to the miner owner",
+	Action: func(cctx *cli.Context) error {
+		nodeApi, closer, err := lcli.GetStorageMinerAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer closer()
+
+		api, acloser, err := lcli.GetFullNodeAPI(cctx)
+		if err != nil {
+			return err
+		}
+		defer acloser()
+
+		ctx := lcli.ReqContext(cctx)
+
+		maddr, err := nodeApi.ActorAddress(ctx)
+		if err != nil {
+			return err
+		}
+
+		owner, err := minerOwner(ctx, api, maddr)
+		if err != nil {
+			return err
+		}
+
+		smsg, err := api.MpoolPushMessage(ctx, &types.Message{
+			To:     actors.RewardActorAddress,
+			From:   owner,
+			Method: actors.RAMethods.WithdrawReward,
+			Value:  types.NewInt(0),
+		})
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("Waiting for message %s\n", smsg.Cid())
+		ret, err := api.StateWaitMsg(ctx, smsg.Cid())
+		if err != nil {
+			return err
+		}
+
+		if ret.Receipt.ExitCode != 0 {
+			return xerrors.Errorf("withdraw message failed with exit code %d", ret.Receipt.ExitCode)
+		}
+
+		return nil
+	},
+}
@@ -21,6 +21,7 @@ import (
 	"github.com/filecoin-project/go-lotus/lib/sectorbuilder"
 	"github.com/filecoin-project/go-lotus/node"
 	"github.com/filecoin-project/go-lotus/node/modules"
+	"github.com/filecoin-project/go-lotus/node/modules/dtypes"
 	"github.com/filecoin-project/go-lotus/node/repo"
 )
 
@@ -32,6 +33,7 @@ var runCmd = &cli.Command{
 			Name:  "api",
 			Value: "2345",
 		},
+		lcli.KeyStorePassphraseFileFlag,
 	},
 	Action: func(cctx *cli.Context) error {
 		if err := build.GetParams(true); err != nil {
@@ -69,6 +71,9 @@ var runCmd = &cli.Command{
 			node.StorageMiner(&minerapi),
 			node.Online(),
 			node.Repo(r),
+			node.Override(new(dtypes.KeyStorePassphrase), func() dtypes.KeyStorePassphrase {
+				return lcli.KeyStorePassphrase(cctx, false)
+			}),
 
 			node.Override(node.SetApiEndpointKey, func(lr repo.LockedRepo) error {
 				apima, err := multiaddr.NewMultiaddr("/ip4/127.0.0.1/tcp/" + cctx.String("api"))
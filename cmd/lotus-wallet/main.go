@@ -0,0 +1,207 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+
+	logging "github.com/ipfs/go-log"
+	"golang.org/x/xerrors"
+	"gopkg.in/urfave/cli.v2"
+
+	"github.com/filecoin-project/go-lotus/api"
+	"github.com/filecoin-project/go-lotus/build"
+	"github.com/filecoin-project/go-lotus/chain/wallet"
+	lcli "github.com/filecoin-project/go-lotus/cli"
+	"github.com/filecoin-project/go-lotus/lib/auth"
+	"github.com/filecoin-project/go-lotus/lib/jsonrpc"
+	"github.com/filecoin-project/go-lotus/node/repo"
+)
+
+var log = logging.Logger("main")
+
+const tokenFile = "token"
+
+func main() {
+	logging.SetLogLevel("*", "INFO")
+
+	app := &cli.App{
+		Name:    "lotus-wallet",
+		Usage:   "Serve a keystore as a remote wallet backend for lotus",
+		Version: build.Version,
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:    "repo",
+				EnvVars: []string{"LOTUS_WALLET_PATH"},
+				Value:   "~/.lotuswallet", // TODO: Consider XDG_DATA_HOME
+			},
+		},
+
+		Commands: []*cli.Command{
+			runCmd,
+		},
+	}
+
+	if err := app.Run(os.Args); err != nil {
+		log.Warnf("%+v", err)
+		os.Exit(1)
+	}
+}
+
+var runCmd = &cli.Command{
+	Name:  "run",
+	Usage: "Start the wallet API",
+	Flags: []cli.Flag{
+		&cli.StringFlag{
+			Name:  "listen",
+			Usage: "address to serve the API on",
+			Value: "127.0.0.1:1777",
+		},
+		&cli.BoolFlag{
+			Name:  "allow-export",
+			Usage: "allow exporting and importing private keys through the API",
+		},
+		lcli.KeyStorePassphraseFileFlag,
+	},
+	Action: func(cctx *cli.Context) error {
+		r, err := repo.NewFS(cctx.String("repo"))
+		if err != nil {
+			return err
+		}
+
+		if err := r.Init(); err != nil && err != repo.ErrRepoExists {
+			return err
+		}
+
+		lr, err := r.Lock()
+		if err != nil {
+			return err
+		}
+		defer lr.Close() //nolint:errcheck
+
+		ks, err := openKeyStore(cctx, lr)
+		if err != nil {
+			return err
+		}
+
+		mds, err := lr.Datastore("/metadata")
+		if err != nil {
+			return err
+		}
+
+		w, err := wallet.NewWallet(ks, mds)
+		if err != nil {
+			return err
+		}
+
+		token, err := loadToken(lr.Path())
+		if err != nil {
+			return err
+		}
+		log.Infof("API token is stored in %s", filepath.Join(lr.Path(), tokenFile))
+
+		allow := []string{api.PermRead, api.PermWrite, api.PermSign}
+		if cctx.Bool("allow-export") {
+			allow = append(allow, api.PermAdmin)
+		}
+
+		rpcServer := jsonrpc.NewServer()
+		rpcServer.Register("Filecoin", api.PermissionedWalletAPI(w))
+
+		ah := &auth.Handler{
+			Verify: func(ctx context.Context, t string) ([]string, error) {
+				if subtle.ConstantTimeCompare([]byte(t), token) != 1 {
+					return nil, xerrors.New("invalid token")
+				}
+				return allow, nil
+			},
+			Next: rpcServer.ServeHTTP,
+		}
+
+		http.Handle("/rpc/v0", ah)
+
+		srv := &http.Server{Addr: cctx.String("listen"), Handler: http.DefaultServeMux}
+
+		sigChan := make(chan os.Signal, 2)
+		go func() {
+			<-sigChan
+			if err := srv.Shutdown(context.TODO()); err != nil {
+				log.Errorf("shutting down RPC server failed: %s", err)
+			}
+		}()
+		signal.Notify(sigChan, syscall.SIGTERM, syscall.SIGINT)
+
+		log.Infof("Serving wallet API on %s", cctx.String("listen"))
+		if err := srv.ListenAndServe(); err != http.ErrServerClosed {
+			return err
+		}
+		return nil
+	},
+}
+
+// openKeyStore returns the repo keystore unlocked with the passphrase. Keys
+// are always kept encrypted, plain keystores are encrypted on first run
+func openKeyStore(cctx *cli.Context, lr repo.LockedRepo) (*repo.EncryptedKeyStore, error) {
+	ks, err := lr.KeyStore()
+	if err != nil {
+		return nil, err
+	}
+
+	eks := repo.NewEncryptedKeyStore(ks)
+	enc, err := eks.Encrypted()
+	if err != nil {
+		return nil, err
+	}
+
+	// a new passphrase is asked twice
+	pp, err := lcli.KeyStorePassphrase(cctx, !enc)()
+	if err != nil {
+		return nil, err
+	}
+
+	if !enc {
+		log.Info("Encrypting keystore")
+		if err := eks.Init(pp); err != nil {
+			return nil, xerrors.Errorf("encrypting keystore: %w", err)
+		}
+		return eks, nil
+	}
+
+	if err := eks.Unlock(pp); err != nil {
+		return nil, xerrors.Errorf("unlocking keystore: %w", err)
+	}
+
+	return eks, nil
+}
+
+// loadToken reads the API token from the repo, generating one on first run
+func loadToken(path string) ([]byte, error) {
+	tpath := filepath.Join(path, tokenFile)
+
+	token, err := ioutil.ReadFile(tpath)
+	if err == nil {
+		return token, nil
+	}
+	if !os.IsNotExist(err) {
+		return nil, xerrors.Errorf("reading API token: %w", err)
+	}
+
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+	token = []byte(hex.EncodeToString(b))
+
+	if err := ioutil.WriteFile(tpath, token, 0600); err != nil {
+		return nil, xerrors.Errorf("writing API token: %w", err)
+	}
+
+	return token, nil
+}
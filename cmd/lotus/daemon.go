@@ -12,8 +12,10 @@ import (
 
 	"github.com/filecoin-project/go-lotus/api"
 	"github.com/filecoin-project/go-lotus/build"
+	lcli "github.com/filecoin-project/go-lotus/cli"
 	"github.com/filecoin-project/go-lotus/node"
 	"github.com/filecoin-project/go-lotus/node/modules"
+	"github.com/filecoin-project/go-lotus/node/modules/dtypes"
 	"github.com/filecoin-project/go-lotus/node/modules/testing"
 	"github.com/filecoin-project/go-lotus/node/repo"
 )
@@ -44,6 +46,7 @@ var DaemonCmd = &cli.Command{
 			Name:  "bootstrap",
 			Value: true,
 		},
+		lcli.KeyStorePassphraseFileFlag,
 	},
 	Action: func(cctx *cli.Context) error {
 		ctx := context.Background()
@@ -84,6 +87,9 @@ var DaemonCmd = &cli.Command{
 
 			node.Online(),
 			node.Repo(r),
+			node.Override(new(dtypes.KeyStorePassphrase), func() dtypes.KeyStorePassphrase {
+				return lcli.KeyStorePassphrase(cctx, false)
+			}),
 
 			genesis,
 
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"fmt"
+
+	"golang.org/x/xerrors"
+	"gopkg.in/urfave/cli.v2"
+
+	lcli "github.com/filecoin-project/go-lotus/cli"
+	"github.com/filecoin-project/go-lotus/node/repo"
+)
+
+var keystoreCmd = &cli.Command{
+	Name:  "keystore",
+	Usage: "Manage the local keystore",
+	Subcommands: []*cli.Command{
+		keystoreEncryptCmd,
+	},
+}
+
+var keystoreEncryptCmd = &cli.Command{
+	Name:  "encrypt",
+	Usage: "Encrypt an existing keystore in place, the daemon must not be running",
+	Flags: []cli.Flag{
+		lcli.KeyStorePassphraseFileFlag,
+	},
+	Action: func(cctx *cli.Context) error {
+		r, err := repo.NewFS(cctx.String("repo"))
+		if err != nil {
+			return err
+		}
+
+		lr, err := r.Lock()
+		if err != nil {
+			return err
+		}
+		defer lr.Close() //nolint:errcheck
+
+		ks, err := lr.KeyStore()
+		if err != nil {
+			return err
+		}
+
+		pp, err := lcli.KeyStorePassphrase(cctx, true)()
+		if err != nil {
+			return err
+		}
+
+		if err := repo.NewEncryptedKeyStore(ks).Init(pp); err != nil {
+			return xerrors.Errorf("encrypting keystore: %w", err)
+		}
+
+		fmt.Println("Keystore encrypted")
+		return nil
+	},
+}
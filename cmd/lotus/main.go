@@ -17,6 +17,7 @@ func main() {
 	logging.SetLogLevel("*", "INFO")
 	local := []*cli.Command{
 		DaemonCmd,
+		keystoreCmd,
 	}
 	jaeger := tracing.SetupJaegerTracing("lotus")
 	defer func() {
@@ -23,6 +23,7 @@ func serveRPC(a api.FullNode, stop node.StopFunc, addr string) error {
 
 	ah := &auth.Handler{
 		Verify: a.AuthVerify,
+		Policy: a.AuthPolicy,
 		Next:   rpcServer.ServeHTTP,
 	}
 
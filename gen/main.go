@@ -22,6 +22,7 @@ func main() {
 		types.Actor{},
 		types.MessageReceipt{},
 		types.BlockMsg{},
+		types.MessageEnvelope{},
 	)
 	if err != nil {
 		fmt.Println(err)
@@ -43,6 +44,7 @@ func main() {
 	err = gen.WriteTupleEncodersToFile("./chain/actors/cbor_gen.go", "actors",
 		actors.InitActorState{},
 		actors.ExecParams{},
+		actors.GetIdForAddressParams{},
 		actors.AccountActorState{},
 		actors.StorageMinerActorState{},
 		actors.StorageMinerConstructorParams{},
@@ -51,6 +53,8 @@ func main() {
 		actors.SubmitPoStParams{},
 		actors.PieceInclVoucherData{},
 		actors.InclusionProof{},
+		actors.VerifyPieceInclusionParams{},
+		actors.IsSectorSlashedParams{},
 		actors.PaymentVerifyParams{},
 		actors.UpdatePeerIDParams{},
 		actors.MultiSigActorState{},
@@ -75,6 +79,29 @@ func main() {
 		actors.ArbitrateConsensusFaultParams{},
 		actors.PledgeCollateralParams{},
 		actors.MinerSlashConsensusFault{},
+		actors.StorageDeal{},
+		actors.ArbitrateDealParams{},
+		actors.DePledgeParams{},
+		actors.ChangeWorkerParams{},
+		actors.SlashStorageFaultParams{},
+		actors.MinerSlashStorageFault{},
+		actors.RewardActorState{},
+		actors.Reward{},
+		actors.OwnerRewards{},
+		actors.AwardBlockRewardParams{},
+		actors.StorageMarketState{},
+		actors.StorageParticipantBalance{},
+		actors.StorageDealProposal{},
+		actors.OnChainDeal{},
+		actors.WithdrawBalanceParams{},
+		actors.AddBalanceParams{},
+		actors.PublishStorageDealsParams{},
+		actors.PublishStorageDealResponse{},
+		actors.ActivateStorageDealsParams{},
+		actors.DealActivation{},
+		actors.ProcessStorageDealsPaymentParams{},
+		actors.SlashStorageDealCollateralParams{},
+		actors.ExpireStorageDealsParams{},
 	)
 	if err != nil {
 		fmt.Println(err)
@@ -13,6 +13,9 @@ var log = logging.Logger("auth")
 
 type Handler struct {
 	Verify func(ctx context.Context, token string) ([]string, error)
+	// Policy optionally returns the spend policy of the token, which is
+	// attached to the request context
+	Policy func(ctx context.Context, token string) (*api.SpendPolicy, error)
 	Next   http.HandlerFunc
 }
 
@@ -43,6 +46,18 @@ func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		}
 
 		ctx = api.WithPerm(ctx, allow)
+
+		if h.Policy != nil {
+			policy, err := h.Policy(ctx, token)
+			if err != nil {
+				log.Warnf("getting token spend policy failed: %s", err)
+				w.WriteHeader(401)
+				return
+			}
+			if policy != nil {
+				ctx = api.WithSpendPolicy(ctx, policy)
+			}
+		}
 	}
 
 	h.Next(w, r.WithContext(ctx))
@@ -4,7 +4,7 @@ export default {
     "Constructor",
     "GetAddress",
   ],
-  "smarket": [
+  "spower": [
     "Send",
     "Constructor",
     "CreateStorageMiner",
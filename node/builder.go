@@ -83,6 +83,7 @@ const (
 	HandleIncomingMessagesKey
 
 	RunDealClientKey
+	RunStorageFaultWatcherKey
 
 	// storage miner
 	HandleDealsKey
@@ -209,7 +210,9 @@ func Online() Option {
 
 			Override(new(*store.ChainStore), modules.ChainStore),
 			Override(new(*stmgr.StateManager), stmgr.NewStateManager),
-			Override(new(*wallet.Wallet), wallet.NewWallet),
+			Override(new(dtypes.WalletKeyStore), modules.WalletKeyStore),
+			Override(new(*wallet.Wallet), modules.Wallet),
+			Override(new(*wallet.PolicyStore), wallet.NewPolicyStore),
 
 			Override(new(dtypes.ChainGCLocker), blockstore.NewGCLocker),
 			Override(new(dtypes.ChainGCBlockstore), modules.ChainGCBlockstore),
@@ -303,6 +306,14 @@ func Config(cfg *config.Root) Option {
 			ApplyIf(func(s *Settings) bool { return s.nodeType == nodeFull },
 				Override(HeadMetricsKey, metrics.SendHeadNotifs(cfg.Metrics.Nickname)),
 			),
+
+			ApplyIf(func(s *Settings) bool { return s.nodeType == nodeFull && cfg.Faults.ReportStorageFaults },
+				Override(RunStorageFaultWatcherKey, modules.RunStorageFaultWatcher(cfg.Faults)),
+			),
+		),
+
+		ApplyIf(func(s *Settings) bool { return s.nodeType == nodeFull && cfg.Wallet.RemoteBackend != "" },
+			Override(new(*wallet.Wallet), modules.RemoteWallet(cfg.Wallet)),
 		),
 	)
 }
@@ -332,6 +343,7 @@ func Repo(r repo.Repo) Option {
 		Override(new(ci.PubKey), ci.PrivKey.GetPublic),
 		Override(new(peer.ID), peer.IDFromPublicKey),
 
+		Override(new(dtypes.KeyStorePassphrase), modules.NoKeyStorePassphrase),
 		Override(new(types.KeyStore), modules.KeyStore),
 
 		Override(new(*dtypes.APIAlg), modules.APISecret),
@@ -8,6 +8,8 @@ type Root struct {
 	Libp2p Libp2p
 
 	Metrics Metrics
+	Faults  Faults
+	Wallet  Wallet
 }
 
 // API contains configs for API endpoint
@@ -26,6 +28,33 @@ type Metrics struct {
 	Nickname string
 }
 
+// Faults contains configs for reporting faults of other miners
+type Faults struct {
+	// ReportStorageFaults enables slashing miners which stopped submitting PoSts
+	ReportStorageFaults bool
+
+	// ReporterAddress is the wallet address reports are sent from. The default
+	// wallet address is used when empty
+	ReporterAddress string
+}
+
+// Wallet contains configs for signing with a remote wallet backend
+type Wallet struct {
+	// RemoteBackend is the API URL of a lotus-wallet process, for example
+	// ws://127.0.0.1:1777/rpc/v0. When set, keys held by the remote are
+	// used for signing and new keys are created there (see LocalKeys), the
+	// local keystore is only used for keys the remote doesn't have
+	RemoteBackend string
+
+	// RemoteBackendToken is the API token printed by lotus-wallet
+	RemoteBackendToken string
+
+	// LocalKeys keeps new and imported keys in the local keystore while
+	// signing with the remote backend. lotus-wallet refuses imports unless
+	// it's run with --allow-export
+	LocalKeys bool
+}
+
 // Default returns the default config
 func Default() *Root {
 	def := Root{
@@ -12,7 +12,6 @@ import (
 	chunker "github.com/ipfs/go-ipfs-chunker"
 	offline "github.com/ipfs/go-ipfs-exchange-offline"
 	files "github.com/ipfs/go-ipfs-files"
-	cbor "github.com/ipfs/go-ipld-cbor"
 	ipld "github.com/ipfs/go-ipld-format"
 	"github.com/ipfs/go-merkledag"
 	"github.com/ipfs/go-unixfs/importer/balanced"
@@ -80,49 +79,65 @@ func (a *API) ClientStartDeal(ctx context.Context, data cid.Cid, miner address.A
 		return nil, err
 	}
 
-	voucherData, err := cbor.DumpObject(vd)
-	if err != nil {
-		return nil, err
-	}
-
-	// setup payments
 	total := types.BigMul(price, types.NewInt(blocksDuration))
 
-	// TODO: at least ping the miner before creating paych / locking the money
-	extra := &types.ModVerifyParams{
-		Actor:  miner,
-		Method: actors.MAMethods.PaymentVerifyInclusion,
-		Data:   voucherData,
-	}
-
-	head := a.Chain.GetHeaviestTipSet()
-	vouchers := deals.VoucherSpec(blocksDuration, total, head.Height(), extra)
-
-	payment, err := a.PaychNewPayment(ctx, self, miner, vouchers)
-	if err != nil {
-		return nil, err
+	// TODO: at least ping the miner before locking the money
+	if err := a.ensureMarketBalance(ctx, self, total); err != nil {
+		return nil, xerrors.Errorf("adding market funds: %w", err)
 	}
 
 	proposal := deals.ClientDealProposal{
-		Data:       data,
-		TotalPrice: total,
-		Duration:   blocksDuration,
-		Payment: actors.PaymentInfo{
-			PayChActor:     payment.Channel,
-			Payer:          self,
-			ChannelMessage: payment.ChannelMessage,
-			Vouchers:       payment.Vouchers,
-		},
+		Data:          data,
+		TotalPrice:    total,
+		Duration:      blocksDuration,
 		MinerAddress:  miner,
 		ClientAddress: self,
 		MinerID:       pid,
 	}
 
 	c, err := a.DealClient.Start(ctx, proposal, vd)
-	// TODO: send updated voucher with PaymentVerifySector for cheaper validation (validate the sector the miner sent us first!)
 	return &c, err
 }
 
+// ensureMarketBalance makes sure the client has at least amt available in the
+// storage market actor escrow, adding the missing funds if needed
+func (a *API) ensureMarketBalance(ctx context.Context, addr address.Address, amt types.BigInt) error {
+	bal, err := a.StateMarketBalance(ctx, addr, nil)
+	if err != nil {
+		return err
+	}
+
+	if !bal.Available.LessThan(amt) {
+		return nil
+	}
+
+	params, aerr := actors.SerializeParams(&actors.AddBalanceParams{Address: addr})
+	if aerr != nil {
+		return aerr
+	}
+
+	smsg, err := a.MpoolPushMessage(ctx, &types.Message{
+		To:     actors.MarketActorAddress,
+		From:   addr,
+		Value:  types.BigSub(amt, bal.Available),
+		Method: actors.SMAMethods.AddBalance,
+		Params: params,
+	})
+	if err != nil {
+		return err
+	}
+
+	r, err := a.StateWaitMsg(ctx, smsg.Cid())
+	if err != nil {
+		return err
+	}
+	if r.Receipt.ExitCode != 0 {
+		return xerrors.Errorf("adding market balance failed: exit %d", r.Receipt.ExitCode)
+	}
+
+	return nil
+}
+
 func (a *API) ClientListDeals(ctx context.Context) ([]api.DealInfo, error) {
 	deals, err := a.DealClient.List()
 	if err != nil {
@@ -2,6 +2,8 @@ package impl
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
 
 	"github.com/gbrlsnchs/jwt/v3"
 	"github.com/libp2p/go-libp2p-core/host"
@@ -24,26 +26,57 @@ type CommonAPI struct {
 }
 
 type jwtPayload struct {
-	Allow []string
+	Allow  []string
+	Policy *api.SpendPolicy `json:",omitempty"`
 }
 
-func (a *CommonAPI) AuthVerify(ctx context.Context, token string) ([]string, error) {
+func (a *CommonAPI) verify(token string) (*jwtPayload, error) {
 	var payload jwtPayload
 	if _, err := jwt.Verify([]byte(token), (*jwt.HMACSHA)(a.APISecret), &payload); err != nil {
 		return nil, xerrors.Errorf("JWT Verification failed: %w", err)
 	}
 
+	return &payload, nil
+}
+
+func (a *CommonAPI) AuthVerify(ctx context.Context, token string) ([]string, error) {
+	payload, err := a.verify(token)
+	if err != nil {
+		return nil, err
+	}
+
 	return payload.Allow, nil
 }
 
-func (a *CommonAPI) AuthNew(ctx context.Context, perms []string) ([]byte, error) {
+func (a *CommonAPI) AuthNew(ctx context.Context, perms []string, policy *api.SpendPolicy) ([]byte, error) {
 	p := jwtPayload{
 		Allow: perms, // TODO: consider checking validity
 	}
 
+	if policy != nil {
+		// each token tracks its own daily spending
+		id := make([]byte, 16)
+		if _, err := rand.Read(id); err != nil {
+			return nil, err
+		}
+
+		pc := *policy
+		pc.ID = "token-" + hex.EncodeToString(id)
+		p.Policy = &pc
+	}
+
 	return jwt.Sign(&p, (*jwt.HMACSHA)(a.APISecret))
 }
 
+func (a *CommonAPI) AuthPolicy(ctx context.Context, token string) (*api.SpendPolicy, error) {
+	payload, err := a.verify(token)
+	if err != nil {
+		return nil, err
+	}
+
+	return payload.Policy, nil
+}
+
 func (a *CommonAPI) NetConnectedness(ctx context.Context, pid peer.ID) (network.Connectedness, error) {
 	return a.Host.Network().Connectedness(pid), nil
 }
@@ -21,6 +21,8 @@ type FullNodeAPI struct {
 	full.ChainAPI
 	client.API
 	full.MpoolAPI
+	full.GasAPI
+	full.MsigAPI
 	paych.PaychAPI
 	full.StateAPI
 	full.WalletAPI
@@ -0,0 +1,139 @@
+package full
+
+import (
+	"context"
+	"sort"
+
+	hamt "github.com/ipfs/go-hamt-ipld"
+	"go.uber.org/fx"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-lotus/build"
+	"github.com/filecoin-project/go-lotus/chain"
+	"github.com/filecoin-project/go-lotus/chain/state"
+	"github.com/filecoin-project/go-lotus/chain/stmgr"
+	"github.com/filecoin-project/go-lotus/chain/store"
+	"github.com/filecoin-project/go-lotus/chain/types"
+)
+
+type GasAPI struct {
+	fx.In
+
+	Stmgr *stmgr.StateManager
+	Chain *store.ChainStore
+	Mpool *chain.MessagePool
+}
+
+// GasEstimateGasLimit executes msg on top of the state computed for ts (or
+// the heaviest tipset if ts is nil) and returns the gas it used plus a safety
+// margin for state changes between estimation and inclusion. When estimating
+// on the head, the messages the sender still has in the message pool are
+// applied first
+func (a *GasAPI) GasEstimateGasLimit(ctx context.Context, msgIn *types.Message, ts *types.TipSet) (types.BigInt, error) {
+	head := a.Chain.GetHeaviestTipSet()
+	if ts == nil {
+		ts = head
+	}
+
+	msg := *msgIn
+	msg.GasLimit = types.EmptyInt
+	msg.GasPrice = types.NewInt(0)
+	if msg.Value == types.EmptyInt {
+		msg.Value = types.NewInt(0)
+	}
+
+	st, _, err := a.Stmgr.TipSetState(ctx, ts)
+	if err != nil {
+		return types.EmptyInt, xerrors.Errorf("computing tipset state: %w", err)
+	}
+
+	stree, err := state.LoadStateTree(hamt.CSTFromBstore(a.Chain.Blockstore()), st)
+	if err != nil {
+		return types.EmptyInt, xerrors.Errorf("loading state tree: %w", err)
+	}
+
+	act, err := stree.GetActor(msg.From)
+	if err != nil {
+		return types.EmptyInt, xerrors.Errorf("getting sender actor: %w", err)
+	}
+	msg.Nonce = act.Nonce
+
+	// the message pool only tracks the head, on other tipsets msg runs
+	// against the state alone
+	var msgs []*types.Message
+	if ts.Equals(head) {
+		nonce, err := a.Mpool.GetNonce(msg.From)
+		if err != nil {
+			return types.EmptyInt, xerrors.Errorf("getting pending nonce: %w", err)
+		}
+		msg.Nonce = nonce
+
+		// the pending messages are applied first, so that msg runs against
+		// the state it will actually see
+		for _, m := range a.Mpool.PendingFor(msg.From) {
+			if m.Message.Nonce < act.Nonce || m.Message.Nonce >= msg.Nonce {
+				continue
+			}
+			pm := m.Message
+			msgs = append(msgs, &pm)
+		}
+	}
+	msgs = append(msgs, &msg)
+
+	_, rets, err := a.Stmgr.ComputeState(ctx, ts.Height()+1, msgs, ts)
+	if err != nil {
+		return types.EmptyInt, xerrors.Errorf("executing message: %w", err)
+	}
+	rcpt := rets[len(rets)-1].MessageReceipt
+
+	if rcpt.ExitCode != 0 {
+		return types.EmptyInt, xerrors.Errorf("message execution failed (exit code %d)", rcpt.ExitCode)
+	}
+
+	margin := types.BigDiv(types.BigMul(rcpt.GasUsed, types.NewInt(build.GasLimitMarginPercent)), types.NewInt(100))
+	return types.BigAdd(rcpt.GasUsed, margin), nil
+}
+
+// GasEstimateGasPrice returns the median gas price of messages included in
+// the last nblocks tipsets
+func (a *GasAPI) GasEstimateGasPrice(ctx context.Context, nblocks uint64) (types.BigInt, error) {
+	ts := a.Chain.GetHeaviestTipSet()
+
+	var prices []types.BigInt
+	for i := uint64(0); i < nblocks && ts.Height() > 0; i++ {
+		msgs, err := a.Chain.MessagesForTipset(ts)
+		if err != nil {
+			return types.EmptyInt, xerrors.Errorf("loading messages for tipset %s: %w", ts.Cids(), err)
+		}
+
+		for _, m := range msgs {
+			prices = append(prices, m.VMMessage().GasPrice)
+		}
+
+		ts, err = a.Chain.LoadTipSet(ts.Parents())
+		if err != nil {
+			return types.EmptyInt, xerrors.Errorf("loading parent tipset: %w", err)
+		}
+	}
+
+	return medianGasPrice(prices), nil
+}
+
+// medianGasPrice returns the median of prices, but at least
+// build.MinimumGasPrice
+func medianGasPrice(prices []types.BigInt) types.BigInt {
+	if len(prices) == 0 {
+		return types.NewInt(build.MinimumGasPrice)
+	}
+
+	sort.Slice(prices, func(i, j int) bool {
+		return prices[i].LessThan(prices[j])
+	})
+
+	median := prices[len(prices)/2]
+	if median.LessThan(types.NewInt(build.MinimumGasPrice)) {
+		return types.NewInt(build.MinimumGasPrice)
+	}
+
+	return median
+}
@@ -0,0 +1,217 @@
+package full
+
+import (
+	"context"
+	"testing"
+
+	pubsub "github.com/libp2p/go-libp2p-pubsub"
+	mocknet "github.com/libp2p/go-libp2p/p2p/net/mock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-lotus/build"
+	"github.com/filecoin-project/go-lotus/chain"
+	"github.com/filecoin-project/go-lotus/chain/gen"
+	"github.com/filecoin-project/go-lotus/chain/types"
+)
+
+func testMpoolAPI(t *testing.T) (*gen.ChainGen, *MpoolAPI) {
+	ctx := context.Background()
+
+	cg, err := gen.NewGenerator()
+	require.NoError(t, err)
+
+	h, err := mocknet.New(ctx).GenPeer()
+	require.NoError(t, err)
+	ps, err := pubsub.NewFloodSub(ctx, h)
+	require.NoError(t, err)
+
+	sm := cg.StateManager()
+	mp := chain.NewMessagePool(sm, ps)
+
+	return cg, &MpoolAPI{
+		WalletAPI: WalletAPI{
+			StateManager: sm,
+			Wallet:       cg.Wallet(),
+		},
+		GasAPI: GasAPI{
+			Stmgr: sm,
+			Chain: sm.ChainStore(),
+			Mpool: mp,
+		},
+		Mpool: mp,
+	}
+}
+
+func signMessage(t *testing.T, cg *gen.ChainGen, msg *types.Message) *types.SignedMessage {
+	sig, err := cg.Wallet().Sign(context.TODO(), msg.From, msg.Cid().Bytes())
+	require.NoError(t, err)
+
+	return &types.SignedMessage{
+		Message:   *msg,
+		Signature: *sig,
+	}
+}
+
+func TestGasEstimateGasLimit(t *testing.T) {
+	ctx := context.Background()
+	cg, a := testMpoolAPI(t)
+
+	// genesis actor IDs aren't deterministic, send to an address without an
+	// actor so that it can't be the sender
+	to, err := cg.Wallet().GenerateKey(types.KTSecp256k1)
+	require.NoError(t, err)
+
+	msg := &types.Message{
+		From:  cg.Banker(),
+		To:    to,
+		Value: types.NewInt(1000),
+	}
+
+	est, err := a.GasEstimateGasLimit(ctx, msg, nil)
+	require.NoError(t, err)
+	assert.Equal(t, types.EmptyInt, msg.GasLimit, "the estimated message is left untouched")
+
+	rcpt, err := a.Stmgr.Call(ctx, &types.Message{
+		From:  cg.Banker(),
+		To:    to,
+		Value: types.NewInt(1000),
+	}, nil)
+	require.NoError(t, err)
+	require.Equal(t, uint8(0), rcpt.ExitCode)
+
+	margin := types.BigDiv(types.BigMul(rcpt.GasUsed, types.NewInt(build.GasLimitMarginPercent)), types.NewInt(100))
+	assert.Equal(t, 0, types.BigCmp(types.BigAdd(rcpt.GasUsed, margin), est), "expected %s plus margin, got %s", rcpt.GasUsed, est)
+
+	// a queued message spending almost everything is applied before the
+	// estimated one
+	act, err := a.Stmgr.GetActor(cg.Banker(), nil)
+	require.NoError(t, err)
+
+	require.NoError(t, a.Mpool.Add(signMessage(t, cg, &types.Message{
+		From:     cg.Banker(),
+		To:       to,
+		Nonce:    act.Nonce,
+		Value:    types.BigSub(act.Balance, types.NewInt(100)),
+		GasPrice: types.NewInt(0),
+		GasLimit: types.NewInt(10000),
+	})))
+
+	_, err = a.GasEstimateGasLimit(ctx, msg, nil)
+	assert.Error(t, err, "the queued message leaves too little to send")
+
+	_, err = a.GasEstimateGasLimit(ctx, &types.Message{
+		From:  cg.Banker(),
+		To:    to,
+		Value: types.NewInt(10),
+	}, nil)
+	assert.NoError(t, err)
+}
+
+func TestGasEstimateGasLimitOnOldTipSet(t *testing.T) {
+	ctx := context.Background()
+	cg, a := testMpoolAPI(t)
+
+	to, err := cg.Wallet().GenerateKey(types.KTSecp256k1)
+	require.NoError(t, err)
+
+	// the generated blocks carry banker messages, so the banker nonce at the
+	// first tipset is behind the one at the head
+	var tss []*types.TipSet
+	for i := 0; i < 2; i++ {
+		mts, err := cg.NextTipSet()
+		require.NoError(t, err)
+		require.NoError(t, a.Chain.PutTipSet(ctx, mts.TipSet.TipSet()))
+		tss = append(tss, mts.TipSet.TipSet())
+	}
+	require.True(t, tss[1].Equals(a.Chain.GetHeaviestTipSet()))
+
+	msg := &types.Message{
+		From:  cg.Banker(),
+		To:    to,
+		Value: types.NewInt(1000),
+	}
+
+	_, err = a.GasEstimateGasLimit(ctx, msg, nil)
+	assert.NoError(t, err)
+
+	_, err = a.GasEstimateGasLimit(ctx, msg, tss[0])
+	assert.NoError(t, err, "the nonce should come from the state of the old tipset")
+}
+
+func TestGasEstimateGasPrice(t *testing.T) {
+	ctx := context.Background()
+	cg, a := testMpoolAPI(t)
+
+	gp, err := a.GasEstimateGasPrice(ctx, build.GasPriceLookback)
+	require.NoError(t, err)
+	assert.Equal(t, 0, types.BigCmp(types.NewInt(build.MinimumGasPrice), gp), "no messages on chain yet")
+
+	for i := 0; i < 3; i++ {
+		mts, err := cg.NextTipSet()
+		require.NoError(t, err)
+		require.NoError(t, a.Chain.PutTipSet(ctx, mts.TipSet.TipSet()))
+	}
+
+	// generated messages are free
+	gp, err = a.GasEstimateGasPrice(ctx, build.GasPriceLookback)
+	require.NoError(t, err)
+	assert.Equal(t, 0, types.BigCmp(types.NewInt(build.MinimumGasPrice), gp))
+
+	prices := func(ps ...uint64) []types.BigInt {
+		out := make([]types.BigInt, len(ps))
+		for i, p := range ps {
+			out[i] = types.NewInt(p)
+		}
+		return out
+	}
+
+	assert.Equal(t, 0, types.BigCmp(types.NewInt(7), medianGasPrice(prices(9, 1, 7, 100, 3))))
+	assert.Equal(t, 0, types.BigCmp(types.NewInt(8), medianGasPrice(prices(8, 2, 10, 5))))
+	assert.Equal(t, 0, types.BigCmp(types.NewInt(build.MinimumGasPrice), medianGasPrice(nil)))
+}
+
+func TestMpoolPushMessageFillsGas(t *testing.T) {
+	ctx := context.Background()
+	cg, a := testMpoolAPI(t)
+
+	to, err := cg.Wallet().GenerateKey(types.KTSecp256k1)
+	require.NoError(t, err)
+
+	gp, err := a.GasEstimateGasPrice(ctx, build.GasPriceLookback)
+	require.NoError(t, err)
+	gl, err := a.GasEstimateGasLimit(ctx, &types.Message{
+		From:  cg.Banker(),
+		To:    to,
+		Value: types.NewInt(10),
+	}, nil)
+	require.NoError(t, err)
+
+	smsg, err := a.MpoolPushMessage(ctx, &types.Message{
+		From:  cg.Banker(),
+		To:    to,
+		Value: types.NewInt(10),
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, uint64(0), smsg.Message.Nonce)
+	assert.Equal(t, 0, types.BigCmp(gp, smsg.Message.GasPrice), "expected gas price %s, got %s", gp, smsg.Message.GasPrice)
+	assert.Equal(t, 0, types.BigCmp(gl, smsg.Message.GasLimit), "expected gas limit %s, got %s", gl, smsg.Message.GasLimit)
+	assert.NoError(t, smsg.Signature.Verify(cg.Banker(), smsg.Message.Cid().Bytes()))
+
+	// gas set by the caller is kept
+	smsg, err = a.MpoolPushMessage(ctx, &types.Message{
+		From:     cg.Banker(),
+		To:       to,
+		Value:    types.NewInt(10),
+		GasPrice: types.NewInt(3),
+		GasLimit: types.NewInt(20000),
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, uint64(1), smsg.Message.Nonce)
+	assert.Equal(t, 0, types.BigCmp(types.NewInt(3), smsg.Message.GasPrice))
+	assert.Equal(t, 0, types.BigCmp(types.NewInt(20000), smsg.Message.GasLimit))
+
+	assert.Len(t, a.Mpool.PendingFor(cg.Banker()), 2)
+}
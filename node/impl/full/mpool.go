@@ -6,6 +6,8 @@ import (
 	"go.uber.org/fx"
 	"golang.org/x/xerrors"
 
+	"github.com/filecoin-project/go-lotus/api"
+	"github.com/filecoin-project/go-lotus/build"
 	"github.com/filecoin-project/go-lotus/chain"
 	"github.com/filecoin-project/go-lotus/chain/address"
 	"github.com/filecoin-project/go-lotus/chain/types"
@@ -15,6 +17,7 @@ type MpoolAPI struct {
 	fx.In
 
 	WalletAPI
+	GasAPI
 
 	Mpool *chain.MessagePool
 }
@@ -34,7 +37,31 @@ func (a *MpoolAPI) MpoolPushMessage(ctx context.Context, msg *types.Message) (*t
 		return nil, xerrors.Errorf("MpoolPushMessage expects message nonce to be 0, was %d", msg.Nonce)
 	}
 
-	return a.Mpool.PushWithNonce(msg.From, func(nonce uint64) (*types.SignedMessage, error) {
+	// the wallet only knows the key address of the sender
+	from, err := a.StateManager.ResolveToKeyAddress(ctx, msg.From, nil)
+	if err != nil {
+		return nil, xerrors.Errorf("mpool push: resolving sender key address: %w", err)
+	}
+	msg.From = from
+
+	if msg.GasPrice == types.EmptyInt {
+		gp, err := a.GasEstimateGasPrice(ctx, build.GasPriceLookback)
+		if err != nil {
+			return nil, xerrors.Errorf("mpool push: estimating gas price: %w", err)
+		}
+		msg.GasPrice = gp
+	}
+
+	if msg.GasLimit == types.EmptyInt {
+		gl, err := a.GasEstimateGasLimit(ctx, msg, nil)
+		if err != nil {
+			return nil, xerrors.Errorf("mpool push: estimating gas limit: %w", err)
+		}
+		msg.GasLimit = gl
+	}
+
+	signed := false
+	smsg, err := a.Mpool.PushWithNonce(msg.From, func(nonce uint64) (*types.SignedMessage, error) {
 		msg.Nonce = nonce
 
 		b, err := a.WalletBalance(ctx, msg.From)
@@ -46,10 +73,30 @@ func (a *MpoolAPI) MpoolPushMessage(ctx context.Context, msg *types.Message) (*t
 			return nil, xerrors.Errorf("mpool push: not enough funds: %s < %s", b, msg.Value)
 		}
 
-		return a.WalletSignMessage(ctx, msg.From, msg)
+		smsg, err := a.WalletSignMessage(ctx, msg.From, msg)
+		signed = err == nil
+		return smsg, err
 	})
+
+	// messages which made it to the pool will be sent, even if publishing
+	// them failed
+	if err != nil && smsg == nil && signed {
+		if rerr := a.refund(ctx, msg); rerr != nil {
+			return nil, xerrors.Errorf("mpool push: %s (refunding spend policies: %w)", err, rerr)
+		}
+	}
+
+	return smsg, err
 }
 
 func (a *MpoolAPI) MpoolGetNonce(ctx context.Context, addr address.Address) (uint64, error) {
 	return a.Mpool.GetNonce(addr)
 }
+
+func (a *MpoolAPI) MpoolStat(ctx context.Context) (*api.MpoolStat, error) {
+	return a.Mpool.Stat(), nil
+}
+
+func (a *MpoolAPI) MpoolSub(ctx context.Context) (<-chan api.MpoolUpdate, error) {
+	return a.Mpool.Updates(ctx)
+}
@@ -0,0 +1,201 @@
+package full
+
+import (
+	"bytes"
+	"context"
+
+	"github.com/filecoin-project/go-amt-ipld"
+	"github.com/ipfs/go-cid"
+	cbg "github.com/whyrusleeping/cbor-gen"
+	"go.uber.org/fx"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-lotus/api"
+	"github.com/filecoin-project/go-lotus/chain/actors"
+	"github.com/filecoin-project/go-lotus/chain/address"
+	"github.com/filecoin-project/go-lotus/chain/stmgr"
+	"github.com/filecoin-project/go-lotus/chain/store"
+	"github.com/filecoin-project/go-lotus/chain/types"
+)
+
+type MsigAPI struct {
+	fx.In
+
+	MpoolAPI
+
+	StateManager *stmgr.StateManager
+	Chain        *store.ChainStore
+}
+
+func (a *MsigAPI) loadState(ctx context.Context, addr address.Address, ts *types.TipSet) (*types.Actor, *actors.MultiSigActorState, error) {
+	var st actors.MultiSigActorState
+	act, err := a.StateManager.LoadActorState(ctx, addr, &st, ts)
+	if err != nil {
+		return nil, nil, xerrors.Errorf("failed to load multisig actor state: %w", err)
+	}
+
+	if act.Code != actors.MultisigActorCodeCid {
+		return nil, nil, xerrors.Errorf("given actor was not a multisig")
+	}
+
+	return act, &st, nil
+}
+
+func (a *MsigAPI) MsigGetAvailableBalance(ctx context.Context, addr address.Address, ts *types.TipSet) (types.BigInt, error) {
+	if ts == nil {
+		ts = a.Chain.GetHeaviestTipSet()
+	}
+
+	act, st, err := a.loadState(ctx, addr, ts)
+	if err != nil {
+		return types.EmptyInt, err
+	}
+
+	locked := st.LockedAt(ts.Height())
+	if act.Balance.LessThan(locked) {
+		return types.NewInt(0), nil
+	}
+
+	return types.BigSub(act.Balance, locked), nil
+}
+
+func (a *MsigAPI) MsigGetPending(ctx context.Context, addr address.Address, ts *types.TipSet) ([]*api.MsigTransaction, error) {
+	_, st, err := a.loadState(ctx, addr, ts)
+	if err != nil {
+		return nil, err
+	}
+
+	txs, err := amt.LoadAMT(amt.WrapBlockstore(a.Chain.Blockstore()), st.Transactions)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to load transactions: %w", err)
+	}
+
+	out := []*api.MsigTransaction{}
+	if err := txs.ForEach(func(i uint64, v *cbg.Deferred) error {
+		var tx actors.MTransaction
+		if err := tx.UnmarshalCBOR(bytes.NewReader(v.Raw)); err != nil {
+			return err
+		}
+
+		if tx.Active() != nil {
+			return nil
+		}
+
+		out = append(out, &api.MsigTransaction{
+			ID:       tx.TxID,
+			To:       tx.To,
+			Value:    tx.Value,
+			Method:   tx.Method,
+			Params:   tx.Params,
+			Approved: tx.Approved,
+		})
+		return nil
+	}); err != nil {
+		return nil, xerrors.Errorf("failed to read transactions: %w", err)
+	}
+
+	return out, nil
+}
+
+func (a *MsigAPI) MsigCreate(ctx context.Context, required uint64, signers []address.Address, unlockStart uint64, unlockDuration uint64, locked types.BigInt, val types.BigInt, src address.Address) (cid.Cid, error) {
+	if required == 0 || required > uint64(len(signers)) {
+		return cid.Undef, xerrors.Errorf("required signatures must be between 1 and the number of signers (%d)", len(signers))
+	}
+
+	if unlockDuration != 0 && locked.GreaterThan(val) {
+		return cid.Undef, xerrors.Errorf("cannot lock %s out of the %s given to the wallet", locked, val)
+	}
+
+	params, aerr := actors.SerializeParams(&actors.MultiSigConstructorParams{
+		Signers:        signers,
+		Required:       required,
+		UnlockDuration: unlockDuration,
+		StartingBlock:  unlockStart,
+		InitialBalance: locked,
+	})
+	if aerr != nil {
+		return cid.Undef, aerr
+	}
+
+	enc, aerr := actors.SerializeParams(&actors.ExecParams{
+		Code:   actors.MultisigActorCodeCid,
+		Params: params,
+	})
+	if aerr != nil {
+		return cid.Undef, aerr
+	}
+
+	return a.push(ctx, &types.Message{
+		To:     actors.InitActorAddress,
+		From:   src,
+		Value:  val,
+		Method: actors.IAMethods.Exec,
+		Params: enc,
+	})
+}
+
+func (a *MsigAPI) MsigPropose(ctx context.Context, msig address.Address, to address.Address, amt types.BigInt, src address.Address, method uint64, params []byte) (cid.Cid, error) {
+	return a.send(ctx, msig, src, actors.MultiSigMethods.Propose, &actors.MultiSigProposeParams{
+		To:     to,
+		Value:  amt,
+		Method: method,
+		Params: params,
+	})
+}
+
+func (a *MsigAPI) MsigApprove(ctx context.Context, msig address.Address, txID uint64, src address.Address) (cid.Cid, error) {
+	return a.send(ctx, msig, src, actors.MultiSigMethods.Approve, &actors.MultiSigTxID{TxID: txID})
+}
+
+func (a *MsigAPI) MsigCancel(ctx context.Context, msig address.Address, txID uint64, src address.Address) (cid.Cid, error) {
+	return a.send(ctx, msig, src, actors.MultiSigMethods.Cancel, &actors.MultiSigTxID{TxID: txID})
+}
+
+func (a *MsigAPI) MsigAddSigner(ctx context.Context, msig address.Address, src address.Address, newSigner address.Address, increase bool) (cid.Cid, error) {
+	enc, aerr := actors.SerializeParams(&actors.MultiSigAddSignerParam{
+		Signer:   newSigner,
+		Increase: increase,
+	})
+	if aerr != nil {
+		return cid.Undef, aerr
+	}
+
+	// signer changes must be sent by the wallet to itself
+	return a.MsigPropose(ctx, msig, msig, types.NewInt(0), src, actors.MultiSigMethods.AddSigner, enc)
+}
+
+func (a *MsigAPI) MsigSwapSigner(ctx context.Context, msig address.Address, src address.Address, oldSigner address.Address, newSigner address.Address) (cid.Cid, error) {
+	enc, aerr := actors.SerializeParams(&actors.MultiSigSwapSignerParams{
+		From: oldSigner,
+		To:   newSigner,
+	})
+	if aerr != nil {
+		return cid.Undef, aerr
+	}
+
+	return a.MsigPropose(ctx, msig, msig, types.NewInt(0), src, actors.MultiSigMethods.SwapSigner, enc)
+}
+
+func (a *MsigAPI) send(ctx context.Context, msig address.Address, src address.Address, method uint64, params cbg.CBORMarshaler) (cid.Cid, error) {
+	enc, aerr := actors.SerializeParams(params)
+	if aerr != nil {
+		return cid.Undef, aerr
+	}
+
+	return a.push(ctx, &types.Message{
+		To:     msig,
+		From:   src,
+		Value:  types.NewInt(0),
+		Method: method,
+		Params: enc,
+	})
+}
+
+func (a *MsigAPI) push(ctx context.Context, msg *types.Message) (cid.Cid, error) {
+	smsg, err := a.MpoolPushMessage(ctx, msg)
+	if err != nil {
+		return cid.Undef, xerrors.Errorf("failed to push message: %w", err)
+	}
+
+	return smsg.Cid(), nil
+}
@@ -1,11 +1,16 @@
 package full
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"strconv"
 
+	"github.com/filecoin-project/go-amt-ipld"
 	cid "github.com/ipfs/go-cid"
 	"github.com/ipfs/go-hamt-ipld"
 	"github.com/libp2p/go-libp2p-core/peer"
+	cbg "github.com/whyrusleeping/cbor-gen"
 	"go.uber.org/fx"
 	"golang.org/x/xerrors"
 
@@ -83,6 +88,52 @@ func (a *StateAPI) StateMinerProvingPeriodEnd(ctx context.Context, actor address
 	return stmgr.GetMinerProvingPeriodEnd(ctx, a.StateManager, ts, actor)
 }
 
+func (a *StateAPI) StateMinerRewards(ctx context.Context, maddr address.Address, ts *types.TipSet) (*api.MinerRewards, error) {
+	if ts == nil {
+		ts = a.Chain.GetHeaviestTipSet()
+	}
+
+	owner, err := stmgr.GetMinerOwner(ctx, a.StateManager, ts.ParentState(), maddr)
+	if err != nil {
+		return nil, err
+	}
+
+	var rst actors.RewardActorState
+	if _, err := a.StateManager.LoadActorState(ctx, actors.RewardActorAddress, &rst, ts); err != nil {
+		return nil, xerrors.Errorf("failed to load reward actor state: %w", err)
+	}
+
+	cst := hamt.CSTFromBstore(a.StateManager.ChainStore().Blockstore())
+	nd, err := hamt.LoadNode(ctx, cst, rst.Rewards)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to load rewards: %w", err)
+	}
+
+	// rewards are keyed by the ID address of the owner
+	ownerID, err := a.StateLookupID(ctx, owner, ts)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to look up owner ID address: %w", err)
+	}
+
+	var rewards actors.OwnerRewards
+	if err := nd.Find(ctx, string(ownerID.Bytes()), &rewards); err != nil && !xerrors.Is(err, hamt.ErrNotFound) {
+		return nil, xerrors.Errorf("failed to look up owner rewards: %w", err)
+	}
+
+	out := &api.MinerRewards{
+		Owner:  owner,
+		Vested: types.NewInt(0),
+		Locked: types.NewInt(0),
+	}
+	for _, r := range rewards.Rewards {
+		vested := r.AmountVested(ts.Height())
+		out.Vested = types.BigAdd(out.Vested, types.BigSub(vested, r.AmountWithdrawn))
+		out.Locked = types.BigAdd(out.Locked, types.BigSub(r.Value, vested))
+	}
+
+	return out, nil
+}
+
 func (a *StateAPI) StatePledgeCollateral(ctx context.Context, ts *types.TipSet) (types.BigInt, error) {
 	param, err := actors.SerializeParams(&actors.PledgeCollateralParams{Size: types.NewInt(0)})
 	if err != nil {
@@ -90,8 +141,8 @@ func (a *StateAPI) StatePledgeCollateral(ctx context.Context, ts *types.TipSet)
 	}
 
 	ret, aerr := a.StateManager.Call(ctx, &types.Message{
-		From:   actors.StorageMarketAddress,
-		To:     actors.StorageMarketAddress,
+		From:   actors.StoragePowerAddress,
+		To:     actors.StoragePowerAddress,
 		Method: actors.SPAMethods.PledgeCollateralForSize,
 
 		Params: param,
@@ -117,16 +168,42 @@ func (a *StateAPI) StateReplay(ctx context.Context, ts *types.TipSet, mc cid.Cid
 		return nil, err
 	}
 
+	if r == nil {
+		return nil, xerrors.Errorf("message %s was not executed in tipset %s", mc, ts.Cids())
+	}
+
+	return replayResults(m, r), nil
+}
+
+func (a *StateAPI) StateCompute(ctx context.Context, height uint64, msgs []*types.Message, ts *types.TipSet) (*api.ComputeStateOutput, error) {
+	root, rets, err := a.StateManager.ComputeState(ctx, height, msgs, ts)
+	if err != nil {
+		return nil, err
+	}
+
+	out := &api.ComputeStateOutput{
+		Root:  root,
+		Trace: make([]*api.ReplayResults, len(rets)),
+	}
+	for i, r := range rets {
+		out.Trace[i] = replayResults(msgs[i], r)
+	}
+
+	return out, nil
+}
+
+func replayResults(m *types.Message, r *vm.ApplyRet) *api.ReplayResults {
 	var errstr string
 	if r.ActorErr != nil {
 		errstr = r.ActorErr.Error()
 	}
 
 	return &api.ReplayResults{
-		Msg:     m,
-		Receipt: &r.MessageReceipt,
-		Error:   errstr,
-	}, nil
+		Msg:            m,
+		Receipt:        &r.MessageReceipt,
+		Error:          errstr,
+		ExecutionTrace: r.ExecutionTrace,
+	}
 }
 
 func (a *StateAPI) stateForTs(ctx context.Context, ts *types.TipSet) (*state.StateTree, error) {
@@ -144,6 +221,41 @@ func (a *StateAPI) stateForTs(ctx context.Context, ts *types.TipSet) (*state.Sta
 	return state.LoadStateTree(cst, st)
 }
 
+func (a *StateAPI) StateDecodeParams(ctx context.Context, toAddr address.Address, method uint64, params []byte, ts *types.TipSet) (*api.DecodedCall, error) {
+	act, err := a.StateGetActor(ctx, toAddr, ts)
+	if err != nil {
+		return nil, xerrors.Errorf("getting actor: %w", err)
+	}
+
+	meta, err := vm.LookupMethod(act.Code, method)
+	if err != nil {
+		return nil, err
+	}
+
+	p, err := vm.DecodeMethodParams(act.Code, method, params)
+	if err != nil {
+		return nil, err
+	}
+
+	return &api.DecodedCall{
+		Method: meta.Name,
+		Params: p,
+	}, nil
+}
+
+func (a *StateAPI) StateDecodeReturn(ctx context.Context, toAddr address.Address, method uint64, ret []byte, ts *types.TipSet) (interface{}, error) {
+	act, err := a.StateGetActor(ctx, toAddr, ts)
+	if err != nil {
+		return nil, xerrors.Errorf("getting actor: %w", err)
+	}
+
+	return vm.DecodeMethodReturn(act.Code, method, ret)
+}
+
+func (a *StateAPI) StateEncodeParams(ctx context.Context, toActCode cid.Cid, method uint64, params json.RawMessage) ([]byte, error) {
+	return vm.EncodeMethodParams(toActCode, method, params)
+}
+
 func (a *StateAPI) StateGetActor(ctx context.Context, actor address.Address, ts *types.TipSet) (*types.Actor, error) {
 	state, err := a.stateForTs(ctx, ts)
 	if err != nil {
@@ -169,6 +281,17 @@ func (a *StateAPI) StateReadState(ctx context.Context, act *types.Actor, ts *typ
 		return nil, err
 	}
 
+	if msas, ok := oif.(actors.MultiSigActorState); ok {
+		if ts == nil {
+			ts = a.Chain.GetHeaviestTipSet()
+		}
+
+		oif = api.MsigActorState{
+			MultiSigActorState: msas,
+			Locked:             msas.LockedAt(ts.Height()),
+		}
+	}
+
 	return &api.ActorState{
 		Balance: act.Balance,
 		State:   oif,
@@ -194,6 +317,80 @@ func (a *StateAPI) MinerCreateBlock(ctx context.Context, addr address.Address, p
 	return &out, nil
 }
 
+func (a *StateAPI) StateMarketBalance(ctx context.Context, addr address.Address, ts *types.TipSet) (api.MarketBalance, error) {
+	var st actors.StorageMarketState
+	if _, err := a.StateManager.LoadActorState(ctx, actors.MarketActorAddress, &st, ts); err != nil {
+		return api.MarketBalance{}, xerrors.Errorf("failed to load market actor state: %w", err)
+	}
+
+	cst := hamt.CSTFromBstore(a.StateManager.ChainStore().Blockstore())
+	nd, err := hamt.LoadNode(ctx, cst, st.Balances)
+	if err != nil {
+		return api.MarketBalance{}, xerrors.Errorf("failed to load balances: %w", err)
+	}
+
+	b := actors.StorageParticipantBalance{
+		Locked:    types.NewInt(0),
+		Available: types.NewInt(0),
+	}
+
+	// balances are keyed by the ID address of the participant
+	id, err := a.StateLookupID(ctx, addr, ts)
+	if err != nil {
+		if xerrors.Is(err, hamt.ErrNotFound) {
+			return api.MarketBalance{Locked: b.Locked, Available: b.Available}, nil
+		}
+		return api.MarketBalance{}, xerrors.Errorf("failed to resolve %s: %w", addr, err)
+	}
+
+	if err := nd.Find(ctx, string(id.Bytes()), &b); err != nil && !xerrors.Is(err, hamt.ErrNotFound) {
+		return api.MarketBalance{}, xerrors.Errorf("failed to look up balance: %w", err)
+	}
+
+	return api.MarketBalance{
+		Locked:    b.Locked,
+		Available: b.Available,
+	}, nil
+}
+
+func (a *StateAPI) StateMarketDeals(ctx context.Context, ts *types.TipSet) (map[string]api.MarketDeal, error) {
+	var st actors.StorageMarketState
+	if _, err := a.StateManager.LoadActorState(ctx, actors.MarketActorAddress, &st, ts); err != nil {
+		return nil, xerrors.Errorf("failed to load market actor state: %w", err)
+	}
+
+	deals, err := amt.LoadAMT(amt.WrapBlockstore(a.StateManager.ChainStore().Blockstore()), st.Deals)
+	if err != nil {
+		return nil, xerrors.Errorf("failed to load deals: %w", err)
+	}
+
+	out := map[string]api.MarketDeal{}
+	if err := deals.ForEach(func(i uint64, v *cbg.Deferred) error {
+		var d actors.OnChainDeal
+		if err := d.UnmarshalCBOR(bytes.NewReader(v.Raw)); err != nil {
+			return err
+		}
+
+		out[strconv.FormatUint(i, 10)] = api.MarketDeal{
+			PieceRef:             d.Proposal.PieceRef,
+			PieceSize:            d.Proposal.PieceSize,
+			Client:               d.Proposal.Client,
+			Provider:             d.Proposal.Provider,
+			Duration:             d.Proposal.Duration,
+			StoragePricePerEpoch: d.Proposal.StoragePricePerEpoch,
+			StorageCollateral:    d.Proposal.StorageCollateral,
+			ActivationEpoch:      d.ActivationEpoch,
+			LastPaymentEpoch:     d.LastPaymentEpoch,
+			SlashedAt:            d.SlashedAt,
+		}
+		return nil
+	}); err != nil {
+		return nil, xerrors.Errorf("failed to iterate deals: %w", err)
+	}
+
+	return out, nil
+}
+
 func (a *StateAPI) StateWaitMsg(ctx context.Context, msg cid.Cid) (*api.MsgWait, error) {
 	// TODO: consider using event system for this, expose confidence
 
@@ -210,7 +407,7 @@ func (a *StateAPI) StateWaitMsg(ctx context.Context, msg cid.Cid) (*api.MsgWait,
 
 func (a *StateAPI) StateListMiners(ctx context.Context, ts *types.TipSet) ([]address.Address, error) {
 	var state actors.StoragePowerState
-	if _, err := a.StateManager.LoadActorState(ctx, actors.StorageMarketAddress, &state, ts); err != nil {
+	if _, err := a.StateManager.LoadActorState(ctx, actors.StoragePowerAddress, &state, ts); err != nil {
 		return nil, err
 	}
 
@@ -226,3 +423,77 @@ func (a *StateAPI) StateListMiners(ctx context.Context, ts *types.TipSet) ([]add
 func (a *StateAPI) StateListActors(ctx context.Context, ts *types.TipSet) ([]address.Address, error) {
 	return a.StateManager.ListAllActors(ctx, ts)
 }
+
+func (a *StateAPI) StateListActorAddresses(ctx context.Context, ts *types.TipSet) ([]api.ActorAddresses, error) {
+	if ts == nil {
+		ts = a.Chain.GetHeaviestTipSet()
+	}
+
+	st, _, err := a.StateManager.TipSetState(ctx, ts)
+	if err != nil {
+		return nil, xerrors.Errorf("computing tipset state: %w", err)
+	}
+
+	cst := hamt.CSTFromBstore(a.Chain.Blockstore())
+	tree, err := state.LoadStateTree(cst, st)
+	if err != nil {
+		return nil, xerrors.Errorf("loading state tree: %w", err)
+	}
+
+	addrs, err := a.StateManager.ListAllActors(ctx, ts)
+	if err != nil {
+		return nil, err
+	}
+
+	out := make([]api.ActorAddresses, len(addrs))
+	for i, addr := range addrs {
+		out[i].ID = addr
+
+		act, err := tree.GetActor(addr)
+		if err != nil {
+			return nil, xerrors.Errorf("loading actor %s: %w", addr, err)
+		}
+		if act.Code != actors.AccountActorCodeCid {
+			continue
+		}
+
+		// the network and burnt funds accounts have no key
+		if addr == actors.NetworkAddress || addr == actors.BurntFundsAddress {
+			continue
+		}
+
+		out[i].Key, err = vm.ResolveToKeyAddr(tree, cst, addr)
+		if err != nil {
+			return nil, xerrors.Errorf("resolving key address of %s: %w", addr, err)
+		}
+	}
+
+	return out, nil
+}
+
+func (a *StateAPI) StateLookupID(ctx context.Context, addr address.Address, ts *types.TipSet) (address.Address, error) {
+	return a.StateManager.LookupID(ctx, addr, ts)
+}
+
+func (a *StateAPI) StateAccountKey(ctx context.Context, addr address.Address, ts *types.TipSet) (address.Address, error) {
+	return a.StateManager.ResolveToKeyAddress(ctx, addr, ts)
+}
+
+func (a *StateAPI) StateChangedActors(ctx context.Context, oldRoot cid.Cid, newRoot cid.Cid) ([]*api.ActorChange, error) {
+	cst := hamt.CSTFromBstore(a.Chain.Blockstore())
+
+	var out []*api.ActorChange
+	err := state.DiffStateTrees(ctx, cst, oldRoot, newRoot, func(addr address.Address, oldAct, newAct *types.Actor) error {
+		out = append(out, &api.ActorChange{
+			Address: addr,
+			Old:     oldAct,
+			New:     newAct,
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, xerrors.Errorf("diffing state trees: %w", err)
+	}
+
+	return out, nil
+}
@@ -0,0 +1,114 @@
+package full
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-lotus/chain/actors"
+	"github.com/filecoin-project/go-lotus/chain/address"
+	"github.com/filecoin-project/go-lotus/chain/gen"
+	"github.com/filecoin-project/go-lotus/chain/types"
+)
+
+func testStateAPI(t *testing.T) (*gen.ChainGen, *StateAPI) {
+	cg, err := gen.NewGenerator()
+	require.NoError(t, err)
+
+	sm := cg.StateManager()
+	return cg, &StateAPI{
+		Wallet:       cg.Wallet(),
+		StateManager: sm,
+		Chain:        sm.ChainStore(),
+	}
+}
+
+func TestStateLookupID(t *testing.T) {
+	ctx := context.Background()
+	cg, a := testStateAPI(t)
+
+	id, err := a.StateLookupID(ctx, cg.Banker(), nil)
+	require.NoError(t, err)
+	assert.Equal(t, address.ID, id.Protocol())
+
+	same, err := a.StateLookupID(ctx, id, nil)
+	require.NoError(t, err)
+	assert.Equal(t, id, same, "ID addresses are returned as they are")
+
+	unknown, err := address.NewIDAddress(12345)
+	require.NoError(t, err)
+	_, err = a.StateLookupID(ctx, blsAddr(t), nil)
+	assert.Error(t, err, "an address without an actor has no ID")
+
+	key, err := a.StateAccountKey(ctx, unknown, nil)
+	assert.Error(t, err, "an ID without an actor has no key (got %s)", key)
+}
+
+func TestStateAccountKey(t *testing.T) {
+	ctx := context.Background()
+	cg, a := testStateAPI(t)
+
+	id, err := a.StateLookupID(ctx, cg.Banker(), nil)
+	require.NoError(t, err)
+
+	key, err := a.StateAccountKey(ctx, id, nil)
+	require.NoError(t, err)
+	assert.Equal(t, cg.Banker(), key)
+
+	key, err = a.StateAccountKey(ctx, cg.Banker(), nil)
+	require.NoError(t, err)
+	assert.Equal(t, cg.Banker(), key, "key addresses are returned as they are")
+
+	_, err = a.StateAccountKey(ctx, actors.StoragePowerAddress, nil)
+	assert.Error(t, err, "only account actors have a key address")
+}
+
+func TestStateListActorAddresses(t *testing.T) {
+	ctx := context.Background()
+	cg, a := testStateAPI(t)
+
+	bankerID, err := a.StateLookupID(ctx, cg.Banker(), nil)
+	require.NoError(t, err)
+
+	addrs, err := a.StateListActorAddresses(ctx, nil)
+	require.NoError(t, err)
+
+	found := map[address.Address]address.Address{}
+	for _, aa := range addrs {
+		assert.Equal(t, address.ID, aa.ID.Protocol())
+		found[aa.ID] = aa.Key
+	}
+
+	if assert.Contains(t, found, bankerID) {
+		assert.Equal(t, cg.Banker(), found[bankerID])
+	}
+	if assert.Contains(t, found, actors.InitActorAddress) {
+		assert.Equal(t, address.Undef, found[actors.InitActorAddress], "non-account actors have no key")
+	}
+}
+
+func TestStateCallUsesStateNonce(t *testing.T) {
+	ctx := context.Background()
+	cg, a := testStateAPI(t)
+
+	to, err := cg.Wallet().GenerateKey(types.KTSecp256k1)
+	require.NoError(t, err)
+
+	// the nonce set by the caller is replaced with the one in the state
+	rcpt, err := a.StateCall(ctx, &types.Message{
+		From:  cg.Banker(),
+		To:    to,
+		Nonce: 1000,
+		Value: types.NewInt(1000),
+	}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, uint8(0), rcpt.ExitCode)
+}
+
+func blsAddr(t *testing.T) address.Address {
+	addr, err := address.NewBLSAddress(make([]byte, 48))
+	require.NoError(t, err)
+	return addr
+}
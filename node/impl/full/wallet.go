@@ -3,6 +3,10 @@ package full
 import (
 	"context"
 
+	"github.com/ipfs/go-cid"
+	hamt "github.com/ipfs/go-hamt-ipld"
+
+	"github.com/filecoin-project/go-lotus/api"
 	"github.com/filecoin-project/go-lotus/chain/address"
 	"github.com/filecoin-project/go-lotus/chain/stmgr"
 	"github.com/filecoin-project/go-lotus/chain/types"
@@ -17,6 +21,7 @@ type WalletAPI struct {
 
 	StateManager *stmgr.StateManager
 	Wallet       *wallet.Wallet
+	Policies     *wallet.PolicyStore
 }
 
 func (a *WalletAPI) WalletNew(ctx context.Context, typ string) (address.Address, error) {
@@ -36,13 +41,37 @@ func (a *WalletAPI) WalletBalance(ctx context.Context, addr address.Address) (ty
 }
 
 func (a *WalletAPI) WalletSign(ctx context.Context, k address.Address, msg []byte) (*types.Signature, error) {
+	policies, err := a.policies(ctx, k)
+	if err != nil {
+		return nil, err
+	}
+	if len(policies) > 0 {
+		// arbitrary data could be the cid of any message
+		return nil, xerrors.Errorf("signing data with %s is not allowed by its spend policy: %w", k, wallet.ErrPolicyViolation)
+	}
+
 	return a.Wallet.Sign(ctx, k, msg)
 }
 
 func (a *WalletAPI) WalletSignMessage(ctx context.Context, k address.Address, msg *types.Message) (*types.SignedMessage, error) {
+	policies, err := a.policies(ctx, k)
+	if err != nil {
+		return nil, err
+	}
+	if len(policies) > 0 {
+		toCode, err := a.actorCode(msg.To)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := a.Policies.Spend(msg, toCode, a.lookupID(ctx), policies...); err != nil {
+			return nil, err
+		}
+	}
+
 	mcid := msg.Cid()
 
-	sig, err := a.WalletSign(ctx, k, mcid.Bytes())
+	sig, err := a.Wallet.Sign(ctx, k, mcid.Bytes())
 	if err != nil {
 		return nil, xerrors.Errorf("failed to sign message: %w", err)
 	}
@@ -54,16 +83,94 @@ func (a *WalletAPI) WalletSignMessage(ctx context.Context, k address.Address, ms
 }
 
 func (a *WalletAPI) WalletDefaultAddress(ctx context.Context) (address.Address, error) {
-	addrs, err := a.Wallet.ListAddrs()
+	return a.Wallet.GetDefault()
+}
+
+func (a *WalletAPI) WalletSetDefault(ctx context.Context, addr address.Address) error {
+	return a.Wallet.SetDefault(addr)
+}
+
+func (a *WalletAPI) WalletDelete(ctx context.Context, addr address.Address) error {
+	return a.Wallet.DeleteKey(addr)
+}
+
+func (a *WalletAPI) WalletVerify(ctx context.Context, addr address.Address, data []byte, sig *types.Signature) (bool, error) {
+	// signatures are made by key addresses
+	kaddr, err := a.StateManager.ResolveToKeyAddress(ctx, addr, nil)
+	if err != nil {
+		return false, xerrors.Errorf("resolving key address: %w", err)
+	}
+
+	return sig.Verify(kaddr, data) == nil, nil
+}
+
+func (a *WalletAPI) WalletSetPolicy(ctx context.Context, addr address.Address, policy *api.SpendPolicy) error {
+	return a.Policies.SetAddressPolicy(addr, policy)
+}
+
+func (a *WalletAPI) WalletGetPolicy(ctx context.Context, addr address.Address) (*api.SpendPolicy, error) {
+	return a.Policies.AddressPolicy(addr)
+}
+
+// refund takes a message signed with WalletSignMessage off the spending of its
+// policies, when it couldn't be sent
+func (a *WalletAPI) refund(ctx context.Context, msg *types.Message) error {
+	policies, err := a.policies(ctx, msg.From)
+	if err != nil {
+		return err
+	}
+
+	return a.Policies.Refund(msg, policies...)
+}
+
+// actorCode returns the code of the actor at addr, or cid.Undef if there is no
+// actor yet
+func (a *WalletAPI) actorCode(addr address.Address) (cid.Cid, error) {
+	act, err := a.StateManager.GetActor(addr, nil)
+	if xerrors.Is(err, types.ErrActorNotFound) {
+		return cid.Undef, nil
+	}
 	if err != nil {
-		return address.Undef, err
+		return cid.Undef, xerrors.Errorf("getting receiver actor: %w", err)
 	}
-	if len(addrs) == 0 {
-		return address.Undef, xerrors.New("no addresses in wallet")
+
+	return act.Code, nil
+}
+
+// lookupID resolves addresses to the ID address of their actor in the head
+// state, keeping addresses without an actor as they are
+func (a *WalletAPI) lookupID(ctx context.Context) wallet.AddressResolver {
+	return func(addr address.Address) (address.Address, error) {
+		id, err := a.StateManager.LookupID(ctx, addr, nil)
+		if xerrors.Is(err, hamt.ErrNotFound) {
+			return addr, nil
+		}
+		return id, err
+	}
+}
+
+// policies returns the spend policies which apply when signing with k. They
+// are only enforced on requests made through the API, the node itself signs
+// freely
+func (a *WalletAPI) policies(ctx context.Context, k address.Address) ([]*api.SpendPolicy, error) {
+	if !api.FromAPI(ctx) {
+		return nil, nil
+	}
+
+	var out []*api.SpendPolicy
+	if p := api.SpendPolicyFromContext(ctx); p != nil {
+		out = append(out, p)
 	}
 
-	// TODO: store a default address in the config or 'wallet' portion of the repo
-	return addrs[0], nil
+	p, err := a.Policies.AddressPolicy(k)
+	if err != nil {
+		return nil, err
+	}
+	if p != nil {
+		out = append(out, p)
+	}
+
+	return out, nil
 }
 
 func (a *WalletAPI) WalletExport(ctx context.Context, addr address.Address) (*types.KeyInfo, error) {
@@ -73,3 +180,46 @@ func (a *WalletAPI) WalletExport(ctx context.Context, addr address.Address) (*ty
 func (a *WalletAPI) WalletImport(ctx context.Context, ki *types.KeyInfo) (address.Address, error) {
 	return a.Wallet.Import(ki)
 }
+
+func (a *WalletAPI) WalletLock(ctx context.Context) error {
+	return a.Wallet.Lock()
+}
+
+func (a *WalletAPI) WalletUnlock(ctx context.Context, passphrase string) error {
+	return a.Wallet.Unlock([]byte(passphrase))
+}
+
+func (a *WalletAPI) WalletNewHD(ctx context.Context, typ string) (*api.HDKey, error) {
+	var mnemonic string
+
+	addr, index, err := a.Wallet.GenerateHDKey(typ)
+	if xerrors.Is(err, wallet.ErrNoHDSeed) {
+		mnemonic, err = wallet.NewMnemonic()
+		if err != nil {
+			return nil, xerrors.Errorf("generating mnemonic: %w", err)
+		}
+
+		if err := a.Wallet.InitHD(mnemonic); err != nil {
+			return nil, err
+		}
+
+		addr, index, err = a.Wallet.GenerateHDKey(typ)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &api.HDKey{
+		Address:  addr,
+		Index:    index,
+		Mnemonic: mnemonic,
+	}, nil
+}
+
+func (a *WalletAPI) WalletRestoreHD(ctx context.Context, mnemonic string) error {
+	return a.Wallet.InitHD(mnemonic)
+}
+
+func (a *WalletAPI) WalletDeriveHD(ctx context.Context, typ string, index uint64) (address.Address, error) {
+	return a.Wallet.DeriveHDKey(typ, index)
+}
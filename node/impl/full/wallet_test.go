@@ -0,0 +1,91 @@
+package full
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ipfs/go-datastore"
+	dssync "github.com/ipfs/go-datastore/sync"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-lotus/api"
+	"github.com/filecoin-project/go-lotus/chain/address"
+	"github.com/filecoin-project/go-lotus/chain/types"
+	"github.com/filecoin-project/go-lotus/chain/wallet"
+)
+
+func TestWalletVerify(t *testing.T) {
+	ctx := context.Background()
+	cg, sa := testStateAPI(t)
+	a := &WalletAPI{
+		StateManager: cg.StateManager(),
+		Wallet:       cg.Wallet(),
+	}
+
+	banker := cg.Banker()
+	bankerID, err := sa.StateLookupID(ctx, banker, nil)
+	require.NoError(t, err)
+
+	sig, err := cg.Wallet().Sign(ctx, banker, []byte("data"))
+	require.NoError(t, err)
+
+	// the signer can be given by its key or ID address
+	for _, addr := range []address.Address{banker, bankerID} {
+		ok, err := a.WalletVerify(ctx, addr, []byte("data"), sig)
+		require.NoError(t, err)
+		assert.True(t, ok, "signed by %s", addr)
+
+		ok, err = a.WalletVerify(ctx, addr, []byte("other data"), sig)
+		require.NoError(t, err)
+		assert.False(t, ok, "other data signed by %s", addr)
+	}
+
+	other, err := cg.Wallet().GenerateKey(types.KTSecp256k1)
+	require.NoError(t, err)
+
+	ok, err := a.WalletVerify(ctx, other, []byte("data"), sig)
+	require.NoError(t, err)
+	assert.False(t, ok, "signed by another key")
+
+	unknown, err := address.NewIDAddress(12345)
+	require.NoError(t, err)
+	_, err = a.WalletVerify(ctx, unknown, []byte("data"), sig)
+	assert.Error(t, err, "ID addresses without an actor can't be resolved")
+}
+
+func TestWalletSignMessagePolicy(t *testing.T) {
+	cg, _ := testStateAPI(t)
+	a := &WalletAPI{
+		StateManager: cg.StateManager(),
+		Wallet:       cg.Wallet(),
+		Policies:     wallet.NewPolicyStore(dssync.MutexWrap(datastore.NewMapDatastore())),
+	}
+
+	ctx := api.WithPerm(context.Background(), api.AllPermissions)
+	ctx = api.WithSpendPolicy(ctx, &api.SpendPolicy{ID: "token", MaxValue: types.NewInt(20)})
+
+	to, err := cg.Wallet().GenerateKey(types.KTSecp256k1)
+	require.NoError(t, err)
+
+	msg := &types.Message{
+		From:     cg.Banker(),
+		To:       to,
+		Value:    types.NewInt(10),
+		GasPrice: types.NewInt(2),
+		GasLimit: types.NewInt(6),
+	}
+
+	_, err = a.WalletSignMessage(ctx, cg.Banker(), msg)
+	assert.True(t, xerrors.Is(err, wallet.ErrPolicyViolation), "the gas counts towards the limit, got %v", err)
+
+	msg.GasLimit = types.NewInt(5)
+	_, err = a.WalletSignMessage(ctx, cg.Banker(), msg)
+	assert.NoError(t, err)
+
+	// the node itself isn't limited
+	msg.GasLimit = types.NewInt(6)
+	_, err = a.WalletSignMessage(context.Background(), cg.Banker(), msg)
+	assert.NoError(t, err)
+}
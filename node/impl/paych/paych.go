@@ -3,6 +3,8 @@ package paych
 import (
 	"context"
 	"fmt"
+	"sort"
+	"strconv"
 
 	"github.com/ipfs/go-cid"
 	"go.uber.org/fx"
@@ -11,6 +13,7 @@ import (
 	"github.com/filecoin-project/go-lotus/api"
 	"github.com/filecoin-project/go-lotus/chain/actors"
 	"github.com/filecoin-project/go-lotus/chain/address"
+	"github.com/filecoin-project/go-lotus/chain/store"
 	"github.com/filecoin-project/go-lotus/chain/types"
 	full "github.com/filecoin-project/go-lotus/node/impl/full"
 	"github.com/filecoin-project/go-lotus/paych"
@@ -96,9 +99,36 @@ func (a *PaychAPI) PaychStatus(ctx context.Context, pch address.Address) (*api.P
 	if err != nil {
 		return nil, err
 	}
+
+	_, pcast, err := a.PaychMgr.ChannelState(ctx, pch)
+	if err != nil {
+		return nil, xerrors.Errorf("loading channel state: %w", err)
+	}
+
+	lanes := make([]api.LaneStatus, 0, len(pcast.LaneStates))
+	for k, ls := range pcast.LaneStates {
+		lane, err := strconv.ParseUint(k, 10, 64)
+		if err != nil {
+			return nil, xerrors.Errorf("parsing lane %q: %w", k, err)
+		}
+
+		lanes = append(lanes, api.LaneStatus{
+			Lane:     lane,
+			Closed:   ls.Closed,
+			Redeemed: ls.Redeemed,
+			Nonce:    ls.Nonce,
+		})
+	}
+	sort.Slice(lanes, func(i, j int) bool {
+		return lanes[i].Lane < lanes[j].Lane
+	})
+
 	return &api.PaychStatus{
 		ControlAddr: ci.Control,
 		Direction:   api.PCHDir(ci.Direction),
+		ToSend:      pcast.ToSend,
+		ClosingAt:   pcast.ClosingAt,
+		Lanes:       lanes,
 	}, nil
 }
 
@@ -108,34 +138,97 @@ func (a *PaychAPI) PaychClose(ctx context.Context, addr address.Address) (cid.Ci
 		return cid.Undef, err
 	}
 
-	nonce, err := a.MpoolGetNonce(ctx, ci.Control)
+	return a.pushChannelMessage(ctx, addr, ci.Control, actors.PCAMethods.Close)
+}
+
+func (a *PaychAPI) PaychSettle(ctx context.Context, addr address.Address) (cid.Cid, error) {
+	from, err := a.localParty(addr)
 	if err != nil {
 		return cid.Undef, err
 	}
 
-	msg := &types.Message{
-		To:     addr,
-		From:   ci.Control,
-		Value:  types.NewInt(0),
-		Method: actors.PCAMethods.Close,
-		Nonce:  nonce,
+	return a.pushChannelMessage(ctx, addr, from, actors.PCAMethods.Close)
+}
 
-		GasLimit: types.NewInt(500),
-		GasPrice: types.NewInt(0),
+func (a *PaychAPI) PaychCollect(ctx context.Context, addr address.Address) (cid.Cid, error) {
+	from, err := a.localParty(addr)
+	if err != nil {
+		return cid.Undef, err
 	}
 
-	smsg, err := a.WalletSignMessage(ctx, ci.Control, msg)
+	_, pcast, err := a.PaychMgr.ChannelState(ctx, addr)
 	if err != nil {
+		return cid.Undef, xerrors.Errorf("loading channel state: %w", err)
+	}
+
+	if pcast.ClosingAt == 0 {
+		return cid.Undef, xerrors.Errorf("payment channel %s isn't settling", addr)
+	}
+
+	if err := a.waitHeight(ctx, pcast.ClosingAt); err != nil {
 		return cid.Undef, err
 	}
 
-	if err := a.MpoolPush(ctx, smsg); err != nil {
+	return a.pushChannelMessage(ctx, addr, from, actors.PCAMethods.Collect)
+}
+
+// pushChannelMessage sends a parameterless message to the payment channel
+func (a *PaychAPI) pushChannelMessage(ctx context.Context, ch address.Address, from address.Address, method uint64) (cid.Cid, error) {
+	smsg, err := a.MpoolPushMessage(ctx, &types.Message{
+		To:     ch,
+		From:   from,
+		Value:  types.NewInt(0),
+		Method: method,
+	})
+	if err != nil {
 		return cid.Undef, err
 	}
 
 	return smsg.Cid(), nil
 }
 
+// localParty returns our address in the given channel
+func (a *PaychAPI) localParty(ch address.Address) (address.Address, error) {
+	ci, err := a.PaychMgr.GetChannelInfo(ch)
+	if err != nil {
+		return address.Undef, err
+	}
+
+	if ci.Direction == paych.DirInbound {
+		return ci.Target, nil
+	}
+	return ci.Control, nil
+}
+
+// waitHeight waits until messages included in the next tipset will execute
+// at or above the given height
+func (a *PaychAPI) waitHeight(ctx context.Context, h uint64) error {
+	notifs, err := a.ChainNotify(ctx)
+	if err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case changes, ok := <-notifs:
+			if !ok {
+				return xerrors.Errorf("chain notify channel closed")
+			}
+
+			for _, hc := range changes {
+				if hc.Type == store.HCRevert {
+					continue
+				}
+				if hc.Val.Height()+1 >= h {
+					return nil
+				}
+			}
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
 func (a *PaychAPI) PaychVoucherCheckValid(ctx context.Context, ch address.Address, sv *types.SignedVoucher) error {
 	return a.PaychMgr.CheckVoucherValid(ctx, ch, sv)
 }
@@ -154,9 +247,30 @@ func (a *PaychAPI) PaychVoucherAdd(ctx context.Context, ch address.Address, sv *
 // with the given lane and amount.  The value passed in is exactly the value
 // that will be used to create the voucher, so if previous vouchers exist, the
 // actual additional value of this voucher will only be the difference between
-// the two.
-func (a *PaychAPI) PaychVoucherCreate(ctx context.Context, pch address.Address, amt types.BigInt, lane uint64) (*types.SignedVoucher, error) {
-	return a.paychVoucherCreate(ctx, pch, types.SignedVoucher{Amount: amt, Lane: lane})
+// the two. If lanes are merged, the amount must include what was redeemed on
+// them.
+func (a *PaychAPI) PaychVoucherCreate(ctx context.Context, pch address.Address, amt types.BigInt, lane uint64, opts *api.VoucherCreateOpts) (*types.SignedVoucher, error) {
+	sv := types.SignedVoucher{Amount: amt, Lane: lane}
+
+	if opts != nil {
+		for _, ml := range opts.MergeLanes {
+			if ml == lane {
+				return nil, xerrors.Errorf("cannot merge lane %d into itself", lane)
+			}
+
+			nonce, err := a.PaychMgr.NextNonceForLane(ctx, pch, ml)
+			if err != nil {
+				return nil, err
+			}
+
+			sv.Merges = append(sv.Merges, types.Merge{
+				Lane:  ml,
+				Nonce: nonce,
+			})
+		}
+	}
+
+	return a.paychVoucherCreate(ctx, pch, sv)
 }
 
 func (a *PaychAPI) paychVoucherCreate(ctx context.Context, pch address.Address, voucher types.SignedVoucher) (*types.SignedVoucher, error) {
@@ -212,11 +326,6 @@ func (a *PaychAPI) PaychVoucherSubmit(ctx context.Context, ch address.Address, s
 		return cid.Undef, err
 	}
 
-	nonce, err := a.MpoolGetNonce(ctx, ci.Control)
-	if err != nil {
-		return cid.Undef, err
-	}
-
 	if sv.Extra != nil || len(sv.SecretPreimage) > 0 {
 		return cid.Undef, fmt.Errorf("cant handle more advanced payment channel stuff yet")
 	}
@@ -229,25 +338,18 @@ func (a *PaychAPI) PaychVoucherSubmit(ctx context.Context, ch address.Address, s
 	}
 
 	msg := &types.Message{
-		From:     ci.Control,
-		To:       ch,
-		Value:    types.NewInt(0),
-		Nonce:    nonce,
-		Method:   actors.PCAMethods.UpdateChannelState,
-		Params:   enc,
-		GasLimit: types.NewInt(100000),
-		GasPrice: types.NewInt(0),
+		From:   ci.Control,
+		To:     ch,
+		Value:  types.NewInt(0),
+		Method: actors.PCAMethods.UpdateChannelState,
+		Params: enc,
 	}
 
-	smsg, err := a.WalletSignMessage(ctx, ci.Control, msg)
+	smsg, err := a.MpoolPushMessage(ctx, msg)
 	if err != nil {
 		return cid.Undef, err
 	}
 
-	if err := a.MpoolPush(ctx, smsg); err != nil {
-		return cid.Undef, err
-	}
-
 	// TODO: should we wait for it...?
 	return smsg.Cid(), nil
 }
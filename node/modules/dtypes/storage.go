@@ -7,6 +7,8 @@ import (
 	blockstore "github.com/ipfs/go-ipfs-blockstore"
 	exchange "github.com/ipfs/go-ipfs-exchange-interface"
 	ipld "github.com/ipfs/go-ipld-format"
+
+	"github.com/filecoin-project/go-lotus/chain/types"
 )
 
 // MetadataDS stores metadata
@@ -25,3 +27,11 @@ type ClientBlockstore blockstore.Blockstore
 type ClientDAG ipld.DAGService
 
 type StagingDAG ipld.DAGService
+
+// KeyStorePassphrase returns the passphrase used to unlock an encrypted
+// keystore when the node starts
+type KeyStorePassphrase func() ([]byte, error)
+
+// WalletKeyStore holds the wallet keys. Locking the wallet only locks this
+// keystore, the other keys of the node stay available
+type WalletKeyStore types.KeyStore
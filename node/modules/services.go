@@ -7,11 +7,17 @@ import (
 	inet "github.com/libp2p/go-libp2p-core/network"
 	pubsub "github.com/libp2p/go-libp2p-pubsub"
 	"go.uber.org/fx"
+	"golang.org/x/xerrors"
 
 	"github.com/filecoin-project/go-lotus/chain"
+	"github.com/filecoin-project/go-lotus/chain/address"
 	"github.com/filecoin-project/go-lotus/chain/deals"
+	"github.com/filecoin-project/go-lotus/chain/faults"
+	"github.com/filecoin-project/go-lotus/chain/stmgr"
 	"github.com/filecoin-project/go-lotus/chain/sub"
+	"github.com/filecoin-project/go-lotus/node/config"
 	"github.com/filecoin-project/go-lotus/node/hello"
+	"github.com/filecoin-project/go-lotus/node/impl/full"
 	"github.com/filecoin-project/go-lotus/node/modules/helpers"
 	"github.com/filecoin-project/go-lotus/retrieval/discovery"
 	"github.com/filecoin-project/go-lotus/storage/sector"
@@ -74,6 +80,32 @@ func RunDealClient(mctx helpers.MetricsCtx, lc fx.Lifecycle, c *deals.Client) {
 	})
 }
 
+func RunStorageFaultWatcher(cfg config.Faults) func(mctx helpers.MetricsCtx, lc fx.Lifecycle, sm *stmgr.StateManager, mpool full.MpoolAPI) error {
+	return func(mctx helpers.MetricsCtx, lc fx.Lifecycle, sm *stmgr.StateManager, mpool full.MpoolAPI) error {
+		ctx := helpers.LifecycleCtx(mctx, lc)
+
+		lc.Append(fx.Hook{
+			OnStart: func(context.Context) error {
+				var reporter address.Address
+				var err error
+				if cfg.ReporterAddress != "" {
+					reporter, err = address.NewFromString(cfg.ReporterAddress)
+				} else {
+					reporter, err = mpool.WalletDefaultAddress(ctx)
+				}
+				if err != nil {
+					return xerrors.Errorf("getting storage fault reporter address: %w", err)
+				}
+
+				go faults.NewStorageFaultWatcher(sm, &mpool, reporter).Run(ctx)
+				return nil
+			},
+		})
+
+		return nil
+	}
+}
+
 func RunSectorService(lc fx.Lifecycle, secst *sector.Store) {
 	lc.Append(fx.Hook{
 		OnStart: func(context.Context) error {
@@ -2,10 +2,15 @@ package modules
 
 import (
 	"context"
+	"net/http"
 
 	"go.uber.org/fx"
+	"golang.org/x/xerrors"
 
+	"github.com/filecoin-project/go-lotus/api/client"
 	"github.com/filecoin-project/go-lotus/chain/types"
+	"github.com/filecoin-project/go-lotus/chain/wallet"
+	"github.com/filecoin-project/go-lotus/node/config"
 	"github.com/filecoin-project/go-lotus/node/modules/dtypes"
 	"github.com/filecoin-project/go-lotus/node/repo"
 )
@@ -22,10 +27,78 @@ func LockedRepo(lr repo.LockedRepo) func(lc fx.Lifecycle) repo.LockedRepo {
 	}
 }
 
-func KeyStore(lr repo.LockedRepo) (types.KeyStore, error) {
-	return lr.KeyStore()
+func KeyStore(lr repo.LockedRepo, passphrase dtypes.KeyStorePassphrase) (types.KeyStore, error) {
+	ks, err := lr.KeyStore()
+	if err != nil {
+		return nil, err
+	}
+
+	eks := repo.NewEncryptedKeyStore(ks)
+	enc, err := eks.Encrypted()
+	if err != nil {
+		return nil, xerrors.Errorf("checking keystore encryption: %w", err)
+	}
+	if !enc {
+		return ks, nil
+	}
+
+	pp, err := passphrase()
+	if err != nil {
+		return nil, xerrors.Errorf("getting keystore passphrase: %w", err)
+	}
+
+	if err := eks.Unlock(pp); err != nil {
+		return nil, xerrors.Errorf("unlocking keystore: %w", err)
+	}
+
+	return eks, nil
+}
+
+// WalletKeyStore gives the wallet its own view of an encrypted keystore, so
+// that locking the wallet doesn't lock the libp2p and API keys
+func WalletKeyStore(ks types.KeyStore) dtypes.WalletKeyStore {
+	if eks, ok := ks.(*repo.EncryptedKeyStore); ok {
+		return eks.Clone()
+	}
+	return ks
+}
+
+func Wallet(ks dtypes.WalletKeyStore, ds dtypes.MetadataDS) (*wallet.Wallet, error) {
+	return wallet.NewWallet(ks, ds)
+}
+
+func NoKeyStorePassphrase() dtypes.KeyStorePassphrase {
+	return func() ([]byte, error) {
+		return nil, xerrors.New("keystore is encrypted, but no passphrase was provided")
+	}
 }
 
 func Datastore(r repo.LockedRepo) (dtypes.MetadataDS, error) {
 	return r.Datastore("/metadata")
 }
+
+// RemoteWallet connects to the configured remote wallet backend and uses it
+// for signing, keeping the local keystore as a fallback
+func RemoteWallet(cfg config.Wallet) func(lc fx.Lifecycle, ks dtypes.WalletKeyStore, ds dtypes.MetadataDS) (*wallet.Wallet, error) {
+	return func(lc fx.Lifecycle, ks dtypes.WalletKeyStore, ds dtypes.MetadataDS) (*wallet.Wallet, error) {
+		var headers http.Header
+		if cfg.RemoteBackendToken != "" {
+			headers = http.Header{}
+			headers.Add("Authorization", "Bearer "+cfg.RemoteBackendToken)
+		}
+
+		remote, closer, err := client.NewWalletRPC(cfg.RemoteBackend, headers)
+		if err != nil {
+			return nil, xerrors.Errorf("connecting to remote wallet %s: %w", cfg.RemoteBackend, err)
+		}
+
+		lc.Append(fx.Hook{
+			OnStop: func(_ context.Context) error {
+				closer()
+				return nil
+			},
+		})
+
+		return wallet.NewRemoteBackedWallet(ks, ds, remote, cfg.LocalKeys)
+	}
+}
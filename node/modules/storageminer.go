@@ -106,6 +106,7 @@ func HandleDeals(mctx helpers.MetricsCtx, lc fx.Lifecycle, host host.Host, h *de
 			h.Run(ctx)
 			host.SetStreamHandler(deals.ProtocolID, h.HandleStream)
 			host.SetStreamHandler(deals.AskProtocolID, h.HandleAskStream)
+			host.SetStreamHandler(deals.DealStatusProtocolID, h.HandleStatusStream)
 			return nil
 		},
 		OnStop: func(context.Context) error {
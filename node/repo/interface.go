@@ -18,6 +18,9 @@ var (
 
 	ErrKeyExists   = errors.New("key already exists")
 	ErrKeyNotFound = errors.New("key not found")
+
+	ErrKeyStoreLocked  = errors.New("keystore is locked")
+	ErrWrongPassphrase = errors.New("wrong keystore passphrase")
 )
 
 type Repo interface {
@@ -0,0 +1,486 @@
+package repo
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/json"
+	"strings"
+	"sync"
+
+	"golang.org/x/crypto/scrypt"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-lotus/chain/types"
+)
+
+const (
+	// keystoreMetaName is the name of the keystore entry holding the key
+	// derivation parameters of an encrypted keystore
+	keystoreMetaName = "keystore-encryption"
+	// keystorePendingMetaName holds the key derivation parameters while
+	// existing keys are being encrypted, so that an interrupted Init can be
+	// resumed
+	keystorePendingMetaName = "keystore-encryption-pending"
+	// keystoreTmpPrefix prefixes the encrypted copy of a key while it replaces
+	// the plain key
+	keystoreTmpPrefix = "keystore-encrypting-"
+
+	ktEncrypted   = "encrypted"
+	ktScryptParam = "scrypt"
+
+	scryptN      = 1 << 15
+	scryptR      = 8
+	scryptP      = 1
+	scryptKeyLen = 32
+)
+
+// keystoreCheck is sealed with the derived key to detect wrong passphrases
+var keystoreCheck = []byte("lotus keystore")
+
+type keystoreMeta struct {
+	Salt []byte
+	N    int
+	R    int
+	P    int
+
+	// Check is keystoreCheck sealed with the derived key
+	Check []byte
+}
+
+// EncryptedKeyStore encrypts the keys of the wrapped keystore with AES-GCM,
+// using a key derived from a passphrase with scrypt. Encrypted keys are stored
+// in the wrapped keystore as KeyInfo with the "encrypted" type, so any
+// keystore backend can be used. Key names aren't encrypted, so List works
+// while the keystore is locked
+type EncryptedKeyStore struct {
+	ks types.KeyStore
+
+	lk  sync.Mutex
+	key []byte // nil when locked
+}
+
+func NewEncryptedKeyStore(ks types.KeyStore) *EncryptedKeyStore {
+	return &EncryptedKeyStore{ks: ks}
+}
+
+// Clone returns a keystore over the same keys, unlocked if eks is. The clone
+// is locked and unlocked independently of eks
+func (eks *EncryptedKeyStore) Clone() *EncryptedKeyStore {
+	eks.lk.Lock()
+	defer eks.lk.Unlock()
+
+	var key []byte
+	if eks.key != nil {
+		key = append([]byte(nil), eks.key...)
+	}
+	return &EncryptedKeyStore{ks: eks.ks, key: key}
+}
+
+// Encrypted returns whether the wrapped keystore was initialized for
+// encryption
+func (eks *EncryptedKeyStore) Encrypted() (bool, error) {
+	_, err := eks.ks.Get(keystoreMetaName)
+	switch {
+	case err == nil:
+		return true, nil
+	case !xerrors.Is(err, ErrKeyNotFound):
+		return false, err
+	}
+
+	_, err = eks.ks.Get(keystorePendingMetaName)
+	switch {
+	case err == nil:
+		return false, xerrors.New("keystore encryption was interrupted, run it again to finish it")
+	case xerrors.Is(err, ErrKeyNotFound):
+		return false, nil
+	default:
+		return false, err
+	}
+}
+
+// Locked returns whether the passphrase is needed before keys can be
+// accessed
+func (eks *EncryptedKeyStore) Locked() bool {
+	eks.lk.Lock()
+	defer eks.lk.Unlock()
+
+	return eks.key == nil
+}
+
+// Init sets up encryption of the wrapped keystore with the given passphrase
+// and unlocks it. Existing plain keys are encrypted in place. The encryption
+// parameters are stored under their final name once all keys are encrypted;
+// if Init is interrupted, calling it again with the same passphrase finishes
+// the encryption
+func (eks *EncryptedKeyStore) Init(passphrase []byte) error {
+	_, err := eks.ks.Get(keystoreMetaName)
+	switch {
+	case err == nil:
+		return eks.finishInit(passphrase)
+	case !xerrors.Is(err, ErrKeyNotFound):
+		return xerrors.Errorf("getting keystore encryption parameters: %w", err)
+	}
+
+	mki, err := eks.ks.Get(keystorePendingMetaName)
+	switch {
+	case xerrors.Is(err, ErrKeyNotFound):
+		mki, err = newKeystoreMeta(passphrase)
+		if err != nil {
+			return err
+		}
+
+		if err := eks.ks.Put(keystorePendingMetaName, mki); err != nil {
+			return xerrors.Errorf("storing keystore encryption parameters: %w", err)
+		}
+	case err != nil:
+		return xerrors.Errorf("getting pending keystore encryption parameters: %w", err)
+	}
+
+	key, err := unlockMeta(mki, passphrase)
+	if err != nil {
+		return err
+	}
+
+	names, err := eks.ks.List()
+	if err != nil {
+		return xerrors.Errorf("listing keys: %w", err)
+	}
+
+	eks.lk.Lock()
+	defer eks.lk.Unlock()
+
+	eks.key = key
+
+	for _, name := range names {
+		// keys whose swap was interrupted are only left under the temporary
+		// name if the plain key was already removed
+		name = strings.TrimPrefix(name, keystoreTmpPrefix)
+		if reservedKeyName(name) {
+			continue
+		}
+
+		if err := eks.encryptInPlace(name); err != nil {
+			return xerrors.Errorf("encrypting key '%s': %w", name, err)
+		}
+	}
+
+	if err := eks.ks.Put(keystoreMetaName, mki); err != nil {
+		return xerrors.Errorf("storing keystore encryption parameters: %w", err)
+	}
+
+	return eks.ks.Delete(keystorePendingMetaName)
+}
+
+// finishInit removes the pending encryption parameters left if Init was
+// interrupted after all keys were encrypted
+func (eks *EncryptedKeyStore) finishInit(passphrase []byte) error {
+	mki, err := eks.ks.Get(keystorePendingMetaName)
+	switch {
+	case xerrors.Is(err, ErrKeyNotFound):
+		return xerrors.New("keystore is already encrypted")
+	case err != nil:
+		return xerrors.Errorf("getting pending keystore encryption parameters: %w", err)
+	}
+
+	key, err := unlockMeta(mki, passphrase)
+	if err != nil {
+		return err
+	}
+
+	if err := eks.ks.Delete(keystorePendingMetaName); err != nil {
+		return err
+	}
+
+	eks.lk.Lock()
+	defer eks.lk.Unlock()
+
+	eks.key = key
+	return nil
+}
+
+// encryptInPlace replaces a plain key with its encrypted form. The encrypted
+// copy is written under a temporary name before the plain key is removed, so
+// that an interrupted swap can be finished by calling it again
+func (eks *EncryptedKeyStore) encryptInPlace(name string) error {
+	tmpName := keystoreTmpPrefix + name
+
+	tmp, err := eks.ks.Get(tmpName)
+	hasTmp := err == nil
+	if err != nil && !xerrors.Is(err, ErrKeyNotFound) {
+		return err
+	}
+
+	ki, err := eks.ks.Get(name)
+	switch {
+	case err == nil && ki.Type != ktEncrypted:
+		enc, err := eks.encrypt(name, ki)
+		if err != nil {
+			return err
+		}
+
+		// an earlier copy may not have been written completely
+		if hasTmp {
+			if err := eks.ks.Delete(tmpName); err != nil {
+				return err
+			}
+		}
+
+		if err := eks.ks.Put(tmpName, enc); err != nil {
+			return err
+		}
+		hasTmp = true
+
+		if err := eks.ks.Delete(name); err != nil {
+			return err
+		}
+		if err := eks.ks.Put(name, enc); err != nil {
+			return err
+		}
+	case err == nil:
+		// already encrypted
+	case xerrors.Is(err, ErrKeyNotFound) && hasTmp:
+		// interrupted after the plain key was removed
+		if err := eks.ks.Put(name, tmp); err != nil {
+			return err
+		}
+	default:
+		return err
+	}
+
+	if hasTmp {
+		return eks.ks.Delete(tmpName)
+	}
+	return nil
+}
+
+// Unlock derives the encryption key from the passphrase
+func (eks *EncryptedKeyStore) Unlock(passphrase []byte) error {
+	mki, err := eks.ks.Get(keystoreMetaName)
+	if err != nil {
+		if xerrors.Is(err, ErrKeyNotFound) {
+			return xerrors.New("keystore isn't encrypted")
+		}
+		return xerrors.Errorf("getting keystore encryption parameters: %w", err)
+	}
+
+	key, err := unlockMeta(mki, passphrase)
+	if err != nil {
+		return err
+	}
+
+	eks.lk.Lock()
+	defer eks.lk.Unlock()
+
+	eks.key = key
+	return nil
+}
+
+// Lock forgets the encryption key
+func (eks *EncryptedKeyStore) Lock() {
+	eks.lk.Lock()
+	defer eks.lk.Unlock()
+
+	for i := range eks.key {
+		eks.key[i] = 0
+	}
+	eks.key = nil
+}
+
+// List lists all the keys stored in the KeyStore
+func (eks *EncryptedKeyStore) List() ([]string, error) {
+	names, err := eks.ks.List()
+	if err != nil {
+		return nil, err
+	}
+
+	out := names[:0]
+	for _, name := range names {
+		if !reservedKeyName(name) {
+			out = append(out, name)
+		}
+	}
+	return out, nil
+}
+
+// Get gets a key out of keystore and decrypts it
+func (eks *EncryptedKeyStore) Get(name string) (types.KeyInfo, error) {
+	ki, err := eks.ks.Get(name)
+	if err != nil {
+		return types.KeyInfo{}, err
+	}
+
+	if ki.Type != ktEncrypted {
+		return types.KeyInfo{}, xerrors.Errorf("key '%s' isn't encrypted", name)
+	}
+
+	eks.lk.Lock()
+	defer eks.lk.Unlock()
+
+	if eks.key == nil {
+		return types.KeyInfo{}, xerrors.Errorf("getting key '%s': %w", name, ErrKeyStoreLocked)
+	}
+
+	data, err := open(eks.key, ki.PrivateKey, []byte(name))
+	if err != nil {
+		return types.KeyInfo{}, xerrors.Errorf("decrypting key '%s': %w", name, err)
+	}
+
+	var res types.KeyInfo
+	if err := json.Unmarshal(data, &res); err != nil {
+		return types.KeyInfo{}, xerrors.Errorf("decoding key '%s': %w", name, err)
+	}
+
+	return res, nil
+}
+
+// Put encrypts key info and saves it under given name
+func (eks *EncryptedKeyStore) Put(name string, info types.KeyInfo) error {
+	if reservedKeyName(name) {
+		return xerrors.Errorf("key name '%s' is reserved", name)
+	}
+
+	eks.lk.Lock()
+	enc, err := eks.encrypt(name, info)
+	eks.lk.Unlock()
+	if err != nil {
+		return err
+	}
+
+	return eks.ks.Put(name, enc)
+}
+
+// Delete removes a key from keystore
+func (eks *EncryptedKeyStore) Delete(name string) error {
+	if reservedKeyName(name) {
+		return xerrors.Errorf("key name '%s' is reserved", name)
+	}
+
+	return eks.ks.Delete(name)
+}
+
+// encrypt must be called with eks.lk held
+func (eks *EncryptedKeyStore) encrypt(name string, info types.KeyInfo) (types.KeyInfo, error) {
+	if eks.key == nil {
+		return types.KeyInfo{}, xerrors.Errorf("putting key '%s': %w", name, ErrKeyStoreLocked)
+	}
+
+	data, err := json.Marshal(info)
+	if err != nil {
+		return types.KeyInfo{}, xerrors.Errorf("encoding key '%s': %w", name, err)
+	}
+
+	// the name is authenticated so that encrypted keys can't be swapped
+	sealed, err := seal(eks.key, data, []byte(name))
+	if err != nil {
+		return types.KeyInfo{}, xerrors.Errorf("encrypting key '%s': %w", name, err)
+	}
+
+	return types.KeyInfo{
+		Type:       ktEncrypted,
+		PrivateKey: sealed,
+	}, nil
+}
+
+// reservedKeyName returns whether the key is used by the encryption itself
+func reservedKeyName(name string) bool {
+	return name == keystoreMetaName || name == keystorePendingMetaName || strings.HasPrefix(name, keystoreTmpPrefix)
+}
+
+// newKeystoreMeta generates key derivation parameters for the passphrase
+func newKeystoreMeta(passphrase []byte) (types.KeyInfo, error) {
+	meta := keystoreMeta{
+		Salt: make([]byte, 32),
+		N:    scryptN,
+		R:    scryptR,
+		P:    scryptP,
+	}
+	if _, err := rand.Read(meta.Salt); err != nil {
+		return types.KeyInfo{}, xerrors.Errorf("generating salt: %w", err)
+	}
+
+	key, err := meta.deriveKey(passphrase)
+	if err != nil {
+		return types.KeyInfo{}, err
+	}
+
+	meta.Check, err = seal(key, keystoreCheck, []byte(keystoreMetaName))
+	if err != nil {
+		return types.KeyInfo{}, err
+	}
+
+	mb, err := json.Marshal(&meta)
+	if err != nil {
+		return types.KeyInfo{}, err
+	}
+
+	return types.KeyInfo{Type: ktScryptParam, PrivateKey: mb}, nil
+}
+
+// unlockMeta derives the encryption key from the passphrase, checking it
+// against the stored parameters
+func unlockMeta(mki types.KeyInfo, passphrase []byte) ([]byte, error) {
+	var meta keystoreMeta
+	if err := json.Unmarshal(mki.PrivateKey, &meta); err != nil {
+		return nil, xerrors.Errorf("decoding keystore encryption parameters: %w", err)
+	}
+
+	key, err := meta.deriveKey(passphrase)
+	if err != nil {
+		return nil, err
+	}
+
+	check, err := open(key, meta.Check, []byte(keystoreMetaName))
+	if err != nil || subtle.ConstantTimeCompare(check, keystoreCheck) != 1 {
+		return nil, ErrWrongPassphrase
+	}
+
+	return key, nil
+}
+
+func (m *keystoreMeta) deriveKey(passphrase []byte) ([]byte, error) {
+	key, err := scrypt.Key(passphrase, m.Salt, m.N, m.R, m.P, scryptKeyLen)
+	if err != nil {
+		return nil, xerrors.Errorf("deriving keystore key: %w", err)
+	}
+	return key, nil
+}
+
+// seal encrypts data with AES-GCM, prefixing the output with a random nonce
+func seal(key, data, ad []byte) ([]byte, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(data)+aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	return aead.Seal(nonce, nonce, data, ad), nil
+}
+
+func open(key, sealed, ad []byte) ([]byte, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(sealed) < aead.NonceSize() {
+		return nil, xerrors.New("sealed data too short")
+	}
+
+	return aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], ad)
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+var _ types.KeyStore = (*EncryptedKeyStore)(nil)
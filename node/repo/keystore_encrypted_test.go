@@ -0,0 +1,186 @@
+package repo
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/xerrors"
+
+	"github.com/filecoin-project/go-lotus/chain/types"
+)
+
+func TestEncryptedKeyStore(t *testing.T) {
+	lr, err := NewMemory(nil).Lock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer lr.Close() //nolint:errcheck
+
+	ks, err := lr.KeyStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	plain := types.KeyInfo{Type: "foo", PrivateKey: []byte("secret")}
+	assert.NoError(t, ks.Put("existing", plain))
+
+	eks := NewEncryptedKeyStore(ks)
+	enc, err := eks.Encrypted()
+	assert.NoError(t, err)
+	assert.False(t, enc)
+
+	// migrate the existing key
+	assert.NoError(t, eks.Init([]byte("hunter2")))
+	assert.Error(t, eks.Init([]byte("hunter2")), "encrypted keystores can't be initialized again")
+
+	raw, err := ks.Get("existing")
+	assert.NoError(t, err)
+	assert.Equal(t, ktEncrypted, raw.Type)
+	assert.NotContains(t, string(raw.PrivateKey), "secret")
+
+	ki, err := eks.Get("existing")
+	assert.NoError(t, err)
+	assert.Equal(t, plain, ki)
+
+	assert.NoError(t, eks.Put("new", types.KeyInfo{Type: "bar", PrivateKey: []byte("other")}))
+
+	names, err := eks.List()
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []string{"existing", "new"}, names)
+
+	// keys can't be swapped around
+	raw, err = ks.Get("new")
+	assert.NoError(t, err)
+	assert.NoError(t, ks.Put("swapped", raw))
+	_, err = eks.Get("swapped")
+	assert.Error(t, err)
+
+	eks.Lock()
+	assert.True(t, eks.Locked())
+
+	_, err = eks.Get("existing")
+	assert.True(t, xerrors.Is(err, ErrKeyStoreLocked))
+	assert.True(t, xerrors.Is(eks.Put("locked", plain), ErrKeyStoreLocked))
+
+	assert.Equal(t, ErrWrongPassphrase, eks.Unlock([]byte("hunter3")))
+	assert.True(t, eks.Locked())
+
+	// a new instance, like after a restart
+	eks = NewEncryptedKeyStore(ks)
+	enc, err = eks.Encrypted()
+	assert.NoError(t, err)
+	assert.True(t, enc)
+
+	assert.NoError(t, eks.Unlock([]byte("hunter2")))
+	ki, err = eks.Get("existing")
+	assert.NoError(t, err)
+	assert.Equal(t, plain, ki)
+}
+
+// interruptedKeyStore fails all writes after the first n
+type interruptedKeyStore struct {
+	types.KeyStore
+	n int
+}
+
+func (iks *interruptedKeyStore) write() error {
+	if iks.n == 0 {
+		return xerrors.New("interrupted")
+	}
+	iks.n--
+	return nil
+}
+
+func (iks *interruptedKeyStore) Put(name string, info types.KeyInfo) error {
+	if err := iks.write(); err != nil {
+		return err
+	}
+	return iks.KeyStore.Put(name, info)
+}
+
+func (iks *interruptedKeyStore) Delete(name string) error {
+	if err := iks.write(); err != nil {
+		return err
+	}
+	return iks.KeyStore.Delete(name)
+}
+
+func TestEncryptedKeyStoreResumeInit(t *testing.T) {
+	plain := map[string]types.KeyInfo{
+		"a": {Type: "foo", PrivateKey: []byte("secret a")},
+		"b": {Type: "foo", PrivateKey: []byte("secret b")},
+	}
+
+	for n := 0; ; n++ {
+		lr, err := NewMemory(nil).Lock()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		ks, err := lr.KeyStore()
+		if err != nil {
+			t.Fatal(err)
+		}
+		for name, ki := range plain {
+			assert.NoError(t, ks.Put(name, ki))
+		}
+
+		err = NewEncryptedKeyStore(&interruptedKeyStore{KeyStore: ks, n: n}).Init([]byte("hunter2"))
+		if err == nil {
+			lr.Close() //nolint:errcheck
+			break
+		}
+
+		eks := NewEncryptedKeyStore(ks)
+		if n > 0 {
+			// until the parameters are stored under their final name
+			if _, err := ks.Get(keystoreMetaName); err != nil {
+				_, err = eks.Encrypted()
+				assert.Error(t, err, "interrupted encryption is reported (after %d writes)", n)
+			}
+			assert.Equal(t, ErrWrongPassphrase, eks.Init([]byte("hunter3")))
+		}
+
+		assert.NoError(t, eks.Init([]byte("hunter2")), "after %d writes", n)
+
+		names, err := ks.List()
+		assert.NoError(t, err)
+		assert.ElementsMatch(t, []string{"a", "b", keystoreMetaName}, names, "after %d writes", n)
+
+		eks = NewEncryptedKeyStore(ks)
+		assert.NoError(t, eks.Unlock([]byte("hunter2")))
+		for name, ki := range plain {
+			got, err := eks.Get(name)
+			assert.NoError(t, err)
+			assert.Equal(t, ki, got)
+		}
+
+		lr.Close() //nolint:errcheck
+	}
+}
+
+func TestEncryptedKeyStoreClone(t *testing.T) {
+	lr, err := NewMemory(nil).Lock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer lr.Close() //nolint:errcheck
+
+	ks, err := lr.KeyStore()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	eks := NewEncryptedKeyStore(ks)
+	assert.NoError(t, eks.Init([]byte("hunter2")))
+
+	clone := eks.Clone()
+	assert.NoError(t, clone.Put("k", types.KeyInfo{Type: "foo", PrivateKey: []byte("secret")}))
+
+	clone.Lock()
+	assert.True(t, clone.Locked())
+	assert.False(t, eks.Locked(), "locking the clone leaves the original unlocked")
+
+	_, err = eks.Get("k")
+	assert.NoError(t, err)
+}
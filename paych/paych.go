@@ -124,53 +124,82 @@ func (pm *Manager) GetChannelInfo(addr address.Address) (*ChannelInfo, error) {
 
 // checks if the given voucher is valid (is or could become spendable at some point)
 func (pm *Manager) CheckVoucherValid(ctx context.Context, ch address.Address, sv *types.SignedVoucher) error {
+	vouchers, err := pm.store.VouchersForPaych(ch)
+	if err != nil && err != ErrChannelNotTracked {
+		return err
+	}
+
+	_, err = pm.checkVoucherValid(ctx, ch, sv, vouchers)
+	return err
+}
+
+// checkVoucherValid validates the voucher against the channel state and the
+// vouchers we already hold for it, and returns the value it adds to its lane
+func (pm *Manager) checkVoucherValid(ctx context.Context, ch address.Address, sv *types.SignedVoucher, vouchers []*VoucherInfo) (types.BigInt, error) {
 	act, pca, err := pm.loadPaychState(ctx, ch)
 	if err != nil {
-		return err
+		return types.NewInt(0), err
 	}
 
 	// verify signature
 	vb, err := sv.SigningBytes()
 	if err != nil {
-		return err
+		return types.NewInt(0), err
 	}
 
 	// TODO: technically, either party may create and sign a voucher.
 	// However, for now, we only accept them from the channel creator.
 	// More complex handling logic can be added later
 	if err := sv.Signature.Verify(pca.From, vb); err != nil {
-		return err
+		return types.NewInt(0), err
 	}
 
-	sendAmount := sv.Amount
-
-	// now check the lane state
-	// TODO: should check against vouchers in our local store too
-	// there might be something conflicting
-	ls, ok := pca.LaneStates[fmt.Sprint(sv.Lane)]
-	if !ok {
-	} else {
-		if ls.Closed {
-			return fmt.Errorf("voucher is on a closed lane")
-		}
-		if ls.Nonce >= sv.Nonce {
-			return fmt.Errorf("nonce too low")
-		}
-
-		sendAmount = types.BigSub(sv.Amount, ls.Redeemed)
+	delta, err := voucherDelta(ch, pca, vouchers, sv)
+	if err != nil {
+		return types.NewInt(0), err
 	}
 
 	// TODO: also account for vouchers on other lanes we've received
-	newTotal := types.BigAdd(sendAmount, pca.ToSend)
+	newTotal := types.BigAdd(delta, pca.ToSend)
 	if act.Balance.LessThan(newTotal) {
-		return fmt.Errorf("not enough funds in channel to cover voucher")
+		return types.NewInt(0), fmt.Errorf("not enough funds in channel to cover voucher")
 	}
 
-	if len(sv.Merges) != 0 {
-		return fmt.Errorf("dont currently support paych lane merges")
+	return delta, nil
+}
+
+// voucherDelta checks the voucher against the lane states built from the
+// chain and the vouchers we hold, and returns how much more it is worth
+func voucherDelta(ch address.Address, pca *actors.PaymentChannelActorState, vouchers []*VoucherInfo, sv *types.SignedVoucher) (types.BigInt, error) {
+	ls := laneStateFromVouchers(ch, pca, vouchers, sv.Lane)
+	if ls.Closed {
+		return types.NewInt(0), fmt.Errorf("voucher is on a closed lane")
+	}
+	if ls.Nonce >= sv.Nonce {
+		return types.NewInt(0), fmt.Errorf("nonce too low")
+	}
+
+	// the voucher amount also covers what was redeemed on the merged lanes
+	delta := types.BigSub(sv.Amount, ls.Redeemed)
+	for _, m := range sv.Merges {
+		if m.Lane == sv.Lane {
+			return types.NewInt(0), fmt.Errorf("voucher cannot merge its own lane")
+		}
+
+		// the actor only merges lanes which were redeemed on chain
+		if _, ok := pca.LaneStates[fmt.Sprint(m.Lane)]; !ok {
+			return types.NewInt(0), fmt.Errorf("voucher merges lane %d which wasn't redeemed on chain", m.Lane)
+		}
+
+		mls := laneStateFromVouchers(ch, pca, vouchers, m.Lane)
+		if mls.Nonce >= m.Nonce {
+			return types.NewInt(0), fmt.Errorf("merge of lane %d has outdated nonce", m.Lane)
+		}
+
+		delta = types.BigSub(delta, mls.Redeemed)
 	}
 
-	return nil
+	return delta, nil
 }
 
 // checks if the given voucher is currently spendable
@@ -242,10 +271,6 @@ func (pm *Manager) getPaychOwner(ctx context.Context, ch address.Address) (addre
 }
 
 func (pm *Manager) AddVoucher(ctx context.Context, ch address.Address, sv *types.SignedVoucher, proof []byte, minDelta types.BigInt) (types.BigInt, error) {
-	if err := pm.CheckVoucherValid(ctx, ch, sv); err != nil {
-		return types.NewInt(0), err
-	}
-
 	pm.store.lk.Lock()
 	defer pm.store.lk.Unlock()
 
@@ -254,19 +279,6 @@ func (pm *Manager) AddVoucher(ctx context.Context, ch address.Address, sv *types
 		return types.NewInt(0), err
 	}
 
-	laneState, err := pm.laneState(ctx, ch, sv.Lane)
-	if err != nil {
-		return types.NewInt(0), err
-	}
-
-	if laneState.Closed {
-		return types.NewInt(0), xerrors.New("lane closed")
-	}
-
-	if minDelta.GreaterThan(types.NewInt(0)) && laneState.Nonce > sv.Nonce {
-		return types.NewInt(0), xerrors.Errorf("already storing voucher with higher nonce; %d > %d", laneState.Nonce, sv.Nonce)
-	}
-
 	// look for duplicates
 	for i, v := range ci.Vouchers {
 		if !sv.Equals(v.Voucher) {
@@ -275,7 +287,11 @@ func (pm *Manager) AddVoucher(ctx context.Context, ch address.Address, sv *types
 		if v.Proof != nil {
 			if !bytes.Equal(v.Proof, proof) {
 				log.Warnf("AddVoucher: multiple proofs for single voucher, storing both")
-				break
+				ci.Vouchers = append(ci.Vouchers, &VoucherInfo{
+					Voucher: sv,
+					Proof:   proof,
+				})
+				return types.NewInt(0), pm.store.putChannelInfo(ci)
 			}
 			log.Warnf("AddVoucher: voucher re-added with matching proof")
 			return types.NewInt(0), nil
@@ -290,9 +306,13 @@ func (pm *Manager) AddVoucher(ctx context.Context, ch address.Address, sv *types
 		return types.NewInt(0), pm.store.putChannelInfo(ci)
 	}
 
-	delta := types.BigSub(sv.Amount, laneState.Redeemed)
+	delta, err := pm.checkVoucherValid(ctx, ch, sv, ci.Vouchers)
+	if err != nil {
+		return types.NewInt(0), err
+	}
+
 	if minDelta.GreaterThan(delta) {
-		return delta, xerrors.Errorf("addVoucher: supplied token amount too low; minD=%s, D=%s; laneAmt=%s; v.Amt=%s", minDelta, delta, laneState.Redeemed, sv.Amount)
+		return delta, xerrors.Errorf("addVoucher: supplied token amount too low; minD=%s, D=%s; v.Amt=%s", minDelta, delta, sv.Amount)
 	}
 
 	ci.Vouchers = append(ci.Vouchers, &VoucherInfo{
@@ -342,6 +362,12 @@ func (pm *Manager) NextNonceForLane(ctx context.Context, ch address.Address, lan
 				maxnonce = v.Voucher.Nonce
 			}
 		}
+
+		for _, m := range v.Voucher.Merges {
+			if m.Lane == lane && m.Nonce > maxnonce {
+				maxnonce = m.Nonce
+			}
+		}
 	}
 
 	return maxnonce + 1, nil
@@ -0,0 +1,104 @@
+package paych
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/filecoin-project/go-lotus/chain/actors"
+	"github.com/filecoin-project/go-lotus/chain/address"
+	"github.com/filecoin-project/go-lotus/chain/types"
+)
+
+func testVoucher(lane, nonce, amount uint64, merges ...types.Merge) *VoucherInfo {
+	return &VoucherInfo{
+		Voucher: &types.SignedVoucher{
+			Lane:   lane,
+			Nonce:  nonce,
+			Amount: types.NewInt(amount),
+			Merges: merges,
+		},
+	}
+}
+
+func TestVoucherDeltaPartlyRedeemed(t *testing.T) {
+	ch, err := address.NewIDAddress(100)
+	require.NoError(t, err)
+
+	// lane 1 was redeemed up to 4 on chain, we hold a newer voucher for 10
+	pca := &actors.PaymentChannelActorState{
+		ToSend: types.NewInt(4),
+		LaneStates: map[string]*actors.LaneState{
+			"1": {Nonce: 1, Redeemed: types.NewInt(4)},
+		},
+	}
+	vouchers := []*VoucherInfo{testVoucher(1, 2, 10)}
+
+	ls := laneStateFromVouchers(ch, pca, vouchers, 1)
+	require.Equal(t, uint64(2), ls.Nonce)
+	require.Equal(t, types.NewInt(10), ls.Redeemed)
+	require.Equal(t, types.NewInt(4), pca.LaneStates["1"].Redeemed, "chain state is left untouched")
+
+	delta, err := voucherDelta(ch, pca, vouchers, testVoucher(1, 3, 15).Voucher)
+	require.NoError(t, err)
+	require.Equal(t, types.NewInt(5), delta)
+
+	_, err = voucherDelta(ch, pca, vouchers, testVoucher(1, 2, 12).Voucher)
+	require.Error(t, err, "the nonce of the held voucher is taken into account")
+
+	delta, err = voucherDelta(ch, pca, nil, testVoucher(1, 2, 12).Voucher)
+	require.NoError(t, err)
+	require.Equal(t, types.NewInt(8), delta)
+}
+
+func TestVoucherDeltaMerge(t *testing.T) {
+	ch, err := address.NewIDAddress(100)
+	require.NoError(t, err)
+
+	pca := &actors.PaymentChannelActorState{
+		ToSend: types.NewInt(7),
+		LaneStates: map[string]*actors.LaneState{
+			"1": {Nonce: 1, Redeemed: types.NewInt(4)},
+			"2": {Nonce: 1, Redeemed: types.NewInt(3)},
+		},
+	}
+	vouchers := []*VoucherInfo{
+		testVoucher(1, 2, 10),
+		testVoucher(2, 2, 6),
+	}
+
+	delta, err := voucherDelta(ch, pca, vouchers, testVoucher(1, 3, 20, types.Merge{Lane: 2, Nonce: 3}).Voucher)
+	require.NoError(t, err)
+	require.Equal(t, types.NewInt(20-10-6), delta)
+
+	_, err = voucherDelta(ch, pca, vouchers, testVoucher(1, 3, 20, types.Merge{Lane: 2, Nonce: 2}).Voucher)
+	require.Error(t, err, "merge nonce must be above the held voucher")
+
+	_, err = voucherDelta(ch, pca, vouchers, testVoucher(1, 3, 20, types.Merge{Lane: 1, Nonce: 4}).Voucher)
+	require.Error(t, err, "a voucher cannot merge its own lane")
+
+	_, err = voucherDelta(ch, pca, vouchers, testVoucher(1, 3, 20, types.Merge{Lane: 3, Nonce: 1}).Voucher)
+	require.Error(t, err, "merged lanes must be redeemed on chain")
+
+	// a merge advances the nonce of the merged lane
+	merged := append(vouchers, testVoucher(1, 3, 20, types.Merge{Lane: 2, Nonce: 3}))
+	ls := laneStateFromVouchers(ch, pca, merged, 2)
+	require.Equal(t, uint64(3), ls.Nonce)
+
+	_, err = voucherDelta(ch, pca, merged, testVoucher(2, 3, 8).Voucher)
+	require.Error(t, err)
+}
+
+func TestVoucherDeltaClosedLane(t *testing.T) {
+	ch, err := address.NewIDAddress(100)
+	require.NoError(t, err)
+
+	pca := &actors.PaymentChannelActorState{
+		LaneStates: map[string]*actors.LaneState{
+			"1": {Nonce: 1, Redeemed: types.NewInt(4), Closed: true},
+		},
+	}
+
+	_, err = voucherDelta(ch, pca, nil, testVoucher(1, 2, 10).Voucher)
+	require.Error(t, err)
+}
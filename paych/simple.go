@@ -26,13 +26,11 @@ func (pm *Manager) createPaych(ctx context.Context, from, to address.Address, am
 	}
 
 	msg := &types.Message{
-		To:       actors.InitActorAddress,
-		From:     from,
-		Value:    amt,
-		Method:   actors.IAMethods.Exec,
-		Params:   enc,
-		GasLimit: types.NewInt(1000000),
-		GasPrice: types.NewInt(0),
+		To:     actors.InitActorAddress,
+		From:   from,
+		Value:  amt,
+		Method: actors.IAMethods.Exec,
+		Params: enc,
 	}
 
 	smsg, err := pm.mpool.MpoolPushMessage(ctx, msg)
@@ -72,12 +70,10 @@ func (pm *Manager) createPaych(ctx context.Context, from, to address.Address, am
 
 func (pm *Manager) addFunds(ctx context.Context, ch address.Address, from address.Address, amt types.BigInt) error {
 	msg := &types.Message{
-		To:       ch,
-		From:     from,
-		Value:    amt,
-		Method:   0,
-		GasLimit: types.NewInt(1000000),
-		GasPrice: types.NewInt(0),
+		To:     ch,
+		From:   from,
+		Value:  amt,
+		Method: 0,
 	}
 
 	smsg, err := pm.mpool.MpoolPushMessage(ctx, msg)
@@ -19,40 +19,49 @@ func (pm *Manager) loadPaychState(ctx context.Context, ch address.Address) (*typ
 	return act, &pcast, nil
 }
 
+// ChannelState returns the on chain state of the payment channel
+func (pm *Manager) ChannelState(ctx context.Context, ch address.Address) (*types.Actor, *actors.PaymentChannelActorState, error) {
+	return pm.loadPaychState(ctx, ch)
+}
+
 func (pm *Manager) laneState(ctx context.Context, ch address.Address, lane uint64) (actors.LaneState, error) {
 	_, state, err := pm.loadPaychState(ctx, ch)
 	if err != nil {
 		return actors.LaneState{}, err
 	}
 
+	vouchers, err := pm.store.VouchersForPaych(ch)
+	if err != nil && err != ErrChannelNotTracked {
+		return actors.LaneState{}, err
+	}
+
+	return laneStateFromVouchers(ch, state, vouchers, lane), nil
+}
+
+// laneStateFromVouchers returns the on chain state of the lane advanced by the
+// vouchers we hold for the channel
+func laneStateFromVouchers(ch address.Address, state *actors.PaymentChannelActorState, vouchers []*VoucherInfo, lane uint64) actors.LaneState {
 	// TODO: we probably want to call UpdateChannelState with all vouchers to be fully correct
 	//  (but technically dont't need to)
-	// TODO: make sure this is correct
-
-	ls, ok := state.LaneStates[fmt.Sprintf("%d", lane)]
-	if !ok {
-		ls = &actors.LaneState{
-			Closed:   false,
-			Redeemed: types.NewInt(0),
-			Nonce:    0,
-		}
-	}
 
-	if ls.Closed {
-		return *ls, nil
+	ls := actors.LaneState{
+		Redeemed: types.NewInt(0),
+	}
+	if cls, ok := state.LaneStates[fmt.Sprintf("%d", lane)]; ok {
+		ls = *cls
 	}
 
-	vouchers, err := pm.store.VouchersForPaych(ch)
-	if err != nil {
-		if err == ErrChannelNotTracked {
-			return *ls, nil
-		}
-		return actors.LaneState{}, err
+	if ls.Closed {
+		return ls
 	}
 
 	for _, v := range vouchers {
-		for range v.Voucher.Merges {
-			panic("merges todo") // TODO: nonce check
+		// merging a lane into another one only advances the nonce of the
+		// merged lane
+		for _, m := range v.Voucher.Merges {
+			if m.Lane == lane && m.Nonce > ls.Nonce {
+				ls.Nonce = m.Nonce
+			}
 		}
 
 		if v.Voucher.Lane != lane {
@@ -68,5 +77,5 @@ func (pm *Manager) laneState(ctx context.Context, ch address.Address, lane uint6
 		ls.Redeemed = v.Voucher.Amount
 	}
 
-	return *ls, nil
+	return ls
 }
@@ -262,7 +262,7 @@ func (cst *clientStream) consumeBlockMessage(block Block, out io.Writer) (uint64
 func (cst *clientStream) setupPayment(ctx context.Context, toSend types.BigInt) (api.PaymentInfo, error) {
 	amount := types.BigAdd(cst.transferred, toSend)
 
-	sv, err := cst.payapi.PaychVoucherCreate(ctx, cst.paych, amount, cst.lane)
+	sv, err := cst.payapi.PaychVoucherCreate(ctx, cst.paych, amount, cst.lane, nil)
 	if err != nil {
 		return api.PaymentInfo{}, err
 	}
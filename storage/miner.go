@@ -55,6 +55,7 @@ type storageMinerApi interface {
 	StateMinerProvingPeriodEnd(context.Context, address.Address, *types.TipSet) (uint64, error)
 	StateMinerProvingSet(context.Context, address.Address, *types.TipSet) ([]*api.SectorInfo, error)
 	StateWaitMsg(context.Context, cid.Cid) (*api.MsgWait, error)
+	StateMarketDeals(context.Context, *types.TipSet) (map[string]api.MarketDeal, error)
 
 	MpoolPushMessage(context.Context, *types.Message) (*types.SignedMessage, error)
 
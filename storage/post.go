@@ -2,6 +2,7 @@ package storage
 
 import (
 	"context"
+	"strconv"
 	"time"
 
 	"golang.org/x/xerrors"
@@ -169,6 +170,8 @@ func (m *Miner) computePost(ppe uint64) func(ts *types.TipSet, curH uint64) erro
 		if rec.Receipt.ExitCode != 0 {
 			log.Warnf("SubmitPoSt EXIT: %d", rec.Receipt.ExitCode)
 			// TODO: Do something
+		} else if err := m.processDealPayments(ctx); err != nil {
+			log.Errorf("processing deal payments: %s", err)
 		}
 
 		m.scheduleNextPost(ppe + build.ProvingPeriodDuration)
@@ -176,6 +179,56 @@ func (m *Miner) computePost(ppe uint64) func(ts *types.TipSet, curH uint64) erro
 	}
 }
 
+// processDealPayments asks the storage market actor to pay for the active
+// deals of the miner, which are paid for the epochs proven so far
+func (m *Miner) processDealPayments(ctx context.Context) error {
+	deals, err := m.api.StateMarketDeals(ctx, nil)
+	if err != nil {
+		return xerrors.Errorf("getting market deals: %w", err)
+	}
+
+	var ids []uint64
+	for k, d := range deals {
+		if d.Provider != m.maddr || d.ActivationEpoch == 0 || d.SlashedAt != 0 {
+			continue
+		}
+		if d.LastPaymentEpoch >= d.ActivationEpoch+d.Duration {
+			continue
+		}
+
+		id, err := strconv.ParseUint(k, 10, 64)
+		if err != nil {
+			return xerrors.Errorf("parsing deal ID: %w", err)
+		}
+		ids = append(ids, id)
+	}
+
+	if len(ids) == 0 {
+		return nil
+	}
+
+	enc, aerr := actors.SerializeParams(&actors.ProcessStorageDealsPaymentParams{DealIDs: ids})
+	if aerr != nil {
+		return xerrors.Errorf("serializing ProcessStorageDealsPayment params: %w", aerr)
+	}
+
+	msg := &types.Message{
+		To:     actors.MarketActorAddress,
+		From:   m.worker,
+		Method: actors.SMAMethods.ProcessStorageDealsPayment,
+		Params: enc,
+		Value:  types.NewInt(0),
+	}
+
+	smsg, err := m.api.MpoolPushMessage(ctx, msg)
+	if err != nil {
+		return xerrors.Errorf("pushing message to mpool: %w", err)
+	}
+
+	log.Infof("requested payment for %d deals in %s", len(ids), smsg.Cid())
+	return nil
+}
+
 func sectorIdList(si []*api.SectorInfo) []uint64 {
 	out := make([]uint64, len(si))
 	for i, s := range si {